@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// AuditEvent describes a read of an audited path.
+type AuditEvent struct {
+	// Path is the path read, relative to the audited config. It is the
+	// audited path itself, or a path inside or above it, like a map
+	// holding it.
+	Path string
+	Time time.Time
+	// Caller is the "file:line" of the code calling the getter.
+	Caller string
+	// Stack is the stack of the reading goroutine, if requested.
+	Stack []byte
+}
+
+// Audit describes the paths whose reads are reported.
+type Audit struct {
+	// Paths lists the audited paths, like "database.password".
+	Paths []string
+	// Stack requests the stack of the readers in the events.
+	Stack bool
+	// Func is called for every read, by the goroutine reading. It
+	// should return quickly, since it delays the getter.
+	Func func(AuditEvent)
+}
+
+// auditor holds the audited paths of a config and its subtrees.
+type auditor struct {
+	paths []string
+	stack bool
+	fn    func(AuditEvent)
+}
+
+// AuditReads reports the reads of the given paths by the getters to
+// a.Func, including reads from the configs returned by Get, like
+// TrackReads. Reading a map or a list holding an audited path counts as
+// reading it, and so does exporting the whole config, with ToEnv, the
+// encoders or the renderers, which is reported as a read of its root. The
+// copies made by Copy, Extend and Profiles, and the views made by
+// WithFallback, keep auditing the same paths. It returns the config itself.
+//
+//	cfg.AuditReads(config.Audit{
+//		Paths: []string{"database.password", "api.keys"},
+//		Func: func(e config.AuditEvent) {
+//			log.Printf("read %s from %s", e.Path, e.Caller)
+//		},
+//	})
+func (cfg *Config) AuditReads(a Audit) *Config {
+	paths := make([]string, len(a.Paths))
+	for i, path := range a.Paths {
+		paths[i] = canonicalPath(cfg.prefix, path)
+	}
+	cfg.audit = &auditor{paths: paths, stack: a.Stack, fn: a.Func}
+	return cfg
+}
+
+// report calls the audit function if a path read is audited.
+func (a *auditor) report(path string) {
+	for _, audited := range a.paths {
+		if pathWithin(path, audited) || pathWithin(audited, path) {
+			e := AuditEvent{Path: path, Time: time.Now(), Caller: auditCaller()}
+			if a.stack {
+				e.Stack = debug.Stack()
+			}
+			a.fn(e)
+			return
+		}
+	}
+}
+
+// auditExport reports the export of the whole config, if it is audited.
+func (cfg *Config) auditExport() {
+	if cfg.audit != nil {
+		cfg.audit.report(cfg.prefix)
+	}
+}
+
+// pathWithin reports whether a canonical path is base or inside it.
+func pathWithin(path, base string) bool {
+	return base == "" || path == base ||
+		strings.HasPrefix(path, base) && (path[len(base)] == '.' || path[len(base)] == '[')
+}
+
+// packageDir is the directory of the sources of the package, whose
+// frames auditCaller skips.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// auditCaller returns the position of the first caller outside the
+// package.
+func auditCaller() string {
+	pcs := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	for {
+		frame, more := frames.Next()
+		if filepath.Dir(frame.File) != packageDir || strings.HasSuffix(frame.File, "_test.go") {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAuditReads(t *testing.T) {
+	cfg := Must(ParseYaml(`
+database: {host: localhost, password: s3cr3t}
+api: {keys: [a, b]}
+`))
+	var events []AuditEvent
+	cfg.AuditReads(Audit{
+		Paths: []string{"database.password", "api.keys"},
+		Func:  func(e AuditEvent) { events = append(events, e) },
+	})
+	cfg.UString("database.host")
+	cfg.UString("database.password")
+	cfg.UString("api.keys.1")
+	cfg.UMap("database")
+	db, err := cfg.Get("database")
+	expect(t, err, nil)
+	db.UString("password")
+	db.UString("host")
+
+	var paths []string
+	for _, e := range events {
+		paths = append(paths, e.Path)
+		expect(t, e.Time.IsZero(), false)
+		expect(t, strings.Contains(e.Caller, "audit_test.go:"), true)
+		expect(t, e.Stack == nil, true)
+	}
+	expect(t, reflect.DeepEqual(paths, []string{
+		"database.password", "api.keys.1", "database", "database.password",
+	}), true)
+
+	events = nil
+	cfg.AuditReads(Audit{
+		Paths: []string{"database.password"},
+		Stack: true,
+		Func:  func(e AuditEvent) { events = append(events, e) },
+	})
+	cfg.Optimize()
+	cfg.UString("database.password")
+	expect(t, len(events), 1)
+	expect(t, strings.Contains(string(events[0].Stack), "TestAuditReads"), true)
+}
+
+func TestAuditCopiesAndExports(t *testing.T) {
+	cfg := Must(ParseYaml(`
+db: {host: localhost, password: s}
+api: {url: /api}
+env:prod: {db: {host: db.internal}}
+`))
+	var paths []string
+	cfg.AuditReads(Audit{
+		Paths: []string{"db.password"},
+		Func:  func(e AuditEvent) { paths = append(paths, e.Path) },
+	})
+	expectPaths := func(want ...string) {
+		t.Helper()
+		expect(t, reflect.DeepEqual(paths, want), true)
+		paths = nil
+	}
+
+	cp := Must(cfg.Copy())
+	cp.UString("db.password")
+	sub := Must(cfg.Copy("db"))
+	sub.UString("password")
+	sub.UString("host")
+	expectPaths("db.password", "db.password")
+
+	merged := Must(cfg.Extend(Must(ParseYaml("api: {url: /v2}"))))
+	merged.UString("db.password")
+	prod := Must(cfg.Profiles("env:prod"))
+	prod.UString("db.password")
+	layered := cfg.WithFallback(Must(ParseYaml("cache: {ttl: 1}")))
+	layered.UString("db.password")
+	layered.UInt("cache.ttl")
+	expectPaths("db.password", "db.password", "db.password")
+
+	cfg.UMap("db")
+	cfg.UMap("")
+	expectPaths("db", "")
+
+	expect(t, len(cfg.ToEnv("")), 4)
+	expectPaths("")
+	expect(t, cfg.EncodeJson(ioutil.Discard), nil)
+	expect(t, cfg.EncodeYaml(ioutil.Discard), nil)
+	_, err := RenderJson(cfg)
+	expect(t, err, nil)
+	expectPaths("", "", "")
+	api := Must(cfg.Get("api"))
+	api.ToEnv("")
+	_, err = RenderYaml(api)
+	expect(t, err, nil)
+	Must(cfg.Get("db")).ToEnv("")
+	expectPaths("db")
+}
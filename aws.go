@@ -0,0 +1,101 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SSMClient is the subset of an AWS Systems Manager client used by
+// SSMSource. With github.com/aws/aws-sdk-go-v2/service/ssm, it pages
+// through GetParametersByPath with Recursive and WithDecryption set, so
+// that SecureString parameters are decrypted with KMS by the service.
+type SSMClient interface {
+	// GetParametersByPath returns the names and values of the
+	// parameters under a path, recursively.
+	GetParametersByPath(ctx context.Context, path string) (map[string]string, error)
+}
+
+// SSMSource loads a config from the SSM Parameter Store parameters under
+// a path: with the path "/app/prod", the parameter "/app/prod/db/host" is
+// read as "db.host". Values are decoded as JSON when possible and kept as
+// strings otherwise.
+type SSMSource struct {
+	Client SSMClient
+	Path   string
+	// Refresh, when positive, makes Watch notify periodically, so that
+	// a Manager reloads the parameters.
+	Refresh time.Duration
+}
+
+// Load reads the parameters under Path.
+func (s *SSMSource) Load(ctx context.Context) (*Config, error) {
+	params, err := s.Client.GetParametersByPath(ctx, s.Path)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	prefix := strings.TrimSuffix(s.Path, "/") + "/"
+	cfg := &Config{Root: map[string]interface{}{}}
+	for _, name := range names {
+		rel := strings.Trim(strings.TrimPrefix(name, prefix), "/")
+		if rel == "" {
+			continue
+		}
+		v, err := decodeFlatValue(params[name])
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Root, err = setParts(cfg.Root, strings.Split(rel, "/"), v); err != nil {
+			return nil, err
+		}
+	}
+	return newConfig(cfg.Root)
+}
+
+// Watch notifies every Refresh interval.
+func (s *SSMSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return pollChanges(ctx, s.Refresh), nil
+}
+
+// SecretsManagerClient is the subset of an AWS Secrets Manager client used
+// by SecretsManagerSource. With
+// github.com/aws/aws-sdk-go-v2/service/secretsmanager, it returns the
+// SecretString of GetSecretValue.
+type SecretsManagerClient interface {
+	GetSecretValue(ctx context.Context, secretID string) (string, error)
+}
+
+// SecretsManagerSource loads a config from a secret holding a JSON
+// document.
+type SecretsManagerSource struct {
+	Client   SecretsManagerClient
+	SecretID string
+	// Refresh, when positive, makes Watch notify periodically, so that
+	// a Manager reloads the secret.
+	Refresh time.Duration
+}
+
+// Load reads the secret.
+func (s *SecretsManagerSource) Load(ctx context.Context) (*Config, error) {
+	secret, err := s.Client.GetSecretValue(ctx, s.SecretID)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJson(secret)
+}
+
+// Watch notifies every Refresh interval.
+func (s *SecretsManagerSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return pollChanges(ctx, s.Refresh), nil
+}
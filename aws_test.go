@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeSSM map[string]string
+
+func (f fakeSSM) GetParametersByPath(ctx context.Context, path string) (map[string]string, error) {
+	return f, nil
+}
+
+type fakeSecrets struct {
+	mu     sync.Mutex
+	secret string
+}
+
+func (f *fakeSecrets) GetSecretValue(ctx context.Context, id string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id != "prod/app" {
+		return "", errors.New("ResourceNotFoundException")
+	}
+	return f.secret, nil
+}
+
+func TestSSMSource(t *testing.T) {
+	src := &SSMSource{Client: fakeSSM{
+		"/app/prod/db/host":     "db.internal",
+		"/app/prod/db/port":     "5432",
+		"/app/prod/feature.x":   "true",
+		"/app/prod/hosts/0":     "a",
+		"/app/prod/hosts/1":     "b",
+		"/app/prod/json/config": `{"retries": 3}`,
+	}, Path: "/app/prod/"}
+	cfg, err := src.Load(context.Background())
+	expect(t, err, nil)
+	expect(t, cfg.UString("db.host"), "db.internal")
+	expect(t, cfg.UInt("db.port"), 5432)
+	expect(t, cfg.UBool("[feature.x]"), true)
+	expect(t, cfg.UString("hosts.1"), "b")
+	expect(t, cfg.UInt("json.config.retries"), 3)
+}
+
+func TestSecretsManagerSource(t *testing.T) {
+	client := &fakeSecrets{secret: `{"db": {"password": "s3cret"}}`}
+	src := &SecretsManagerSource{Client: client, SecretID: "prod/app", Refresh: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	expect(t, m.Config().UString("db.password"), "s3cret")
+
+	// Refreshes pick up rotated secrets.
+	rotated := make(chan string, 1)
+	m.OnChange(func(old, new *Config) {
+		if p := new.UString("db.password"); p != old.UString("db.password") {
+			select {
+			case rotated <- p:
+			default:
+			}
+		}
+	})
+	go m.Watch(ctx)
+	client.mu.Lock()
+	client.secret = `{"db": {"password": "rotated"}}`
+	client.mu.Unlock()
+	expect(t, <-rotated, "rotated")
+
+	_, err = (&SecretsManagerSource{Client: client, SecretID: "missing"}).Load(ctx)
+	expect(t, err.Error(), "ResourceNotFoundException")
+}
@@ -0,0 +1,127 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AzureSetting is a key-value of Azure App Configuration.
+type AzureSetting struct {
+	Key         string
+	Value       string
+	ContentType string
+}
+
+// azureKeyVaultRefType is the content type of App Configuration settings
+// referencing a Key Vault secret.
+const azureKeyVaultRefType = "application/vnd.microsoft.appconfig.keyvaultref+json"
+
+// AzureAppConfigClient is the subset of an Azure App Configuration client
+// used by AzureAppConfigSource. With
+// github.com/Azure/azure-sdk-for-go/sdk/data/azappconfig, it pages through
+// NewListSettingsPager with the key and label filters.
+type AzureAppConfigClient interface {
+	ListSettings(ctx context.Context, keyFilter, label string) ([]AzureSetting, error)
+}
+
+// KeyVaultClient is the subset of an Azure Key Vault client used to
+// resolve secret references. With
+// github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets, it
+// picks the client of the vault named in the URI and calls GetSecret.
+type KeyVaultClient interface {
+	// GetSecret returns the value of a secret, given its identifier, like
+	// "https://vault.vault.azure.net/secrets/name" or the same with a
+	// trailing version.
+	GetSecret(ctx context.Context, uri string) (string, error)
+}
+
+// AzureAppConfigSource loads a config from the settings of Azure App
+// Configuration. Keys are split into paths by Separator, so with the
+// default ":" separator, the key "db:host" is read as "db.host".
+// Values are decoded as JSON when possible and kept as strings otherwise.
+type AzureAppConfigSource struct {
+	Client AzureAppConfigClient
+	// KeyFilter selects the keys, like "app:*"; empty selects all.
+	KeyFilter string
+	// Label selects the label of the settings; empty selects the ones
+	// without a label.
+	Label string
+	// TrimPrefix is removed from the keys, like "app:".
+	TrimPrefix string
+	// Separator splits keys into paths, ":" by default.
+	Separator string
+	// KeyVault, when set, resolves the settings referencing Key Vault
+	// secrets. Loading fails on such settings otherwise.
+	KeyVault KeyVaultClient
+}
+
+// Load reads the selected settings.
+func (s *AzureAppConfigSource) Load(ctx context.Context) (*Config, error) {
+	settings, err := s.Client.ListSettings(ctx, s.KeyFilter, s.Label)
+	if err != nil {
+		return nil, err
+	}
+	sep := s.Separator
+	if sep == "" {
+		sep = ":"
+	}
+	cfg := &Config{Root: map[string]interface{}{}}
+	for _, setting := range settings {
+		key := strings.TrimPrefix(setting.Key, s.TrimPrefix)
+		if key == "" {
+			continue
+		}
+		var v interface{}
+		if setting.ContentType == azureKeyVaultRefType {
+			v, err = s.resolveRef(ctx, setting)
+		} else {
+			v, err = decodeFlatValue(setting.Value)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cfg.Root, err = setParts(cfg.Root, strings.Split(key, sep), v); err != nil {
+			return nil, err
+		}
+	}
+	return newConfig(cfg.Root)
+}
+
+// resolveRef returns the secret referenced by a setting.
+func (s *AzureAppConfigSource) resolveRef(ctx context.Context, setting AzureSetting) (interface{}, error) {
+	if s.KeyVault == nil {
+		return nil, fmt.Errorf("Unresolved Key Vault reference at %q: no KeyVault client", setting.Key)
+	}
+	var ref struct {
+		URI string `json:"uri"`
+	}
+	if err := json.Unmarshal([]byte(setting.Value), &ref); err != nil || ref.URI == "" {
+		return nil, fmt.Errorf("Invalid Key Vault reference at %q", setting.Key)
+	}
+	return s.KeyVault.GetSecret(ctx, ref.URI)
+}
+
+// keyVaultRef matches the Key Vault references of App Service settings,
+// like "@Microsoft.KeyVault(SecretUri=https://vault.vault.azure.net/secrets/name/)".
+var keyVaultRef = regexp.MustCompile(`^@Microsoft\.KeyVault\(SecretUri=([^)]+)\)$`)
+
+// ResolveKeyVaultRefs replaces the string values of the config written as
+// App Service Key Vault references,
+// "@Microsoft.KeyVault(SecretUri=...)", by the secrets they reference.
+func ResolveKeyVaultRefs(ctx context.Context, cfg *Config, client KeyVaultClient) error {
+	return resolveRefs(cfg, "Key Vault", func(s string) (string, bool, error) {
+		m := keyVaultRef.FindStringSubmatch(s)
+		if m == nil {
+			return "", false, nil
+		}
+		secret, err := client.GetSecret(ctx, m[1])
+		return secret, true, err
+	})
+}
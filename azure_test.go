@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type fakeAppConfig []AzureSetting
+
+func (f fakeAppConfig) ListSettings(ctx context.Context, keyFilter, label string) ([]AzureSetting, error) {
+	var settings []AzureSetting
+	for _, s := range f {
+		if strings.HasPrefix(s.Key, strings.TrimSuffix(keyFilter, "*")) {
+			settings = append(settings, s)
+		}
+	}
+	return settings, nil
+}
+
+type fakeKeyVault map[string]string
+
+func (f fakeKeyVault) GetSecret(ctx context.Context, uri string) (string, error) {
+	if s, ok := f[uri]; ok {
+		return s, nil
+	}
+	return "", errors.New("SecretNotFound")
+}
+
+func TestAzureAppConfigSource(t *testing.T) {
+	settings := fakeAppConfig{
+		{Key: "app:db:host", Value: "db.internal"},
+		{Key: "app:db:port", Value: "5432"},
+		{Key: "app:db:password", Value: `{"uri":"https://v.vault.azure.net/secrets/db"}`,
+			ContentType: azureKeyVaultRefType},
+		{Key: "other:key", Value: "ignored"},
+	}
+	vault := fakeKeyVault{"https://v.vault.azure.net/secrets/db": "s3cret"}
+	src := &AzureAppConfigSource{
+		Client:     settings,
+		KeyFilter:  "app:*",
+		TrimPrefix: "app:",
+		KeyVault:   vault,
+	}
+	ctx := context.Background()
+	cfg, err := src.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UString("db.host"), "db.internal")
+	expect(t, cfg.UInt("db.port"), 5432)
+	expect(t, cfg.UString("db.password"), "s3cret")
+	_, err = cfg.Get("other")
+	expect(t, err != nil, true)
+
+	// Azure sources are layers like any other.
+	merged, err := NewLoader(StaticSource{Must(ParseYaml("db: {port: 1, pool: 5}"))}, src).Load(ctx)
+	expect(t, err, nil)
+	expect(t, merged.UInt("db.port"), 5432)
+	expect(t, merged.UInt("db.pool"), 5)
+
+	src.KeyVault = nil
+	_, err = src.Load(ctx)
+	expect(t, err.Error(), `Unresolved Key Vault reference at "app:db:password": no KeyVault client`)
+}
+
+func TestResolveKeyVaultRefs(t *testing.T) {
+	cfg, err := ParseYaml(`
+db:
+  password: "@Microsoft.KeyVault(SecretUri=https://v.vault.azure.net/secrets/db/)"
+  user: admin
+`)
+	expect(t, err, nil)
+	vault := fakeKeyVault{"https://v.vault.azure.net/secrets/db/": "s3cret"}
+	expect(t, ResolveKeyVaultRefs(context.Background(), cfg, vault), nil)
+	expect(t, cfg.UString("db.password"), "s3cret")
+	expect(t, cfg.UString("db.user"), "admin")
+
+	cfg.Set("db.password", "@Microsoft.KeyVault(SecretUri=https://v.vault.azure.net/secrets/missing)")
+	err = ResolveKeyVaultRefs(context.Background(), cfg, vault)
+	expect(t, err.Error(), `Unresolved Key Vault reference at "db.password": SecretNotFound`)
+}
@@ -0,0 +1,201 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Performance targets -------------------------------------------------------
+//
+// The getters are called in request paths, so a shallow lookup hitting a
+// value should take well under 200ns and not allocate, and deep lookups
+// should only grow with the depth of the path. TestGetAllocs guards the
+// allocations, the benchmarks the timings.
+
+// benchYaml returns a YAML document with n services of a few keys each.
+func benchYaml(n int) string {
+	var b strings.Builder
+	b.WriteString("name: bench\nport: 8080\nservices:\n")
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "  svc%d:\n    host: host%d.internal\n    port: %d\n    tags: [a, b, c]\n"+
+			"    limits: {cpu: 0.5, memory: 512Mi, pool: {min: 1, max: 10}}\n", i, i, 8000+i)
+	}
+	return b.String()
+}
+
+func TestGetAllocs(t *testing.T) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	allocs := testing.AllocsPerRun(100, func() {
+		cfg.Int("port")
+		cfg.String("services.svc5.tags.2")
+		cfg.UInt("services.svc5.limits.pool.max")
+		cfg.UInt("services.svc5.missing", 1)
+		cfg.StringOk("services.missing")
+	})
+	expect(t, allocs, 0.0)
+}
+
+func BenchmarkGetShallow(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.Int("port")
+	}
+}
+
+func BenchmarkGetDeep(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.Int("services.svc5.limits.pool.max")
+	}
+}
+
+func BenchmarkGetOptimized(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	cfg.Optimize()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.Int("services.svc5.limits.pool.max")
+	}
+}
+
+func BenchmarkGetList(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.String("services.svc5.tags.2")
+	}
+}
+
+func BenchmarkGetConfig(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.Get("services.svc5")
+	}
+}
+
+func BenchmarkSet(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.Set("services.svc5.limits.pool.max", i)
+	}
+}
+
+func BenchmarkParseLargeYaml(b *testing.B) {
+	doc := benchYaml(1000)
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseYaml(doc); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	base := Must(ParseYaml(benchYaml(100)))
+	override := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := base.Extend(override); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderJson(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenderJson(cfg)
+	}
+}
+
+func BenchmarkRenderJsonWith(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	opts := JsonOptions{Indent: "  ", KeyOrder: []string{"name"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenderJsonWith(cfg, opts)
+	}
+}
+
+func BenchmarkRenderYaml(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenderYaml(cfg)
+	}
+}
+
+func BenchmarkRenderYamlWith(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	opts := YamlOptions{Anchors: true, KeyOrder: []string{"name"}}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		RenderYamlWith(cfg, opts)
+	}
+}
+
+func BenchmarkEncodeYaml(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(10)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cfg.EncodeYaml(ioutil.Discard)
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	cfg := Must(ParseYaml(benchYaml(1000)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cp, _ := cfg.Copy()
+		cp.Set("services.svc5.port", i)
+	}
+}
+
+func BenchmarkParseDir(b *testing.B) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for i := 0; i < 24; i++ {
+		doc := strings.Replace(benchYaml(50), "svc", fmt.Sprintf("f%d_svc", i), -1)
+		if err := ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%02d.yaml", i)), []byte(doc), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := ParseDir(dir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// castValue converts a scalar to a schema type: string, int, float, bool
+// or time. Other types leave the value as is.
+func castValue(v interface{}, typ string) (interface{}, error) {
+	switch typ {
+	case "string":
+		return toString(v)
+	case "int":
+		return toInt(v)
+	case "float":
+		return toFloat64(v)
+	case "bool":
+		return toBool(v)
+	case "time":
+		return toTime(v)
+	case "", "list", "map":
+		return v, nil
+	}
+	return nil, fmt.Errorf("Unsupported type %q", typ)
+}
+
+// Cast converts the value at a dotted path to a type, once, so that later
+// getters find the values they expect. Types are the ones of schemas:
+// string, int, float, bool and time. When the value is a map or a list,
+// all the values under it are converted, like the ports of a list read
+// from environment variables:
+//
+//	err := cfg.Cast("ports", "int")
+//
+// Null values are kept. Nothing is changed when any conversion fails.
+func (cfg *Config) Cast(path, typ string) error {
+	if err := cfg.checkSealed("Cast", path); err != nil {
+		return err
+	}
+	n, err := Get(cfg.Root, path)
+	if err != nil {
+		return err
+	}
+	casted := map[string]interface{}{}
+	walkLeaves(n, "", func(leaf string, v interface{}) {
+		switch v.(type) {
+		case nil, map[string]interface{}, []interface{}:
+			return
+		}
+		if err != nil {
+			return
+		}
+		leaf = canonicalPath(path, leaf)
+		if casted[leaf], err = castValue(v, typ); err != nil {
+			err = fmt.Errorf("Can't cast %q to %s: %v", displayPath(leaf), typ, err)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for leaf, v := range casted {
+		if leaf == "" {
+			if err := cfg.checkRoot(v); err != nil {
+				return err
+			}
+			cfg.Root = v
+		} else if err := cfg.Set(leaf, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Coerce converts the values of the config to the types declared by the
+// schema, once, so that getters are exact afterwards. Values missing from
+// the config are skipped, and the first value which can't be converted
+// is reported, leaving the config partly converted; Validate the config
+// first to report every mismatch.
+func (s *Schema) Coerce(cfg *Config) error {
+	if err := cfg.checkSealed("Coerce", ""); err != nil {
+		return err
+	}
+	return s.coerce(cfg, "")
+}
+
+func (s *Schema) coerce(cfg *Config, path string) error {
+	n, err := Get(cfg.Root, path)
+	if err != nil {
+		// Missing values are the business of Validate.
+		return nil
+	}
+	if n != nil {
+		switch s.Type {
+		case "string", "int", "float", "bool", "time":
+			v, err := castValue(n, s.Type)
+			if err != nil {
+				return fmt.Errorf("Can't cast %q to %s: %v", displayPath(path), s.Type, err)
+			}
+			if path == "" {
+				if err := cfg.checkRoot(v); err != nil {
+					return err
+				}
+				cfg.Root = v
+			} else if err := cfg.Set(path, v); err != nil {
+				return err
+			}
+		}
+	}
+	for name, sub := range s.Keys {
+		if err := sub.coerce(cfg, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	if s.Items != nil {
+		list, _ := n.([]interface{})
+		for i := range list {
+			if err := s.Items.coerce(cfg, joinPath(path, strconv.Itoa(i))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestCast(t *testing.T) {
+	cfg := Must(ParseYaml(`
+ports: ["80", "443", null]
+limits: {cpu: "2", memory: "512"}
+debug: "true"
+name: x
+`))
+	expect(t, cfg.Cast("ports", "int"), nil)
+	expect(t, cfg.UList("ports")[1], 443)
+	expect(t, cfg.UList("ports")[2], nil)
+	expect(t, cfg.Cast("limits", "float"), nil)
+	expect(t, cfg.UMap("limits")["memory"], 512.0)
+	expect(t, cfg.Cast("debug", "bool"), nil)
+	expect(t, cfg.Root.(map[string]interface{})["debug"], true)
+
+	expect(t, cfg.Cast("name", "int").Error(),
+		`Can't cast "name" to int: strconv.ParseInt: parsing "x": invalid syntax`)
+	expect(t, cfg.UString("name"), "x")
+	expect(t, cfg.Cast("name", "decimal") != nil, true)
+	expect(t, cfg.Cast("missing", "int") != nil, true)
+
+	root := Must(ParseYaml(`"42"`))
+	expect(t, root.Cast("", "int"), nil)
+	expect(t, root.Root, 42)
+}
+
+func TestSchemaCoerce(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(schemaString)))
+	expect(t, err, nil)
+	cfg := Must(ParseYaml(`
+database: {host: localhost, port: "5432"}
+servers: [{name: a, weight: "0.5"}, {name: 2}]
+debug: "true"
+`))
+	expect(t, schema.Coerce(cfg), nil)
+	root := cfg.Root.(map[string]interface{})
+	expect(t, root["debug"], true)
+	expect(t, cfg.UMap("database")["port"], 5432)
+	expect(t, cfg.UMap("servers.0")["weight"], 0.5)
+	expect(t, cfg.UMap("servers.1")["name"], "2")
+
+	cfg = Must(ParseYaml("database: {port: many}"))
+	expect(t, schema.Coerce(cfg).Error(),
+		`Can't cast "database.port" to int: strconv.ParseInt: parsing "many": invalid syntax`)
+}
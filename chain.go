@@ -0,0 +1,152 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Cursor navigates a config one step at a time, without checking for
+// errors on each step:
+//
+//	name, err := cfg.At("development").At("users").Index(0).Key("name").AsString()
+//
+// The first error, like an invalid path or a negative index, is kept and
+// returned by the terminal As* call, which resolves the whole path with
+// the matching getter. Cursors are immutable; every step returns a new one.
+type Cursor struct {
+	cfg  *Config
+	keys []string
+	err  error
+}
+
+// At returns a cursor positioned at a dotted path.
+func (cfg *Config) At(path string) *Cursor {
+	return (&Cursor{cfg: cfg}).At(path)
+}
+
+// At moves the cursor by a dotted path.
+func (c *Cursor) At(path string) *Cursor {
+	if c.err != nil {
+		return c
+	}
+	parts, err := parsePath(path)
+	if err != nil {
+		return &Cursor{cfg: c.cfg, keys: c.keys, err: err}
+	}
+	return c.with(parts...)
+}
+
+// Key moves the cursor to a map key, which is taken literally, so it may
+// contain dots.
+func (c *Cursor) Key(key string) *Cursor {
+	if c.err != nil {
+		return c
+	}
+	return c.with(key)
+}
+
+// Index moves the cursor to a list item.
+func (c *Cursor) Index(i int) *Cursor {
+	if c.err != nil {
+		return c
+	}
+	if i < 0 {
+		return &Cursor{cfg: c.cfg, keys: c.keys, err: fmt.Errorf(
+			"Invalid list index at %q", joinPath(c.Path(), strconv.Itoa(i)))}
+	}
+	return c.with(strconv.Itoa(i))
+}
+
+// with returns a cursor moved by the given keys.
+func (c *Cursor) with(keys ...string) *Cursor {
+	moved := make([]string, 0, len(c.keys)+len(keys))
+	moved = append(append(moved, c.keys...), keys...)
+	return &Cursor{cfg: c.cfg, keys: moved}
+}
+
+// Path returns the dotted path of the cursor.
+func (c *Cursor) Path() string {
+	return formatPath(c.keys)
+}
+
+// Err returns the first error met while moving the cursor, if any.
+func (c *Cursor) Err() error {
+	return c.err
+}
+
+// Config returns the config at the cursor.
+func (c *Cursor) Config() (*Config, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.cfg.Get(c.Path())
+}
+
+// AsBool returns the bool at the cursor.
+func (c *Cursor) AsBool() (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	return c.cfg.Bool(c.Path())
+}
+
+// AsFloat64 returns the float64 at the cursor.
+func (c *Cursor) AsFloat64() (float64, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.cfg.Float64(c.Path())
+}
+
+// AsInt returns the int at the cursor.
+func (c *Cursor) AsInt() (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.cfg.Int(c.Path())
+}
+
+// AsList returns the []interface{} at the cursor.
+func (c *Cursor) AsList() ([]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.cfg.List(c.Path())
+}
+
+// AsMap returns the map[string]interface{} at the cursor.
+func (c *Cursor) AsMap() (map[string]interface{}, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.cfg.Map(c.Path())
+}
+
+// AsString returns the string at the cursor.
+func (c *Cursor) AsString() (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	return c.cfg.String(c.Path())
+}
+
+// AsTime returns the time.Time at the cursor.
+func (c *Cursor) AsTime() (time.Time, error) {
+	if c.err != nil {
+		return time.Time{}, c.err
+	}
+	return c.cfg.Time(c.Path())
+}
+
+// AsBytes returns the []byte at the cursor.
+func (c *Cursor) AsBytes() ([]byte, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.cfg.Bytes(c.Path())
+}
@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestCursor(t *testing.T) {
+	cfg, err := ParseYaml(`
+development:
+  users:
+    - name: calvin
+      age: 6
+  dotted.key: value
+`)
+	expect(t, err, nil)
+
+	name, err := cfg.At("development").At("users").Index(0).Key("name").AsString()
+	expect(t, err, nil)
+	expect(t, name, "calvin")
+	age, err := cfg.At("development.users").Index(0).At("age").AsInt()
+	expect(t, err, nil)
+	expect(t, age, 6)
+	s, err := cfg.At("development").Key("dotted.key").AsString()
+	expect(t, err, nil)
+	expect(t, s, "value")
+	expect(t, cfg.At("development").Key("dotted.key").Path(), "development.[dotted.key]")
+
+	// Cursors don't share their keys.
+	users := cfg.At("development.users")
+	first, second := users.Index(0), users.Index(1)
+	expect(t, first.Path(), "development.users.0")
+	expect(t, second.Path(), "development.users.1")
+
+	// The first error is kept until the terminal call.
+	c := cfg.At("development").Index(-1).Key("name")
+	expect(t, c.Err().Error(), `Invalid list index at "development.-1"`)
+	_, err = c.AsString()
+	expect(t, err.Error(), `Invalid list index at "development.-1"`)
+	_, err = cfg.At("a..b").At("c").AsInt()
+	expect(t, err.Error(), `Invalid path "a..b": empty key at offset 2`)
+
+	_, err = second.Key("name").AsString()
+	expect(t, err.Error(), `Index out of range at "development.users.1": list has only 1 items`)
+	_, err = first.Key("name").AsInt()
+	expect(t, err != nil, true)
+
+	sub, err := first.Config()
+	expect(t, err, nil)
+	expect(t, sub.UString("name"), "calvin")
+}
@@ -0,0 +1,504 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command config reads, edits and converts JSON, YAML and property list
+// configuration files using the dotted paths of the github.com/olebedev/config package.
+//
+// Usage:
+//
+//	config get [-o format] FILE PATH
+//	config set [-o format] [-i] FILE PATH VALUE
+//	config merge [-o format] [--set PATH=VALUE]... FILE...
+//	config convert [-o format] [--set PATH=VALUE]... FILE
+//	config validate -schema SCHEMA FILE
+//	config diff OLD NEW
+//	config explain [-env PREFIX] PATH FILE...
+//	config complete FILE PREFIX
+//
+// The format of a file is guessed from its extension, and the output uses
+// the format of the first file unless -o is given. Values passed to set are
+// parsed as YAML, so "5" is an int and "true" a bool. The --set flags of
+// merge and convert override values like kubectl and helm ones, see
+// config.ApplyOverrides: --set replicas=3 --set image.tag:string=1.10.
+// complete prints the paths extending a partial path, for shell
+// completion.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/olebedev/config"
+)
+
+const usage = `usage: config <command> [flags] [args]
+
+commands:
+  get [-o format] FILE PATH             print the value at PATH
+  set [-o format] [-i] FILE PATH VALUE  set PATH to VALUE and print the result
+  merge [-o format] FILE...             merge files, later ones taking priority
+  convert [-o format] FILE              print FILE in another format
+  validate -schema SCHEMA FILE          validate FILE against a schema
+  diff OLD NEW                          print the paths which differ
+  explain [-env PREFIX] PATH FILE...    show which layer sets PATH
+  complete FILE PREFIX                  print the paths completing PREFIX
+
+merge and convert take --set PATH[:TYPE]=VALUE flags overriding values.
+
+formats: yaml, json, plist
+`
+
+// errUsage is returned when the command line is malformed.
+var errUsage = errors.New("invalid usage")
+
+// errFailed is returned when a command fails after reporting the
+// problems itself, like validate or diff.
+var errFailed = errors.New("failed")
+
+// commands maps command names to their implementations.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"get":      get,
+	"set":      set,
+	"merge":    merge,
+	"convert":  convert,
+	"validate": validate,
+	"diff":     diff,
+	"explain":  explain,
+	"complete": complete,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if err == errUsage {
+			fmt.Fprint(os.Stderr, usage)
+			os.Exit(2)
+		}
+		if err != errFailed {
+			fmt.Fprintln(os.Stderr, "config:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// run executes the command line given in args.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return errUsage
+	}
+	return cmd(args[1:], stdout)
+}
+
+// newFlagSet returns a flag set for the named command, with the -o flag
+// defined.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return fs, fs.String("o", "", "output format: yaml, json or plist")
+}
+
+// overrides collects the values of repeated --set flags.
+type overrides []string
+
+func (o *overrides) String() string { return strings.Join(*o, ",") }
+
+func (o *overrides) Set(value string) error {
+	*o = append(*o, value)
+	return nil
+}
+
+// outputFormat resolves the -o flag, defaulting to the format of the
+// given file.
+func outputFormat(name, filename string) (config.Format, error) {
+	switch name {
+	case "":
+		return config.FormatOf(filename), nil
+	case "yaml", "yml":
+		return config.FormatYaml, nil
+	case "json":
+		return config.FormatJson, nil
+	case "plist":
+		return config.FormatPlist, nil
+	}
+	return 0, fmt.Errorf("unknown format %q", name)
+}
+
+// write renders the value in the given format to w.
+func write(w io.Writer, format config.Format, value interface{}) error {
+	var out string
+	var err error
+	switch format {
+	case config.FormatJson:
+		if out, err = config.RenderJson(value); err == nil {
+			out += "\n"
+		}
+	case config.FormatPlist:
+		out, err = config.RenderPlist(value)
+	default:
+		out, err = config.RenderYaml(value)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, out)
+	return err
+}
+
+func get(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("get")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 2 {
+		return errUsage
+	}
+	format, err := outputFormat(*o, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	sub, err := cfg.Get(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	switch sub.Root.(type) {
+	case map[string]interface{}, []interface{}:
+		return write(stdout, format, sub.Root)
+	case nil:
+		_, err = fmt.Fprintln(stdout, "null")
+		return err
+	}
+	value, err := cfg.String(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, value)
+	return err
+}
+
+func set(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("set")
+	inPlace := fs.Bool("i", false, "write the result back to the file")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 3 {
+		return errUsage
+	}
+	filename := fs.Arg(0)
+	format, err := outputFormat(*o, filename)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(filename)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Set(fs.Arg(1), parseValue(fs.Arg(2))); err != nil {
+		return err
+	}
+	if *inPlace {
+		return writeFile(filename, cfg.Root)
+	}
+	return write(stdout, format, cfg.Root)
+}
+
+// formatExts holds the extensions of the formats written by writeFile.
+var formatExts = map[string]bool{".json": true, ".yaml": true, ".yml": true, ".plist": true}
+
+// writeFile writes the value to a file in the format of the file, and
+// compressed like it. The value is rendered first and written to a
+// temporary file renamed over the file, which is left alone on errors.
+func writeFile(filename string, value interface{}) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	compressed := !formatExts[ext] && formatExts[strings.ToLower(filepath.Ext(filename[:len(filename)-len(ext)]))]
+	format := config.FormatOf(filename)
+	if compressed && format == config.FormatPlist {
+		return fmt.Errorf("can't write %s: compressed property lists aren't supported", filename)
+	}
+
+	var data []byte
+	var buf bytes.Buffer
+	switch {
+	case format == config.FormatPlist && isBinaryPlist(filename):
+		b, err := config.RenderBinaryPlist(value)
+		if err != nil {
+			return err
+		}
+		data = b
+	default:
+		if err := write(&buf, format, value); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	f, err := ioutil.TempFile(filepath.Dir(filename), "."+filepath.Base(filename)+".*"+filepath.Ext(filename))
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	switch {
+	case !compressed:
+		_, err = f.Write(data)
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	case format == config.FormatJson:
+		// The temporary file keeps the extension choosing the codec.
+		f.Close()
+		err = config.SaveJsonFileCompressed(tmp, value)
+	default:
+		f.Close()
+		err = config.SaveYamlFileCompressed(tmp, value)
+	}
+	if err == nil {
+		if info, serr := os.Stat(filename); serr == nil {
+			err = os.Chmod(tmp, info.Mode())
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, filename)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// isBinaryPlist reports whether a file holds a binary property list.
+func isBinaryPlist(filename string) bool {
+	f, err := os.Open(filename)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, 6)
+	_, err = io.ReadFull(f, magic)
+	return err == nil && string(magic) == "bplist"
+}
+
+// parseValue parses a command line value as a YAML scalar or document,
+// falling back to the plain string.
+func parseValue(value string) interface{} {
+	if value == "" {
+		return value
+	}
+	cfg, err := config.ParseYaml(value)
+	if err != nil || cfg.Root == nil {
+		return value
+	}
+	return cfg.Root
+}
+
+func merge(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("merge")
+	var sets overrides
+	fs.Var(&sets, "set", "override a value: PATH[:TYPE]=VALUE")
+	if err := fs.Parse(args); err != nil || fs.NArg() == 0 {
+		return errUsage
+	}
+	format, err := outputFormat(*o, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, filename := range fs.Args()[1:] {
+		next, err := config.ParseFile(filename)
+		if err != nil {
+			return err
+		}
+		if cfg, err = cfg.Extend(next); err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	}
+	if err := config.ApplyOverrides(cfg, sets); err != nil {
+		return err
+	}
+	return write(stdout, format, cfg.Root)
+}
+
+func convert(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("convert")
+	var sets overrides
+	fs.Var(&sets, "set", "override a value: PATH[:TYPE]=VALUE")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 {
+		return errUsage
+	}
+	format, err := outputFormat(*o, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := config.ApplyOverrides(cfg, sets); err != nil {
+		return err
+	}
+	return write(stdout, format, cfg.Root)
+}
+
+func validate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	schemaFile := fs.String("schema", "", "schema file")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 1 || *schemaFile == "" {
+		return errUsage
+	}
+	schemaCfg, err := config.ParseFile(*schemaFile)
+	if err != nil {
+		return err
+	}
+	schema, err := config.ParseSchema(schemaCfg)
+	if err != nil {
+		return err
+	}
+	cfg, err := config.ParseFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	report := schema.Check(cfg)
+	if s := report.String(); s != "" {
+		fmt.Fprintln(stdout, s)
+	}
+	if report.Fatal() {
+		return errFailed
+	}
+	return nil
+}
+
+func diff(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	from, err := config.ParseFile(args[0])
+	if err != nil {
+		return err
+	}
+	to, err := config.ParseFile(args[1])
+	if err != nil {
+		return err
+	}
+	changes := config.Diff(from, to)
+	for _, change := range changes {
+		fmt.Fprintln(stdout, change)
+	}
+	if len(changes) > 0 {
+		return errFailed
+	}
+	return nil
+}
+
+// layer is a named source of values considered by explain.
+type layer struct {
+	name string
+	cfg  *config.Config
+}
+
+func explain(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	prefix := fs.String("env", "", "environment variable prefix, as used by EnvPrefix")
+	if err := fs.Parse(args); err != nil || fs.NArg() < 2 {
+		return errUsage
+	}
+	path := fs.Arg(0)
+
+	layers := []layer{}
+	for _, filename := range fs.Args()[1:] {
+		cfg, err := config.ParseFile(filename)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, layer{filename, cfg})
+	}
+	if *prefix != "" {
+		env, err := envLayer(*prefix, path)
+		if err != nil {
+			return err
+		}
+		if env != nil {
+			layers = append(layers, *env)
+		}
+	}
+
+	// Report layers from the highest priority down.
+	found := false
+	for i := len(layers) - 1; i >= 0; i-- {
+		l := layers[i]
+		value, err := display(l.cfg, path)
+		if err != nil {
+			continue
+		}
+		if !found {
+			fmt.Fprintf(stdout, "%s: %s (from %s)\n", path, value, l.name)
+			fmt.Fprintf(stdout, "  * %s: %s\n", l.name, value)
+			found = true
+		} else {
+			fmt.Fprintf(stdout, "    %s: %s\n", l.name, value)
+		}
+	}
+	if !found {
+		return fmt.Errorf("%s is not set in any layer", path)
+	}
+	return nil
+}
+
+// envLayer returns the layer of the environment variable which EnvPrefix
+// would read for path, or nil when it isn't set. The layer holds the value
+// at path, like the layers of files.
+func envLayer(prefix, path string) (*layer, error) {
+	cfg := &config.Config{}
+	if err := cfg.Set(path, ""); err != nil {
+		return nil, err
+	}
+	// ToEnv names the variable like EnvPrefix, whatever the form of path.
+	name := strings.TrimSuffix(cfg.ToEnv(prefix)[0], "=")
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, nil
+	}
+	if err := cfg.Set(path, value); err != nil {
+		return nil, err
+	}
+	return &layer{"$" + name, cfg}, nil
+}
+
+// display returns the value at path in a single-line form.
+func display(cfg *config.Config, path string) (string, error) {
+	sub, err := cfg.Get(path)
+	if err != nil {
+		return "", err
+	}
+	switch sub.Root.(type) {
+	case map[string]interface{}, []interface{}, nil:
+		return config.RenderJson(sub.Root)
+	}
+	return cfg.String(path)
+}
+
+func complete(args []string, stdout io.Writer) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	cfg, err := config.ParseFile(args[0])
+	if err != nil {
+		return err
+	}
+	for _, path := range cfg.Complete(args[1]) {
+		fmt.Fprintln(stdout, path)
+	}
+	return nil
+}
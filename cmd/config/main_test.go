@@ -0,0 +1,221 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olebedev/config"
+)
+
+// tempFiles writes the given files into a new temporary directory and
+// returns its path.
+func tempFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "config-cmd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCommands(t *testing.T) {
+	dir := tempFiles(t, map[string]string{
+		"base.yaml": "database:\n  host: localhost\n  port: 5432\n",
+		"prod.json": `{"database": {"host": "db.example.com"}}`,
+		"schema.yaml": `
+type: map
+keys:
+  database:
+    type: map
+    keys:
+      host: {type: string, deprecated: "use database.url"}
+      port: {type: int, required: true}
+      user: {type: string, required: true}
+`,
+	})
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "base.yaml")
+	prod := filepath.Join(dir, "prod.json")
+	schema := filepath.Join(dir, "schema.yaml")
+
+	tests := []struct {
+		args []string
+		want string
+		err  error
+	}{
+		{[]string{"get", base, "database.host"}, "localhost\n", nil},
+		{[]string{"get", "-o", "json", base, "database"}, `{"host":"localhost","port":5432}` + "\n", nil},
+		{[]string{"get", prod, "database"}, `{"host":"db.example.com"}` + "\n", nil},
+		{[]string{"set", base, "database.port", "6432"}, "database:\n  host: localhost\n  port: 6432\n", nil},
+		{[]string{"set", "-o", "json", base, "debug", "true"}, `{"database":{"host":"localhost","port":5432},"debug":true}` + "\n", nil},
+		{[]string{"merge", base, prod}, "database:\n  host: db.example.com\n  port: 5432\n", nil},
+		{[]string{"convert", "-o", "json", base}, `{"database":{"host":"localhost","port":5432}}` + "\n", nil},
+		{[]string{"convert", "-o", "json", "--set", "database.port=6432", "--set", "database.user:string=007", base},
+			`{"database":{"host":"localhost","port":6432,"user":"007"}}` + "\n", nil},
+		{[]string{"merge", "--set", "debug=true", base, prod}, "database:\n  host: db.example.com\n  port: 5432\ndebug: true\n", nil},
+		{[]string{"validate", "-schema", schema, base}, "error: database.user: required value is missing\n" +
+			"warning: database.host: use database.url\n", errFailed},
+		{[]string{"validate", base}, "", errUsage},
+		{[]string{"diff", base, prod}, "~ database.host: localhost -> db.example.com\n- database.port: 5432\n", errFailed},
+		{[]string{"diff", base, base}, "", nil},
+		{[]string{"explain", "database.host", base, prod}, "database.host: db.example.com (from " + prod + ")\n" +
+			"  * " + prod + ": db.example.com\n" +
+			"    " + base + ": localhost\n", nil},
+		{[]string{"explain", "database.port", base, prod}, "database.port: 5432 (from " + base + ")\n" +
+			"  * " + base + ": 5432\n", nil},
+		{[]string{"complete", base, "database."}, "database.host\ndatabase.port\n", nil},
+		{[]string{"complete", base, "x"}, "", nil},
+		{[]string{"get", base}, "", errUsage},
+		{[]string{"unknown"}, "", errUsage},
+		{nil, "", errUsage},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		err := run(test.args, &out)
+		if err != test.err {
+			t.Errorf("%v: got error %v, want %v", test.args, err, test.err)
+		}
+		if out.String() != test.want {
+			t.Errorf("%v: got %q, want %q", test.args, out.String(), test.want)
+		}
+	}
+
+	// Errors from the package are passed through.
+	if err := run([]string{"get", base, "database.user"}, ioutil.Discard); err == nil {
+		t.Error("expected error for a missing path")
+	}
+}
+
+func TestSetInPlace(t *testing.T) {
+	dir := tempFiles(t, map[string]string{
+		"app.json": `{"name": "app"}`,
+	})
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "app.json")
+
+	var out bytes.Buffer
+	if err := run([]string{"set", "-i", filename, "replicas", "3"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("unexpected output %q", out.String())
+	}
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"name":"app","replicas":3}` + "\n"; string(content) != want {
+		t.Errorf("got %q, want %q", content, want)
+	}
+}
+
+func TestSetInPlaceFormats(t *testing.T) {
+	dir := tempFiles(t, map[string]string{
+		"app.plist": `<?xml version="1.0" encoding="UTF-8"?>
+<plist version="1.0"><dict><key>name</key><string>app</string></dict></plist>`,
+	})
+	defer os.RemoveAll(dir)
+	plist := filepath.Join(dir, "app.plist")
+	gz := filepath.Join(dir, "app.yaml.gz")
+	if err := config.SaveYamlFileCompressed(gz, map[string]interface{}{"name": "app"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Property lists are printed as property lists, like the other formats.
+	for _, args := range [][]string{{"convert", plist}, {"convert", "-o", "plist", gz}} {
+		var out bytes.Buffer
+		if err := run(args, &out); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.ParsePlist(out.Bytes())
+		if err != nil {
+			t.Fatalf("%v: %v in %q", args, err, out.String())
+		}
+		if cfg.UString("name") != "app" {
+			t.Errorf("%v: unexpected content %v", args, cfg.Root)
+		}
+	}
+
+	for _, filename := range []string{plist, gz} {
+		if err := run([]string{"set", "-i", filename, "replicas", "3"}, ioutil.Discard); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := config.ParseFile(filename)
+		if err != nil {
+			t.Fatalf("%s: %v", filename, err)
+		}
+		if cfg.UString("name") != "app" || cfg.UInt("replicas") != 3 {
+			t.Errorf("%s: unexpected content %v", filename, cfg.Root)
+		}
+	}
+
+	// Values which can't be rendered leave the file alone.
+	before, err := ioutil.ReadFile(plist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run([]string{"set", "-i", plist, "extra", "{a: null}"}, ioutil.Discard); err == nil {
+		t.Error("expected an error for a null value in a property list")
+	}
+	after, err := ioutil.ReadFile(plist)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Errorf("the file changed to %q", after)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 2 {
+		t.Errorf("temporary files were left: %d files", len(files))
+	}
+}
+
+func TestExplainEnv(t *testing.T) {
+	dir := tempFiles(t, map[string]string{
+		"base.yaml": "database:\n  host: localhost\n",
+	})
+	defer os.RemoveAll(dir)
+	base := filepath.Join(dir, "base.yaml")
+
+	os.Setenv("EXPLAIN_DATABASE_HOST", "env.example.com")
+	defer os.Unsetenv("EXPLAIN_DATABASE_HOST")
+
+	var out bytes.Buffer
+	if err := run([]string{"explain", "-env", "explain", "database.host", base}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "database.host: env.example.com (from $EXPLAIN_DATABASE_HOST)\n" +
+		"  * $EXPLAIN_DATABASE_HOST: env.example.com\n" +
+		"    " + base + ": localhost\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+
+	if err := run([]string{"explain", "database.user", base}, ioutil.Discard); err == nil {
+		t.Error("expected error for a path missing from every layer")
+	}
+
+	// Variables are named like EnvPrefix names them, whatever the form
+	// of the path.
+	os.Setenv("EXPLAIN_SERVERS_0_A_B", "env")
+	defer os.Unsetenv("EXPLAIN_SERVERS_0_A_B")
+	out.Reset()
+	if err := run([]string{"explain", "-env", "explain", `servers[0]."a.b"`, base}, &out); err != nil {
+		t.Fatal(err)
+	}
+	want = `servers[0]."a.b": env (from $EXPLAIN_SERVERS_0_A_B)` + "\n" +
+		"  * $EXPLAIN_SERVERS_0_A_B: env\n"
+	if out.String() != want {
+		t.Errorf("got %q, want %q", out.String(), want)
+	}
+}
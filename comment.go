@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+// Comment holds the comments of a value, written when the config is
+// rendered as YAML: the head comment on the lines above the value, and
+// the line comment at the end of its line.
+type Comment struct {
+	Head string
+	Line string
+}
+
+// SetComment sets the comments of the value at a dotted path, so that
+// tools editing a file can tell why a value changed:
+//
+//	cfg.Set("replicas", 5)
+//	cfg.SetComment("replicas", "Raised for the launch, see INC-42.", "was 3")
+//	out, err := config.RenderYaml(cfg)
+//
+// Head comments may span several lines. Empty comments remove them. The
+// comments are kept by Copy, and written by RenderYaml, EncodeYaml and
+// the Save functions when given the *Config; YAML parsing doesn't read
+// comments back.
+func (cfg *Config) SetComment(path, head, line string) error {
+	parts, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	path = formatPath(parts)
+	if head == "" && line == "" {
+		delete(cfg.comments, path)
+		return nil
+	}
+	if cfg.comments == nil {
+		cfg.comments = map[string]Comment{}
+	}
+	cfg.comments[path] = Comment{Head: head, Line: line}
+	return nil
+}
+
+// Comment returns the comments of the value at a dotted path.
+func (cfg *Config) Comment(path string) (head, line string) {
+	c := cfg.comments[canonicalPath("", path)]
+	return c.Head, c.Line
+}
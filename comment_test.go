@@ -0,0 +1,73 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	cfg := Must(ParseYaml(`
+name: app
+replicas: 3
+servers:
+  - host: a
+    port: 80
+  - host: b
+notes: "first\nsecond"
+matrix: [[1, 2], []]
+empty: {}
+`))
+	expect(t, cfg.Set("replicas", 5), nil)
+	expect(t, cfg.SetComment("replicas", "Raised for the launch.\nSee INC-42.", "was 3"), nil)
+	expect(t, cfg.SetComment("servers.0", "Primary", ""), nil)
+	expect(t, cfg.SetComment("servers[1].host", "", "standby"), nil)
+	expect(t, cfg.SetComment("matrix.0.1", "", "second"), nil)
+	expect(t, cfg.SetComment("name", "Service name", ""), nil)
+	expect(t, cfg.SetComment("name", "", ""), nil)
+
+	head, line := cfg.Comment("[replicas]")
+	expect(t, head, "Raised for the launch.\nSee INC-42.")
+	expect(t, line, "was 3")
+
+	want := `empty: {}
+matrix:
+- - 1
+  - 2 # second
+- []
+name: app
+notes: |-
+  first
+  second
+# Raised for the launch.
+# See INC-42.
+replicas: 5 # was 3
+servers:
+# Primary
+- host: a
+  port: 80
+- host: b # standby
+`
+	out, err := RenderYaml(cfg)
+	expect(t, err, nil)
+	expect(t, out, want)
+
+	// Without comments, the output is the one of yaml.v2.
+	plain, err := RenderYaml(cfg.Root)
+	expect(t, err, nil)
+	reparsed := Must(ParseYaml(out))
+	expect(t, len(Diff(cfg, reparsed)), 0)
+	expect(t, plain != out, true)
+
+	copied, err := cfg.Copy()
+	expect(t, err, nil)
+	_, line = copied.Comment("replicas")
+	expect(t, line, "was 3")
+
+	var buf bytes.Buffer
+	expect(t, cfg.EncodeYaml(&buf), nil)
+	expect(t, buf.String(), want)
+}
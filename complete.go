@@ -0,0 +1,102 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Complete returns the paths extending a partial dotted path by one
+// segment, for shell completion and key pickers:
+//
+//	cfg.Complete("db.h")  // ["db.host"]
+//	cfg.Complete("db.")   // ["db.host", "db.port"]
+//	cfg.Complete("")      // the top-level keys
+//
+// Keys needing brackets, like the ones containing dots, are completed in
+// their bracketed form, and lists complete to their indices. Fallback
+// configs contribute their keys too.
+func (cfg *Config) Complete(prefix string) []string {
+	base, partial := splitPartialPath(prefix)
+	parts, err := parsePath(base)
+	if err != nil {
+		return []string{}
+	}
+	base = formatPath(parts)
+
+	seen := map[string]bool{}
+	var keys, indices []string
+	var collect func(c *Config)
+	collect = func(c *Config) {
+		node, err := getParts(c.Root, parts)
+		if err == nil {
+			switch n := node.(type) {
+			case map[string]interface{}:
+				for k := range n {
+					if key := formatKey(k); !seen[key] && strings.HasPrefix(key, partial) {
+						seen[key] = true
+						keys = append(keys, key)
+					}
+				}
+			case []interface{}:
+				for i := range n {
+					if key := strconv.Itoa(i); !seen[key] && strings.HasPrefix(key, partial) {
+						seen[key] = true
+						indices = append(indices, key)
+					}
+				}
+			}
+		}
+		if c.primary != nil {
+			collect(c.primary)
+		}
+		for _, fallback := range c.fallbacks {
+			collect(fallback)
+		}
+	}
+	collect(cfg)
+
+	// Indices are already in their numeric order.
+	sort.Strings(keys)
+	candidates := make([]string, 0, len(keys)+len(indices))
+	for _, key := range append(indices, keys...) {
+		if base == "" {
+			candidates = append(candidates, key)
+		} else {
+			candidates = append(candidates, base+"."+key)
+		}
+	}
+	return candidates
+}
+
+// splitPartialPath splits a partial path at its last dot outside of
+// brackets and quotes, into the complete part and the segment being typed.
+func splitPartialPath(path string) (string, string) {
+	last := -1
+	var closing byte
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		switch {
+		case c == '\\':
+			i++
+		case closing != 0:
+			if c == closing {
+				closing = 0
+			}
+		case c == '[':
+			closing = ']'
+		case c == '"' || c == '\'':
+			closing = c
+		case c == '.':
+			last = i
+		}
+	}
+	if last < 0 {
+		return "", path
+	}
+	return path[:last], path[last+1:]
+}
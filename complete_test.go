@@ -0,0 +1,53 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	cfg, err := ParseYaml(`
+db:
+  host: localhost
+  hosts: [a, b]
+  port: 5432
+servers: [{name: a}, {name: b}]
+a.b: {c: 1}
+`)
+	expect(t, err, nil)
+	defaults, err := ParseYaml(`
+db: {user: app, host: default}
+debug: false
+`)
+	expect(t, err, nil)
+	cfg = cfg.WithFallback(defaults)
+
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"[a.b]", "db", "debug", "servers"}},
+		{"d", []string{"db", "debug"}},
+		{"db.", []string{"db.host", "db.hosts", "db.port", "db.user"}},
+		{"db.ho", []string{"db.host", "db.hosts"}},
+		{"db.hosts.", []string{"db.hosts.0", "db.hosts.1"}},
+		{"servers.1.", []string{"servers.1.name"}},
+		{"servers[0].n", []string{"servers.0.name"}},
+		{"[a", []string{"[a.b]"}},
+		{"[a.b].", []string{"[a.b].c"}},
+		{"db.x", []string{}},
+		{"db.port.", []string{}},
+		{"missing.", []string{}},
+		{"db[", []string{}},
+	}
+	for _, test := range tests {
+		got := cfg.Complete(test.prefix)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Complete(%q): got %q; want %q", test.prefix, got, test.want)
+		}
+	}
+}
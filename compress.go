@@ -0,0 +1,181 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Compression describes a file compression. Compressed files are
+// recognized by their magic bytes when read, and by their extension when
+// written.
+type Compression struct {
+	// Name identifies the compression, like "gzip".
+	Name string
+	// Ext is the file extension, including the dot, like ".gz".
+	Ext string
+	// Magic are the bytes starting every compressed file.
+	Magic []byte
+	// NewReader and NewWriter wrap a stream to decompress or compress it.
+	NewReader func(r io.Reader) (io.ReadCloser, error)
+	NewWriter func(w io.Writer) (io.WriteCloser, error)
+}
+
+var (
+	compressionsMu sync.RWMutex
+	compressions   = []Compression{
+		{
+			Name:  "gzip",
+			Ext:   ".gz",
+			Magic: []byte{0x1f, 0x8b},
+			NewReader: func(r io.Reader) (io.ReadCloser, error) {
+				return gzip.NewReader(r)
+			},
+			NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			},
+		},
+		// The standard library has no zstd codec; files are recognized,
+		// but reading them needs RegisterCompression with a codec.
+		{
+			Name:  "zstd",
+			Ext:   ".zst",
+			Magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+		},
+	}
+)
+
+// RegisterCompression adds a compression, or replaces the one with the
+// same name. For instance, zstd files are supported with
+// github.com/klauspost/compress/zstd by:
+//
+//	config.RegisterCompression(config.Compression{
+//		Name:  "zstd",
+//		Ext:   ".zst",
+//		Magic: []byte{0x28, 0xb5, 0x2f, 0xfd},
+//		NewReader: func(r io.Reader) (io.ReadCloser, error) {
+//			d, err := zstd.NewReader(r)
+//			if err != nil {
+//				return nil, err
+//			}
+//			return d.IOReadCloser(), nil
+//		},
+//		NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+//			return zstd.NewWriter(w)
+//		},
+//	})
+func RegisterCompression(c Compression) {
+	compressionsMu.Lock()
+	defer compressionsMu.Unlock()
+	for i := range compressions {
+		if compressions[i].Name == c.Name {
+			compressions[i] = c
+			return
+		}
+	}
+	compressions = append(compressions, c)
+}
+
+// compressionOf returns the compression of a file, by its magic bytes
+// when data is given or by its extension otherwise.
+func compressionOf(filename string, data []byte) (Compression, bool) {
+	compressionsMu.RLock()
+	defer compressionsMu.RUnlock()
+	for _, c := range compressions {
+		if data != nil && len(c.Magic) > 0 && bytes.HasPrefix(data, c.Magic) {
+			return c, true
+		}
+		if data == nil && strings.EqualFold(filepath.Ext(filename), c.Ext) {
+			return c, true
+		}
+	}
+	return Compression{}, false
+}
+
+// trimCompressionExt removes a compression extension from a filename, so
+// "app.json.gz" becomes "app.json".
+func trimCompressionExt(filename string) string {
+	if _, ok := compressionOf(filename, nil); ok {
+		return strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	return filename
+}
+
+// readFile reads a file, decompressing it when it starts with the magic
+// bytes of a known compression.
+func readFile(filename string) ([]byte, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	c, ok := compressionOf(filename, data)
+	if !ok {
+		return data, nil
+	}
+	if c.NewReader == nil {
+		return nil, fmt.Errorf("Unsupported compression of %q: %s codec is not registered",
+			filename, c.Name)
+	}
+	r, err := c.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return ioutil.ReadAll(r)
+}
+
+// writeFileCompressed writes data to a file, compressed according to the
+// file extension.
+func writeFileCompressed(filename string, data []byte) error {
+	c, ok := compressionOf(filename, nil)
+	if !ok {
+		return fmt.Errorf("Unsupported compression of %q: unknown extension", filename)
+	}
+	if c.NewWriter == nil {
+		return fmt.Errorf("Unsupported compression of %q: %s codec is not registered",
+			filename, c.Name)
+	}
+	var buf bytes.Buffer
+	w, err := c.NewWriter(&buf)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), 0644)
+}
+
+// SaveJsonFileCompressed renders a configuration as JSON and writes it to
+// the given filename, compressed according to its extension, like
+// "inventory.json.gz".
+func SaveJsonFileCompressed(filename string, cfg interface{}) error {
+	s, err := RenderJson(cfg)
+	if err != nil {
+		return err
+	}
+	return writeFileCompressed(filename, []byte(s))
+}
+
+// SaveYamlFileCompressed renders a configuration as YAML and writes it to
+// the given filename, compressed according to its extension, like
+// "inventory.yaml.gz".
+func SaveYamlFileCompressed(filename string, cfg interface{}) error {
+	s, err := RenderYaml(cfg)
+	if err != nil {
+		return err
+	}
+	return writeFileCompressed(filename, []byte(s))
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// prefixCompression "compresses" by prepending its magic bytes.
+var prefixCompression = Compression{
+	Name:  "prefix",
+	Ext:   ".pfx",
+	Magic: []byte("PFX:"),
+	NewReader: func(r io.Reader) (io.ReadCloser, error) {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(bytes.NewReader(bytes.TrimPrefix(b, []byte("PFX:")))), nil
+	},
+	NewWriter: func(w io.Writer) (io.WriteCloser, error) {
+		if _, err := w.Write([]byte("PFX:")); err != nil {
+			return nil, err
+		}
+		return nopWriteCloser{w}, nil
+	},
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestCompressedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+
+	jsonFile := filepath.Join(dir, "app.json.gz")
+	expect(t, SaveJsonFileCompressed(jsonFile, cfg), nil)
+	yamlFile := filepath.Join(dir, "app.yaml.GZ")
+	expect(t, SaveYamlFileCompressed(yamlFile, cfg), nil)
+	expect(t, FormatOf(jsonFile), FormatJson)
+	expect(t, FormatOf(yamlFile), FormatYaml)
+	expect(t, FormatOf("agent.plist"), FormatPlist)
+
+	for _, file := range []string{jsonFile, yamlFile} {
+		raw, err := ioutil.ReadFile(file)
+		expect(t, err, nil)
+		expect(t, bytes.HasPrefix(raw, []byte{0x1f, 0x8b}), true)
+
+		parsed, err := ParseFile(file)
+		expect(t, err, nil)
+		expect(t, len(Diff(cfg, parsed)), 0)
+	}
+
+	// Detection uses the magic bytes, whatever the extension.
+	renamed := filepath.Join(dir, "app.json")
+	expect(t, os.Rename(jsonFile, renamed), nil)
+	parsed, err := ParseJsonFile(renamed)
+	expect(t, err, nil)
+	expect(t, parsed.UString("map.key8"), "value8")
+
+	// zstd files are recognized, but need a registered codec.
+	zstFile := filepath.Join(dir, "app.yaml.zst")
+	expect(t, ioutil.WriteFile(zstFile, []byte{0x28, 0xb5, 0x2f, 0xfd, 0}, 0644), nil)
+	_, err = ParseYamlFile(zstFile)
+	expect(t, err.Error(), `Unsupported compression of "`+zstFile+`": zstd codec is not registered`)
+	err = SaveYamlFileCompressed(zstFile, cfg)
+	expect(t, err.Error(), `Unsupported compression of "`+zstFile+`": zstd codec is not registered`)
+	err = SaveYamlFileCompressed(filepath.Join(dir, "app.yaml"), cfg)
+	expect(t, err != nil, true)
+
+	RegisterCompression(prefixCompression)
+	defer func() {
+		compressionsMu.Lock()
+		compressions = compressions[:len(compressions)-1]
+		compressionsMu.Unlock()
+	}()
+	pfxFile := filepath.Join(dir, "app.json.pfx")
+	expect(t, SaveJsonFileCompressed(pfxFile, cfg), nil)
+	parsed, err = ParseFile(pfxFile)
+	expect(t, err, nil)
+	expect(t, parsed.UString("map.key8"), "value8")
+}
@@ -10,9 +10,13 @@ import (
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	yaml "gopkg.in/yaml.v2"
 )
@@ -23,6 +27,27 @@ import (
 type Config struct {
 	Root    interface{}
 	lastErr error
+	// primary and fallbacks are set on the views made by WithFallback.
+	primary   *Config
+	fallbacks []*Config
+	// reads is set when reads are tracked, see TrackReads, and audit
+	// when they are audited, see AuditReads. prefix is the path of the
+	// views returned by Get, which the paths they report start with.
+	reads  *readTracker
+	audit  *auditor
+	prefix string
+	// validators are set by Validator.
+	validators []validator
+	// comments are set by SetComment.
+	comments map[string]Comment
+	// refresh holds the values refreshed after their TTL, see Refresh.
+	refresh *refresher
+	// cow holds the *cowState telling which nodes are shared with copies.
+	cow atomic.Value
+	// parent and parts are set on the views returned by Get, which change
+	// the tree of their parent in place.
+	parent *Config
+	parts  []string
 }
 
 // Error return last error
@@ -30,18 +55,253 @@ func (c *Config) Error() error {
 	return c.lastErr
 }
 
-// Get returns a nested config according to a dotted path.
+// Get returns a nested config according to a dotted path. The nested
+// config is a view: changes made through it show in the config.
 func (cfg *Config) Get(path string) (*Config, error) {
-	n, err := Get(cfg.Root, path)
+	var sub *Config
+	var err error
+	if cfg.primary != nil {
+		sub, err = cfg.primary.Get(path)
+	} else if n, gerr := Get(cfg.Root, path); gerr != nil {
+		err = gerr
+	} else {
+		parts, _ := parsePath(path)
+		sub = &Config{Root: n, parent: cfg, parts: parts, prefix: canonicalPath(cfg.prefix, path)}
+		sub.cow.Store(cfg.state())
+		sub.reads, sub.audit, sub.validators = cfg.reads, cfg.audit, cfg.validators
+	}
+	for _, fallback := range cfg.fallbacks {
+		fsub, ferr := fallback.Get(path)
+		if ferr != nil {
+			continue
+		}
+		if sub == nil {
+			sub = fsub
+		} else {
+			sub.fallbacks = append(sub.fallbacks, fsub)
+		}
+	}
+	if sub == nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// WithFallback returns a view of the config which resolves paths from the
+// config first and from the other config when they are missing. The configs
+// are not merged: both are held by reference, so changes to either, or to
+// their Root, are visible through the view. Chaining WithFallback calls
+// adds further layers of lower priority. The view has no tree of its own,
+// its Root is nil: set values, and call the methods working on whole
+// trees, like Copy, Extend, Env and Flag, on the layers. The reads through
+// the view are tracked and audited like those of the config.
+func (cfg *Config) WithFallback(other *Config) *Config {
+	return &Config{primary: cfg, fallbacks: []*Config{other}, reads: cfg.reads, audit: cfg.audit, prefix: cfg.prefix}
+}
+
+// get returns the value at the given dotted path, consulting the fallback
+// chain when the path is missing from the root, and runs the get hooks.
+func (cfg *Config) get(path string) (interface{}, error) {
+	n, err := cfg.lookup(path)
+	cfg.observeLookup(path, err == nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg.trackRead(path)
+	return cfg.resolved(path, n)
+}
+
+// peek returns the value at a dotted path like get, but without tracking,
+// auditing or observing the read, for the checks of the config itself.
+func (cfg *Config) peek(path string) (interface{}, error) {
+	n, err := cfg.lookup(path)
 	if err != nil {
 		return nil, err
 	}
-	return &Config{Root: n}, nil
+	return cfg.resolved(path, n)
+}
+
+// resolved returns the value found at a path, refreshed and passed to the
+// get hooks.
+func (cfg *Config) resolved(path string, n interface{}) (interface{}, error) {
+	if cfg.refresh != nil {
+		n = cfg.refreshed(path, n)
+	}
+	return runGetHooks(path, n)
+}
+
+// lookup resolves a dotted path through the fallback chain.
+func (cfg *Config) lookup(path string) (interface{}, error) {
+	var n interface{}
+	var err error
+	if cfg.primary != nil {
+		n, err = cfg.primary.lookup(path)
+	} else {
+		n, err = Get(cfg.Root, path)
+	}
+	if err != nil {
+		for _, fallback := range cfg.fallbacks {
+			if fn, ferr := fallback.lookup(path); ferr == nil {
+				return fn, nil
+			}
+		}
+	}
+	return n, err
 }
 
-// Set a nested config according to a dotted path.
+// Set a nested config according to a dotted path. An empty config gets
+// a map or a list root, depending on the first path segment.
 func (cfg *Config) Set(path string, val interface{}) error {
-	return Set(cfg.Root, path, val)
+	if err := cfg.checkSealed("Set", path); err != nil {
+		return err
+	}
+	parts, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if cfg.validators != nil {
+		if err := cfg.checkValidators(parts, val); err != nil {
+			return err
+		}
+	}
+	if cfg.Root == nil {
+		if len(parts) == 0 {
+			return fmt.Errorf("Invalid path %q", path)
+		}
+		cfg.Root = newContainer(parts[0])
+	}
+	if len(parts) > 0 {
+		cfg.ownPath(parts[:len(parts)-1])
+	}
+	if cfg.Root, err = setParts(cfg.Root, parts, val); err != nil {
+		return err
+	}
+	cfg.forget(parts)
+	return nil
+}
+
+// Delete removes the value at a dotted path. Items removed from lists
+// shift the following items down.
+func (cfg *Config) Delete(path string) error {
+	if err := cfg.checkSealed("Delete", path); err != nil {
+		return err
+	}
+	parts, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("Invalid path %q", path)
+	}
+	last := parts[len(parts)-1]
+	if cfg.validators != nil {
+		// Check the values changed by the removal on a copy first.
+		root, err := normalizeValue(cfg.Root)
+		if err != nil {
+			return err
+		}
+		candidate := &Config{Root: root}
+		if err := candidate.Delete(path); err != nil {
+			return err
+		}
+		if err := cfg.checkRoot(candidate.Root); err != nil {
+			return err
+		}
+	}
+
+	cfg.ownPath(parts[:len(parts)-1])
+	parent, err := getParts(cfg.Root, parts[:len(parts)-1])
+	if err != nil {
+		return err
+	}
+	cfg.forget(parts)
+	switch c := parent.(type) {
+	case map[string]interface{}:
+		if _, ok := c[last]; !ok {
+			return fmt.Errorf("Nonexistent map key at %q", path)
+		}
+		delete(c, last)
+		return nil
+	case []interface{}:
+		i, err := strconv.Atoi(last)
+		if err != nil || i < 0 || i >= len(c) {
+			return fmt.Errorf("Invalid list index at %q", path)
+		}
+		list := append(c[:i:i], c[i+1:]...)
+		if len(parts) == 1 {
+			cfg.Root = list
+			return nil
+		}
+		// Lists can't shrink in place, so store the new one in the
+		// grandparent.
+		grandparent, _ := getParts(cfg.Root, parts[:len(parts)-2])
+		key := parts[len(parts)-2]
+		switch g := grandparent.(type) {
+		case map[string]interface{}:
+			g[key] = list
+		case []interface{}:
+			j, _ := strconv.Atoi(key)
+			g[j] = list
+		}
+		return nil
+	}
+	return fmt.Errorf(
+		"Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
+		formatPath(parts[:len(parts)-1]), parent)
+}
+
+// Mount grafts the root of another config under a dotted path, by
+// reference: changes made to the mounted tree through either config are
+// visible in both. It fails if the path is already set, so that sections
+// contributed by different plugins can't silently replace each other.
+// The configs returned by Copy and Extend hold a copy of the mounted tree.
+func (cfg *Config) Mount(path string, sub *Config) error {
+	if strings.Trim(path, ".") == "" {
+		return fmt.Errorf("Invalid path %q", path)
+	}
+	if _, err := Get(cfg.Root, path); err == nil {
+		return fmt.Errorf("Path %q is already set", path)
+	}
+	if err := cfg.Set(path, sub.Root); err != nil {
+		return err
+	}
+	// Neither config may copy the mounted root on write, see cowState.
+	if id, ok := nodeID(sub.Root); ok {
+		cfg.state().mount(id)
+		sub.state().mount(id)
+	}
+	return nil
+}
+
+// MountCopy works like Mount, but grafts a deep copy of the other config.
+func (cfg *Config) MountCopy(path string, sub *Config) error {
+	n, err := sub.deepCopy()
+	if err != nil {
+		return err
+	}
+	return cfg.Mount(path, n)
+}
+
+// Unmount removes the subtree mounted at a dotted path. See `.Delete()`.
+func (cfg *Config) Unmount(path string) error {
+	return cfg.Delete(path)
+}
+
+// IsEmpty reports whether the config holds no values: its root is nil,
+// an empty map or an empty list.
+func (cfg *Config) IsEmpty() bool {
+	if cfg == nil {
+		return true
+	}
+	switch root := cfg.Root.(type) {
+	case nil:
+		return true
+	case map[string]interface{}:
+		return len(root) == 0
+	case []interface{}:
+		return len(root) == 0
+	}
+	return false
 }
 
 // Fetch data from system env, based on existing config keys.
@@ -51,18 +311,83 @@ func (cfg *Config) Env() *Config {
 
 // Fetch data from system env using prefix, based on existing config keys.
 func (cfg *Config) EnvPrefix(prefix string) *Config {
-	if prefix != "" {
-		prefix = strings.ToUpper(prefix) + "_"
+	cfg.envLookup(prefix, syscall.Getenv)
+	return cfg
+}
+
+// envLookup sets the existing keys named by the variables found by lookup,
+// with the naming of EnvPrefix.
+func (cfg *Config) envLookup(prefix string, lookup func(name string) (string, bool)) {
+	cfg.envNamed(EnvNaming{Prefix: prefix}, lookup)
+}
+
+// envNamed sets the existing keys named by the variables found by lookup,
+// with the given naming.
+func (cfg *Config) envNamed(naming EnvNaming, lookup func(name string) (string, bool)) {
+	keys := getKeys(cfg.Root)
+	for _, key := range keys {
+		if val, exist := lookup(naming.name(key)); exist {
+			cfg.Set(formatPath(key), val)
+		}
 	}
+}
 
+// ToEnv flattens the config into sorted `KEY=value` pairs, using the same
+// naming as EnvPrefix, e.g. PREFIX_DATABASE_HOST=localhost. The result is
+// suitable for exec.Cmd.Env. Null values are exported as empty strings.
+// Exporting doesn't count as reading the values, see TrackReads, but is
+// audited, see AuditReads.
+func (cfg *Config) ToEnv(prefix string) []string {
+	cfg.auditExport()
+	naming := EnvNaming{Prefix: prefix}
 	keys := getKeys(cfg.Root)
+	env := make([]string, 0, len(keys))
 	for _, key := range keys {
-		k := strings.ToUpper(strings.Join(key, "_"))
-		if val, exist := syscall.Getenv(prefix + k); exist {
-			cfg.Set(strings.Join(key, "."), val)
+		n, _ := findParts(cfg.Root, key)
+		val, _ := toString(n)
+		env = append(env, naming.name(key)+"="+val)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// SetProcessEnv exports the config into the environment of the current
+// process, so that it is inherited by child processes. See `.ToEnv()`.
+func (cfg *Config) SetProcessEnv(prefix string) error {
+	for _, pair := range cfg.ToEnv(prefix) {
+		kv := strings.SplitN(pair, "=", 2)
+		if err := os.Setenv(kv[0], kv[1]); err != nil {
+			return err
 		}
 	}
-	return cfg
+	return nil
+}
+
+// TempFile renders the config in the given format into a new temporary
+// file, readable by the current user only, for tools that expect a config
+// file argument. It returns the file path and a func removing the file.
+func (cfg *Config) TempFile(format Format) (string, func(), error) {
+	cfg.auditExport()
+	out, err := render(format, cfg.Root)
+	if err != nil {
+		return "", nil, err
+	}
+	f, err := ioutil.TempFile("", "config-*."+format.String())
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.Remove(f.Name()) }
+	if err = f.Chmod(0600); err == nil {
+		_, err = f.WriteString(out)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return f.Name(), cleanup, nil
 }
 
 // Parse command line arguments, based on existing config keys.
@@ -136,32 +461,63 @@ func getKeys(source interface{}, base ...string) [][]string {
 			acc = append(acc, keys...)
 		}
 	default:
-		acc = append(acc, nextBase)
+		// A scalar or nil root has no keys to address.
+		if len(nextBase) != 0 {
+			acc = append(acc, nextBase)
+		}
 		return acc
 	}
 	return acc
 }
 
+// walkLeaves calls fn for every leaf of the given tree with its dotted
+// path, in sorted order. Keys containing dots are bracketed, and empty maps
+// and lists are reported as leaves.
+func walkLeaves(node interface{}, path string, fn func(path string, value interface{})) {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		if len(c) == 0 && path != "" {
+			break
+		}
+		keys := make([]string, 0, len(c))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walkLeaves(c[k], joinPath(path, k), fn)
+		}
+		return
+	case []interface{}:
+		if len(c) == 0 && path != "" {
+			break
+		}
+		for i, v := range c {
+			walkLeaves(v, joinPath(path, strconv.Itoa(i)), fn)
+		}
+		return
+	}
+	fn(path, node)
+}
+
 // Bool returns a bool according to a dotted path.
 func (cfg *Config) Bool(path string) (bool, error) {
-	n, err := Get(cfg.Root, path)
+	if v, ok := cfg.flat(path, flatBool); ok {
+		return v.b, nil
+	}
+	n, err := cfg.get(path)
 	if err != nil {
 		return false, err
 	}
-	switch n := n.(type) {
-	case bool:
-		return n, nil
-	case string:
-		return strconv.ParseBool(n)
-	}
-	return false, typeMismatch("bool or string", n)
+	v, err := toBool(n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UBool returns a bool according to a dotted path or default value or false.
 func (c *Config) UBool(path string, defaults ...bool) bool {
-	value, err := c.Bool(path)
+	value, ok := c.BoolOk(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -173,26 +529,22 @@ func (c *Config) UBool(path string, defaults ...bool) bool {
 
 // Float64 returns a float64 according to a dotted path.
 func (cfg *Config) Float64(path string) (float64, error) {
-	n, err := Get(cfg.Root, path)
+	if v, ok := cfg.flat(path, flatFloat); ok {
+		return v.f, nil
+	}
+	n, err := cfg.get(path)
 	if err != nil {
 		return 0, err
 	}
-	switch n := n.(type) {
-	case float64:
-		return n, nil
-	case int:
-		return float64(n), nil
-	case string:
-		return strconv.ParseFloat(n, 64)
-	}
-	return 0, typeMismatch("float64, int or string", n)
+	v, err := toFloat64(n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UFloat64 returns a float64 according to a dotted path or default value or 0.
 func (c *Config) UFloat64(path string, defaults ...float64) float64 {
-	value, err := c.Float64(path)
+	value, ok := c.Float64Ok(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -204,36 +556,22 @@ func (c *Config) UFloat64(path string, defaults ...float64) float64 {
 
 // Int returns an int according to a dotted path.
 func (cfg *Config) Int(path string) (int, error) {
-	n, err := Get(cfg.Root, path)
+	if v, ok := cfg.flat(path, flatInt); ok {
+		return v.i, nil
+	}
+	n, err := cfg.get(path)
 	if err != nil {
 		return 0, err
 	}
-	switch n := n.(type) {
-	case float64:
-		// encoding/json unmarshals numbers into floats, so we compare
-		// the string representation to see if we can return an int.
-		if i := int(n); fmt.Sprint(i) == fmt.Sprint(n) {
-			return i, nil
-		} else {
-			return 0, fmt.Errorf("Value can't be converted to int: %v", n)
-		}
-	case int:
-		return n, nil
-	case string:
-		if v, err := strconv.ParseInt(n, 10, 0); err == nil {
-			return int(v), nil
-		} else {
-			return 0, err
-		}
-	}
-	return 0, typeMismatch("float64, int or string", n)
+	v, err := toInt(n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UInt returns an int according to a dotted path or default value or 0.
 func (c *Config) UInt(path string, defaults ...int) int {
-	value, err := c.Int(path)
+	value, ok := c.IntOk(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -243,23 +581,26 @@ func (c *Config) UInt(path string, defaults ...int) int {
 	return 0
 }
 
-// List returns a []interface{} according to a dotted path.
+// List returns a []interface{} according to a dotted path. Once the config
+// shared its tree with a copy, see Copy, the list is a copy too: change the
+// config through Set rather than in place.
 func (cfg *Config) List(path string) ([]interface{}, error) {
-	n, err := Get(cfg.Root, path)
+	n, err := cfg.get(path)
 	if err != nil {
 		return nil, err
 	}
-	if value, ok := n.([]interface{}); ok {
-		return value, nil
+	v, err := toList(n)
+	if err == nil {
+		v = cfg.detached(v).([]interface{})
 	}
-	return nil, typeMismatch("[]interface{}", n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UList returns a []interface{} according to a dotted path or defaults or []interface{}.
 func (c *Config) UList(path string, defaults ...[]interface{}) []interface{} {
-	value, err := c.List(path)
+	value, ok := c.ListOk(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -269,23 +610,26 @@ func (c *Config) UList(path string, defaults ...[]interface{}) []interface{} {
 	return make([]interface{}, 0)
 }
 
-// Map returns a map[string]interface{} according to a dotted path.
+// Map returns a map[string]interface{} according to a dotted path. Once
+// the config shared its tree with a copy, see Copy, the map is a copy too:
+// change the config through Set rather than in place.
 func (cfg *Config) Map(path string) (map[string]interface{}, error) {
-	n, err := Get(cfg.Root, path)
+	n, err := cfg.get(path)
 	if err != nil {
 		return nil, err
 	}
-	if value, ok := n.(map[string]interface{}); ok {
-		return value, nil
+	v, err := toMap(n)
+	if err == nil {
+		v = cfg.detached(v).(map[string]interface{})
 	}
-	return nil, typeMismatch("map[string]interface{}", n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UMap returns a map[string]interface{} according to a dotted path or default or map[string]interface{}.
 func (c *Config) UMap(path string, defaults ...map[string]interface{}) map[string]interface{} {
-	value, err := c.Map(path)
+	value, ok := c.MapOk(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -297,24 +641,22 @@ func (c *Config) UMap(path string, defaults ...map[string]interface{}) map[strin
 
 // String returns a string according to a dotted path.
 func (cfg *Config) String(path string) (string, error) {
-	n, err := Get(cfg.Root, path)
+	if v, ok := cfg.flat(path, flatString); ok {
+		return v.s, nil
+	}
+	n, err := cfg.get(path)
 	if err != nil {
 		return "", err
 	}
-	switch n := n.(type) {
-	case bool, float64, int:
-		return fmt.Sprint(n), nil
-	case string:
-		return n, nil
-	}
-	return "", typeMismatch("bool, float64, int or string", n)
+	v, err := toString(n)
+	return v, cfg.observeConversion(path, err)
 }
 
 // UString returns a string according to a dotted path or default or "".
 func (c *Config) UString(path string, defaults ...string) string {
-	value, err := c.String(path)
+	value, ok := c.StringOk(path)
 
-	if err == nil {
+	if ok {
 		return value
 	}
 
@@ -324,45 +666,135 @@ func (c *Config) UString(path string, defaults ...string) string {
 	return ""
 }
 
-// Copy returns a deep copy with given path or without.
-func (c *Config) Copy(dottedPath ...string) (*Config, error) {
-	toJoin := []string{}
-	for _, part := range dottedPath {
-		if len(part) != 0 {
-			toJoin = append(toJoin, part)
-		}
+// timestampFormats lists the layouts accepted for YAML timestamps.
+// See http://yaml.org/type/timestamp.html.
+var timestampFormats = []string{
+	time.RFC3339Nano,
+	"2006-1-2T15:4:5.999999999Z07:00",
+	"2006-1-2t15:4:5.999999999Z07:00",
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+// Time returns a time.Time according to a dotted path. Strings are parsed
+// using the YAML timestamp formats, since gopkg.in/yaml.v2 decodes
+// !!timestamp values as plain strings.
+func (cfg *Config) Time(path string) (time.Time, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return time.Time{}, err
 	}
+	v, err := toTime(n)
+	return v, cfg.observeConversion(path, err)
+}
+
+// UTime returns a time.Time according to a dotted path or default value or zero time.
+func (c *Config) UTime(path string, defaults ...time.Time) time.Time {
+	value, ok := c.TimeOk(path)
 
+	if ok {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return time.Time{}
+}
+
+// Bytes returns a []byte according to a dotted path. Values tagged as
+// !!binary in YAML are already base64-decoded by the parser, so strings are
+// returned as their raw bytes.
+func (cfg *Config) Bytes(path string) ([]byte, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return nil, err
+	}
+	v, err := toBytes(n)
+	return v, cfg.observeConversion(path, err)
+}
+
+// UBytes returns a []byte according to a dotted path or default or []byte{}.
+func (c *Config) UBytes(path string, defaults ...[]byte) []byte {
+	value, ok := c.BytesOk(path)
+
+	if ok {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return []byte{}
+}
+
+// Copy returns a copy with given path or without. The copy shares the
+// branches neither config changes with the current one, see cowState.
+func (c *Config) Copy(dottedPath ...string) (*Config, error) {
 	var err error
-	var path = strings.Join(toJoin, ".")
+	var path = strings.Join(nonEmpty(dottedPath), ".")
 	var cfg = c
-	var root = ""
 
 	if len(path) > 0 {
 		if cfg, err = c.Get(path); err != nil {
 			return nil, err
 		}
 	}
+	n := cfg.share()
+	if len(path) > 0 {
+		// The prefix keeps the validators of the paths inside the
+		// copied one.
+		n.comments = nil
+	}
+	return n, nil
+}
 
-	if root, err = RenderYaml(cfg.Root); err != nil {
+// deepCopy returns a copy sharing nothing with the config, for the
+// methods which give a copy to code modifying it in place.
+func (c *Config) deepCopy() (*Config, error) {
+	// normalizeValue always builds new maps and lists, so it doubles as a
+	// deep copy which keeps time.Time and []byte values intact.
+	root, err := normalizeValue(c.Root)
+	if err != nil {
 		return nil, err
 	}
-	return ParseYaml(root)
+	return &Config{Root: root, comments: copyComments(c.comments), validators: c.validators, audit: c.audit, prefix: c.prefix}, nil
+}
+
+// nonEmpty returns the non-empty strings of a slice.
+func nonEmpty(parts []string) []string {
+	toJoin := []string{}
+	for _, part := range parts {
+		if len(part) != 0 {
+			toJoin = append(toJoin, part)
+		}
+	}
+	return toJoin
+}
+
+// copyComments returns a copy of the comments of a config.
+func copyComments(comments map[string]Comment) map[string]Comment {
+	if comments == nil {
+		return nil
+	}
+	n := make(map[string]Comment, len(comments))
+	for p, comment := range comments {
+		n[p] = comment
+	}
+	return n
 }
 
 // Extend returns extended copy of current config with applied
 // values from the given config instance. Note that if you extend
 // with different structure you will get an error. See: `.Set()` method
-// for details.
+// for details. The copy shares the branches the given config leaves
+// alone with the current one, like Copy.
 func (c *Config) Extend(cfg *Config) (*Config, error) {
-	n, err := c.Copy()
-	if err != nil {
-		return nil, err
-	}
+	n := c.share()
 
 	keys := getKeys(cfg.Root)
 	for _, key := range keys {
-		k := strings.Join(key, ".")
+		k := formatPath(key)
 		i, err := Get(cfg.Root, k)
 		if err != nil {
 			return nil, err
@@ -374,166 +806,466 @@ func (c *Config) Extend(cfg *Config) (*Config, error) {
 	return n, nil
 }
 
-// typeMismatch returns an error for an expected type.
-func typeMismatch(expected string, got interface{}) error {
-	return fmt.Errorf("Type mismatch: expected %s; got %T", expected, got)
+// Conflict describes a path which has different values in both configs
+// of a merge.
+type Conflict struct {
+	Path string
+	Old  interface{}
+	New  interface{}
 }
 
-// Fetching -------------------------------------------------------------------
+// Resolver picks the value to keep for a conflicting path during a merge.
+type Resolver func(c Conflict) (interface{}, error)
+
+// KeepOld is a Resolver which keeps the value of the extended config.
+func KeepOld(c Conflict) (interface{}, error) { return c.Old, nil }
+
+// TakeNew is a Resolver which takes the value of the applied config, the
+// same way Extend does.
+func TakeNew(c Conflict) (interface{}, error) { return c.New, nil }
+
+// MergeReport works like Extend, but also returns the conflicts found,
+// sorted by path. A conflict is a path which the given config sets to a
+// value different from the current one; resolve is called for each of
+// them to pick the value to keep. A nil resolve behaves like TakeNew, so
+// passing KeepOld gives an audit of what the merge would change without
+// changing anything.
+func (cfg *Config) MergeReport(other *Config, resolve Resolver) (*Config, []Conflict, error) {
+	if resolve == nil {
+		resolve = TakeNew
+	}
+	n := cfg.share()
 
-// Get returns a child of the given value according to a dotted path.
-func Get(cfg interface{}, path string) (interface{}, error) {
-	parts := splitKeyOnParts(path)
-	// Normalize path.
-	for k, v := range parts {
-		if v == "" {
-			if k == 0 {
-				parts = parts[1:]
-			} else {
-				return nil, fmt.Errorf("Invalid path %q", path)
+	keys := getKeys(other.Root)
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = formatPath(key)
+	}
+	sort.Strings(paths)
+
+	conflicts := []Conflict{}
+	for _, k := range paths {
+		i, err := Get(other.Root, k)
+		if err != nil {
+			return nil, nil, err
+		}
+		if old, err := Get(n.Root, k); err == nil && !equalValues(old, i) {
+			conflict := Conflict{Path: k, Old: old, New: i}
+			conflicts = append(conflicts, conflict)
+			if i, err = resolve(conflict); err != nil {
+				return nil, nil, err
 			}
 		}
+		if err := n.Set(k, i); err != nil {
+			return nil, nil, err
+		}
 	}
-	// Get the value.
-	for pos, part := range parts {
-		switch c := cfg.(type) {
-		case []interface{}:
-			if i, error := strconv.ParseInt(part, 10, 0); error == nil {
-				if int(i) < len(c) {
-					cfg = c[i]
-				} else {
-					return nil, fmt.Errorf(
-						"Index out of range at %q: list has only %v items",
-						strings.Join(parts[:pos+1], "."), len(c))
+	return n, conflicts, nil
+}
+
+// ExtendByKeys returns extended copy of current config like Extend, but
+// lists of maps at the paths given in listKeys are merged item by item,
+// matching items on the value of the named key field instead of their
+// position. For example {"users": "name"} merges the entries of `users`
+// which share the same name, and appends the new ones. Paths in listKeys
+// skip list indexes, so "clusters.nodes" names the nodes lists of every
+// item of clusters. Other lists are merged by position, and values of
+// different types are replaced.
+func (c *Config) ExtendByKeys(cfg *Config, listKeys map[string]string) (*Config, error) {
+	n, err := c.deepCopy()
+	if err != nil {
+		return nil, err
+	}
+	src, err := normalizeValue(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	root, err := mergeByKeys(n.Root, src, "", listKeys)
+	if err != nil {
+		return nil, err
+	}
+	n.Root = root
+	return n, nil
+}
+
+// mergeByKeys merges src into dst, which are found at the given path with
+// list indexes skipped, and returns the result.
+func mergeByKeys(dst, src interface{}, path string, listKeys map[string]string) (interface{}, error) {
+	switch s := src.(type) {
+	case map[string]interface{}:
+		d, ok := dst.(map[string]interface{})
+		if !ok {
+			return src, nil
+		}
+		for k, v := range s {
+			merged, err := mergeByKeys(d[k], v, joinPath(path, k), listKeys)
+			if err != nil {
+				return nil, err
+			}
+			d[k] = merged
+		}
+		return d, nil
+	case []interface{}:
+		d, ok := dst.([]interface{})
+		if !ok {
+			return src, nil
+		}
+		key, keyed := listKeys[path]
+		for i, v := range s {
+			target := -1
+			if keyed {
+				id, ok := listItemKey(v, key)
+				if !ok {
+					return nil, fmt.Errorf("Item %d of %q has no %q key", i, path, key)
 				}
-			} else {
-				return nil, fmt.Errorf("Invalid list index at %q",
-					strings.Join(parts[:pos+1], "."))
+				for j, item := range d {
+					if other, ok := listItemKey(item, key); ok && equalValues(id, other) {
+						target = j
+						break
+					}
+				}
+			} else if i < len(d) {
+				target = i
 			}
-		case map[string]interface{}:
-			if value, ok := c[part]; ok {
-				cfg = value
-			} else {
-				return nil, fmt.Errorf("Nonexistent map key at %q",
-					strings.Join(parts[:pos+1], "."))
+			if target < 0 {
+				d = append(d, v)
+				continue
 			}
-		default:
-			return nil, fmt.Errorf(
-				"Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
-				strings.Join(parts[:pos+1], "."), cfg)
+			merged, err := mergeByKeys(d[target], v, path, listKeys)
+			if err != nil {
+				return nil, err
+			}
+			d[target] = merged
 		}
+		return d, nil
 	}
+	return src, nil
+}
 
-	return cfg, nil
+// listItemKey returns the value of the key field of a list item.
+func listItemKey(item interface{}, key string) (interface{}, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	id, ok := m[key]
+	return id, ok
 }
 
-func splitKeyOnParts(key string) []string {
-	parts := []string{}
+// Profiles returns a copy of the config with the given profile overlays
+// applied. Profile overlays are top-level sections whose key names a kind
+// and a profile separated by a colon, e.g. "env:prod" or "region:eu". Any
+// number of them can be active at once; they are applied in the given
+// order, so later profiles take priority. Profiles which aren't defined in
+// the config are skipped, and all profile sections are removed from the
+// result. See `.Extend()` for how overlays are applied.
+func (c *Config) Profiles(active ...string) (*Config, error) {
+	n := c.share()
+	n.ownPath(nil)
+	root, ok := n.Root.(map[string]interface{})
+	if !ok {
+		return nil, typeMismatch("map[string]interface{}", n.Root)
+	}
 
-	bracketOpened := false
-	var buffer bytes.Buffer
-	for _, char := range key {
-		if char == 91 || char == 93 { // [ ]
-			bracketOpened = char == 91
-			continue
+	profiles := map[string]interface{}{}
+	for k, v := range root {
+		if strings.Contains(k, ":") {
+			profiles[k] = v
+			delete(root, k)
 		}
-		if char == 46 && !bracketOpened { // point
-			parts = append(parts, buffer.String())
-			buffer.Reset()
+	}
+
+	for _, name := range active {
+		overlay, ok := profiles[name]
+		if !ok {
 			continue
 		}
+		var err error
+		if n, err = n.Extend(&Config{Root: overlay}); err != nil {
+			return nil, fmt.Errorf("Profile %q: %v", name, err)
+		}
+	}
+	return n, nil
+}
 
-		buffer.WriteRune(char)
+// Conversion ----------------------------------------------------------------
+
+// toBool converts a config value to a bool.
+func toBool(n interface{}) (bool, error) {
+	switch n := n.(type) {
+	case bool:
+		return n, nil
+	case string:
+		return strconv.ParseBool(n)
 	}
+	return false, typeMismatch("bool or string", n)
+}
 
-	if buffer.String() != "" {
-		parts = append(parts, buffer.String())
-		buffer.Reset()
+// toFloat64 converts a config value to a float64.
+func toFloat64(n interface{}) (float64, error) {
+	switch n := n.(type) {
+	case float64:
+		return n, nil
+	case int:
+		return float64(n), nil
+	case string:
+		return strconv.ParseFloat(n, 64)
 	}
+	return 0, typeMismatch("float64, int or string", n)
+}
 
-	return parts
+// toInt converts a config value to an int.
+func toInt(n interface{}) (int, error) {
+	switch n := n.(type) {
+	case float64:
+		// encoding/json unmarshals numbers into floats, so we compare
+		// the string representation to see if we can return an int.
+		if i := int(n); fmt.Sprint(i) == fmt.Sprint(n) {
+			return i, nil
+		} else {
+			return 0, fmt.Errorf("Value can't be converted to int: %v", n)
+		}
+	case int:
+		return n, nil
+	case string:
+		if v, err := strconv.ParseInt(n, 10, 0); err == nil {
+			return int(v), nil
+		} else {
+			return 0, err
+		}
+	}
+	return 0, typeMismatch("float64, int or string", n)
 }
 
-// Set returns an error, in case when it is not possible to
-// establish the value obtained in accordance with given dotted path.
-func Set(cfg interface{}, path string, value interface{}) error {
-	parts := strings.Split(path, ".")
-	// Normalize path.
-	for k, v := range parts {
-		if v == "" {
-			if k == 0 {
-				parts = parts[1:]
-			} else {
-				return fmt.Errorf("Invalid path %q", path)
+// toList converts a config value to a []interface{}.
+func toList(n interface{}) ([]interface{}, error) {
+	if value, ok := n.([]interface{}); ok {
+		return value, nil
+	}
+	return nil, typeMismatch("[]interface{}", n)
+}
+
+// toMap converts a config value to a map[string]interface{}.
+func toMap(n interface{}) (map[string]interface{}, error) {
+	if value, ok := n.(map[string]interface{}); ok {
+		return value, nil
+	}
+	return nil, typeMismatch("map[string]interface{}", n)
+}
+
+// toString converts a config value to a string.
+func toString(n interface{}) (string, error) {
+	switch n := n.(type) {
+	case bool, float64, int:
+		return fmt.Sprint(n), nil
+	case string:
+		return n, nil
+	case time.Time:
+		return n.Format(time.RFC3339Nano), nil
+	case []byte:
+		return string(n), nil
+	}
+	return "", typeMismatch("bool, float64, int or string", n)
+}
+
+// toTime converts a config value to a time.Time.
+func toTime(n interface{}) (time.Time, error) {
+	switch n := n.(type) {
+	case time.Time:
+		return n, nil
+	case string:
+		s := strings.TrimSpace(n)
+		for _, format := range timestampFormats {
+			if t, err := time.Parse(format, s); err == nil {
+				return t, nil
 			}
 		}
+		return time.Time{}, fmt.Errorf("Value can't be converted to time: %q", n)
+	}
+	return time.Time{}, typeMismatch("time.Time or string", n)
+}
+
+// toBytes converts a config value to a []byte.
+func toBytes(n interface{}) ([]byte, error) {
+	switch n := n.(type) {
+	case []byte:
+		return n, nil
+	case string:
+		return []byte(n), nil
 	}
+	return nil, typeMismatch("[]byte or string", n)
+}
 
-	point := &cfg
+// typeMismatch returns an error for an expected type.
+func typeMismatch(expected string, got interface{}) error {
+	return fmt.Errorf("Type mismatch: expected %s; got %T", expected, got)
+}
+
+// Fetching -------------------------------------------------------------------
+
+// Get returns a child of the given value according to a dotted path.
+func Get(cfg interface{}, path string) (interface{}, error) {
+	if n, ok := getPlain(cfg, path); ok {
+		return n, nil
+	}
+	parts, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return getParts(cfg, parts)
+}
+
+// getPlain resolves a plain dotted path, without quotes, brackets or
+// escapes, without splitting it, so that lookups hitting a value don't
+// allocate. It reports false when the path isn't plain or doesn't resolve,
+// leaving the errors to getParts.
+func getPlain(node interface{}, path string) (interface{}, bool) {
+	if !plainPath(path) {
+		return nil, false
+	}
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "."), ".")
+	for path != "" {
+		part, rest := path, ""
+		if i := strings.IndexByte(path, '.'); i >= 0 {
+			part, rest = path[:i], path[i+1:]
+		}
+		switch c := node.(type) {
+		case map[string]interface{}:
+			v, ok := c[part]
+			if !ok {
+				return nil, false
+			}
+			node = v
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			node = c[i]
+		default:
+			return nil, false
+		}
+		path = rest
+	}
+	return node, true
+}
+
+// getParts returns a child of the given value according to parsed path keys.
+func getParts(cfg interface{}, parts []string) (interface{}, error) {
+	// Get the value.
 	for pos, part := range parts {
-		switch c := (*point).(type) {
+		switch c := cfg.(type) {
 		case []interface{}:
-			if i, error := strconv.ParseInt(part, 10, 0); error == nil {
-				// 1. normalize slice capacity
-				if int(i) >= cap(c) {
-					c = append(c, make([]interface{}, int(i)-cap(c)+1, int(i)-cap(c)+1)...)
-				}
+			i, err := strconv.ParseInt(part, 10, 0)
+			if err != nil || i < 0 || int(i) >= len(c) {
+				return nil, newNotFoundError(c, parts, pos)
+			}
+			cfg = c[i]
+		case map[string]interface{}:
+			value, ok := c[part]
+			if !ok {
+				return nil, newNotFoundError(c, parts, pos)
+			}
+			cfg = value
+		default:
+			return nil, newNotFoundError(cfg, parts, pos)
+		}
+	}
 
-				// 2. set value or go further
-				if pos+1 == len(parts) {
-					c[i] = value
-				} else {
-
-					// if exists just pick the pointer
-					if va := c[i]; va != nil {
-						point = &va
-					} else {
-						// is next part slice or map?
-						if i, err := strconv.ParseInt(parts[pos+1], 10, 0); err == nil {
-							va = make([]interface{}, int(i)+1, int(i)+1)
-						} else {
-							va = make(map[string]interface{})
-						}
-						c[i] = va
-						point = &va
-					}
+	return cfg, nil
+}
 
-				}
+// Set returns an error, in case when it is not possible to
+// establish the value obtained in accordance with given dotted path.
+// Nested lists grow as needed, but a list given as cfg can't grow in
+// place; Config.Set handles that case.
+func Set(cfg interface{}, path string, value interface{}) error {
+	parts, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	_, err = setParts(cfg, parts, value)
+	return err
+}
 
-			} else {
-				return fmt.Errorf("Invalid list index at %q",
+// setParts sets a value according to parsed path keys. It returns the
+// root, which is a new list when a list root had to grow.
+func setParts(root interface{}, parts []string, value interface{}) (interface{}, error) {
+	// store replaces the current node in its parent, for growing lists.
+	store := func(v interface{}) { root = v }
+	node := root
+	for pos, part := range parts {
+		last := pos+1 == len(parts)
+		switch c := node.(type) {
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 {
+				return root, fmt.Errorf("Invalid list index at %q",
 					strings.Join(parts[:pos+1], "."))
 			}
+			if i >= len(c) {
+				c = append(c, make([]interface{}, i-len(c)+1)...)
+				store(c)
+			}
+			if last {
+				c[i] = value
+				break
+			}
+			if c[i] == nil {
+				c[i] = newContainer(parts[pos+1])
+			}
+			node = c[i]
+			store = func(v interface{}) { c[i] = v }
 		case map[string]interface{}:
-			if pos+1 == len(parts) {
+			if last {
 				c[part] = value
-			} else {
-				// if exists just pick the pointer
-				if va, ok := c[part]; ok {
-					point = &va
-				} else {
-					// is next part slice or map?
-					if i, err := strconv.ParseInt(parts[pos+1], 10, 0); err == nil {
-						va = make([]interface{}, int(i)+1, int(i)+1)
-					} else {
-						va = make(map[string]interface{})
-					}
-					c[part] = va
-					point = &va
-				}
+				break
+			}
+			if _, ok := c[part]; !ok {
+				c[part] = newContainer(parts[pos+1])
 			}
+			key := part
+			node = c[key]
+			store = func(v interface{}) { c[key] = v }
 		default:
-			return fmt.Errorf(
+			return root, fmt.Errorf(
 				"Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
 				strings.Join(parts[:pos+1], "."), c)
 		}
 	}
+	return root, nil
+}
 
-	return nil
+// newContainer returns an empty node to hold the given key: a list for
+// indices and a map otherwise.
+func newContainer(key string) interface{} {
+	if i, err := strconv.Atoi(key); err == nil && i >= 0 {
+		return make([]interface{}, i+1)
+	}
+	return map[string]interface{}{}
 }
 
 // Parsing --------------------------------------------------------------------
 
+// newConfig wraps a parsed and normalized root, running the parse hooks.
+func newConfig(root interface{}) (*Config, error) {
+	root, err := runParseHooks(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Root: root}, nil
+}
+
+// New returns a config holding a normalized deep copy of the given tree,
+// built from maps, lists and scalars as the parsing functions produce.
+func New(root interface{}) (*Config, error) {
+	root, err := normalizeValue(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Root: root}, nil
+}
+
 // Must is a wrapper for parsing functions to be used during initialization.
 // It panics on failure.
 func Must(cfg *Config, err error) *Config {
@@ -543,6 +1275,62 @@ func Must(cfg *Config, err error) *Config {
 	return cfg
 }
 
+// ParseOptions tune the parsing of ParseYamlWith and ParseJsonWith, and of
+// the files loaded by a FileSource.
+type ParseOptions struct {
+	// StrictMapKeys makes parsing fail on map keys which are not strings,
+	// like the ints and bools YAML produces for `80: ...` or `yes: ...`.
+	// By default such keys are converted to their string form.
+	StrictMapKeys bool
+	// EmptyDocumentAsMap makes parsing of empty or `null` documents
+	// return a config rooted at an empty map instead of a nil root, so
+	// that empty override files can be extended and set like any other
+	// config.
+	EmptyDocumentAsMap bool
+}
+
+// config wraps the root of a parsed document like newConfig.
+func (opts ParseOptions) config(root interface{}) (*Config, error) {
+	if root == nil && opts.EmptyDocumentAsMap {
+		root = map[string]interface{}{}
+	}
+	return newConfig(root)
+}
+
+// normalizeKey converts an unmarshalled map key to a string.
+func normalizeKey(k interface{}) (string, error) {
+	switch key := k.(type) {
+	case string:
+		return key, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(key), nil
+	}
+	return "", fmt.Errorf("Unsupported map key: %#v", k)
+}
+
+// checkMapKeys returns an error for a map key of an unmarshalled tree
+// which is not a string, see ParseOptions.StrictMapKeys.
+func checkMapKeys(value interface{}) error {
+	switch value := value.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range value {
+			if _, ok := k.(string); !ok {
+				return fmt.Errorf("Unsupported map key: %#v", k)
+			}
+			if err := checkMapKeys(v); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, v := range value {
+			if err := checkMapKeys(v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // normalizeValue normalizes a unmarshalled value. This is needed because
 // encoding/json doesn't support marshalling map[interface{}]interface{}.
 func normalizeValue(value interface{}) (interface{}, error) {
@@ -550,9 +1338,12 @@ func normalizeValue(value interface{}) (interface{}, error) {
 	case map[interface{}]interface{}:
 		node := make(map[string]interface{}, len(value))
 		for k, v := range value {
-			key, ok := k.(string)
-			if !ok {
-				return nil, fmt.Errorf("Unsupported map key: %#v", k)
+			key, err := normalizeKey(k)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := node[key]; ok {
+				return nil, fmt.Errorf("Duplicate map key: %q", key)
 			}
 			item, err := normalizeValue(v)
 			if err != nil {
@@ -581,30 +1372,123 @@ func normalizeValue(value interface{}) (interface{}, error) {
 			node[key] = item
 		}
 		return node, nil
-	case bool, float64, int, string, nil:
+	case []byte:
+		node := make([]byte, len(value))
+		copy(node, value)
+		return node, nil
+	case bool, float64, int, string, time.Time, nil:
 		return value, nil
 	}
 	return nil, fmt.Errorf("Unsupported type: %T", value)
 }
 
+// renderable prepares a value for rendering: a *Config is replaced by its
+// root, and maps with interface{} keys, like the ones decoded from YAML
+// sub-nodes by gopkg.in/yaml.v2, get string keys. Values which need no
+// changes are returned as is, so rendering doesn't copy the tree.
+func renderable(value interface{}) (interface{}, error) {
+	v, _, err := renderableValue(value)
+	return v, err
+}
+
+// renderableValue is renderable, also reporting whether value was replaced.
+func renderableValue(value interface{}) (interface{}, bool, error) {
+	switch value := value.(type) {
+	case *Config:
+		if value == nil {
+			return nil, true, nil
+		}
+		value.auditExport()
+		v, _, err := renderableValue(value.Root)
+		return v, true, err
+	case Config:
+		v, _, err := renderableValue(value.Root)
+		return v, true, err
+	case map[interface{}]interface{}:
+		node := make(map[string]interface{}, len(value))
+		for k, v := range value {
+			key, err := normalizeKey(k)
+			if err != nil {
+				return nil, false, err
+			}
+			if _, ok := node[key]; ok {
+				return nil, false, fmt.Errorf("Duplicate map key: %q", key)
+			}
+			if node[key], _, err = renderableValue(v); err != nil {
+				return nil, false, err
+			}
+		}
+		return node, true, nil
+	case map[string]interface{}:
+		var node map[string]interface{}
+		for key, v := range value {
+			item, changed, err := renderableValue(v)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && node == nil {
+				node = make(map[string]interface{}, len(value))
+				for k, v := range value {
+					node[k] = v
+				}
+			}
+			if node != nil {
+				node[key] = item
+			}
+		}
+		if node == nil {
+			return value, false, nil
+		}
+		return node, true, nil
+	case []interface{}:
+		var node []interface{}
+		for i, v := range value {
+			item, changed, err := renderableValue(v)
+			if err != nil {
+				return nil, false, err
+			}
+			if changed && node == nil {
+				node = make([]interface{}, len(value))
+				copy(node, value)
+			}
+			if node != nil {
+				node[i] = item
+			}
+		}
+		if node == nil {
+			return value, false, nil
+		}
+		return node, true, nil
+	}
+	return value, false, nil
+}
+
 // JSON -----------------------------------------------------------------------
 
 // ParseJson reads a JSON configuration from the given string.
 func ParseJson(cfg string) (*Config, error) {
-	return parseJson([]byte(cfg))
+	return parseJson([]byte(cfg), ParseOptions{})
+}
+
+// ParseJsonWith reads a JSON configuration like ParseJson, with options.
+func ParseJsonWith(cfg string, opts ParseOptions) (*Config, error) {
+	return parseJson([]byte(cfg), opts)
 }
 
-// ParseJsonFile reads a JSON configuration from the given filename.
+// ParseJsonFile reads a JSON configuration from the given filename. Gzip
+// files, and the ones of other registered compressions, are decompressed
+// transparently.
 func ParseJsonFile(filename string) (*Config, error) {
-	cfg, err := ioutil.ReadFile(filename)
+	cfg, err := readFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return parseJson(cfg)
+	return parseJson(cfg, ParseOptions{})
 }
 
-// parseJson performs the real JSON parsing.
-func parseJson(cfg []byte) (*Config, error) {
+// parseJson performs the real JSON parsing. JSON maps only have string
+// keys, so StrictMapKeys changes nothing.
+func parseJson(cfg []byte, opts ParseOptions) (*Config, error) {
 	var out interface{}
 	var err error
 	if err = json.Unmarshal(cfg, &out); err != nil {
@@ -613,54 +1497,81 @@ func parseJson(cfg []byte) (*Config, error) {
 	if out, err = normalizeValue(out); err != nil {
 		return nil, err
 	}
-	return &Config{Root: out}, nil
+	return opts.config(out)
 }
 
-// RenderJson renders a JSON configuration.
+// RenderJson renders a JSON configuration. It accepts a *Config, or any
+// value from a config tree, see renderable.
 func RenderJson(cfg interface{}) (string, error) {
-	b, err := json.Marshal(cfg)
+	cfg, err := renderable(cfg)
 	if err != nil {
 		return "", err
 	}
-	return string(b), nil
+	buf := getBuffer()
+	defer putBuffer(buf)
+	// Encode writes the same document as json.Marshal, followed by a
+	// newline, into the reused buffer.
+	if err := json.NewEncoder(buf).Encode(cfg); err != nil {
+		return "", err
+	}
+	return string(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
 }
 
 // YAML -----------------------------------------------------------------------
 
 // ParseYamlBytes reads a YAML configuration from the given []byte.
 func ParseYamlBytes(cfg []byte) (*Config, error) {
-	return parseYaml(cfg)
+	return parseYaml(cfg, ParseOptions{})
 }
 
 // ParseYaml reads a YAML configuration from the given string.
 func ParseYaml(cfg string) (*Config, error) {
-	return parseYaml([]byte(cfg))
+	return parseYaml([]byte(cfg), ParseOptions{})
+}
+
+// ParseYamlWith reads a YAML configuration like ParseYaml, with options.
+func ParseYamlWith(cfg string, opts ParseOptions) (*Config, error) {
+	return parseYaml([]byte(cfg), opts)
 }
 
-// ParseYamlFile reads a YAML configuration from the given filename.
+// ParseYamlFile reads a YAML configuration from the given filename. Like
+// ParseJsonFile, it decompresses compressed files transparently.
 func ParseYamlFile(filename string) (*Config, error) {
-	cfg, err := ioutil.ReadFile(filename)
+	cfg, err := readFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	return parseYaml(cfg)
+	return parseYaml(cfg, ParseOptions{})
 }
 
 // parseYaml performs the real YAML parsing.
-func parseYaml(cfg []byte) (*Config, error) {
+func parseYaml(cfg []byte, opts ParseOptions) (*Config, error) {
 	var out interface{}
 	var err error
 	if err = yaml.Unmarshal(cfg, &out); err != nil {
 		return nil, err
 	}
+	if opts.StrictMapKeys {
+		if err = checkMapKeys(out); err != nil {
+			return nil, err
+		}
+	}
 	if out, err = normalizeValue(out); err != nil {
 		return nil, err
 	}
-	return &Config{Root: out}, nil
+	return opts.config(out)
 }
 
-// RenderYaml renders a YAML configuration.
+// RenderYaml renders a YAML configuration. Like RenderJson, it accepts a
+// *Config or any value from a config tree.
 func RenderYaml(cfg interface{}) (string, error) {
+	if c, ok := cfg.(*Config); ok && c != nil && len(c.comments) > 0 {
+		return RenderYamlWith(c, YamlOptions{})
+	}
+	cfg, err := renderable(cfg)
+	if err != nil {
+		return "", err
+	}
 	b, err := yaml.Marshal(cfg)
 	if err != nil {
 		return "", err
@@ -5,9 +5,12 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 var yamlString = `
@@ -278,6 +281,126 @@ func TestSetUnexistingValue(t *testing.T) {
 	expect(t, cfg.Set("some.thing.more", val) != nil, true)
 }
 
+// TestSetGrowsLists covers the fix of Set, and of the Set function, which
+// lost the values set past the end of nested lists, as the grown list
+// wasn't stored back into its parent.
+func TestSetGrowsLists(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	val := "test"
+
+	// lists grow past their end, including the root one
+	first := cfg.UString("config.server.0")
+	expect(t, cfg.Set("config.server.4", val), nil)
+	expect(t, len(cfg.UList("config.server")), 5)
+	expect(t, cfg.UString("config.server.4"), val)
+	expect(t, cfg.UString("config.server.0"), first)
+	expect(t, Set(cfg.Root, "config.server.6.name", val), nil)
+	expect(t, cfg.UString("config.server.6.name"), val)
+	list := &Config{}
+	expect(t, list.Set("0", "a"), nil)
+	expect(t, list.Set("2.x", "c"), nil)
+	expect(t, len(list.UList("")), 3)
+	expect(t, list.UString("2.x"), "c")
+	expect(t, list.Set("-1", val).Error(), `Invalid list index at "-1"`)
+}
+
+func TestDelete(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+
+	expect(t, cfg.Delete("map.key8"), nil)
+	_, err = cfg.String("map.key8")
+	expect(t, err != nil, true)
+	expect(t, cfg.Delete("map.key8").Error(), `Nonexistent map key at "map.key8"`)
+
+	expect(t, cfg.Delete("config.server.1"), nil)
+	expect(t, len(cfg.UList("config.server")), 2)
+	expect(t, cfg.UString("config.server.1"), "www.example.com")
+	expect(t, cfg.Delete("config.server.2").Error(), `Invalid list index at "config.server.2"`)
+
+	list, err := ParseYaml("[a, [b, c], d]")
+	expect(t, err, nil)
+	expect(t, list.Delete("1.0"), nil)
+	expect(t, list.UString("1.0"), "c")
+	expect(t, list.Delete("0"), nil)
+	expect(t, list.UString("0.0"), "c")
+	expect(t, list.UString("1"), "d")
+
+	complex, err := ParseYaml("root: {field.one: 1, field.two: 2}")
+	expect(t, err, nil)
+	expect(t, complex.Delete("root.[field.one]"), nil)
+	expect(t, len(complex.UMap("root")), 1)
+	complex.Set("list", map[string]interface{}{"a.b": []interface{}{1, 2}})
+	expect(t, complex.Delete("list.[a.b].0"), nil)
+	expect(t, complex.UInt("list.[a.b].0"), 2)
+}
+
+func TestMount(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	plugin, err := ParseYaml("endpoint: /foo\nretries: 3")
+	expect(t, err, nil)
+	other, err := ParseYaml("endpoint: /bar")
+	expect(t, err, nil)
+
+	expect(t, cfg.Mount("plugins.foo", plugin), nil)
+	expect(t, cfg.MountCopy("plugins.bar", other), nil)
+	expect(t, cfg.UString("plugins.foo.endpoint"), "/foo")
+	expect(t, cfg.UInt("plugins.foo.retries"), 3)
+	expect(t, cfg.UString("plugins.bar.endpoint"), "/bar")
+
+	// Mounted by reference or by copy.
+	plugin.Set("retries", 5)
+	other.Set("endpoint", "/baz")
+	expect(t, cfg.UInt("plugins.foo.retries"), 5)
+	expect(t, cfg.UString("plugins.bar.endpoint"), "/bar")
+
+	expect(t, cfg.Mount("plugins.foo", other).Error(), `Path "plugins.foo" is already set`)
+	expect(t, cfg.Mount("", other).Error(), `Invalid path ""`)
+
+	expect(t, cfg.Unmount("plugins.foo"), nil)
+	_, err = cfg.Get("plugins.foo")
+	expect(t, err != nil, true)
+	expect(t, cfg.UString("plugins.bar.endpoint"), "/bar")
+	expect(t, cfg.Mount("plugins.foo", other), nil)
+}
+
+func TestMountAfterCopy(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	plugin, err := ParseYaml("endpoint: /foo\nretries: 3")
+	expect(t, err, nil)
+
+	cp, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, cfg.Mount("plugins.foo", plugin), nil)
+	expect(t, cfg.Set("plugins.foo.retries", 9), nil)
+	expect(t, plugin.UInt("retries"), 9)
+	expect(t, plugin.Set("endpoint", "/bar"), nil)
+	expect(t, cfg.UString("plugins.foo.endpoint"), "/bar")
+	_, err = cp.Get("plugins.foo")
+	expect(t, err != nil, true)
+
+	// Trees shared by Extend copy the mounted tree.
+	merged, err := cfg.Extend(Must(ParseYaml("name: merged")))
+	expect(t, err, nil)
+	expect(t, cfg.Set("plugins.foo.retries", 10), nil)
+	expect(t, plugin.UInt("retries"), 10)
+	expect(t, merged.UInt("plugins.foo.retries"), 9)
+	expect(t, plugin.Set("endpoint", "/baz"), nil)
+	expect(t, merged.UString("plugins.foo.endpoint"), "/bar")
+	_, err = plugin.Extend(Must(ParseYaml("retries: 1")))
+	expect(t, err, nil)
+	expect(t, plugin.Set("retries", 11), nil)
+	expect(t, cfg.UInt("plugins.foo.retries"), 11)
+
+	copied, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, copied.Set("plugins.foo.retries", 12), nil)
+	expect(t, plugin.UInt("retries"), 11)
+}
+
 func TestEnv(t *testing.T) {
 	cfg, err := ParseYaml(yamlString)
 	if err != nil {
@@ -308,6 +431,72 @@ func TestEnvPrefix(t *testing.T) {
 	}
 }
 
+func TestToEnv(t *testing.T) {
+	cfg, err := ParseYaml(`
+database:
+  host: localhost
+  port: 5432
+  replicas:
+    - db1
+    - db2
+debug: true
+empty: ~
+`)
+	expect(t, err, nil)
+	expect(t, reflect.DeepEqual(cfg.ToEnv("app"), []string{
+		"APP_DATABASE_HOST=localhost",
+		"APP_DATABASE_PORT=5432",
+		"APP_DATABASE_REPLICAS_0=db1",
+		"APP_DATABASE_REPLICAS_1=db2",
+		"APP_DEBUG=true",
+		"APP_EMPTY=",
+	}), true)
+	expect(t, cfg.ToEnv("")[0], "DATABASE_HOST=localhost")
+
+	// Keys holding dots are exported with their values, under valid
+	// names, and exporting doesn't count as reading.
+	dotted := Must(ParseYaml("root: {a.b: 1, c-d: x}")).TrackReads()
+	expect(t, reflect.DeepEqual(dotted.ToEnv("p"), []string{"P_ROOT_A_B=1", "P_ROOT_C_D=x"}), true)
+	expect(t, len(dotted.UnreadPaths()), 2)
+
+	// The export is the inverse of EnvPrefix.
+	expect(t, cfg.SetProcessEnv("toenv"), nil)
+	expect(t, os.Getenv("TOENV_DATABASE_REPLICAS_1"), "db2")
+	cfg2, _ := ParseYaml("database: {host: other, port: 1}")
+	cfg2.EnvPrefix("toenv")
+	expect(t, cfg2.UString("database.host"), "localhost")
+	expect(t, cfg2.UInt("database.port"), 5432)
+}
+
+func TestTempFile(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	sub, err := cfg.Copy("config")
+	expect(t, err, nil)
+
+	for _, format := range []Format{FormatYaml, FormatJson} {
+		path, cleanup, err := sub.TempFile(format)
+		expect(t, err, nil)
+		info, err := os.Stat(path)
+		expect(t, err, nil)
+		expect(t, info.Mode().Perm(), os.FileMode(0600))
+		expect(t, filepath.Ext(path), "."+format.String())
+
+		var parsed *Config
+		if format == FormatJson {
+			parsed, err = ParseJsonFile(path)
+		} else {
+			parsed, err = ParseYamlFile(path)
+		}
+		expect(t, err, nil)
+		expect(t, parsed.UString("admin.1.username"), "hobbes")
+
+		cleanup()
+		_, err = os.Stat(path)
+		expect(t, os.IsNotExist(err), true)
+	}
+}
+
 func TestFlag(t *testing.T) {
 	cfg, err := ParseYaml(`
 map:
@@ -432,6 +621,135 @@ list:
 	expect(t, extended.UString("list.8"), "item8")
 }
 
+func TestMergeReport(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg2, err := ParseYaml(`
+map:
+  key0: true
+  key6: 42.0
+  key8: changed
+  key10: added
+list:
+  - false
+`)
+	expect(t, err, nil)
+
+	merged, conflicts, err := cfg.MergeReport(cfg2, nil)
+	expect(t, err, nil)
+	expect(t, len(conflicts), 2)
+	expect(t, conflicts[0], Conflict{Path: "list.0", Old: true, New: false})
+	expect(t, conflicts[1], Conflict{Path: "map.key8", Old: "value8", New: "changed"})
+	expect(t, merged.UString("map.key8"), "changed")
+	expect(t, merged.UString("map.key10"), "added")
+	expect(t, merged.UBool("list.0", true), false)
+
+	// Auditing leaves conflicting values alone.
+	audited, conflicts, err := cfg.MergeReport(cfg2, KeepOld)
+	expect(t, err, nil)
+	expect(t, len(conflicts), 2)
+	expect(t, audited.UString("map.key8"), "value8")
+	expect(t, audited.UString("map.key10"), "added")
+
+	// Resolution can be chosen per path.
+	picked, _, err := cfg.MergeReport(cfg2, func(c Conflict) (interface{}, error) {
+		if c.Path == "map.key8" {
+			return "picked", nil
+		}
+		return c.Old, nil
+	})
+	expect(t, err, nil)
+	expect(t, picked.UString("map.key8"), "picked")
+	expect(t, picked.UBool("list.0"), true)
+
+	_, _, err = cfg.MergeReport(cfg2, func(c Conflict) (interface{}, error) {
+		return nil, fmt.Errorf("refusing to change %s", c.Path)
+	})
+	expect(t, err.Error(), "refusing to change list.0")
+
+	// Keys holding dots are quoted, like by Extend.
+	dotted := Must(ParseYaml("root: {a.b: 1}"))
+	merged, conflicts, err = dotted.MergeReport(Must(ParseYaml("root: {a.b: 2}")), nil)
+	expect(t, err, nil)
+	expect(t, len(conflicts), 1)
+	expect(t, conflicts[0], Conflict{Path: "root.[a.b]", Old: 1, New: 2})
+	v, _ := Get(merged.Root, conflicts[0].Path)
+	expect(t, v, 2)
+}
+
+func TestExtendByKeys(t *testing.T) {
+	cfg, err := ParseYaml(`
+users:
+  - name: calvin
+    password: yukon
+    roles: [admin]
+  - name: hobbes
+    password: tuna
+clusters:
+  - name: eu
+    nodes:
+      - {host: eu1, weight: 1}
+      - {host: eu2, weight: 1}
+ports: [80, 443]
+`)
+	expect(t, err, nil)
+	override, err := ParseYaml(`
+users:
+  - name: hobbes
+    password: salmon
+  - name: susie
+    password: derkins
+clusters:
+  - name: eu
+    nodes:
+      - {host: eu2, weight: 3}
+ports: [8080]
+`)
+	expect(t, err, nil)
+
+	extended, err := cfg.ExtendByKeys(override, map[string]string{
+		"users":          "name",
+		"clusters":       "name",
+		"clusters.nodes": "host",
+	})
+	expect(t, err, nil)
+	expect(t, len(extended.UList("users")), 3)
+	expect(t, extended.UString("users.0.password"), "yukon")
+	expect(t, extended.UString("users.0.roles.0"), "admin")
+	expect(t, extended.UString("users.1.password"), "salmon")
+	expect(t, extended.UString("users.2.name"), "susie")
+	expect(t, len(extended.UList("clusters.0.nodes")), 2)
+	expect(t, extended.UInt("clusters.0.nodes.0.weight"), 1)
+	expect(t, extended.UInt("clusters.0.nodes.1.weight"), 3)
+	// Lists without a key are merged by position.
+	expect(t, extended.UInt("ports.0"), 8080)
+	expect(t, extended.UInt("ports.1"), 443)
+	// immutable
+	expect(t, cfg.UString("users.1.password"), "tuna")
+
+	bad, err := ParseYaml("users: [{password: secret}]")
+	expect(t, err, nil)
+	_, err = cfg.ExtendByKeys(bad, map[string]string{"users": "name"})
+	expect(t, err.Error(), `Item 0 of "users" has no "name" key`)
+}
+
+func TestNew(t *testing.T) {
+	tree := map[string]interface{}{
+		"map": map[interface{}]interface{}{"key": "value", 80: "http"},
+	}
+	cfg, err := New(tree)
+	expect(t, err, nil)
+	expect(t, cfg.UString("map.key"), "value")
+	expect(t, cfg.UString("map.80"), "http")
+	cfg.Set("map.key", "changed")
+	expect(t, tree["map"].(map[interface{}]interface{})["key"], "value")
+
+	_, err = New(map[string]interface{}{"bad": struct{}{}})
+	expect(t, err != nil, true)
+}
+
 func TestComplexYamlKeys(t *testing.T) {
 	cfg, err := ParseYaml(`
 root:
@@ -453,6 +771,233 @@ root:
 	expect(t, cfg.UString("root.[field.something.4].[field.6]"), "value6")
 }
 
+func TestTimeAndBytes(t *testing.T) {
+	cfg, err := ParseYaml(`
+created: 2001-12-14t21:59:43.10-05:00
+date: !!timestamp 2002-12-14
+spaced: 2001-12-14 21:59:43.10
+picture: !!binary R0lGODlh
+name: calvin
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created, err := cfg.Time("created")
+	expect(t, err, nil)
+	expect(t, created.Equal(time.Date(2001, 12, 15, 2, 59, 43, 1e8, time.UTC)), true)
+
+	date, err := cfg.Time("date")
+	expect(t, err, nil)
+	expect(t, date, time.Date(2002, 12, 14, 0, 0, 0, 0, time.UTC))
+
+	spaced, err := cfg.Time("spaced")
+	expect(t, err, nil)
+	expect(t, spaced, time.Date(2001, 12, 14, 21, 59, 43, 1e8, time.UTC))
+
+	_, err = cfg.Time("name")
+	expect(t, err != nil, true)
+	expect(t, cfg.UTime("name", date), date)
+
+	picture, err := cfg.Bytes("picture")
+	expect(t, err, nil)
+	expect(t, string(picture), "GIF89a")
+	expect(t, len(cfg.UBytes("undefined")), 0)
+
+	// time.Time and []byte values survive normalization and copying.
+	cfg.Set("stamp", created)
+	cfg.Set("raw", []byte("raw"))
+	cfg2, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, cfg2.UTime("stamp"), created)
+	expect(t, string(cfg2.UBytes("raw")), "raw")
+	expect(t, cfg2.UString("raw"), "raw")
+}
+
+func TestNonStringMapKeys(t *testing.T) {
+	source := `
+ports:
+  80: http
+  443: https
+flags:
+  true: enabled
+  1.5: ratio
+`
+	cfg, err := ParseYaml(source)
+	expect(t, err, nil)
+	expect(t, cfg.UString("ports.80"), "http")
+	expect(t, cfg.UString("ports.443"), "https")
+	expect(t, cfg.UString("flags.true"), "enabled")
+	expect(t, cfg.UString("flags.[1.5]"), "ratio")
+
+	_, err = ParseYaml("80: a\n\"80\": b\n")
+	expect(t, err.Error(), `Duplicate map key: "80"`)
+
+	_, err = ParseYamlWith(source, ParseOptions{StrictMapKeys: true})
+	expect(t, err != nil, true)
+	_, err = ParseYamlWith("ports: [{80: http}]", ParseOptions{StrictMapKeys: true})
+	expect(t, err.Error(), "Unsupported map key: 80")
+	cfg, err = ParseYamlWith("ports: {http: 80}", ParseOptions{StrictMapKeys: true})
+	expect(t, err, nil)
+	expect(t, cfg.UInt("ports.http"), 80)
+	// Other parsers keep converting the keys.
+	_, err = ParseYaml(source)
+	expect(t, err, nil)
+}
+
+func TestEmptyDocument(t *testing.T) {
+	for _, source := range []string{"", "null", "# comment only\n"} {
+		cfg, err := ParseYaml(source)
+		expect(t, err, nil)
+		expect(t, cfg.Root, nil)
+		expect(t, cfg.IsEmpty(), true)
+		expect(t, len(getKeys(cfg.Root)), 0)
+
+		// Extending with an empty override is a no-op.
+		base, _ := ParseYaml("key: value")
+		extended, err := base.Extend(cfg)
+		expect(t, err, nil)
+		expect(t, extended.UString("key"), "value")
+
+		// Setting a value gives the config a root.
+		expect(t, cfg.Set("some.key", "value"), nil)
+		expect(t, cfg.UString("some.key"), "value")
+		expect(t, cfg.IsEmpty(), false)
+	}
+
+	cfg, err := ParseJson("null")
+	expect(t, err, nil)
+	expect(t, cfg.Set("0.key", "value"), nil)
+	expect(t, cfg.UString("0.key"), "value")
+
+	opts := ParseOptions{EmptyDocumentAsMap: true}
+	cfg, err = ParseYamlWith("", opts)
+	expect(t, err, nil)
+	expect(t, cfg.IsEmpty(), true)
+	expect(t, len(cfg.UMap("")), 0)
+	cfg, err = ParseJsonWith("null", opts)
+	expect(t, err, nil)
+	expect(t, len(cfg.UMap("")), 0)
+	cfg, err = ParseYaml("")
+	expect(t, err, nil)
+	expect(t, cfg.Root, nil)
+
+	var nilCfg *Config
+	expect(t, nilCfg.IsEmpty(), true)
+}
+
+func TestWithFallback(t *testing.T) {
+	defaults, err := ParseYaml(`
+server:
+  host: localhost
+  port: 8080
+  tls:
+    enabled: false
+users:
+  - calvin
+`)
+	expect(t, err, nil)
+	override, err := ParseYaml(`
+server:
+  port: 9090
+`)
+	expect(t, err, nil)
+	env, err := ParseYaml(`
+server:
+  tls:
+    enabled: true
+`)
+	expect(t, err, nil)
+
+	cfg := override.WithFallback(defaults)
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UString("users.0"), "calvin")
+	expect(t, cfg.UBool("server.tls.enabled"), false)
+	_, err = cfg.String("server.missing")
+	expect(t, err.Error(), `Nonexistent map key at "server.missing"`)
+
+	// Nested configs keep falling back.
+	server, err := cfg.Get("server")
+	expect(t, err, nil)
+	expect(t, server.UInt("port"), 9090)
+	expect(t, server.UString("host"), "localhost")
+	tls, err := cfg.Get("server.tls")
+	expect(t, err, nil)
+	expect(t, tls.UBool("enabled"), false)
+
+	// Layers are not merged, so they can be changed independently.
+	defaults.Set("server.host", "example.com")
+	expect(t, cfg.UString("server.host"), "example.com")
+	expect(t, override.UString("server.host", "none"), "none")
+	swapped, _ := ParseYaml("server: {host: swapped.com}")
+	defaults.Root = swapped.Root
+	expect(t, cfg.UString("server.host"), "swapped.com")
+	defaults.Set("server.host", "example.com")
+	root := override.Root
+	reloaded, _ := ParseYaml("server: {port: 7070, host: reloaded.com}")
+	override.Root = reloaded.Root
+	expect(t, cfg.UInt("server.port"), 7070)
+	server, err = cfg.Get("server")
+	expect(t, err, nil)
+	expect(t, server.UString("host"), "reloaded.com")
+	override.Root = nil
+	expect(t, cfg.UString("server.host"), "example.com")
+	override.Root = root
+
+	// Chaining adds lower priority layers.
+	chained := env.WithFallback(override).WithFallback(defaults)
+	expect(t, chained.UBool("server.tls.enabled"), true)
+	expect(t, chained.UInt("server.port"), 9090)
+	expect(t, chained.UString("server.host"), "example.com")
+	env.Set("server.port", 6060)
+	expect(t, chained.UInt("server.port"), 6060)
+}
+
+func TestProfiles(t *testing.T) {
+	cfg, err := ParseYaml(`
+database:
+  host: localhost
+  pool: 5
+cdn: cdn.example.com
+env:prod:
+  database:
+    host: db.example.com
+    pool: 50
+region:eu:
+  database:
+    host: db.eu.example.com
+  cdn: cdn.eu.example.com
+tier:canary:
+  database:
+    pool: 1
+`)
+	expect(t, err, nil)
+
+	prod, err := cfg.Profiles("env:prod")
+	expect(t, err, nil)
+	expect(t, prod.UString("database.host"), "db.example.com")
+	expect(t, prod.UInt("database.pool"), 50)
+	expect(t, prod.UString("cdn"), "cdn.example.com")
+	_, err = prod.Get("[env:prod]")
+	expect(t, err != nil, true)
+
+	matrix, err := cfg.Profiles("env:prod", "region:eu", "tier:canary", "region:ap")
+	expect(t, err, nil)
+	expect(t, matrix.UString("database.host"), "db.eu.example.com")
+	expect(t, matrix.UInt("database.pool"), 1)
+	expect(t, matrix.UString("cdn"), "cdn.eu.example.com")
+
+	// Priority follows the order of activation.
+	reversed, err := cfg.Profiles("region:eu", "env:prod")
+	expect(t, err, nil)
+	expect(t, reversed.UString("database.host"), "db.example.com")
+
+	// The source config is left untouched.
+	expect(t, cfg.UString("database.host"), "localhost")
+	expect(t, cfg.UString("[env:prod].database.host"), "db.example.com")
+}
+
 func testConfig(t *testing.T, cfg *Config) {
 Loop:
 	for _, test := range configTests {
@@ -500,6 +1045,31 @@ Loop:
 	}
 }
 
+func TestRenderConfig(t *testing.T) {
+	cfg, err := ParseYaml("a: {b: 1}")
+	expect(t, err, nil)
+
+	j, err := RenderJson(cfg)
+	expect(t, err, nil)
+	expect(t, j, `{"a":{"b":1}}`)
+	y, err := RenderYaml(cfg)
+	expect(t, err, nil)
+	expect(t, y, "a:\n  b: 1\n")
+
+	// Trees decoded by yaml.v2 directly have interface{} keys.
+	tree := map[string]interface{}{
+		"list": []interface{}{map[interface{}]interface{}{"k": "v", 1: true}},
+	}
+	j, err = RenderJson(tree)
+	expect(t, err, nil)
+	expect(t, j, `{"list":[{"1":true,"k":"v"}]}`)
+	_, ok := tree["list"].([]interface{})[0].(map[interface{}]interface{})
+	expect(t, ok, true)
+
+	_, err = RenderJson(map[interface{}]interface{}{[2]int{}: 1})
+	expect(t, err.Error(), "Unsupported map key: [2]int{0, 0}")
+}
+
 func equalList(l1, l2 interface{}) bool {
 	v1, ok1 := l1.([]interface{})
 	v2, ok2 := l2.([]interface{})
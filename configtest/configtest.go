@@ -0,0 +1,118 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package configtest provides helpers for tests of code using
+// github.com/olebedev/config.
+package configtest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/olebedev/config"
+)
+
+// Update makes AssertRendersLike rewrite the golden files instead of
+// comparing with them. Tests usually set it from a flag of their own:
+//
+//	func init() {
+//		flag.BoolVar(&configtest.Update, "update", false, "update golden files")
+//	}
+var Update = false
+
+// FromMap returns a config holding the given tree, failing the test if it
+// can't be normalized.
+func FromMap(t testing.TB, m map[string]interface{}) *config.Config {
+	t.Helper()
+	cfg, err := config.New(m)
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+	return cfg
+}
+
+// TempYamlFile writes the content into a temporary YAML file, removed
+// when the test finishes, and returns its path.
+func TempYamlFile(t testing.TB, content string) string {
+	t.Helper()
+	return tempFile(t, "*.yaml", content)
+}
+
+// TempJsonFile writes the content into a temporary JSON file, removed
+// when the test finishes, and returns its path.
+func TempJsonFile(t testing.TB, content string) string {
+	t.Helper()
+	return tempFile(t, "*.json", content)
+}
+
+func tempFile(t testing.TB, pattern, content string) string {
+	t.Helper()
+	f, err := ioutil.TempFile("", "configtest-"+pattern)
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+	t.Cleanup(func() { os.Remove(f.Name()) })
+	_, err = f.WriteString(content)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+	return f.Name()
+}
+
+// AssertRendersLike checks that the config renders exactly like the
+// golden file, in the format given by the file extension. On mismatch the
+// test fails with the differing paths. When Update is set, the golden file
+// is written instead.
+func AssertRendersLike(t testing.TB, cfg *config.Config, golden string) {
+	t.Helper()
+	format := config.FormatOf(golden)
+	var got string
+	var err error
+	if format == config.FormatJson {
+		got, err = config.RenderJson(cfg.Root)
+	} else {
+		got, err = config.RenderYaml(cfg.Root)
+	}
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+
+	if Update {
+		if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+			t.Fatalf("configtest: %v", err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("configtest: %v", err)
+	}
+	if string(want) == got {
+		return
+	}
+	wantCfg, err := config.ParseFile(golden)
+	if err != nil {
+		t.Errorf("configtest: %s renders differently:\n%s", golden, got)
+		return
+	}
+	changes := config.Diff(wantCfg, cfg)
+	if len(changes) == 0 {
+		t.Errorf("configtest: %s has the same values but renders differently:\n%s", golden, got)
+		return
+	}
+	msg := ""
+	for _, change := range changes {
+		msg += "\n\t" + change.String()
+	}
+	t.Errorf("configtest: config doesn't render like %s:%s", golden, msg)
+}
@@ -0,0 +1,78 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/olebedev/config"
+)
+
+// recorder is a testing.TB recording failures instead of reporting them.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestFromMap(t *testing.T) {
+	cfg := FromMap(t, map[string]interface{}{
+		"database": map[string]interface{}{"port": 5432},
+	})
+	if port := cfg.UInt("database.port"); port != 5432 {
+		t.Errorf("got port %d", port)
+	}
+}
+
+func TestTempFiles(t *testing.T) {
+	var path string
+	t.Run("create", func(t *testing.T) {
+		path = TempYamlFile(t, "key: value")
+		cfg, err := config.ParseFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if cfg.UString("key") != "value" {
+			t.Errorf("unexpected content %v", cfg.Root)
+		}
+		json := TempJsonFile(t, `{"key": 1}`)
+		if filepath.Ext(json) != ".json" {
+			t.Errorf("unexpected name %s", json)
+		}
+	})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("%s wasn't removed: %v", path, err)
+	}
+}
+
+func TestAssertRendersLike(t *testing.T) {
+	cfg := FromMap(t, map[string]interface{}{
+		"database": map[string]interface{}{"host": "localhost", "port": 5432},
+	})
+	AssertRendersLike(t, cfg, "testdata/want.yaml")
+
+	cfg.Set("database.port", 6432)
+	r := &recorder{TB: t}
+	AssertRendersLike(r, cfg, "testdata/want.yaml")
+	if len(r.errors) != 1 || !strings.Contains(r.errors[0], "~ database.port: 5432 -> 6432") {
+		t.Errorf("unexpected failures %q", r.errors)
+	}
+
+	Update = true
+	defer func() { Update = false }()
+	golden := filepath.Join(t.TempDir(), "golden", "want.json")
+	AssertRendersLike(t, cfg, golden)
+	Update = false
+	AssertRendersLike(t, cfg, golden)
+}
@@ -0,0 +1,114 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configtest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/olebedev/config"
+)
+
+// Source is a fake config.Source and config.Watcher serving a config set
+// by the test, to exercise reloads without a remote service:
+//
+//	src := configtest.NewSource(configtest.FromMap(t, m))
+//	manager, _ := config.NewManager(ctx, src)
+//	go manager.Watch(ctx)
+//	src.Set(updated) // manager reloads
+type Source struct {
+	mu       sync.Mutex
+	cfg      *config.Config
+	err      error
+	loads    int
+	watchers []chan struct{}
+	// changes counts calls to Set and Fail, and loaded is the count seen
+	// by the last Load.
+	changes, loaded int
+}
+
+// NewSource returns a source serving cfg.
+func NewSource(cfg *config.Config) *Source {
+	return &Source{cfg: cfg}
+}
+
+// Load returns a copy of the current config, or the error set by Fail.
+func (s *Source) Load(ctx context.Context) (*config.Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loads++
+	s.loaded = s.changes
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.cfg.Copy()
+}
+
+// Watch returns a channel notified by Set and Fail, including the calls
+// made since the last Load and before Watch. Notifications which aren't
+// received yet are coalesced.
+func (s *Source) Watch(ctx context.Context) (<-chan struct{}, error) {
+	ch := make(chan struct{}, 1)
+	s.mu.Lock()
+	s.watchers = append(s.watchers, ch)
+	if s.changes != s.loaded {
+		ch <- struct{}{}
+	}
+	s.mu.Unlock()
+
+	out := make(chan struct{})
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ch:
+				select {
+				case out <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// Set replaces the served config and notifies the watchers.
+func (s *Source) Set(cfg *config.Config) {
+	s.mu.Lock()
+	s.cfg, s.err = cfg, nil
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Fail makes the following loads fail with err and notifies the
+// watchers.
+func (s *Source) Fail(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	s.notify()
+}
+
+// Loads returns the number of calls to Load.
+func (s *Source) Loads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.loads
+}
+
+func (s *Source) notify() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.changes++
+	for _, ch := range s.watchers {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/olebedev/config"
+)
+
+func TestSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	src := NewSource(FromMap(t, map[string]interface{}{"version": 1}))
+	m, err := config.NewManager(ctx, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed := make(chan int)
+	m.OnChange(func(old, new *config.Config) { changed <- new.UInt("version") })
+	failed := make(chan error)
+	m.OnError(func(err error) { failed <- err })
+	done := make(chan error)
+	go func() { done <- m.Watch(ctx) }()
+
+	src.Set(FromMap(t, map[string]interface{}{"version": 2}))
+	if v := <-changed; v != 2 {
+		t.Errorf("got version %d after Set; want 2", v)
+	}
+	src.Fail(errors.New("unavailable"))
+	if err := <-failed; err.Error() != "unavailable" {
+		t.Errorf("got error %v after Fail", err)
+	}
+	if v := m.Config().UInt("version"); v != 2 {
+		t.Errorf("got version %d after a failed reload; want 2", v)
+	}
+	if n := src.Loads(); n != 3 {
+		t.Errorf("got %d loads; want 3", n)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch returned %v; want context.Canceled", err)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"sync/atomic"
+)
+
+// Copy-on-write --------------------------------------------------------------
+//
+// Copy and Extend share the tree of a config with the copy they return
+// instead of duplicating it. Afterwards, both configs copy the maps and
+// lists on the path of a value before changing it, so the other one keeps
+// its values, while the branches left alone stay shared. Layering large
+// configs thus only duplicates the branches the layers set. Since the
+// shared maps and lists can't be changed in place anymore, Map and List
+// return deep copies of them once a config shared its tree, see detached.
+
+// cowState tracks the nodes of a tree which a config may modify in place.
+// It is shared by a config and the views returned by its Get method.
+type cowState struct {
+	// epoch is incremented by every share, which shares all the nodes
+	// of the tree. It is 0 as long as the tree was never shared.
+	epoch uint32
+	// owned holds the nodes copied during ownedEpoch, by identity. It
+	// is only used by the methods modifying the tree.
+	owned      map[uintptr]bool
+	ownedEpoch uint32
+	// mounts holds the roots of the trees grafted by Mount, which are
+	// changed in place whatever the epoch, as they are held by reference.
+	mounts map[uintptr]bool
+	// flat holds the *flatIndex built by Optimize.
+	flat atomic.Value
+	// sealed holds the SealMode of a sealed config.
+	sealed uint32
+}
+
+// state returns the copy-on-write state of a config, creating it when
+// needed. It is safe to call concurrently, like the getters.
+func (cfg *Config) state() *cowState {
+	if s, ok := cfg.cow.Load().(*cowState); ok {
+		return s
+	}
+	cfg.cow.CompareAndSwap(nil, &cowState{})
+	return cfg.cow.Load().(*cowState)
+}
+
+// share returns a copy of the config sharing its tree. The trees grafted
+// by Mount are copied instead, since the config keeps changing them in
+// place; finding them walks the tree, but only when there are some.
+func (cfg *Config) share() *Config {
+	s := cfg.state()
+	s.share()
+	n := &Config{Root: cfg.Root, comments: copyComments(cfg.comments), validators: cfg.validators, audit: cfg.audit, prefix: cfg.prefix}
+	ns := &cowState{epoch: 1}
+	n.cow.Store(ns)
+	if len(s.mounts) > 0 {
+		n.Root, _ = ns.detach(n.Root, s.mounts)
+	}
+	return n
+}
+
+// detach returns node with the mounted trees found in it replaced by deep
+// copies, copying the shared maps and lists holding them, and whether it
+// changed anything.
+func (s *cowState) detach(node interface{}, mounts map[uintptr]bool) (interface{}, bool) {
+	id, ok := nodeID(node)
+	if !ok {
+		return node, false
+	}
+	if mounts[id] {
+		return copyTree(node), true
+	}
+	changed := false
+	switch c := node.(type) {
+	case map[string]interface{}:
+		for k, v := range c {
+			if v, ok := s.detach(v, mounts); ok {
+				if !changed {
+					node, changed = s.own(c), true
+				}
+				node.(map[string]interface{})[k] = v
+			}
+		}
+	case []interface{}:
+		for i, v := range c {
+			if v, ok := s.detach(v, mounts); ok {
+				if !changed {
+					node, changed = s.own(c), true
+				}
+				node.([]interface{})[i] = v
+			}
+		}
+	}
+	return node, changed
+}
+
+// copyTree returns a deep copy of the maps and lists of a tree.
+func copyTree(node interface{}) interface{} {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			m[k] = copyTree(v)
+		}
+		return m
+	case []interface{}:
+		list := make([]interface{}, len(c))
+		for i, v := range c {
+			list[i] = copyTree(v)
+		}
+		return list
+	}
+	return node
+}
+
+// detached returns a map or a list read from the config for the caller to
+// keep. It is the node itself as long as the config never shared its
+// tree, so that changing it in place changes the config, like it always
+// did, and a deep copy of it afterwards, so that changing it can't reach
+// the configs sharing the node.
+func (cfg *Config) detached(node interface{}) interface{} {
+	if s, ok := cfg.cow.Load().(*cowState); !ok || atomic.LoadUint32(&s.epoch) == 0 {
+		return node
+	}
+	return copyTree(node)
+}
+
+// mount marks the node with the given identity as held by reference.
+func (s *cowState) mount(id uintptr) {
+	if s.mounts == nil {
+		s.mounts = map[uintptr]bool{}
+	}
+	s.mounts[id] = true
+}
+
+// share marks the whole tree of a config as shared with a copy.
+func (s *cowState) share() {
+	if atomic.AddUint32(&s.epoch, 1) == 0 {
+		// Skip 0 on overflow, which means the tree was never shared.
+		atomic.AddUint32(&s.epoch, 1)
+	}
+}
+
+// nodeID returns the identity of a map or non-empty list. Other values
+// are never modified in place.
+func nodeID(node interface{}) (uintptr, bool) {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		return reflect.ValueOf(c).Pointer(), true
+	case []interface{}:
+		if len(c) > 0 {
+			return reflect.ValueOf(c).Pointer(), true
+		}
+	}
+	return 0, false
+}
+
+// owns reports whether a node may be modified in place.
+func (s *cowState) owns(node interface{}) bool {
+	epoch := atomic.LoadUint32(&s.epoch)
+	if epoch == 0 {
+		return true
+	}
+	id, ok := nodeID(node)
+	if !ok {
+		return true
+	}
+	return s.ownedEpoch == epoch && s.owned[id] || s.mounts[id]
+}
+
+// own returns node, or a shallow copy of it which may be modified in
+// place when node is shared.
+func (s *cowState) own(node interface{}) interface{} {
+	if s.owns(node) {
+		return node
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			m[k] = v
+		}
+		node = m
+	case []interface{}:
+		node = append([]interface{}(nil), c...)
+	}
+	if epoch := atomic.LoadUint32(&s.epoch); s.ownedEpoch != epoch {
+		s.owned, s.ownedEpoch = map[uintptr]bool{}, epoch
+	}
+	id, _ := nodeID(node)
+	s.owned[id] = true
+	return node
+}
+
+// ownPath makes the maps and lists from the root along the given keys
+// modifiable in place, copying the shared ones, before Set or Delete
+// change the last of them.
+func (cfg *Config) ownPath(parts []string) {
+	s := cfg.state()
+	s.dropIndex()
+	if atomic.LoadUint32(&s.epoch) == 0 {
+		return
+	}
+	cfg.ownView()
+	node := s.own(cfg.Root)
+	cfg.Root = node
+	for _, part := range parts {
+		switch c := node.(type) {
+		case map[string]interface{}:
+			child, ok := c[part]
+			if !ok {
+				return
+			}
+			node = s.own(child)
+			c[part] = node
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return
+			}
+			node = s.own(c[i])
+			c[i] = node
+		default:
+			return
+		}
+	}
+}
+
+// ownTree makes the whole tree modifiable in place, for the methods
+// changing values anywhere in it.
+func (cfg *Config) ownTree() {
+	s := cfg.state()
+	s.dropIndex()
+	if atomic.LoadUint32(&s.epoch) == 0 {
+		return
+	}
+	cfg.ownView()
+	var walk func(node interface{}) interface{}
+	walk = func(node interface{}) interface{} {
+		node = s.own(node)
+		switch c := node.(type) {
+		case map[string]interface{}:
+			for k, v := range c {
+				c[k] = walk(v)
+			}
+		case []interface{}:
+			for i, v := range c {
+				c[i] = walk(v)
+			}
+		}
+		return node
+	}
+	cfg.Root = walk(cfg.Root)
+}
+
+// ownView makes the node of a view returned by Get modifiable in place
+// through its parent, so that the changes made through the view keep
+// showing in the parent once the parent shared its tree. The view is left
+// alone when the parent no longer holds a map or a list at its path.
+func (cfg *Config) ownView() {
+	if cfg.parent == nil {
+		return
+	}
+	node, err := getParts(cfg.parent.Root, cfg.parts)
+	if _, ok := nodeID(node); err != nil || !ok {
+		return
+	}
+	cfg.parent.ownPath(cfg.parts)
+	cfg.Root, _ = getParts(cfg.parent.Root, cfg.parts)
+}
+
+// replaceRoot replaces the tree of the config with a new one. The views
+// returned by Get store it in their parent too, like Set does, unless the
+// parent no longer holds a value at their path.
+func (cfg *Config) replaceRoot(root interface{}) {
+	if p := cfg.parent; p != nil {
+		if _, err := getParts(p.Root, cfg.parts); err == nil {
+			if len(cfg.parts) == 0 {
+				p.replaceRoot(root)
+			} else {
+				p.ownPath(cfg.parts[:len(cfg.parts)-1])
+				p.Root, _ = setParts(p.Root, cfg.parts, root)
+				p.forget(cfg.parts)
+			}
+		}
+	}
+	cfg.state().dropIndex()
+	cfg.Root = root
+}
@@ -0,0 +1,157 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+const cowYaml = `
+db: {host: localhost, port: 5432, replicas: [a, b]}
+cache: {ttl: 10, servers: [{host: c1}, {host: c2}]}
+`
+
+// sameNode reports whether two maps or lists are the same node.
+func sameNode(a, b interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func TestCopyOnWrite(t *testing.T) {
+	cfg := Must(ParseYaml(cowYaml))
+	cp, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, sameNode(cfg.Root, cp.Root), true)
+
+	expect(t, cp.Set("db.host", "db.internal"), nil)
+	expect(t, cp.Set("db.replicas.1", "c"), nil)
+	expect(t, cp.Delete("cache.servers.0"), nil)
+	expect(t, cfg.UString("db.host"), "localhost")
+	expect(t, cfg.UString("db.replicas.1"), "b")
+	expect(t, cfg.UString("cache.servers.0.host"), "c1")
+	expect(t, cp.UString("db.host"), "db.internal")
+	expect(t, cp.UString("db.replicas.1"), "c")
+	expect(t, cp.UString("cache.servers.0.host"), "c2")
+
+	// Changed branches were copied, while the others are still shared.
+	db, _ := Get(cfg.Root, "db")
+	cpDB, _ := Get(cp.Root, "db")
+	expect(t, sameNode(db, cpDB), false)
+	cache, _ := Get(cfg.Root, "cache.servers.1")
+	cpCache, _ := Get(cp.Root, "cache.servers.0")
+	expect(t, sameNode(cache, cpCache), true)
+
+	// The original copies the shared branches it changes too.
+	expect(t, cfg.Set("cache.servers.1.host", "c3"), nil)
+	expect(t, cp.UString("cache.servers.0.host"), "c2")
+	expect(t, cfg.Delete("db.port"), nil)
+	expect(t, cp.UInt("db.port"), 5432)
+
+	// Owned branches are changed in place.
+	cpDB, _ = Get(cp.Root, "db")
+	expect(t, cp.Set("db.user", "app"), nil)
+	owned, _ := Get(cp.Root, "db")
+	expect(t, sameNode(cpDB, owned), true)
+
+	// Views of a config copy the shared branches like it.
+	view, err := cfg.Get("cache")
+	expect(t, err, nil)
+	other := cfg.share()
+	expect(t, view.Set("ttl", 20), nil)
+	expect(t, other.UInt("cache.ttl"), 10)
+	expect(t, cfg.UInt("cache.ttl"), 20)
+
+	expect(t, cp.ReplaceAll("c2", "c4"), 1)
+	expect(t, cfg.UString("cache.servers.1.host"), "c3")
+	expect(t, other.UString("cache.servers.1.host"), "c3")
+}
+
+func TestExtendSharesBranches(t *testing.T) {
+	base := Must(ParseYaml(cowYaml))
+	override := Must(ParseYaml("db: {host: db.internal}"))
+	merged, err := base.Extend(override)
+	expect(t, err, nil)
+	expect(t, merged.UString("db.host"), "db.internal")
+	expect(t, base.UString("db.host"), "localhost")
+	for path, shared := range map[string]bool{"cache": true, "db": false} {
+		node, _ := Get(base.Root, path)
+		mergedNode, _ := Get(merged.Root, path)
+		expect(t, sameNode(node, mergedNode), shared)
+	}
+
+	profiles := Must(ParseYaml("a: 1\nenv:prod: {a: 2}"))
+	prod, err := profiles.Profiles("env:prod")
+	expect(t, err, nil)
+	expect(t, prod.UInt("a"), 2)
+	_, err = profiles.Get("env:prod")
+	expect(t, err, nil)
+}
+
+func TestSharedMapsAndLists(t *testing.T) {
+	cfg := Must(ParseYaml(cowYaml))
+	db := cfg.UMap("db")
+	db["user"] = "app"
+	expect(t, cfg.UString("db.user"), "app")
+
+	for _, share := range []func() *Config{
+		func() *Config { return Must(cfg.Copy()) },
+		func() *Config { return Must(cfg.Extend(Must(ParseYaml("a: 1")))) },
+	} {
+		cp := share()
+		cfg.UMap("db")["host"] = "db.internal"
+		cfg.UList("db.replicas")[0] = "c"
+		expect(t, cfg.UString("db.host"), "localhost")
+		expect(t, cp.UString("db.replicas.0"), "a")
+		cp.UMap("db")["host"] = "db.internal"
+		cp.UList("db.replicas")[0] = "c"
+		m, _ := cp.Map("cache")
+		m["ttl"] = 20
+		list, _ := cp.List("cache.servers")
+		list[0].(map[string]interface{})["host"] = "c3"
+		expect(t, cfg.UString("db.host"), "localhost")
+		expect(t, cfg.UString("db.replicas.0"), "a")
+		expect(t, cfg.UInt("cache.ttl"), 10)
+		expect(t, cfg.UString("cache.servers.0.host"), "c1")
+		expect(t, cp.UString("db.host"), "localhost")
+		expect(t, cp.UString("cache.servers.0.host"), "c1")
+	}
+
+	sub, err := cfg.Copy("cache")
+	expect(t, err, nil)
+	sub.UList("servers")[0] = "c3"
+	expect(t, cfg.UString("cache.servers.0.host"), "c1")
+	expect(t, sub.UString("servers.0.host"), "c1")
+}
+
+func TestViewWritesThroughAfterCopy(t *testing.T) {
+	cfg := Must(ParseYaml("a: {x: a}"))
+	sub, err := cfg.Get("a")
+	expect(t, err, nil)
+	_, err = cfg.Copy()
+	expect(t, err, nil)
+	expect(t, sub.Set("x", "b"), nil)
+	expect(t, cfg.UString("a.x"), "b")
+
+	// Sharing the tree, and the parent copying the branch of the view,
+	// doesn't detach the view either.
+	merged, err := cfg.Extend(Must(ParseYaml("b: 1")))
+	expect(t, err, nil)
+	expect(t, cfg.Set("a.y", "c"), nil)
+	expect(t, sub.Set("x", "d"), nil)
+	expect(t, cfg.UString("a.x"), "d")
+	expect(t, sub.UString("y"), "c")
+	expect(t, merged.UString("a.x"), "b")
+
+	nested, err := sub.Get("z")
+	if err == nil {
+		t.Fatalf("expected an error, got %v", nested)
+	}
+	expect(t, sub.Set("z", map[string]interface{}{"k": "v"}), nil)
+	nested, err = sub.Get("z")
+	expect(t, err, nil)
+	cfg.share()
+	expect(t, nested.Set("k", "w"), nil)
+	expect(t, cfg.UString("a.z.k"), "w")
+}
@@ -0,0 +1,227 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	expr string
+	// every is the interval of "@every" schedules, which have no fields.
+	every time.Duration
+	// fields are the bitsets of the seconds, minutes, hours, days of the
+	// month, months and days of the week matched.
+	fields [6]uint64
+	// anyDom and anyDow tell whether the days of the month and of the week
+	// are "*": when neither is, matching either of them is enough, like in
+	// cron.
+	anyDom, anyDow bool
+}
+
+// cronField describes a field of cron expressions.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = [6]cronField{
+	{"second", 0, 59, nil},
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{"day of week", 0, 7, []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// cronDescriptors are the predefined schedules, in the 6 fields form.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// ParseCron parses a cron expression: 5 fields, for the minute, hour, day
+// of the month, month and day of the week, or 6 fields starting with the
+// second. Fields are lists of "*", values and ranges, with optional steps,
+// like "1-10/2,15", and months and days of the week can be named, like
+// "jan" and "mon-fri". The descriptors "@hourly", "@daily", "@weekly",
+// "@monthly" and "@yearly" are supported, as well as "@every 1h30m".
+func ParseCron(expr string) (*Schedule, error) {
+	s := &Schedule{expr: expr}
+	spec := strings.TrimSpace(expr)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := toDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("Invalid cron expression %q: invalid interval", expr)
+		}
+		s.every = d
+		return s, nil
+	}
+	if d, ok := cronDescriptors[spec]; ok {
+		spec = d
+	} else if strings.HasPrefix(spec, "@") {
+		return nil, fmt.Errorf("Invalid cron expression %q: unknown descriptor %q", expr, spec)
+	}
+
+	fields := strings.Fields(spec)
+	// Positions are reported as written, so the seconds only count when
+	// they are.
+	offset := 0
+	switch len(fields) {
+	case 5:
+		fields, offset = append([]string{"0"}, fields...), 1
+	case 6:
+	default:
+		return nil, fmt.Errorf("Invalid cron expression %q: expected 5 or 6 fields; got %d", expr, len(fields))
+	}
+	for i, field := range fields {
+		bits, err := parseCronField(field, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cron expression %q: field %d (%s): %v", expr, i+1-offset, cronFields[i].name, err)
+		}
+		s.fields[i] = bits
+	}
+	// Sunday is both 0 and 7.
+	if s.fields[5]&(1<<7) != 0 {
+		s.fields[5] |= 1
+	}
+	s.anyDom = fields[3] == "*" || fields[3] == "?"
+	s.anyDow = fields[5] == "*" || fields[5] == "?"
+	return s, nil
+}
+
+// parseCronField returns the bitset of the values matched by a field.
+func parseCronField(field string, f cronField) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		rng, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[i+1:])
+			}
+			rng, step = part[:i], n
+		}
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*" || rng == "?":
+			if f.name == "day of week" {
+				hi = 6
+			}
+		case strings.IndexByte(rng, '-') > 0:
+			i := strings.IndexByte(rng, '-')
+			var err error
+			if lo, err = cronValue(rng[:i], f); err != nil {
+				return 0, err
+			}
+			if hi, err = cronValue(rng[i+1:], f); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("invalid range %q", rng)
+			}
+		default:
+			var err error
+			if lo, err = cronValue(rng, f); err != nil {
+				return 0, err
+			}
+			hi = lo
+			// Like in cron, "5/15" stands for "5-59/15".
+			if step > 1 {
+				hi = f.max
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// cronValue parses a value of a field, a number or a name.
+func cronValue(s string, f cronField) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", s)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the cron expression of the schedule.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next returns the first time matched by the schedule after t, or the zero
+// time when there is none within five years, like for "0 0 30 2 *".
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		y, mon, d := t.Date()
+		h, m, sec := t.Clock()
+		switch {
+		case !s.match(4, int(mon)):
+			t = time.Date(y, mon+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchDay(t):
+			t = time.Date(y, mon, d+1, 0, 0, 0, 0, loc)
+		case !s.match(2, h):
+			t = time.Date(y, mon, d, h+1, 0, 0, 0, loc)
+		case !s.match(1, m):
+			t = time.Date(y, mon, d, h, m+1, 0, 0, loc)
+		case !s.match(0, sec):
+			t = time.Date(y, mon, d, h, m, sec+1, 0, loc)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) match(field, v int) bool {
+	return s.fields[field]&(1<<uint(v)) != 0
+}
+
+func (s *Schedule) matchDay(t time.Time) bool {
+	dom := s.match(3, t.Day())
+	dow := s.match(5, int(t.Weekday()))
+	if s.anyDom || s.anyDow {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// Cron returns a schedule according to a dotted path, validating its cron
+// expression, see ParseCron for the syntax.
+func (cfg *Config) Cron(path string) (*Schedule, error) {
+	v, err := cfg.String(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := ParseCron(v)
+	return s, cfg.observeConversion(path, err)
+}
@@ -0,0 +1,58 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCron(t *testing.T) {
+	cfg := Must(ParseYaml(`
+jobs:
+  backup: "30 2 * * mon-fri"
+  report: "0 0 9 1,15 * ?"
+  poll: "@every 90s"
+  daily: "@daily"
+  steps: "*/15 8-10 * * *"
+  either: "0 0 13 * fri"
+  leap: "0 0 29 2 *"
+  never: "0 0 30 2 *"
+  hour: "0 25 * * *"
+  month: "0 0 1 foo *"
+  step: "*/0 * * * *"
+  short: "* * *"
+  unknown: "@sometimes"
+`))
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC) // a Friday
+	for path, want := range map[string]time.Time{
+		"jobs.backup": time.Date(2024, time.March, 4, 2, 30, 0, 0, time.UTC),
+		"jobs.report": time.Date(2024, time.March, 15, 9, 0, 0, 0, time.UTC),
+		"jobs.poll":   time.Date(2024, time.March, 1, 12, 1, 30, 0, time.UTC),
+		"jobs.daily":  time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC),
+		"jobs.steps":  time.Date(2024, time.March, 2, 8, 0, 0, 0, time.UTC),
+		"jobs.either": time.Date(2024, time.March, 8, 0, 0, 0, 0, time.UTC),
+		"jobs.leap":   time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		"jobs.never":  {},
+	} {
+		s, err := cfg.Cron(path)
+		expect(t, err, nil)
+		expect(t, s.Next(start), want)
+	}
+	s, _ := cfg.Cron("jobs.steps")
+	expect(t, s.Next(time.Date(2024, time.March, 2, 8, 15, 0, 0, time.UTC)), time.Date(2024, time.March, 2, 8, 30, 0, 0, time.UTC))
+	expect(t, s.String(), "*/15 8-10 * * *")
+
+	for path, msg := range map[string]string{
+		"jobs.hour":    `Invalid cron expression "0 25 * * *": field 2 (hour): 25 is out of range 0-23`,
+		"jobs.month":   `Invalid cron expression "0 0 1 foo *": field 4 (month): invalid value "foo"`,
+		"jobs.step":    `Invalid cron expression "*/0 * * * *": field 1 (minute): invalid step "0"`,
+		"jobs.short":   `Invalid cron expression "* * *": expected 5 or 6 fields; got 3`,
+		"jobs.unknown": `Invalid cron expression "@sometimes": unknown descriptor "@sometimes"`,
+	} {
+		_, err := cfg.Cron(path)
+		expect(t, err.Error(), msg)
+	}
+}
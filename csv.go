@@ -0,0 +1,119 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// CsvHeader tells how ParseCsv reads the first row of a table.
+type CsvHeader int
+
+// Header modes.
+const (
+	// CsvHeaderRow reads the column names from the first row, and the
+	// other rows as maps of the column names to the cells.
+	CsvHeaderRow CsvHeader = iota
+	// CsvNoHeader reads every row as a list of cells.
+	CsvNoHeader
+)
+
+// ParseCsv reads a CSV table into a config rooted at a list of rows, for
+// lookup tables shipped next to a config, like country codes:
+//
+//	codes, err := config.ParseCsvFile("countries.csv", config.CsvHeaderRow)
+//	err = cfg.Mount("countries", codes)
+//	name, err := cfg.String("countries.0.name")
+//
+// Cells are strings, which getters like Int convert. Rows must have the
+// same number of cells, and column names must be unique and non-empty.
+func ParseCsv(cfg string, header CsvHeader) (*Config, error) {
+	return parseCsv(cfg, ',', header)
+}
+
+// ParseTsv reads a table of tab-separated values, see ParseCsv.
+func ParseTsv(cfg string, header CsvHeader) (*Config, error) {
+	return parseCsv(cfg, '\t', header)
+}
+
+// ParseCsvFile reads a table from the given filename, see ParseCsv.
+// ".tsv" files hold tab-separated values. Like ParseJsonFile, it
+// decompresses compressed files transparently.
+func ParseCsvFile(filename string, header CsvHeader) (*Config, error) {
+	cfg, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	comma := ','
+	if strings.ToLower(filepath.Ext(trimCompressionExt(filename))) == ".tsv" {
+		comma = '\t'
+	}
+	return parseCsv(string(cfg), comma, header)
+}
+
+// parseCsv performs the real table parsing.
+func parseCsv(cfg string, comma rune, header CsvHeader) (*Config, error) {
+	var records [][]string
+	if comma == '\t' {
+		// TSV files have no quoting: cells can't hold tabs nor newlines.
+		// Empty lines are skipped, like by encoding/csv.
+		for i, line := range strings.Split(cfg, "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if line == "" {
+				continue
+			}
+			record := strings.Split(line, "\t")
+			if len(records) > 0 && len(record) != len(records[0]) {
+				return nil, fmt.Errorf("line %d: wrong number of fields", i+1)
+			}
+			records = append(records, record)
+		}
+	} else {
+		var err error
+		if records, err = csv.NewReader(strings.NewReader(cfg)).ReadAll(); err != nil {
+			return nil, err
+		}
+	}
+
+	rows := make([]interface{}, 0, len(records))
+	switch header {
+	case CsvNoHeader:
+		for _, record := range records {
+			row := make([]interface{}, len(record))
+			for i, cell := range record {
+				row[i] = cell
+			}
+			rows = append(rows, row)
+		}
+	case CsvHeaderRow:
+		if len(records) == 0 {
+			break
+		}
+		names := records[0]
+		seen := map[string]bool{}
+		for i, name := range names {
+			if name == "" {
+				return nil, fmt.Errorf("Empty name of column %d", i+1)
+			}
+			if seen[name] {
+				return nil, fmt.Errorf("Duplicate column %q", name)
+			}
+			seen[name] = true
+		}
+		for _, record := range records[1:] {
+			row := make(map[string]interface{}, len(names))
+			for i, cell := range record {
+				row[names[i]] = cell
+			}
+			rows = append(rows, row)
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported CSV header mode: %d", int(header))
+	}
+	return &Config{Root: rows}, nil
+}
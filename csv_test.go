@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseCsv(t *testing.T) {
+	cfg, err := ParseCsv("code,name,rate\nFR,France,0.2\nDE,\"Germany, Federal Republic\",0.19\n", CsvHeaderRow)
+	expect(t, err, nil)
+	expect(t, len(cfg.UList("")), 2)
+	expect(t, cfg.UString("1.name"), "Germany, Federal Republic")
+	expect(t, cfg.UFloat64("0.rate"), 0.2)
+
+	cfg, err = ParseTsv("a\t\"b\nc\td\n", CsvNoHeader)
+	expect(t, err, nil)
+	expect(t, cfg.UString("0.1"), `"b`)
+	expect(t, cfg.UString("1.0"), "c")
+
+	// Blank lines are skipped, trailing ones included.
+	cfg, err = ParseTsv("code\trate\n\nFR\t0.2\r\n\nDE\t0.19\n\n", CsvHeaderRow)
+	expect(t, err, nil)
+	expect(t, len(cfg.UList("")), 2)
+	expect(t, cfg.UString("1.code"), "DE")
+	_, err = ParseTsv("a\tb\n1\n", CsvHeaderRow)
+	expect(t, err.Error(), "line 2: wrong number of fields")
+
+	cfg, err = ParseCsv("", CsvHeaderRow)
+	expect(t, err, nil)
+	expect(t, len(cfg.UList("")), 0)
+
+	_, err = ParseCsv("a,a\n1,2\n", CsvHeaderRow)
+	expect(t, err.Error(), `Duplicate column "a"`)
+	_, err = ParseCsv("a,\n1,2\n", CsvHeaderRow)
+	expect(t, err.Error(), "Empty name of column 2")
+	_, err = ParseCsv("a,b\n1\n", CsvHeaderRow)
+	expect(t, err != nil, true)
+
+	dir, err := ioutil.TempDir("", "config-csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "tiers.tsv")
+	ioutil.WriteFile(filename, []byte("tier\tlimit\nfree\t100\npro\t1000\n"), 0644)
+	table, err := ParseCsvFile(filename, CsvHeaderRow)
+	expect(t, err, nil)
+	root := Must(ParseYaml("app: {}"))
+	expect(t, root.Mount("tiers", table), nil)
+	expect(t, root.UInt("tiers.1.limit"), 1000)
+}
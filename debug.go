@@ -0,0 +1,154 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SecretKeys are the substrings of the keys holding secrets, compared
+// case-insensitively. Values under such keys are redacted by DebugString.
+var SecretKeys = []string{
+	"password", "passwd", "secret", "token", "apikey", "api_key",
+	"private_key", "credential",
+}
+
+// IsSecret reports whether a dotted path goes through a key holding
+// secrets, see SecretKeys.
+func IsSecret(path string) bool {
+	keys, err := parsePath(path)
+	if err != nil {
+		keys = []string{path}
+	}
+	for _, key := range keys {
+		key = strings.ToLower(key)
+		for _, secret := range SecretKeys {
+			if strings.Contains(key, secret) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// DebugString returns a dump of the config meant for humans, like for a
+// --dump-config flag or a bug report: a line per leaf, sorted by path,
+// with its value and type, and the values of secrets redacted:
+//
+//	database.host: "localhost" (string)
+//	database.password: <redacted> (string)
+//	database.port: 5432 (int)
+//
+// On views made by WithFallback, the values coming from a fallback are
+// followed by its position, like "[fallback 1]".
+func (cfg *Config) DebugString() string {
+	cfg.auditExport()
+	lines := map[string]string{}
+	layers := cfg.layers()
+	for i, layer := range layers {
+		walkLeaves(layer.Root, "", func(path string, v interface{}) {
+			// Values shadowed by a previous layer aren't visible.
+			if hasPath(layers[:i], path) {
+				return
+			}
+			value := debugValue(v)
+			if IsSecret(path) && v != nil {
+				value = "<redacted>"
+			}
+			line := fmt.Sprintf("%s (%s)", value, debugType(v))
+			if i > 0 {
+				line += fmt.Sprintf(" [fallback %d]", i)
+			}
+			lines[path] = line
+		})
+	}
+
+	paths := make([]string, 0, len(lines))
+	for path := range lines {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var buf bytes.Buffer
+	for _, path := range paths {
+		if path == "" {
+			fmt.Fprintf(&buf, ".: %s\n", lines[path])
+		} else {
+			fmt.Fprintf(&buf, "%s: %s\n", path, lines[path])
+		}
+	}
+	return buf.String()
+}
+
+// layers returns the trees of the config and of its fallbacks, in lookup
+// order.
+func (cfg *Config) layers() []*Config {
+	layers := []*Config{cfg}
+	if cfg.primary != nil {
+		layers = cfg.primary.layers()
+	}
+	for _, fallback := range cfg.fallbacks {
+		layers = append(layers, fallback.layers()...)
+	}
+	return layers
+}
+
+// hasPath reports whether any of the layers has a value at path.
+func hasPath(layers []*Config, path string) bool {
+	for _, layer := range layers {
+		if _, err := Get(layer.Root, path); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// debugValue formats a leaf for DebugString.
+func debugValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(v))
+	case map[string]interface{}:
+		return "{}"
+	case []interface{}:
+		return "[]"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprint(v)
+}
+
+// debugType names the type of a leaf for DebugString.
+func debugType(v interface{}) string {
+	switch v.(type) {
+	case string:
+		return "string"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "time"
+	case []byte:
+		return "bytes"
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,43 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestDebugString(t *testing.T) {
+	cfg, err := ParseYaml(`
+database:
+  host: localhost
+  port: 5432
+  password: hunter2
+  replicas: []
+credentials: {user: admin, pass: admin}
+ratio: 0.5
+debug: true
+nothing: null
+`)
+	expect(t, err, nil)
+	expect(t, cfg.DebugString(), `credentials.pass: <redacted> (string)
+credentials.user: <redacted> (string)
+database.host: "localhost" (string)
+database.password: <redacted> (string)
+database.port: 5432 (int)
+database.replicas: [] (list)
+debug: true (bool)
+nothing: null (null)
+ratio: 0.5 (float)
+`)
+
+	defaults := Must(ParseYaml("database: {host: db, timeout: 5s}\nlevel: info"))
+	view := Must(ParseYaml("level: debug")).WithFallback(defaults)
+	expect(t, view.DebugString(), `database.host: "db" (string) [fallback 1]
+database.timeout: "5s" (string) [fallback 1]
+level: "debug" (string)
+`)
+
+	expect(t, IsSecret("auth.API_TOKEN"), true)
+	expect(t, IsSecret("[db.password].x"), true)
+	expect(t, IsSecret("database.host"), false)
+}
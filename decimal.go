@@ -0,0 +1,88 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// toDecimal converts a config value to the exact decimal it was written
+// as. Strings like "19.99" are exact; YAML and JSON parse numbers into
+// float64, whose shortest representation is the number as written in the
+// document for up to 15 significant digits, so 0.1 is 1/10 rather than
+// the float closest to it. Longer numbers should be quoted.
+func toDecimal(n interface{}) (string, error) {
+	switch n := n.(type) {
+	case int:
+		return strconv.Itoa(n), nil
+	case float64:
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", fmt.Errorf("Invalid decimal %v", n)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	case string:
+		s := strings.TrimSpace(n)
+		if _, ok := new(big.Rat).SetString(s); !ok || strings.ContainsRune(s, '/') {
+			return "", fmt.Errorf("Invalid decimal %q", n)
+		}
+		return s, nil
+	}
+	return "", typeMismatch("int, float64 or string", n)
+}
+
+// Decimal returns an exact number according to a dotted path, for money
+// amounts and other values which shouldn't go through float64 rounding:
+//
+//	price, err := cfg.Decimal("plans.pro.price") // "19.99" is 1999/100
+func (cfg *Config) Decimal(path string) (*big.Rat, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return nil, err
+	}
+	s, err := toDecimal(n)
+	if err != nil {
+		return nil, cfg.observeConversion(path, err)
+	}
+	r, _ := new(big.Rat).SetString(s)
+	return r, nil
+}
+
+// UDecimal returns an exact number according to a dotted path or default
+// value or 0.
+func (c *Config) UDecimal(path string, defaults ...*big.Rat) *big.Rat {
+	value, err := c.Decimal(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return new(big.Rat)
+}
+
+// DecimalTo decodes the exact number at a dotted path into a decimal type
+// of another package, like github.com/shopspring/decimal.Decimal, from
+// its text, without depending on that package:
+//
+//	var price decimal.Decimal
+//	err := cfg.DecimalTo("plans.pro.price", &price)
+func (cfg *Config) DecimalTo(path string, dst encoding.TextUnmarshaler) error {
+	n, err := cfg.get(path)
+	if err != nil {
+		return err
+	}
+	s, err := toDecimal(n)
+	if err != nil {
+		return cfg.observeConversion(path, err)
+	}
+	return dst.UnmarshalText([]byte(s))
+}
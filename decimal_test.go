@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"math/big"
+	"testing"
+)
+
+// textDecimal records the text it is decoded from.
+type textDecimal string
+
+func (d *textDecimal) UnmarshalText(text []byte) error {
+	*d = textDecimal(text)
+	return nil
+}
+
+func TestDecimal(t *testing.T) {
+	cfg := Must(ParseYaml(`
+price: "19.99"
+float: 0.1
+int: 42
+exp: 1e-7
+fraction: 1/3
+word: cheap
+`))
+	for path, want := range map[string]string{
+		"price": "1999/100",
+		"float": "1/10",
+		"int":   "42/1",
+		"exp":   "1/10000000",
+	} {
+		r, err := cfg.Decimal(path)
+		expect(t, err, nil)
+		expect(t, r.String(), want)
+	}
+	_, err := cfg.Decimal("fraction")
+	expect(t, err.Error(), `Invalid decimal "1/3"`)
+	_, err = cfg.Decimal("word")
+	expect(t, err.Error(), `Invalid decimal "cheap"`)
+	expect(t, cfg.UDecimal("word", big.NewRat(1, 2)).String(), "1/2")
+	expect(t, cfg.UDecimal("missing").Sign(), 0)
+
+	var d textDecimal
+	expect(t, cfg.DecimalTo("float", &d), nil)
+	expect(t, d, textDecimal("0.1"))
+	expect(t, cfg.DecimalTo("price", &d), nil)
+	expect(t, d, textDecimal("19.99"))
+	expect(t, cfg.DecimalTo("word", &d) != nil, true)
+}
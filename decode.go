@@ -0,0 +1,320 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TypeKey is the key of the maps naming their registered type, see
+// RegisterType.
+const TypeKey = "type"
+
+var (
+	typesMu sync.RWMutex
+	// types maps interface types to the factories of their
+	// implementations, by name.
+	types = map[reflect.Type]map[string]func() interface{}{}
+)
+
+// RegisterType registers a factory of an implementation of an interface,
+// given as a nil pointer to it, so that Decode can decode sections of
+// different types into the interface, selected by their "type" key:
+//
+//	config.RegisterType((*Sink)(nil), "file", func() interface{} { return &FileSink{} })
+//	config.RegisterType((*Sink)(nil), "kafka", func() interface{} { return &KafkaSink{} })
+//
+//	// sinks: [{type: file, path: /var/log/app}, {type: kafka, topic: logs}]
+//	var sinks []Sink
+//	err := cfg.Decode("sinks", &sinks)
+//
+// Factories return pointers to zero values, or to values holding defaults.
+func RegisterType(iface interface{}, name string, factory func() interface{}) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("config: RegisterType of %T: not a pointer to an interface", iface))
+	}
+	typesMu.Lock()
+	defer typesMu.Unlock()
+	if types[t.Elem()] == nil {
+		types[t.Elem()] = map[string]func() interface{}{}
+	}
+	types[t.Elem()][name] = factory
+}
+
+// registeredTypes returns the factories registered for an interface.
+func registeredTypes(t reflect.Type) map[string]func() interface{} {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+	return types[t]
+}
+
+// Decode stores the value at a dotted path in the value pointed to by v,
+// like encoding/json does. Maps are decoded into structs, whose keys are
+// named by the config, yaml or json tags of their fields, or their
+// lowercase names, and into maps with string keys. Values are converted
+// like by the getters, so durations can be written as "5s", and strings
+// are decoded by the encoding.TextUnmarshaler implementations. Keys
+// missing from the config leave their fields as they are, so fields can be
+// set to defaults beforehand.
+//
+// Interfaces registered with RegisterType are decoded into the types
+// named by the "type" key of their maps. Other interfaces hold copies of
+// the values.
+func (cfg *Config) Decode(path string, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("Can't decode into %T: not a non-nil pointer", v)
+	}
+	n, err := cfg.get(path)
+	if err != nil {
+		return err
+	}
+	return decodeValue(rv.Elem(), n, canonicalPath("", path))
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// decodeValue stores a config value in v, found at the given path.
+func decodeValue(v reflect.Value, n interface{}, path string) error {
+	if v.Kind() == reflect.Ptr {
+		if n == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), n, path)
+	}
+	invalid := func(err error) error {
+		return fmt.Errorf("Invalid value of %q: %v", displayPath(path), err)
+	}
+	s, isString := n.(string)
+
+	var err error
+	var value interface{}
+	switch t := v.Type(); {
+	case t == durationType:
+		value, err = toDuration(n)
+	case t == timeType:
+		value, err = toTime(n)
+	case isString && t.Kind() != reflect.String && reflect.PtrTo(t).Implements(textUnmarshalerType):
+		if err := v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			return invalid(err)
+		}
+		return nil
+	case t.Kind() == reflect.Interface:
+		return decodeInterface(v, n, path)
+	case t.Kind() == reflect.Struct:
+		m, err := toMap(n)
+		if err != nil {
+			return invalid(err)
+		}
+		return decodeStruct(v, m, path)
+	case t.Kind() == reflect.Map:
+		return decodeMap(v, n, path)
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		value, err = toBytes(n)
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		return decodeList(v, n, path)
+	case t.Kind() == reflect.String:
+		value, err = toString(n)
+	case t.Kind() == reflect.Bool:
+		value, err = toBool(n)
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Int64:
+		var i int
+		if i, err = toInt(n); err == nil {
+			if v.OverflowInt(int64(i)) {
+				return invalid(fmt.Errorf("%d overflows %s", i, t))
+			}
+			v.SetInt(int64(i))
+			return nil
+		}
+	case t.Kind() >= reflect.Uint && t.Kind() <= reflect.Uintptr:
+		var i int
+		if i, err = toInt(n); err == nil {
+			if i < 0 || v.OverflowUint(uint64(i)) {
+				return invalid(fmt.Errorf("%d overflows %s", i, t))
+			}
+			v.SetUint(uint64(i))
+			return nil
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		var f float64
+		if f, err = toFloat64(n); err == nil {
+			v.SetFloat(f)
+			return nil
+		}
+	default:
+		return invalid(fmt.Errorf("Can't decode into %s", t))
+	}
+	if err != nil {
+		return invalid(err)
+	}
+	v.Set(reflect.ValueOf(value).Convert(v.Type()))
+	return nil
+}
+
+// decodeInterface stores a value in an interface, in the type named by
+// its "type" key when the interface has registered types.
+func decodeInterface(v reflect.Value, n interface{}, path string) error {
+	factories := registeredTypes(v.Type())
+	if factories == nil {
+		if n == nil {
+			v.Set(reflect.Zero(v.Type()))
+			return nil
+		}
+		if v.NumMethod() > 0 {
+			return fmt.Errorf("Invalid value of %q: Can't decode into %s, which has no registered types", displayPath(path), v.Type())
+		}
+		value, err := normalizeValue(n)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(value))
+		return nil
+	}
+
+	m, err := toMap(n)
+	if err != nil {
+		return fmt.Errorf("Invalid value of %q: %v", displayPath(path), err)
+	}
+	keyPath := joinPath(path, TypeKey)
+	name, err := toString(m[TypeKey])
+	if m[TypeKey] == nil {
+		return fmt.Errorf("Invalid value of %q: missing type of %s", displayPath(keyPath), v.Type())
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid value of %q: %v", displayPath(keyPath), err)
+	}
+	factory, ok := factories[name]
+	if !ok {
+		names := make([]string, 0, len(factories))
+		for name := range factories {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("Invalid value of %q: unknown type %q of %s, expected one of %s", displayPath(keyPath), name, v.Type(), quotePaths(names))
+	}
+	obj := reflect.ValueOf(factory())
+	target := obj
+	if obj.Kind() == reflect.Ptr {
+		target = obj.Elem()
+	} else {
+		// Values are copied, so that they can be set.
+		target = reflect.New(obj.Type()).Elem()
+		target.Set(obj)
+		obj = target
+	}
+	if err := decodeValue(target, n, path); err != nil {
+		return err
+	}
+	if !obj.Type().AssignableTo(v.Type()) {
+		return fmt.Errorf("Invalid value of %q: type %q is a %s, which doesn't implement %s", displayPath(keyPath), name, obj.Type(), v.Type())
+	}
+	v.Set(obj)
+	return nil
+}
+
+// decodeStruct stores the keys of a map in the fields of a struct.
+// Embedded structs without tags have their fields inlined.
+func decodeStruct(v reflect.Value, m map[string]interface{}, path string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, _ := structKeyTag(f)
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if f.Type.Kind() == reflect.Ptr {
+					if f.PkgPath != "" {
+						continue
+					}
+					if fv.IsNil() {
+						fv.Set(reflect.New(ft))
+					}
+					fv = fv.Elem()
+				}
+				if err := decodeStruct(fv, m, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		n, ok := m[name]
+		if !ok {
+			continue
+		}
+		if err := decodeValue(fv, n, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeMap stores the keys of a config map in a map with string keys.
+func decodeMap(v reflect.Value, n interface{}, path string) error {
+	t := v.Type()
+	if t.Key().Kind() != reflect.String {
+		return fmt.Errorf("Invalid value of %q: Can't decode into %s, which hasn't string keys", displayPath(path), t)
+	}
+	m, err := toMap(n)
+	if err != nil {
+		return fmt.Errorf("Invalid value of %q: %v", displayPath(path), err)
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMapWithSize(t, len(m)))
+	}
+	for key, item := range m {
+		elem := reflect.New(t.Elem()).Elem()
+		if err := decodeValue(elem, item, joinPath(path, key)); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(key).Convert(t.Key()), elem)
+	}
+	return nil
+}
+
+// decodeList stores the items of a list in a slice or an array.
+func decodeList(v reflect.Value, n interface{}, path string) error {
+	list, err := toList(n)
+	if err != nil {
+		return fmt.Errorf("Invalid value of %q: %v", displayPath(path), err)
+	}
+	if v.Kind() == reflect.Array {
+		if len(list) != v.Len() {
+			return fmt.Errorf("Invalid value of %q: expected %d items; got %d", displayPath(path), v.Len(), len(list))
+		}
+	} else {
+		v.Set(reflect.MakeSlice(v.Type(), len(list), len(list)))
+	}
+	for i, item := range list {
+		if err := decodeValue(v.Index(i), item, joinPath(path, strconv.Itoa(i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testSink interface {
+	Write(string) error
+}
+
+type testFileSink struct {
+	Path string `config:"path"`
+	Mode int    `config:"mode"`
+}
+
+func (s *testFileSink) Write(string) error { return nil }
+
+type testKafkaSink struct {
+	Brokers []string      `yaml:"brokers"`
+	Topic   string        `json:"topic"`
+	Timeout time.Duration `config:"timeout"`
+}
+
+func (s *testKafkaSink) Write(string) error { return nil }
+
+type testNotSink struct{}
+
+func init() {
+	RegisterType((*testSink)(nil), "file", func() interface{} { return &testFileSink{Mode: 0644} })
+	RegisterType((*testSink)(nil), "kafka", func() interface{} { return &testKafkaSink{} })
+	RegisterType((*testSink)(nil), "broken", func() interface{} { return &testNotSink{} })
+}
+
+func TestDecode(t *testing.T) {
+	cfg := Must(ParseYaml(`
+server:
+  host: example.com
+  port: "8080"
+  timeout: 5s
+  ip: 10.0.0.1
+  tags: [a, b]
+  limits: {cpu: 2, memory: 512}
+  extra: {any: [1, x]}
+  unknown: ignored
+sinks:
+  - {type: file, path: /var/log/app}
+  - {type: kafka, brokers: [k1, k2], topic: logs, timeout: 2s}
+outputs:
+  audit: {type: file, path: /var/log/audit, mode: 0600}
+bad:
+  untyped: {path: x}
+  unknown: {type: s3}
+  broken: {type: broken}
+  port: {port: -1}
+  field: {type: kafka, timeout: soon}
+`))
+	type common struct {
+		Host string
+	}
+	var server struct {
+		common
+		Port    uint16
+		Timeout time.Duration
+		IP      net.IP `config:"ip"`
+		Tags    []string
+		Limits  map[string]int
+		Extra   interface{}
+		Default string
+	}
+	server.Default = "kept"
+	expect(t, cfg.Decode("server", &server), nil)
+	expect(t, server.Host, "example.com")
+	expect(t, server.Port, uint16(8080))
+	expect(t, server.Timeout, 5*time.Second)
+	expect(t, server.IP.String(), "10.0.0.1")
+	expect(t, server.Default, "kept")
+	if !reflect.DeepEqual(server.Tags, []string{"a", "b"}) ||
+		!reflect.DeepEqual(server.Limits, map[string]int{"cpu": 2, "memory": 512}) ||
+		!reflect.DeepEqual(server.Extra, map[string]interface{}{"any": []interface{}{1, "x"}}) {
+		t.Errorf("Unexpected server: %+v", server)
+	}
+
+	var sinks []testSink
+	expect(t, cfg.Decode("sinks", &sinks), nil)
+	expect(t, len(sinks), 2)
+	expect(t, *sinks[0].(*testFileSink), testFileSink{"/var/log/app", 0644})
+	kafka := sinks[1].(*testKafkaSink)
+	expect(t, kafka.Topic, "logs")
+	expect(t, kafka.Timeout, 2*time.Second)
+	expect(t, len(kafka.Brokers), 2)
+
+	var outputs map[string]testSink
+	expect(t, cfg.Decode("outputs", &outputs), nil)
+	expect(t, *outputs["audit"].(*testFileSink), testFileSink{"/var/log/audit", 0600})
+
+	var sink testSink
+	for path, msg := range map[string]string{
+		"bad.untyped": `Invalid value of "bad.untyped.type": missing type of config.testSink`,
+		"bad.unknown": `Invalid value of "bad.unknown.type": unknown type "s3" of config.testSink, expected one of "broken", "file", "kafka"`,
+		"bad.broken":  `Invalid value of "bad.broken.type": type "broken" is a *config.testNotSink, which doesn't implement config.testSink`,
+		"bad.field":   `Invalid value of "bad.field.timeout": Invalid duration "soon"`,
+	} {
+		err := cfg.Decode(path, &sink)
+		expect(t, err.Error(), msg)
+	}
+	err := cfg.Decode("bad.port", &server)
+	expect(t, err.Error(), `Invalid value of "bad.port.port": -1 overflows uint16`)
+	err = cfg.Decode("server.port", 0)
+	expect(t, err.Error(), "Can't decode into int: not a non-nil pointer")
+}
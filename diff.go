@@ -0,0 +1,116 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind tells how a value differs between two configs.
+type ChangeKind int
+
+// Kinds of changes reported by Diff.
+const (
+	Added ChangeKind = iota
+	Removed
+	Modified
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// Change describes a leaf value which differs between two configs.
+type Change struct {
+	Kind ChangeKind
+	Path string
+	// From is the old value, nil when the value was added.
+	From interface{}
+	// To is the new value, nil when the value was removed.
+	To interface{}
+}
+
+func (c Change) String() string {
+	switch c.Kind {
+	case Added:
+		return fmt.Sprintf("+ %s: %v", c.Path, c.To)
+	case Removed:
+		return fmt.Sprintf("- %s: %v", c.Path, c.From)
+	}
+	return fmt.Sprintf("~ %s: %v -> %v", c.Path, c.From, c.To)
+}
+
+// Diff returns the leaf values which differ between two configs, sorted by
+// path. Numbers are compared by value, so 42 parsed from YAML equals 42
+// parsed from JSON. A path holding a map in one config and a scalar in the
+// other is reported as removed and added leaves.
+func Diff(from, to *Config) []Change {
+	old := map[string]interface{}{}
+	walkLeaves(from.Root, "", func(path string, value interface{}) {
+		old[path] = value
+	})
+
+	changes := []Change{}
+	seen := map[string]bool{}
+	walkLeaves(to.Root, "", func(path string, value interface{}) {
+		seen[path] = true
+		prev, ok := old[path]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Kind: Added, Path: path, To: value})
+		case !equalValues(prev, value):
+			changes = append(changes, Change{Kind: Modified, Path: path, From: prev, To: value})
+		}
+	})
+	walkLeaves(from.Root, "", func(path string, value interface{}) {
+		if !seen[path] {
+			changes = append(changes, Change{Kind: Removed, Path: path, From: value})
+		}
+	})
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return lessChange(changes[i], changes[j])
+	})
+	return changes
+}
+
+// lessChange orders changes by path, keeping removals before additions.
+func lessChange(a, b Change) bool {
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	return a.Kind == Removed && b.Kind != Removed
+}
+
+// equalValues compares two leaf values, treating ints and floats with the
+// same value as equal.
+func equalValues(a, b interface{}) bool {
+	if fa, ok := number(a); ok {
+		fb, ok := number(b)
+		return ok && fa == fb
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// number returns the value of an int or float64.
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
@@ -0,0 +1,49 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	from, err := ParseYaml(`
+database:
+  host: localhost
+  port: 5432
+  options: {}
+servers:
+  - a
+  - b
+cache: memory
+dotted.key: 1
+`)
+	expect(t, err, nil)
+	to, err := ParseJson(`{
+  "database": {"host": "db.example.com", "port": 5432, "options": {"ssl": true}},
+  "servers": ["a"],
+  "cache": {"kind": "redis"},
+  "dotted.key": 1.0
+}`)
+	expect(t, err, nil)
+
+	changes := Diff(from, to)
+	want := []string{
+		"- cache: memory",
+		"+ cache.kind: redis",
+		"~ database.host: localhost -> db.example.com",
+		"- database.options: map[]",
+		"+ database.options.ssl: true",
+		"- servers.1: b",
+	}
+	expect(t, len(changes), len(want))
+	for i, change := range changes {
+		if i < len(want) {
+			expect(t, change.String(), want[i])
+		}
+	}
+	expect(t, changes[2].Kind, Modified)
+	expect(t, changes[2].From, "localhost")
+
+	expect(t, len(Diff(from, from)), 0)
+}
@@ -0,0 +1,143 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// DocFormat identifies the markup produced by Document.
+type DocFormat int
+
+// Supported documentation formats.
+const (
+	DocMarkdown DocFormat = iota
+	DocHTML
+)
+
+// docRow describes a key in the documentation.
+type docRow struct {
+	path, typ, required, def, description string
+}
+
+// Document returns a table describing every key of the schema, with its
+// type, whether it is required, its default and description, for docs
+// generated from the same schema, or struct, the program uses:
+//
+//	schema, err := config.SchemaFromStruct(&Config{})
+//	table, err := config.Document(schema, config.DocMarkdown)
+//
+// Keys are written as dotted paths, with "*" standing for the items of
+// lists, like "servers.*.name".
+func Document(schema *Schema, format DocFormat) (string, error) {
+	var rows []docRow
+	if err := schema.docRows("", &rows); err != nil {
+		return "", err
+	}
+	header := docRow{"Key", "Type", "Required", "Default", "Description"}
+
+	var buf bytes.Buffer
+	switch format {
+	case DocMarkdown:
+		for i, row := range append([]docRow{header}, rows...) {
+			cells := []string{row.path, row.typ, row.required, row.def, row.description}
+			for j, cell := range cells {
+				cell = strings.Replace(cell, "|", `\|`, -1)
+				cell = strings.Replace(cell, "\n", " ", -1)
+				// Keys, types and defaults are code.
+				if cell != "" && i > 0 && (j < 2 || j == 3) {
+					cell = "`" + cell + "`"
+				}
+				cells[j] = cell
+			}
+			fmt.Fprintf(&buf, "| %s |\n", strings.Join(cells, " | "))
+			if i == 0 {
+				buf.WriteString("|-----|------|----------|---------|-------------|\n")
+			}
+		}
+	case DocHTML:
+		buf.WriteString("<table>\n<thead>\n")
+		for i, row := range append([]docRow{header}, rows...) {
+			tag := "td"
+			if i == 0 {
+				tag = "th"
+			}
+			buf.WriteString("<tr>")
+			for _, cell := range []string{row.path, row.typ, row.required, row.def, row.description} {
+				fmt.Fprintf(&buf, "<%s>%s</%s>", tag, html.EscapeString(cell), tag)
+			}
+			buf.WriteString("</tr>\n")
+			if i == 0 {
+				buf.WriteString("</thead>\n<tbody>\n")
+			}
+		}
+		buf.WriteString("</tbody>\n</table>\n")
+	default:
+		return "", fmt.Errorf("Unsupported documentation format: %d", int(format))
+	}
+	return buf.String(), nil
+}
+
+// docRows appends the rows of the keys of the schema found at path.
+func (s *Schema) docRows(path string, rows *[]docRow) error {
+	names := make([]string, 0, len(s.Keys))
+	for name := range s.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub := s.Keys[name]
+		row := docRow{path: joinPath(path, name), typ: sub.Type}
+		if sub.Items != nil && sub.Items.Type != "" {
+			row.typ += " of " + sub.Items.Type
+		}
+		if sub.Required {
+			row.required = "yes"
+		}
+		if sub.Default != nil {
+			def, err := sampleScalar(sub.Default)
+			if err != nil {
+				return err
+			}
+			row.def = def
+		}
+		row.description = sub.docDescription()
+		*rows = append(*rows, row)
+
+		if err := sub.docRows(row.path, rows); err != nil {
+			return err
+		}
+		if sub.Items != nil {
+			if err := sub.Items.docRows(row.path+".*", rows); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// docDescription returns the description of a key, with its allowed
+// values and deprecation.
+func (s *Schema) docDescription() string {
+	parts := []string{}
+	if s.Description != "" {
+		parts = append(parts, s.Description)
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = fmt.Sprint(v)
+		}
+		parts = append(parts, "One of: "+strings.Join(values, ", ")+".")
+	}
+	if s.Deprecated != "" {
+		parts = append(parts, "Deprecated: "+s.Deprecated+".")
+	}
+	return strings.Join(parts, " ")
+}
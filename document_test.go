@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestDocument(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(`
+keys:
+  database:
+    type: map
+    required: true
+    keys:
+      host: {type: string, required: true, description: Server address.}
+      port: {type: int, default: 5432}
+      mode: {type: string, enum: [disable, require], description: TLS mode.}
+  servers:
+    type: list
+    items:
+      type: map
+      keys:
+        name: {type: string, description: "Host <name> | alias"}
+`)))
+	expect(t, err, nil)
+
+	doc, err := Document(schema, DocMarkdown)
+	expect(t, err, nil)
+	expect(t, doc, "| Key | Type | Required | Default | Description |\n"+
+		"|-----|------|----------|---------|-------------|\n"+
+		"| `database` | `map` | yes |  |  |\n"+
+		"| `database.host` | `string` | yes |  | Server address. |\n"+
+		"| `database.mode` | `string` |  |  | TLS mode. One of: disable, require. |\n"+
+		"| `database.port` | `int` |  | `5432` |  |\n"+
+		"| `servers` | `list of map` |  |  |  |\n"+
+		"| `servers.*.name` | `string` |  |  | Host <name> \\| alias |\n")
+
+	doc, err = Document(schema, DocHTML)
+	expect(t, err, nil)
+	expect(t, doc, "<table>\n<thead>\n"+
+		"<tr><th>Key</th><th>Type</th><th>Required</th><th>Default</th><th>Description</th></tr>\n"+
+		"</thead>\n<tbody>\n"+
+		"<tr><td>database</td><td>map</td><td>yes</td><td></td><td></td></tr>\n"+
+		"<tr><td>database.host</td><td>string</td><td>yes</td><td></td><td>Server address.</td></tr>\n"+
+		"<tr><td>database.mode</td><td>string</td><td></td><td></td><td>TLS mode. One of: disable, require.</td></tr>\n"+
+		"<tr><td>database.port</td><td>int</td><td></td><td>5432</td><td></td></tr>\n"+
+		"<tr><td>servers</td><td>list of map</td><td></td><td></td><td></td></tr>\n"+
+		"<tr><td>servers.*.name</td><td>string</td><td></td><td></td><td>Host &lt;name&gt; | alias</td></tr>\n"+
+		"</tbody>\n</table>\n")
+
+	_, err = Document(schema, DocFormat(7))
+	expect(t, err != nil, true)
+}
@@ -0,0 +1,160 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Streaming ------------------------------------------------------------------
+
+// EncodeJson writes the config as JSON to w. Unlike RenderJson, it walks
+// the tree and writes it piece by piece, so very large configs are never
+// held in memory as a whole encoded document. The output is the same as
+// RenderJson's.
+func (cfg *Config) EncodeJson(w io.Writer) error {
+	root, err := renderable(cfg)
+	if err != nil {
+		return err
+	}
+	bw := getWriter(w)
+	defer putWriter(bw)
+	if err := encodeJson(bw, root, nil); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeJson writes a value as JSON, sorting map keys like encoding/json,
+// after the keys of order, see orderedKeys.
+func encodeJson(w *bufio.Writer, value interface{}, order []string) error {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := orderedKeys(value, order)
+		w.WriteByte('{')
+		for i, k := range keys {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeJsonScalar(w, k); err != nil {
+				return err
+			}
+			w.WriteByte(':')
+			if err := encodeJson(w, value[k], order); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte('}')
+	case []interface{}:
+		w.WriteByte('[')
+		for i, v := range value {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := encodeJson(w, v, order); err != nil {
+				return err
+			}
+		}
+		return w.WriteByte(']')
+	}
+	return encodeJsonScalar(w, value)
+}
+
+// encodeJsonScalar writes a leaf value as JSON.
+func encodeJsonScalar(w *bufio.Writer, value interface{}) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// EncodeYaml writes the config as YAML to w. The top-level keys, or list
+// items, are emitted one at a time, so only a single top-level section is
+// held in memory as encoded YAML. Configs with comments are rendered as a
+// whole.
+func (cfg *Config) EncodeYaml(w io.Writer) error {
+	if len(cfg.comments) > 0 {
+		out, err := RenderYaml(cfg)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+	root, err := renderable(cfg)
+	if err != nil {
+		return err
+	}
+	bw := getWriter(w)
+	defer putWriter(bw)
+	switch root := root.(type) {
+	case map[string]interface{}:
+		if len(root) == 0 {
+			break
+		}
+		keys := make([]string, 0, len(root))
+		for k := range root {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			chunk := yaml.MapSlice{{Key: k, Value: root[k]}}
+			if err := encodeYamlChunk(bw, chunk); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	case []interface{}:
+		if len(root) == 0 {
+			break
+		}
+		for _, v := range root {
+			if err := encodeYamlChunk(bw, []interface{}{v}); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	}
+	if err := encodeYamlChunk(bw, root); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// encodeYamlChunk writes a part of a YAML document.
+func encodeYamlChunk(w *bufio.Writer, chunk interface{}) error {
+	b, err := yaml.Marshal(chunk)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// Encode writes the config to w in the given format.
+func (cfg *Config) Encode(w io.Writer, format Format) error {
+	switch format {
+	case FormatYaml:
+		return cfg.EncodeYaml(w)
+	case FormatJson:
+		return cfg.EncodeJson(w)
+	case FormatPlist:
+		out, err := RenderPlist(cfg)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(w, out)
+		return err
+	}
+	return fmt.Errorf("Unsupported format: %v", format)
+}
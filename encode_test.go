@@ -0,0 +1,45 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	docs := []string{
+		yamlString,
+		"[1, {a: b}, [x, y]]",
+		"just a string",
+		"{}",
+		"[]",
+		"a.b: {'c d': \"e\\nf\", g: null, h: 1.5}",
+	}
+	for _, doc := range docs {
+		cfg, err := ParseYaml(doc)
+		expect(t, err, nil)
+
+		for _, format := range []Format{FormatJson, FormatYaml} {
+			want, err := render(format, cfg.Root)
+			expect(t, err, nil)
+			var buf bytes.Buffer
+			expect(t, cfg.Encode(&buf, format), nil)
+			if buf.String() != want {
+				t.Errorf("Encode(%v) of %q:\ngot  %q\nwant %q", format, doc, buf.String(), want)
+			}
+		}
+	}
+}
+
+func BenchmarkEncodeJson(b *testing.B) {
+	cfg, _ := ParseYaml(yamlString)
+	var buf bytes.Buffer
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		cfg.EncodeJson(&buf)
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ParseEnvironmentFile reads variables in the syntax of systemd's
+// EnvironmentFile=, which differs from shell and dotenv files:
+//
+//   - lines starting with "#" or ";" are comments, and "#" elsewhere is
+//     part of the value;
+//   - single-quoted values are literal, and double-quoted ones only
+//     unescape \", \\, \`, \$ and line continuations;
+//   - unquoted values have their trailing whitespace removed, and a
+//     backslash escapes any character;
+//   - a backslash at the end of a line continues the value on the next
+//     one, and quoted values span lines.
+//
+// Lines without "=" are ignored, like systemd does, and invalid variable
+// names are reported with their line numbers.
+func ParseEnvironmentFile(data string) (map[string]string, error) {
+	p := envFileParser{data: data, line: 1}
+	return p.parse()
+}
+
+// envFileParser reads an EnvironmentFile, a character at a time.
+type envFileParser struct {
+	data string
+	pos  int
+	line int
+}
+
+func (p *envFileParser) next() (byte, bool) {
+	if p.pos >= len(p.data) {
+		return 0, false
+	}
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c, true
+}
+
+func (p *envFileParser) parse() (map[string]string, error) {
+	vars := map[string]string{}
+	for p.pos < len(p.data) {
+		line := p.line
+		rest := p.data[p.pos:]
+		if i := strings.IndexByte(rest, '\n'); i >= 0 {
+			rest = rest[:i]
+		}
+		trimmed := strings.TrimLeft(rest, " \t\r")
+		eq := strings.IndexByte(trimmed, '=')
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';' || eq < 0 {
+			p.pos += len(rest)
+			p.next()
+			continue
+		}
+		key := strings.TrimRight(trimmed[:eq], " \t")
+		if !validEnvName(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", line, key)
+		}
+		p.pos += len(rest) - len(trimmed) + eq + 1
+		value, err := p.value()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		vars[key] = value
+	}
+	return vars, nil
+}
+
+// value reads a value, up to the end of its line. Like in systemd,
+// quotes are only special at the start of the value, or right after a
+// quoted part, so that 'a'"b" is "ab" while a'b' is kept as is.
+func (p *envFileParser) value() (string, error) {
+	var buf []byte
+	// trim is the length of buf without the trailing whitespace of the
+	// unquoted part being read.
+	trim := 0
+	pre := true
+	for {
+		c, ok := p.next()
+		if !ok || c == '\n' {
+			return string(buf[:trim]), nil
+		}
+		if pre {
+			switch c {
+			case ' ', '\t', '\r':
+				continue
+			case '\'', '"':
+				s, err := p.quoted(c)
+				if err != nil {
+					return "", err
+				}
+				buf = append(buf, s...)
+				trim = len(buf)
+				continue
+			}
+			pre = false
+		}
+		if c == '\\' {
+			e, ok := p.next()
+			if !ok {
+				return string(buf[:trim]), nil
+			}
+			if e != '\n' {
+				buf = append(buf, e)
+				trim = len(buf)
+			}
+			continue
+		}
+		buf = append(buf, c)
+		if c != ' ' && c != '\t' && c != '\r' {
+			trim = len(buf)
+		}
+	}
+}
+
+// quoted reads a quoted part of a value, after its opening quote.
+func (p *envFileParser) quoted(quote byte) (string, error) {
+	var buf []byte
+	for {
+		c, ok := p.next()
+		if !ok {
+			return "", fmt.Errorf("unterminated %c quote", quote)
+		}
+		if c == quote {
+			return string(buf), nil
+		}
+		if c == '\\' && quote == '"' {
+			e, ok := p.next()
+			if !ok {
+				return "", fmt.Errorf("unterminated %c quote", quote)
+			}
+			switch e {
+			case '"', '\\', '`', '$':
+				buf = append(buf, e)
+			case '\n':
+			default:
+				buf = append(buf, c, e)
+			}
+			continue
+		}
+		buf = append(buf, c)
+	}
+}
+
+// validEnvName reports whether s is a valid variable name: letters,
+// digits and underscores, not starting with a digit.
+func validEnvName(s string) bool {
+	if s == "" || s[0] >= '0' && s[0] <= '9' {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// EnvironmentFile reads the variables of systemd EnvironmentFile= files,
+// in order, and sets the existing config keys they name, like EnvPrefix
+// does with the environment of the process. Like in units, a filename
+// starting with "-" is ignored when it doesn't exist:
+//
+//	err := cfg.EnvironmentFile("app", "/etc/default/app", "-/etc/app/local.env")
+func (cfg *Config) EnvironmentFile(prefix string, filenames ...string) error {
+	vars := map[string]string{}
+	for _, filename := range filenames {
+		optional := strings.HasPrefix(filename, "-")
+		if optional {
+			filename = filename[1:]
+		}
+		data, err := readFile(filename)
+		if os.IsNotExist(err) && optional {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fileVars, err := ParseEnvironmentFile(string(data))
+		if err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+		for k, v := range fileVars {
+			vars[k] = v
+		}
+	}
+	cfg.envLookup(prefix, func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	})
+	return nil
+}
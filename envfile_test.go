@@ -0,0 +1,74 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseEnvironmentFile(t *testing.T) {
+	vars, err := ParseEnvironmentFile(`# comment
+; also a comment
+PLAIN=value # not a comment
+  SPACED = trailing whitespace   
+SINGLE='literal \n $HOME'
+DOUBLE="say \"hi\" \$HOME \n"
+JOINED='a'"b"
+INNER=a'b'
+ESCAPED=a\ b\\c
+CONTINUED=first \
+second
+MULTI="line one
+line two"
+EMPTY=
+NOEQUALS
+`)
+	expect(t, err, nil)
+	for key, want := range map[string]string{
+		"PLAIN":     "value # not a comment",
+		"SPACED":    "trailing whitespace",
+		"SINGLE":    `literal \n $HOME`,
+		"DOUBLE":    `say "hi" $HOME \n`,
+		"JOINED":    "ab",
+		"INNER":     "a'b'",
+		"ESCAPED":   `a b\c`,
+		"CONTINUED": "first second",
+		"MULTI":     "line one\nline two",
+		"EMPTY":     "",
+	} {
+		expect(t, vars[key], want)
+	}
+	expect(t, len(vars), 10)
+
+	_, err = ParseEnvironmentFile("A=1\n1B=2\n")
+	expect(t, err.Error(), `line 2: invalid variable name "1B"`)
+	_, err = ParseEnvironmentFile("A=1\nB='open\n")
+	expect(t, err.Error(), "line 2: unterminated ' quote")
+}
+
+func TestEnvironmentFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-envfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defaults := filepath.Join(dir, "app")
+	local := filepath.Join(dir, "local.env")
+	ioutil.WriteFile(defaults, []byte("APP_DATABASE_HOST=db\nAPP_DATABASE_PORT=5433\n"), 0644)
+	ioutil.WriteFile(local, []byte("APP_DATABASE_HOST='db.local'\n"), 0644)
+
+	cfg := Must(ParseYaml("database: {host: localhost, port: 5432, user: app}"))
+	expect(t, cfg.EnvironmentFile("app", defaults, local, "-"+filepath.Join(dir, "missing")), nil)
+	expect(t, cfg.UString("database.host"), "db.local")
+	expect(t, cfg.UString("database.port"), "5433")
+	expect(t, cfg.UString("database.user"), "app")
+
+	if err := cfg.EnvironmentFile("app", filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
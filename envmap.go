@@ -0,0 +1,157 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// EnvNaming describes how the environment variables of config keys are
+// named. The zero value is the naming of EnvPrefix: upper-cased keys
+// joined by underscores, like DATABASE_MAX_CONNS for "database.max_conns".
+// Since keys may contain underscores too, a longer separator avoids
+// ambiguous names:
+//
+//	// APP__DATABASE__MAX_CONNS
+//	err := cfg.EnvWith(config.EnvNaming{Prefix: "app", Separator: "__"})
+type EnvNaming struct {
+	// Prefix starts the names, followed by the separator, so that only
+	// the variables of the application are read.
+	Prefix string
+	// Separator joins the prefix and the keys; "" stands for "_".
+	Separator string
+	// Case transforms the names, like strings.ToLower; nil stands for
+	// strings.ToUpper.
+	Case func(string) string
+}
+
+// name returns the variable name of the keys of a path. The characters
+// of the keys which can't appear in variable names, like dots, are
+// replaced by underscores.
+func (n EnvNaming) name(key []string) string {
+	sep := n.Separator
+	if sep == "" {
+		sep = "_"
+	}
+	transform := n.Case
+	if transform == nil {
+		transform = strings.ToUpper
+	}
+	parts := make([]string, len(key))
+	for i, k := range key {
+		parts[i] = strings.Map(envRune, k)
+	}
+	name := strings.Join(parts, sep)
+	if n.Prefix != "" {
+		name = n.Prefix + sep + name
+	}
+	return transform(name)
+}
+
+// envRune maps the characters of keys to the ones allowed in variable
+// names.
+func envRune(r rune) rune {
+	if r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' {
+		return r
+	}
+	return '_'
+}
+
+// EnvWith sets the existing keys of the config from the environment
+// variables named with the given naming, like EnvPrefix. Keys sharing a
+// variable name, like "a_b" and "a.b" with the default separator, are an
+// error, and leave the config unchanged.
+func (cfg *Config) EnvWith(naming EnvNaming) error {
+	return cfg.envWithLookup(naming, syscall.Getenv)
+}
+
+// envWithLookup is EnvWith, looking variables up with lookup.
+func (cfg *Config) envWithLookup(naming EnvNaming, lookup func(name string) (string, bool)) error {
+	paths := map[string]string{}
+	for _, key := range getKeys(cfg.Root) {
+		name, path := naming.name(key), formatPath(key)
+		if other, ok := paths[name]; ok {
+			if other > path {
+				other, path = path, other
+			}
+			return fmt.Errorf("Ambiguous environment variable $%s: it names both %q and %q",
+				name, other, path)
+		}
+		paths[name] = path
+	}
+	cfg.envNamed(naming, lookup)
+	return nil
+}
+
+// EnvMap sets dotted paths from the environment variables named by the
+// mapping, for variables whose names don't follow the convention of
+// EnvPrefix. Unlike with EnvPrefix, the paths don't need to exist. A path
+// may end with a type hint, one of the types of schemas, converting the
+// value of the variable:
+//
+//	err := cfg.EnvMap(map[string]string{
+//		"DB_HOST": "database.host",
+//		"DB_PORT": "database.port:int",
+//		"VERBOSE": "log.debug:bool",
+//	})
+//
+// Variables are applied in the order of their names, and nothing is set
+// when a mapping is invalid or a value can't be converted.
+func (cfg *Config) EnvMap(mapping map[string]string) error {
+	return cfg.envMapLookup(mapping, syscall.Getenv)
+}
+
+// envMapLookup is EnvMap, looking variables up with lookup.
+func (cfg *Config) envMapLookup(mapping map[string]string, lookup func(name string) (string, bool)) error {
+	names := make([]string, 0, len(mapping))
+	for name := range mapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	type assignment struct {
+		path  string
+		value interface{}
+	}
+	var set []assignment
+	for _, name := range names {
+		path, typ := splitTypeHint(mapping[name])
+		if _, err := parsePath(path); err != nil {
+			return fmt.Errorf("Invalid mapping of $%s: %v", name, err)
+		}
+		val, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		v, err := castValue(val, typ)
+		if err != nil {
+			return fmt.Errorf("Can't cast $%s to %s: %v", name, typ, err)
+		}
+		set = append(set, assignment{path, v})
+	}
+	for _, a := range set {
+		if err := cfg.Set(a.path, a.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitTypeHint splits a path like "database.port:int" into the path and
+// its type. Paths not ending with the name of a type are returned whole.
+func splitTypeHint(target string) (string, string) {
+	i := strings.LastIndexByte(target, ':')
+	if i < 0 {
+		return target, ""
+	}
+	switch typ := target[i+1:]; typ {
+	case "string", "int", "float", "bool", "time":
+		return target[:i], typ
+	}
+	return target, ""
+}
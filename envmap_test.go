@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvMap(t *testing.T) {
+	env := map[string]string{
+		"DB_HOST": "db.example.com",
+		"DB_PORT": "5433",
+		"VERBOSE": "true",
+		"RATIO":   "0.25",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	cfg := Must(ParseYaml("database: {host: localhost, port: 5432}"))
+	err := cfg.envMapLookup(map[string]string{
+		"DB_HOST": "database.host",
+		"DB_PORT": "database.port:int",
+		"VERBOSE": "log.debug:bool",
+		"RATIO":   "sampling[ratio:float]",
+		"UNSET":   "database.user",
+	}, lookup)
+	expect(t, err, nil)
+	expect(t, cfg.UString("database.host"), "db.example.com")
+	port, _ := Get(cfg.Root, "database.port")
+	expect(t, port, 5433)
+	debug, _ := Get(cfg.Root, "log.debug")
+	expect(t, debug, true)
+	expect(t, cfg.UString("sampling[ratio:float]"), "0.25")
+	_, err = cfg.String("database.user")
+	expect(t, err != nil, true)
+
+	// Invalid values leave the config unchanged.
+	err = cfg.envMapLookup(map[string]string{
+		"DB_HOST": "database.host",
+		"VERBOSE": "log.debug:int",
+	}, func(name string) (string, bool) {
+		return map[string]string{"DB_HOST": "other", "VERBOSE": "yes"}[name], true
+	})
+	expect(t, err != nil, true)
+	expect(t, cfg.UString("database.host"), "db.example.com")
+}
+
+func TestEnvWith(t *testing.T) {
+	env := map[string]string{
+		"APP__DATABASE__MAX_CONNS": "10",
+		"APP__DATABASE__HOST":      "db",
+		"app_database_host":        "lower",
+		"DATABASE_HOST":            "default",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	doc := "database: {host: localhost, max_conns: 5}"
+
+	cfg := Must(ParseYaml(doc))
+	expect(t, cfg.envWithLookup(EnvNaming{Prefix: "app", Separator: "__"}, lookup), nil)
+	expect(t, cfg.UString("database.host"), "db")
+	expect(t, cfg.UString("database.max_conns"), "10")
+
+	cfg = Must(ParseYaml(doc))
+	expect(t, cfg.envWithLookup(EnvNaming{Prefix: "app", Case: strings.ToLower}, lookup), nil)
+	expect(t, cfg.UString("database.host"), "lower")
+	expect(t, cfg.UInt("database.max_conns"), 5)
+
+	cfg = Must(ParseYaml(doc))
+	expect(t, cfg.envWithLookup(EnvNaming{}, lookup), nil)
+	expect(t, cfg.UString("database.host"), "default")
+
+	// Keys with the same variable name are reported.
+	cfg = Must(ParseYaml("database: {max_conns: 5, max: {conns: 6}}"))
+	err := cfg.envWithLookup(EnvNaming{}, lookup)
+	expect(t, err.Error(), `Ambiguous environment variable $DATABASE_MAX_CONNS: it names both "database.max.conns" and "database.max_conns"`)
+	expect(t, cfg.envWithLookup(EnvNaming{Separator: "__"}, lookup), nil)
+}
@@ -0,0 +1,149 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// execPrefix starts the values replaced by the output of a command. The
+// YAML decoder drops unknown tags, so the tag is written in the string.
+const execPrefix = "!exec "
+
+// defaultExecTimeout is the default time limit of a command.
+const defaultExecTimeout = 10 * time.Second
+
+// ExecOptions restricts the commands run by ResolveExecRefs.
+type ExecOptions struct {
+	// Allow lists the programs which may run, as written in the commands,
+	// like "pass" or "/usr/bin/op". Nothing runs when it is empty.
+	Allow []string
+	// Timeout limits the time of every command; 0 stands for 10 seconds.
+	Timeout time.Duration
+	// Shell runs the commands with "sh -c", allowing pipes and
+	// expansions. By default, commands are split into words, honoring
+	// quotes and backslashes, and run directly.
+	Shell bool
+}
+
+// ResolveExecRefs replaces the string values of the config starting with
+// "!exec " by the output of the command following it, without its final
+// newline, like password managers on developer machines:
+//
+//	database:
+//	  password: "!exec pass show db/password"
+//
+// Only the programs listed by opts.Allow run. Errors of the commands
+// include their standard error, and leave the config unchanged.
+func ResolveExecRefs(ctx context.Context, cfg *Config, opts ExecOptions) error {
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultExecTimeout
+	}
+	return resolveRefs(cfg, "exec", func(s string) (string, bool, error) {
+		if !strings.HasPrefix(s, execPrefix) {
+			return "", false, nil
+		}
+		out, err := runExecRef(ctx, strings.TrimSpace(s[len(execPrefix):]), opts)
+		return out, true, err
+	})
+}
+
+// runExecRef runs a command and returns its output.
+func runExecRef(ctx context.Context, command string, opts ExecOptions) (string, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return "", err
+	}
+	if len(args) == 0 {
+		return "", fmt.Errorf("empty command")
+	}
+	if !execAllowed(args[0], opts.Allow) {
+		return "", fmt.Errorf("program %q is not allowed", args[0])
+	}
+	if opts.Shell {
+		args = []string{"sh", "-c", command}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return "", fmt.Errorf("%q timed out after %v", command, opts.Timeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%q failed: %v: %s", command, err, msg)
+		}
+		return "", fmt.Errorf("%q failed: %v", command, err)
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+// execAllowed reports whether a program is listed.
+func execAllowed(program string, allow []string) bool {
+	for _, a := range allow {
+		if a == program {
+			return true
+		}
+	}
+	return false
+}
+
+// splitCommand splits a command into words separated by whitespace, where
+// single quotes keep their contents as is, and backslashes escape the
+// next character, except in single quotes.
+func splitCommand(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote == '\'':
+			if c == '\'' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\\' && i+1 < len(s):
+			i++
+			word.WriteByte(s[i])
+			inWord = true
+		case quote == '"':
+			if c == '"' {
+				quote = 0
+			} else {
+				word.WriteByte(c)
+			}
+		case c == '\'' || c == '"':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,55 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSplitCommand(t *testing.T) {
+	for s, want := range map[string][]string{
+		"pass show db/password":      {"pass", "show", "db/password"},
+		`echo "a b" 'c\d' e\ f`:      {"echo", "a b", `c\d`, "e f"},
+		`  op read "op://vault/x"  `: {"op", "read", "op://vault/x"},
+		`echo ""`:                    {"echo", ""},
+	} {
+		words, err := splitCommand(s)
+		expect(t, err, nil)
+		expect(t, reflect.DeepEqual(words, want), true)
+	}
+	_, err := splitCommand(`echo "a`)
+	expect(t, err.Error(), "unterminated \" quote")
+}
+
+func TestResolveExecRefs(t *testing.T) {
+	ctx := context.Background()
+	cfg := Must(ParseYaml(`
+database: {password: "!exec echo s3cr3t", user: app}
+shell: "!exec echo a | tr a b"
+`))
+	err := ResolveExecRefs(ctx, cfg, ExecOptions{Allow: []string{"echo"}, Shell: true})
+	expect(t, err, nil)
+	expect(t, cfg.UString("database.password"), "s3cr3t")
+	expect(t, cfg.UString("database.user"), "app")
+	expect(t, cfg.UString("shell"), "b")
+
+	for doc, msg := range map[string]string{
+		`a: "!exec rm -rf /tmp/x"`:                 `program "rm" is not allowed`,
+		`a: "!exec sh -c 'echo oops >&2; exit 3'"`: "exit status 3: oops",
+		`a: "!exec sleep 5"`:                       "timed out",
+	} {
+		cfg = Must(ParseYaml(doc))
+		err = ResolveExecRefs(ctx, cfg, ExecOptions{
+			Allow:   []string{"sh", "sleep"},
+			Timeout: 100 * time.Millisecond,
+		})
+		expect(t, err != nil && strings.Contains(err.Error(), msg), true)
+		expect(t, strings.HasPrefix(cfg.UString("a"), "!exec "), true)
+	}
+}
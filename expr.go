@@ -0,0 +1,582 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Expressions ----------------------------------------------------------------
+//
+// Expressions are small formulas evaluated against the config, like the
+// conditions of Resolve:
+//
+//	goos == "linux" && ${features.gpu}
+//	env.DEPLOY != "prod" || ${replicas} > 1
+//
+// They are made of numbers, strings in double or single quotes, true,
+// false and null, ${path} references to config values, and names: goos
+// and goarch are runtime.GOOS and runtime.GOARCH, and env.NAME is the
+// environment variable NAME, or "" when it isn't set. Operators are, by
+// increasing precedence, ||, &&, == and !=, <, <=, > and >=, + and -, *,
+// / and %, and the unary ! and -. + also concatenates strings, and /
+// divides ints into an int only when there is no remainder.
+//
+// Functions are limited to the ones of exprFuncs: min, max, abs, floor,
+// ceil, round, int, float, string and numcpu. Expressions can't call
+// anything else, so configs can't do more than compute values.
+
+// exprNode is a node of a parsed expression.
+type exprNode interface {
+	eval(lookup exprLookup) (interface{}, error)
+}
+
+// exprLookup returns the value of a reference.
+type exprLookup func(path string) (interface{}, error)
+
+type (
+	exprLit   struct{ v interface{} }
+	exprRef   struct{ path string }
+	exprName  struct{ name string }
+	exprUnary struct {
+		op string
+		x  exprNode
+	}
+	exprBinary struct {
+		op   string
+		x, y exprNode
+	}
+	exprCall struct {
+		name string
+		args []exprNode
+	}
+)
+
+// exprPrecedence are the precedences of the binary operators.
+var exprPrecedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+	"+": 5, "-": 5,
+	"*": 6, "/": 6, "%": 6,
+}
+
+// exprOperators are the operators, longest first.
+var exprOperators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "%", "(", ")", ","}
+
+// evalExpr parses and evaluates an expression, reading the references
+// in the config.
+func (cfg *Config) evalExpr(s string) (interface{}, error) {
+	return evalExprWith(s, func(path string) (interface{}, error) {
+		return cfg.lookup(path)
+	})
+}
+
+// evalExprWith parses and evaluates an expression, reading the
+// references with the given lookup.
+func evalExprWith(s string, lookup exprLookup) (interface{}, error) {
+	n, err := parseExpr(s)
+	if err != nil {
+		return nil, err
+	}
+	v, err := n.eval(lookup)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid expression %q: %v", s, err)
+	}
+	return v, nil
+}
+
+// exprToken kinds.
+const (
+	exprEOF = iota
+	exprNumber
+	exprString
+	exprIdent
+	exprReference
+	exprOperator
+)
+
+// exprParser parses expressions by precedence climbing.
+type exprParser struct {
+	src  string
+	pos  int
+	kind int
+	tok  string
+	val  interface{}
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Invalid expression %q: %s", p.src, fmt.Sprintf(format, args...))
+}
+
+// parseExpr parses an expression.
+func parseExpr(s string) (exprNode, error) {
+	p := &exprParser{src: s}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	n, err := p.binary(1)
+	if err != nil {
+		return nil, err
+	}
+	if p.kind != exprEOF {
+		return nil, p.errorf("unexpected %q", p.tok)
+	}
+	return n, nil
+}
+
+// next reads the next token.
+func (p *exprParser) next() error {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+	p.val = nil
+	if p.pos >= len(p.src) {
+		p.kind, p.tok = exprEOF, ""
+		return nil
+	}
+	start := p.pos
+	switch c := p.src[p.pos]; {
+	case c >= '0' && c <= '9' || c == '.':
+		for p.pos < len(p.src) && (p.src[p.pos] >= '0' && p.src[p.pos] <= '9' || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		p.kind, p.tok = exprNumber, p.src[start:p.pos]
+		if i, err := strconv.Atoi(p.tok); err == nil {
+			p.val = i
+		} else if f, err := strconv.ParseFloat(p.tok, 64); err == nil {
+			p.val = f
+		} else {
+			return p.errorf("invalid number %q", p.tok)
+		}
+	case c == '"' || c == '\'':
+		end := strings.IndexByte(p.src[p.pos+1:], c)
+		if end < 0 {
+			return p.errorf("unterminated string")
+		}
+		p.pos += end + 2
+		p.kind, p.tok, p.val = exprString, p.src[start:p.pos], p.src[start+1:p.pos-1]
+	case c == '$' && strings.HasPrefix(p.src[p.pos:], "${"):
+		end := strings.IndexByte(p.src[p.pos:], '}')
+		if end < 0 {
+			return p.errorf("unterminated reference")
+		}
+		p.pos += end + 1
+		p.kind, p.tok = exprReference, p.src[start:p.pos]
+		p.val = canonicalPath("", strings.TrimSpace(p.tok[2:len(p.tok)-1]))
+	case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+		for p.pos < len(p.src) {
+			c := p.src[p.pos]
+			if !(c == '_' || c == '.' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				break
+			}
+			p.pos++
+		}
+		p.kind, p.tok = exprIdent, p.src[start:p.pos]
+	default:
+		for _, op := range exprOperators {
+			if strings.HasPrefix(p.src[p.pos:], op) {
+				p.pos += len(op)
+				p.kind, p.tok = exprOperator, op
+				return nil
+			}
+		}
+		return p.errorf("unexpected %q", string(c))
+	}
+	return nil
+}
+
+// binary parses the binary operations of at least the given precedence.
+func (p *exprParser) binary(min int) (exprNode, error) {
+	x, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		prec, ok := exprPrecedence[p.tok]
+		if p.kind != exprOperator || !ok || prec < min {
+			return x, nil
+		}
+		op := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		y, err := p.binary(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		x = exprBinary{op, x, y}
+	}
+}
+
+// unary parses a unary operation or a primary expression.
+func (p *exprParser) unary() (exprNode, error) {
+	if p.kind == exprOperator && (p.tok == "!" || p.tok == "-") {
+		op := p.tok
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return exprUnary{op, x}, nil
+	}
+	return p.primary()
+}
+
+// primary parses a literal, a reference, a name, a call or a
+// parenthesized expression.
+func (p *exprParser) primary() (exprNode, error) {
+	var n exprNode
+	switch p.kind {
+	case exprNumber, exprString:
+		n = exprLit{p.val}
+	case exprReference:
+		n = exprRef{p.val.(string)}
+	case exprIdent:
+		switch p.tok {
+		case "true", "false":
+			n = exprLit{p.tok == "true"}
+		case "null":
+			n = exprLit{nil}
+		default:
+			name := p.tok
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			if p.kind != exprOperator || p.tok != "(" {
+				return exprName{name}, nil
+			}
+			return p.call(name)
+		}
+	case exprOperator:
+		if p.tok != "(" {
+			return nil, p.errorf("unexpected %q", p.tok)
+		}
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		x, err := p.binary(1)
+		if err != nil {
+			return nil, err
+		}
+		if p.kind != exprOperator || p.tok != ")" {
+			return nil, p.errorf("missing ')'")
+		}
+		n = x
+	default:
+		return nil, p.errorf("unexpected end")
+	}
+	return n, p.next()
+}
+
+// call parses the arguments of a call, from their opening parenthesis.
+func (p *exprParser) call(name string) (exprNode, error) {
+	if _, ok := exprFuncs[name]; !ok {
+		return nil, p.errorf("unknown function %q", name)
+	}
+	n := exprCall{name: name}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	for !(p.kind == exprOperator && p.tok == ")") {
+		if len(n.args) > 0 {
+			if p.kind != exprOperator || p.tok != "," {
+				return nil, p.errorf("missing ',' or ')' in call of %s", name)
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		arg, err := p.binary(1)
+		if err != nil {
+			return nil, err
+		}
+		n.args = append(n.args, arg)
+	}
+	return n, p.next()
+}
+
+func (n exprLit) eval(lookup exprLookup) (interface{}, error) {
+	return n.v, nil
+}
+
+func (n exprRef) eval(lookup exprLookup) (interface{}, error) {
+	v, err := lookup(n.path)
+	if err != nil {
+		return nil, fmt.Errorf("unresolved reference ${%s}", n.path)
+	}
+	return v, nil
+}
+
+func (n exprName) eval(lookup exprLookup) (interface{}, error) {
+	switch {
+	case n.name == "goos":
+		return runtime.GOOS, nil
+	case n.name == "goarch":
+		return runtime.GOARCH, nil
+	case strings.HasPrefix(n.name, "env.") && len(n.name) > len("env."):
+		return os.Getenv(n.name[len("env."):]), nil
+	}
+	return nil, fmt.Errorf("unknown name %q", n.name)
+}
+
+func (n exprUnary) eval(lookup exprLookup) (interface{}, error) {
+	x, err := n.x.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	if n.op == "-" {
+		switch x := x.(type) {
+		case int:
+			return -x, nil
+		case float64:
+			return -x, nil
+		}
+		return nil, fmt.Errorf("- expects a number; got %T", x)
+	}
+	b, ok := x.(bool)
+	if !ok {
+		return nil, fmt.Errorf("%s expects a bool; got %T", n.op, x)
+	}
+	return !b, nil
+}
+
+func (n exprBinary) eval(lookup exprLookup) (interface{}, error) {
+	x, err := n.x.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	// && and || only evaluate their second operand when needed.
+	if n.op == "&&" || n.op == "||" {
+		b, ok := x.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s expects bools; got %T", n.op, x)
+		}
+		if b == (n.op == "||") {
+			return b, nil
+		}
+		y, err := n.y.eval(lookup)
+		if err != nil {
+			return nil, err
+		}
+		if b, ok = y.(bool); !ok {
+			return nil, fmt.Errorf("%s expects bools; got %T", n.op, y)
+		}
+		return b, nil
+	}
+	y, err := n.y.eval(lookup)
+	if err != nil {
+		return nil, err
+	}
+	return exprOperate(n.op, x, y)
+}
+
+func (n exprCall) eval(lookup exprLookup) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		var err error
+		if args[i], err = arg.eval(lookup); err != nil {
+			return nil, err
+		}
+	}
+	v, err := exprFuncs[n.name](args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", n.name, err)
+	}
+	return v, nil
+}
+
+// exprOperate applies a binary operator, other than && and ||, to values.
+func exprOperate(op string, x, y interface{}) (interface{}, error) {
+	xf, xnum := exprNumberValue(x)
+	yf, ynum := exprNumberValue(y)
+	switch op {
+	case "==", "!=":
+		equal := reflect.DeepEqual(x, y)
+		if xnum && ynum {
+			equal = xf == yf
+		}
+		return equal == (op == "=="), nil
+	}
+	xi, xint := x.(int)
+	yi, yint := y.(int)
+	switch op {
+	case "+", "-", "*", "%":
+		if xint && yint {
+			switch op {
+			case "+":
+				return xi + yi, nil
+			case "-":
+				return xi - yi, nil
+			case "*":
+				return xi * yi, nil
+			}
+			if yi == 0 {
+				return nil, fmt.Errorf("division by zero")
+			}
+			return xi % yi, nil
+		}
+	case "/":
+		if xnum && ynum && yf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		if xint && yint && xi%yi == 0 {
+			return xi / yi, nil
+		}
+	}
+	if xnum && ynum {
+		switch op {
+		case "+":
+			return xf + yf, nil
+		case "-":
+			return xf - yf, nil
+		case "*":
+			return xf * yf, nil
+		case "/":
+			return xf / yf, nil
+		case "<":
+			return xf < yf, nil
+		case "<=":
+			return xf <= yf, nil
+		case ">":
+			return xf > yf, nil
+		case ">=":
+			return xf >= yf, nil
+		}
+	}
+	xs, xok := x.(string)
+	ys, yok := y.(string)
+	if xok && yok {
+		switch op {
+		case "+":
+			return xs + ys, nil
+		case "<":
+			return xs < ys, nil
+		case "<=":
+			return xs <= ys, nil
+		case ">":
+			return xs > ys, nil
+		case ">=":
+			return xs >= ys, nil
+		}
+	}
+	return nil, fmt.Errorf("can't apply %s to %T and %T", op, x, y)
+}
+
+// exprNumberValue returns the value of a number as a float64.
+func exprNumberValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// exprFuncs are the functions which expressions can call.
+var exprFuncs = map[string]func(args []interface{}) (interface{}, error){
+	"min": func(args []interface{}) (interface{}, error) {
+		return exprExtreme(args, func(x, y float64) bool { return x < y })
+	},
+	"max": func(args []interface{}) (interface{}, error) {
+		return exprExtreme(args, func(x, y float64) bool { return x > y })
+	},
+	"abs": func(args []interface{}) (interface{}, error) {
+		x, err := exprNumberArg(args)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := args[0].(int); ok {
+			if i < 0 {
+				return -i, nil
+			}
+			return i, nil
+		}
+		return math.Abs(x), nil
+	},
+	"floor": exprRounding(math.Floor),
+	"ceil":  exprRounding(math.Ceil),
+	"round": exprRounding(math.Round),
+	"int": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument; got %d", len(args))
+		}
+		if f, ok := args[0].(float64); ok {
+			return int(f), nil
+		}
+		return toInt(args[0])
+	},
+	"float": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument; got %d", len(args))
+		}
+		return toFloat64(args[0])
+	},
+	"string": func(args []interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("expected 1 argument; got %d", len(args))
+		}
+		return toString(args[0])
+	},
+	"numcpu": func(args []interface{}) (interface{}, error) {
+		if len(args) != 0 {
+			return nil, fmt.Errorf("expected no arguments; got %d", len(args))
+		}
+		return runtime.NumCPU(), nil
+	},
+}
+
+// exprNumberArg returns the single number argument of a function.
+func exprNumberArg(args []interface{}) (float64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("expected 1 argument; got %d", len(args))
+	}
+	f, ok := exprNumberValue(args[0])
+	if !ok {
+		return 0, fmt.Errorf("expected a number; got %T", args[0])
+	}
+	return f, nil
+}
+
+// exprRounding returns a function rounding a number to an int.
+func exprRounding(round func(float64) float64) func(args []interface{}) (interface{}, error) {
+	return func(args []interface{}) (interface{}, error) {
+		x, err := exprNumberArg(args)
+		if err != nil {
+			return nil, err
+		}
+		return int(round(x)), nil
+	}
+}
+
+// exprExtreme returns the argument which is before all the others
+// according to less, like the smallest one.
+func exprExtreme(args []interface{}, less func(x, y float64) bool) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("expected arguments")
+	}
+	var best interface{}
+	var bestf float64
+	for _, arg := range args {
+		f, ok := exprNumberValue(arg)
+		if !ok {
+			return nil, fmt.Errorf("expected numbers; got %T", arg)
+		}
+		if best == nil || less(f, bestf) {
+			best, bestf = arg, f
+		}
+	}
+	return best, nil
+}
@@ -0,0 +1,80 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"runtime"
+	"testing"
+)
+
+func TestExpressions(t *testing.T) {
+	os.Setenv("CONFIG_TEST_DEPLOY", "prod")
+	defer os.Unsetenv("CONFIG_TEST_DEPLOY")
+	cfg := Must(ParseYaml(`
+replicas: 3
+ratio: 0.5
+name: api
+gpu: true
+list: [1, 2]
+`))
+	for expr, want := range map[string]interface{}{
+		`1`:                                     1,
+		`'single' == "single"`:                  true,
+		`${replicas} > 1 && ${ratio} <= 0.5`:    true,
+		`${replicas} == 3.0`:                    true,
+		`${name} != "api" || !${gpu}`:           false,
+		`!(${replicas} < 2)`:                    true,
+		`"a" < "b"`:                             true,
+		`goos == "` + runtime.GOOS + `"`:        true,
+		`goarch`:                                runtime.GOARCH,
+		`env.CONFIG_TEST_DEPLOY == "prod"`:      true,
+		`env.CONFIG_TEST_MISSING == ""`:         true,
+		`null == null`:                          true,
+		`true || ${missing}`:                    true,
+		`${list} == ${list}`:                    true,
+		`false && ${replicas} > "x"`:            false,
+		`${replicas} >= 3 == (${ratio} > 0.25)`: true,
+		`${replicas} * 2 + 1`:                   7,
+		`(${replicas} + 1) * -2`:                -8,
+		`${replicas} / 2`:                       1.5,
+		`${replicas} * 4 / 2`:                   6,
+		`${replicas} % 2`:                       1,
+		`${ratio} * 4`:                          2.0,
+		`${name} + "-" + string(${replicas})`:   "api-3",
+		`max(4, ${replicas}, 2.5)`:              4,
+		`min(4, ${replicas}, 2.5)`:              2.5,
+		`abs(-2) + abs(-0.5)`:                   2.5,
+		`floor(2.5) + ceil(2.1) + round(2.5)`:   8,
+		`int("12") + int(2.9) + float(1)`:       15.0,
+		`numcpu() > 0`:                          true,
+	} {
+		v, err := cfg.evalExpr(expr)
+		expect(t, err, nil)
+		expect(t, v, want)
+	}
+
+	for expr, msg := range map[string]string{
+		`${missing}`:        `Invalid expression "${missing}": unresolved reference ${missing}`,
+		`${replicas} > "x"`: `Invalid expression "${replicas} > \"x\"": can't apply > to int and string`,
+		`!${name}`:          `Invalid expression "!${name}": ! expects a bool; got string`,
+		`${name} && true`:   `Invalid expression "${name} && true": && expects bools; got string`,
+		`os.exit`:           `Invalid expression "os.exit": unknown name "os.exit"`,
+		`(1 == 1`:           `Invalid expression "(1 == 1": missing ')'`,
+		`1 ==`:              `Invalid expression "1 ==": unexpected end`,
+		`1 2`:               `Invalid expression "1 2": unexpected "2"`,
+		`"open`:             `Invalid expression "\"open": unterminated string`,
+		`1 # 2`:             `Invalid expression "1 # 2": unexpected "#"`,
+		`1 / 0`:             `Invalid expression "1 / 0": division by zero`,
+		`-"a"`:              `Invalid expression "-\"a\"": - expects a number; got string`,
+		`exec("rm")`:        `Invalid expression "exec(\"rm\")": unknown function "exec"`,
+		`max()`:             `Invalid expression "max()": max: expected arguments`,
+		`abs(1, 2)`:         `Invalid expression "abs(1, 2)": abs: expected 1 argument; got 2`,
+		`max(1 2)`:          `Invalid expression "max(1 2)": missing ',' or ')' in call of max`,
+	} {
+		_, err := cfg.evalExpr(expr)
+		expect(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// defaultMaxFileRefSize is the default limit of the size of the files
+// read by ResolveFileRefs.
+const defaultMaxFileRefSize = 1 << 20
+
+// FileRefOptions tunes ResolveFileRefsWith.
+type FileRefOptions struct {
+	// MaxSize is the largest file read, in bytes; 0 stands for 1 MiB.
+	MaxSize int64
+	// KeepSpace keeps the leading and trailing whitespace of the files,
+	// like the final newline most editors add.
+	KeepSpace bool
+}
+
+// ResolveFileRefs replaces the keys ending with "_file" by the contents of
+// the file they name, following the convention of Docker secrets:
+//
+//	database:
+//	  password_file: /run/secrets/db
+//
+// sets "database.password" to the contents of /run/secrets/db, trimmed of
+// surrounding whitespace, and removes "database.password_file". A key
+// set both directly and through a file is an error, and so are files
+// larger than 1 MiB; nothing is changed on errors.
+func (cfg *Config) ResolveFileRefs() error {
+	return cfg.ResolveFileRefsWith(FileRefOptions{})
+}
+
+// ResolveFileRefsWith is ResolveFileRefs with the given options.
+func (cfg *Config) ResolveFileRefsWith(opts FileRefOptions) error {
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = defaultMaxFileRefSize
+	}
+	type fileRef struct {
+		ref, path string
+		value     string
+	}
+	var refs []fileRef
+	var err error
+	var walk func(node interface{}, path string)
+	walk = func(node interface{}, path string) {
+		if err != nil {
+			return
+		}
+		switch c := node.(type) {
+		case map[string]interface{}:
+			keys := make([]string, 0, len(c))
+			for k := range c {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				ref := joinPath(path, k)
+				target := strings.TrimSuffix(k, "_file")
+				if target == k || target == "" {
+					walk(c[k], ref)
+					continue
+				}
+				if err != nil {
+					return
+				}
+				filename, ok := c[k].(string)
+				if !ok {
+					err = fmt.Errorf("Invalid file reference at %q: %v",
+						displayPath(ref), typeMismatch("string", c[k]))
+					return
+				}
+				if _, dup := c[target]; dup {
+					err = fmt.Errorf("Conflicting keys %q and %q",
+						displayPath(joinPath(path, target)), displayPath(ref))
+					return
+				}
+				var value string
+				if value, err = readFileRef(filename, opts); err != nil {
+					err = fmt.Errorf("Invalid file reference at %q: %v", displayPath(ref), err)
+					return
+				}
+				refs = append(refs, fileRef{ref, joinPath(path, target), value})
+			}
+		case []interface{}:
+			for i, v := range c {
+				walk(v, joinPath(path, strconv.Itoa(i)))
+			}
+		}
+	}
+	walk(cfg.Root, "")
+	if err != nil {
+		return err
+	}
+	for _, r := range refs {
+		if err := cfg.Set(r.path, r.value); err != nil {
+			return err
+		}
+		if err := cfg.Delete(r.ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readFileRef reads a file named by a reference, up to the size limit.
+func readFileRef(filename string, opts FileRefOptions) (string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(io.LimitReader(f, opts.MaxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > opts.MaxSize {
+		return "", fmt.Errorf("%s is larger than %d bytes", filename, opts.MaxSize)
+	}
+	if opts.KeepSpace {
+		return string(data), nil
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveFileRefs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-secrets")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "db")
+	expect(t, ioutil.WriteFile(secret, []byte("s3cr3t\n"), 0600), nil)
+	large := filepath.Join(dir, "large")
+	expect(t, ioutil.WriteFile(large, []byte(strings.Repeat("x", 100)), 0600), nil)
+
+	cfg := Must(ParseYaml(`
+database: {user: app, password_file: ` + secret + `}
+replicas: [{password_file: ` + secret + `}]
+`))
+	expect(t, cfg.ResolveFileRefs(), nil)
+	expect(t, cfg.UString("database.password"), "s3cr3t")
+	expect(t, cfg.UString("replicas.0.password"), "s3cr3t")
+	_, ok := cfg.StringOk("database.password_file")
+	expect(t, ok, false)
+
+	cfg = Must(ParseYaml("token_file: " + secret))
+	expect(t, cfg.ResolveFileRefsWith(FileRefOptions{KeepSpace: true}), nil)
+	expect(t, cfg.UString("token"), "s3cr3t\n")
+
+	// Errors leave the config unchanged.
+	for doc, msg := range map[string]string{
+		"a_file: " + large + "\nb_file: " + secret: "larger than 10 bytes",
+		"a_file: " + secret + "\na: set":           `Conflicting keys "a" and "a_file"`,
+		"a_file: [x]":                              `Invalid file reference at "a_file"`,
+		"a_file: " + filepath.Join(dir, "missing"): `Invalid file reference at "a_file"`,
+	} {
+		cfg = Must(ParseYaml(doc))
+		err = cfg.ResolveFileRefsWith(FileRefOptions{MaxSize: 10})
+		expect(t, err != nil && strings.Contains(err.Error(), msg), true)
+		_, ok = cfg.StringOk("b")
+		expect(t, ok, false)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// etcDir is the system-wide config directory, replaced by tests.
+var etcDir = "/etc"
+
+// Locations returns the conventional paths of the config of an app, in
+// decreasing precedence:
+//
+//	./myapp.yaml
+//	$XDG_CONFIG_HOME/myapp/config.yaml
+//	/etc/myapp/config.yaml
+//
+// where XDG_CONFIG_HOME defaults to ~/.config, and the extension is the
+// one of the format.
+func Locations(app string, format Format) []string {
+	ext := "." + format.String()
+	paths := []string{app + ext}
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		if home := os.Getenv("HOME"); home != "" {
+			dir = filepath.Join(home, ".config")
+		}
+	}
+	if dir != "" {
+		paths = append(paths, filepath.Join(dir, app, "config"+ext))
+	}
+	return append(paths, filepath.Join(etcDir, app, "config"+ext))
+}
+
+// Find parses the first existing file among the Locations of an app, and
+// returns it along with its path.
+//
+//	cfg, path, err := config.Find("myapp", config.FormatYaml)
+func Find(app string, format Format) (*Config, string, error) {
+	paths, err := findLocations(app, format)
+	if err != nil {
+		return nil, "", err
+	}
+	cfg, err := ParseFile(paths[0])
+	if err != nil {
+		return nil, "", err
+	}
+	return cfg, paths[0], nil
+}
+
+// FindAll parses all the existing files among the Locations of an app and
+// merges them with Extend, the files of higher precedence overriding the
+// others, so that ./myapp.yaml can override a few keys of
+// /etc/myapp/config.yaml. It returns the paths found, in decreasing
+// precedence.
+func FindAll(app string, format Format) (*Config, []string, error) {
+	paths, err := findLocations(app, format)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg := &Config{Root: map[string]interface{}{}}
+	for i := len(paths) - 1; i >= 0; i-- {
+		c, err := ParseFile(paths[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		if cfg, err = cfg.Extend(c); err != nil {
+			return nil, nil, err
+		}
+	}
+	return cfg, paths, nil
+}
+
+// findLocations returns the existing Locations, failing if there is none.
+func findLocations(app string, format Format) ([]string, error) {
+	var found []string
+	locations := Locations(app, format)
+	for _, path := range locations {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			found = append(found, path)
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("No config of %q found in %s", app,
+			strings.Join(locations, ", "))
+	}
+	return found, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFind(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	wd, err := os.Getwd()
+	expect(t, err, nil)
+	expect(t, os.Chdir(dir), nil)
+	defer os.Chdir(wd)
+	defer func(dir string) { etcDir = dir }(etcDir)
+	etcDir = filepath.Join(dir, "etc")
+	defer os.Setenv("XDG_CONFIG_HOME", os.Getenv("XDG_CONFIG_HOME"))
+	os.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+
+	write := func(path, doc string) {
+		expect(t, os.MkdirAll(filepath.Dir(path), 0755), nil)
+		expect(t, ioutil.WriteFile(path, []byte(doc), 0644), nil)
+	}
+	etcFile := filepath.Join(dir, "etc", "myapp", "config.yaml")
+	xdgFile := filepath.Join(dir, "xdg", "myapp", "config.yaml")
+
+	expect(t, len(Locations("myapp", FormatJson)), 3)
+	expect(t, Locations("myapp", FormatJson)[0], "myapp.json")
+	_, _, err = Find("myapp", FormatYaml)
+	expect(t, err != nil, true)
+
+	write(etcFile, "db: {host: db.internal, port: 5432}\nlevel: info")
+	write(xdgFile, "level: warn")
+	write("myapp.yaml", "db: {port: 5433}")
+
+	cfg, path, err := Find("myapp", FormatYaml)
+	expect(t, err, nil)
+	expect(t, path, "myapp.yaml")
+	expect(t, cfg.UInt("db.port"), 5433)
+	expect(t, cfg.UString("db.host"), "")
+
+	cfg, paths, err := FindAll("myapp", FormatYaml)
+	expect(t, err, nil)
+	expect(t, len(paths), 3)
+	expect(t, paths[2], etcFile)
+	expect(t, cfg.UInt("db.port"), 5433)
+	expect(t, cfg.UString("db.host"), "db.internal")
+	expect(t, cfg.UString("level"), "warn")
+}
@@ -0,0 +1,71 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"strings"
+	"time"
+)
+
+// BindFlag sets the value at path from a flag defined elsewhere, so that
+// libraries reading dotted paths see the values of established command
+// lines. It is called once the flags are parsed. A flag set on the command
+// line replaces the value, while the default of an unset flag only fills
+// a missing path, keeping the values of config files.
+//
+// Values of the standard flag types keep their types: bool, int and
+// float64 values are set as such, and durations as strings, like "1m30s",
+// which Duration parses. Other values are set as their String.
+func (cfg *Config) BindFlag(path string, f *flag.Flag) error {
+	return cfg.bindFlag(path, f, f.Value.String() != f.DefValue)
+}
+
+// BindFlagSet binds every flag of fs, see BindFlag. Dashes in flag names
+// separate keys, like for Flag, so with the prefix "db" the flag
+// -pool-size is bound to "db.pool.size". Flags set on the command line are
+// told apart with fs.Visit, which is more precise than comparing defaults.
+func (cfg *Config) BindFlagSet(prefix string, fs *flag.FlagSet) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil {
+			return
+		}
+		path := canonicalPath(prefix, strings.Replace(f.Name, "-", ".", -1))
+		err = cfg.bindFlag(path, f, set[f.Name])
+	})
+	return err
+}
+
+func (cfg *Config) bindFlag(path string, f *flag.Flag, set bool) error {
+	if !set {
+		if _, err := cfg.lookup(path); err == nil {
+			return nil
+		}
+	}
+	return cfg.Set(path, flagValue(f))
+}
+
+// flagValue returns the value of a flag as a config value.
+func flagValue(f *flag.Flag) interface{} {
+	if getter, ok := f.Value.(flag.Getter); ok {
+		switch v := getter.Get().(type) {
+		case bool, int, float64, string:
+			return v
+		case int64:
+			return int(v)
+		case uint:
+			return int(v)
+		case uint64:
+			return int(v)
+		case time.Duration:
+			return v.String()
+		}
+	}
+	return f.Value.String()
+}
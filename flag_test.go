@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestBindFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.String("host", "localhost", "")
+	fs.Int("port", 5432, "")
+	fs.Bool("tls", false, "")
+	fs.Duration("pool-timeout", time.Second, "")
+	fs.Float64("ratio", 0.5, "")
+	if err := fs.Parse([]string{"-host", "db.example.com", "-pool-timeout", "1m30s"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := Must(ParseYaml("db:\n  port: 6432\n  tls: true"))
+	expect(t, cfg.BindFlagSet("db", fs), nil)
+	// Set flags replace values, defaults only fill missing ones.
+	expect(t, cfg.UString("db.host"), "db.example.com")
+	expect(t, cfg.UInt("db.port"), 6432)
+	expect(t, cfg.UBool("db.tls"), true)
+	expect(t, cfg.UString("db.pool.timeout"), "1m30s")
+	expect(t, cfg.UFloat64("db.ratio"), 0.5)
+
+	cfg = Must(ParseYaml("{}"))
+	expect(t, cfg.BindFlag("server.port", fs.Lookup("port")), nil)
+	expect(t, cfg.UInt("server.port"), 5432)
+	expect(t, cfg.BindFlag("server.address", fs.Lookup("host")), nil)
+	expect(t, cfg.UString("server.address"), "db.example.com")
+}
@@ -0,0 +1,196 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Format identifies a configuration encoding.
+type Format int
+
+// Supported formats.
+const (
+	FormatYaml Format = iota
+	FormatJson
+	FormatPlist
+)
+
+// String returns the conventional file extension of the format, without
+// the leading dot.
+func (f Format) String() string {
+	switch f {
+	case FormatYaml:
+		return "yaml"
+	case FormatJson:
+		return "json"
+	case FormatPlist:
+		return "plist"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// render renders a configuration in the given format.
+func render(format Format, cfg interface{}) (string, error) {
+	switch format {
+	case FormatYaml:
+		return RenderYaml(cfg)
+	case FormatJson:
+		return RenderJson(cfg)
+	case FormatPlist:
+		return RenderPlist(cfg)
+	}
+	return "", fmt.Errorf("Unsupported format: %v", format)
+}
+
+// FormatOf guesses the format of a file from its extension: ".json" files
+// are JSON, ".plist" files property lists, and anything else is YAML.
+// Compression extensions are skipped, so "app.json.gz" is JSON too.
+func FormatOf(filename string) Format {
+	switch strings.ToLower(filepath.Ext(trimCompressionExt(filename))) {
+	case ".json":
+		return FormatJson
+	case ".plist":
+		return FormatPlist
+	}
+	return FormatYaml
+}
+
+// ParseFile reads a configuration from the given filename, in the format
+// guessed by FormatOf.
+func ParseFile(filename string) (*Config, error) {
+	switch FormatOf(filename) {
+	case FormatJson:
+		return ParseJsonFile(filename)
+	case FormatPlist:
+		return ParsePlistFile(filename)
+	}
+	return ParseYamlFile(filename)
+}
+
+// parseFormat parses a configuration in the given format. Property lists
+// are never empty, and their keys always strings, so they ignore opts.
+func parseFormat(format Format, data []byte, opts ParseOptions) (*Config, error) {
+	switch format {
+	case FormatJson:
+		return parseJson(data, opts)
+	case FormatPlist:
+		return ParsePlist(data)
+	}
+	return parseYaml(data, opts)
+}
+
+// ParseDir reads the ".yaml", ".yml", ".json" and ".plist" files of a
+// directory, in lexical order, and merges them with Extend, like the fragments of a
+// conf.d directory: the values of later files override the earlier ones.
+// Other files and subdirectories are skipped. Errors name the file they
+// come from. The files are parsed concurrently, see ParseFiles.
+func ParseDir(dir string) (*Config, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var filenames []string
+	for _, info := range infos {
+		if info.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(trimCompressionExt(info.Name()))) {
+		case ".yaml", ".yml", ".json", ".plist":
+			filenames = append(filenames, filepath.Join(dir, info.Name()))
+		}
+	}
+	return ParseFiles(filenames...)
+}
+
+// ParseFiles reads configuration files in the formats guessed by
+// FormatOf and merges them with Extend, in the given order, so that later
+// files override the earlier ones. The files are parsed concurrently, by
+// at most GOMAXPROCS goroutines, which speeds up the startup of services
+// reading dozens of fragments; registered parse hooks must then be safe
+// for concurrent use. The result and the errors don't depend on the
+// scheduling: the error reported is the one of the first failing file,
+// prefixed with its name.
+func ParseFiles(filenames ...string) (*Config, error) {
+	configs := make([]*Config, len(filenames))
+	errs := make([]error, len(filenames))
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(filenames) {
+		workers = len(filenames)
+	}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				configs[i], errs[i] = ParseFile(filenames[i])
+			}
+		}()
+	}
+	for i := range filenames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	cfg := &Config{Root: map[string]interface{}{}}
+	for i, c := range configs {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("%s: %v", filenames[i], errs[i])
+		}
+		var err error
+		if cfg, err = cfg.Extend(c); err != nil {
+			return nil, fmt.Errorf("%s: %v", filenames[i], err)
+		}
+	}
+	return cfg, nil
+}
+
+// SplitTopLevel writes every top-level key of the config to its own file
+// of the directory, named after the key, like "database.yaml" holding
+// the "database" key. It is the inverse of ParseDir, which merges the
+// files back. The directory is created if needed, and the paths of the
+// written files are returned in lexical order.
+func (cfg *Config) SplitTopLevel(dir string, format Format) ([]string, error) {
+	cfg.auditExport()
+	root, ok := cfg.Root.(map[string]interface{})
+	if !ok {
+		return nil, typeMismatch("map[string]interface{}", cfg.Root)
+	}
+	keys := make([]string, 0, len(root))
+	for key := range root {
+		if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+			return nil, fmt.Errorf("Key %q can't be used as a file name", key)
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(keys))
+	for _, key := range keys {
+		out, err := render(format, map[string]interface{}{key: root[key]})
+		if err != nil {
+			return nil, err
+		}
+		path := filepath.Join(dir, key+"."+format.String())
+		if err := ioutil.WriteFile(path, []byte(out), 0644); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
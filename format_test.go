@@ -0,0 +1,107 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	write := func(name, doc string) {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(doc), 0644)
+		expect(t, err, nil)
+	}
+	write("00-defaults.yaml", "db: {host: localhost, port: 5432}\nlevel: info")
+	write("10-db.json", `{"db": {"host": "db.internal"}}`)
+	write("20-level.yml", "level: debug")
+	write("README", "not: [a config")
+	expect(t, os.Mkdir(filepath.Join(dir, "30-dir.yaml"), 0755), nil)
+
+	cfg, err := ParseDir(dir)
+	expect(t, err, nil)
+	expect(t, cfg.UString("db.host"), "db.internal")
+	expect(t, cfg.UInt("db.port"), 5432)
+	expect(t, cfg.UString("level"), "debug")
+
+	write("15-broken.yaml", "level: [")
+	_, err = ParseDir(dir)
+	expect(t, strings.HasPrefix(err.Error(), filepath.Join(dir, "15-broken.yaml")+": "), true)
+
+	_, err = ParseDir(filepath.Join(dir, "missing"))
+	expect(t, os.IsNotExist(err), true)
+}
+
+func TestParseFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	var filenames []string
+	for i := 0; i < 20; i++ {
+		filename := filepath.Join(dir, fmt.Sprintf("%02d.yaml", i))
+		doc := fmt.Sprintf("last: %d\nfile%d: true", i, i)
+		expect(t, ioutil.WriteFile(filename, []byte(doc), 0644), nil)
+		filenames = append(filenames, filename)
+	}
+	cfg, err := ParseFiles(filenames...)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("last"), 19)
+	expect(t, len(cfg.UMap("")), 21)
+
+	// Reversing the files reverses the priorities.
+	for i, j := 0, len(filenames)-1; i < j; i, j = i+1, j-1 {
+		filenames[i], filenames[j] = filenames[j], filenames[i]
+	}
+	cfg, err = ParseFiles(filenames...)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("last"), 0)
+
+	// The first failing file is reported, whichever fails first.
+	for _, i := range []int{5, 12} {
+		expect(t, ioutil.WriteFile(filenames[i], []byte("last: ["), 0644), nil)
+	}
+	for n := 0; n < 10; n++ {
+		_, err = ParseFiles(filenames...)
+		expect(t, strings.HasPrefix(err.Error(), filenames[5]+": "), true)
+	}
+
+	cfg, err = ParseFiles()
+	expect(t, err, nil)
+	expect(t, cfg.IsEmpty(), true)
+}
+
+func TestSplitTopLevel(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	for _, format := range []Format{FormatYaml, FormatJson, FormatPlist} {
+		out := filepath.Join(dir, format.String())
+		paths, err := cfg.SplitTopLevel(out, format)
+		expect(t, err, nil)
+		expect(t, len(paths), len(cfg.UMap("")))
+		expect(t, paths[0], filepath.Join(out, "config."+format.String()))
+
+		merged, err := ParseDir(out)
+		expect(t, err, nil)
+		expect(t, len(Diff(cfg, merged)), 0)
+	}
+
+	_, err = Must(ParseYaml("../x: 1")).SplitTopLevel(dir, FormatYaml)
+	expect(t, err != nil, true)
+	_, err = Must(ParseYaml("[1]")).SplitTopLevel(dir, FormatYaml)
+	expect(t, err != nil, true)
+}
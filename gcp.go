@@ -0,0 +1,82 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// GCPSecretClient is the subset of a Google Secret Manager client used by
+// GCPSecretSource and ResolveGCPSecretRefs. With
+// cloud.google.com/go/secretmanager/apiv1, it calls AccessSecretVersion
+// and returns the payload data; the client finds Application Default
+// Credentials by itself.
+type GCPSecretClient interface {
+	// AccessSecretVersion returns the payload of a secret version, given
+	// its resource name, like "projects/p/secrets/s/versions/latest".
+	AccessSecretVersion(ctx context.Context, name string) ([]byte, error)
+}
+
+// gcpSecretScheme prefixes the secret references resolved by
+// ResolveGCPSecretRefs.
+const gcpSecretScheme = "gcpsecret://"
+
+// gcpSecretName returns the resource name of a secret version, given
+// either a resource name or a reference like "gcpsecret://project/name",
+// optionally followed by "/version". The version defaults to "latest".
+func gcpSecretName(ref string) (string, error) {
+	if !strings.HasPrefix(ref, gcpSecretScheme) {
+		return ref, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(ref, gcpSecretScheme), "/")
+	if len(parts) == 2 {
+		parts = append(parts, "latest")
+	}
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", fmt.Errorf("Invalid secret reference %q", ref)
+	}
+	return fmt.Sprintf("projects/%s/secrets/%s/versions/%s", parts[0], parts[1], parts[2]), nil
+}
+
+// GCPSecretSource loads a config from a secret holding a YAML or JSON
+// document.
+type GCPSecretSource struct {
+	Client GCPSecretClient
+	// Secret is the resource name of the secret version or a
+	// "gcpsecret://project/name/version" reference.
+	Secret string
+}
+
+// Load reads the secret.
+func (s *GCPSecretSource) Load(ctx context.Context) (*Config, error) {
+	name, err := gcpSecretName(s.Secret)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := s.Client.AccessSecretVersion(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return ParseYamlBytes(payload)
+}
+
+// ResolveGCPSecretRefs replaces the string values of the config written as
+// "gcpsecret://project/name/version" references by the secrets they
+// reference. The version may be omitted for the latest one.
+func ResolveGCPSecretRefs(ctx context.Context, cfg *Config, client GCPSecretClient) error {
+	return resolveRefs(cfg, "secret", func(s string) (string, bool, error) {
+		if !strings.HasPrefix(s, gcpSecretScheme) {
+			return "", false, nil
+		}
+		name, err := gcpSecretName(s)
+		if err != nil {
+			return "", true, err
+		}
+		payload, err := client.AccessSecretVersion(ctx, name)
+		return string(payload), true, err
+	})
+}
@@ -0,0 +1,65 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type fakeSecretManager map[string]string
+
+func (f fakeSecretManager) AccessSecretVersion(ctx context.Context, name string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s, ok := f[name]; ok {
+		return []byte(s), nil
+	}
+	return nil, errors.New("NotFound")
+}
+
+func TestGCPSecretSource(t *testing.T) {
+	client := fakeSecretManager{
+		"projects/p/secrets/app/versions/latest": "db: {user: admin}",
+		"projects/p/secrets/app/versions/2":      "db: {user: old}",
+	}
+	ctx := context.Background()
+	for secret, user := range map[string]string{
+		"gcpsecret://p/app":                      "admin",
+		"gcpsecret://p/app/2":                    "old",
+		"projects/p/secrets/app/versions/latest": "admin",
+	} {
+		cfg, err := (&GCPSecretSource{Client: client, Secret: secret}).Load(ctx)
+		expect(t, err, nil)
+		expect(t, cfg.UString("db.user"), user)
+	}
+
+	_, err := (&GCPSecretSource{Client: client, Secret: "gcpsecret://p"}).Load(ctx)
+	expect(t, err.Error(), `Invalid secret reference "gcpsecret://p"`)
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = (&GCPSecretSource{Client: client, Secret: "gcpsecret://p/app"}).Load(cancelled)
+	expect(t, err, context.Canceled)
+}
+
+func TestResolveGCPSecretRefs(t *testing.T) {
+	client := fakeSecretManager{"projects/p/secrets/db/versions/latest": "s3cret"}
+	cfg, err := ParseYaml(`
+db:
+  password: gcpsecret://p/db
+  hosts: [a, b]
+`)
+	expect(t, err, nil)
+	expect(t, ResolveGCPSecretRefs(context.Background(), cfg, client), nil)
+	expect(t, cfg.UString("db.password"), "s3cret")
+	expect(t, cfg.UString("db.hosts.1"), "b")
+
+	cfg.Set("db.hosts.1", "gcpsecret://p/missing")
+	err = ResolveGCPSecretRefs(context.Background(), cfg, client)
+	expect(t, err.Error(), `Unresolved secret reference at "db.hosts.1": NotFound`)
+}
@@ -0,0 +1,144 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Pinger is implemented by sources which can check that their backend is
+// reachable, for the health checks of managers.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// HealthError lists the problems found by a health check.
+type HealthError struct {
+	Problems []string
+}
+
+func (e *HealthError) Error() string {
+	return "Unhealthy config: " + strings.Join(e.Problems, "; ")
+}
+
+// healthError returns a HealthError listing problems, or nil.
+func healthError(problems []string) error {
+	if len(problems) == 0 {
+		return nil
+	}
+	return &HealthError{Problems: problems}
+}
+
+// Healthz reports whether the config is valid, according to the
+// validators registered with Validator, and whether the command line
+// given to Args was parsed without errors. The checks don't count as
+// reads, see TrackReads and AuditReads.
+func (cfg *Config) Healthz() error {
+	return healthError(cfg.healthProblems())
+}
+
+func (cfg *Config) healthProblems() []string {
+	var problems []string
+	if cfg.lastErr != nil {
+		problems = append(problems, fmt.Sprintf("arguments: %v", cfg.lastErr))
+	}
+	if err := cfg.untracked().Validate(); err != nil {
+		problems = append(problems, fmt.Sprintf("validation: %v", err))
+	}
+	return problems
+}
+
+// untracked returns a view of the config whose reads aren't tracked or
+// audited, for the validators run by every health probe.
+func (cfg *Config) untracked() *Config {
+	v := &Config{
+		Root:       cfg.Root,
+		primary:    cfg.primary,
+		fallbacks:  cfg.fallbacks,
+		prefix:     cfg.prefix,
+		validators: cfg.validators,
+		comments:   cfg.comments,
+		refresh:    cfg.refresh,
+	}
+	v.cow.Store(cfg.state())
+	return v
+}
+
+// LastGoodTime returns the time the current config was loaded.
+func (m *Manager) LastGoodTime() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastGood
+}
+
+// Healthz checks that the source of the manager is reachable, when it is
+// a Pinger, that the current config is healthy and passes the validators
+// of the manager, and that the manager isn't degraded, see
+// SetFailurePolicy. Failed reloads under the KeepLastGood policy don't
+// make the manager unhealthy, since its config is still the last good one.
+func (m *Manager) Healthz(ctx context.Context) error {
+	return healthError(m.healthProblems(ctx))
+}
+
+func (m *Manager) healthProblems(ctx context.Context) []string {
+	var problems []string
+	if p, ok := m.source.(Pinger); ok {
+		if err := p.Ping(ctx); err != nil {
+			problems = append(problems, fmt.Sprintf("source: %v", err))
+		}
+	}
+	cfg := m.Config()
+	problems = append(problems, cfg.healthProblems()...)
+	if err := m.validate(cfg.untracked()); err != nil {
+		problems = append(problems, fmt.Sprintf("validation: %v", err))
+	}
+	m.mu.Lock()
+	if m.degraded {
+		problems = append(problems, fmt.Sprintf("degraded: %v", m.lastErr))
+	}
+	m.mu.Unlock()
+	return problems
+}
+
+// healthStatus is the JSON document served by HealthHandler.
+type healthStatus struct {
+	Status    string    `json:"status"`
+	Revision  int       `json:"revision"`
+	LastLoad  time.Time `json:"last_load"`
+	LastError string    `json:"last_error,omitempty"`
+	Problems  []string  `json:"problems,omitempty"`
+}
+
+// HealthHandler returns an HTTP handler for readiness probes, answering
+// with 200 OK when Healthz succeeds and 503 Service Unavailable otherwise,
+// and a JSON document like
+//
+//	{"status": "ok", "revision": 3, "last_load": "2024-03-01T12:00:00Z"}
+//
+// which lists the problems found, and the error of the last reload if it
+// failed.
+func (m *Manager) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := healthStatus{Status: "ok", Problems: m.healthProblems(r.Context())}
+		m.mu.Lock()
+		status.Revision, status.LastLoad = m.revision, m.lastGood
+		if m.lastErr != nil {
+			status.LastError = m.lastErr.Error()
+		}
+		m.mu.Unlock()
+		code := http.StatusOK
+		if len(status.Problems) > 0 {
+			status.Status, code = "unhealthy", http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(status)
+	})
+}
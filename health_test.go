@@ -0,0 +1,103 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// pingedSource is a source whose backend can be unreachable.
+type pingedSource struct {
+	doc  string
+	down bool
+}
+
+func (s *pingedSource) Load(ctx context.Context) (*Config, error) {
+	if s.down {
+		return nil, errors.New("connection refused")
+	}
+	return ParseYaml(s.doc)
+}
+
+func (s *pingedSource) Ping(ctx context.Context) error {
+	if s.down {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestConfigHealthz(t *testing.T) {
+	cfg := Must(ParseYaml("port: 0"))
+	expect(t, cfg.Healthz(), nil)
+	cfg.Validator("port", func(v interface{}) error {
+		if v == 0 {
+			return errors.New("must be set")
+		}
+		return nil
+	})
+	err := cfg.Healthz()
+	expect(t, len(err.(*HealthError).Problems), 1)
+	expect(t, err.Error()[:len("Unhealthy config: validation: ")], "Unhealthy config: validation: ")
+
+	cfg = Must(ParseYaml("port: 80")).Args("app", "-unknown")
+	expect(t, cfg.Healthz().Error(), "Unhealthy config: arguments: flag provided but not defined: -unknown")
+}
+
+func TestManagerHealthz(t *testing.T) {
+	ctx := context.Background()
+	src := &pingedSource{doc: "port: 80"}
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	m.SetFailurePolicy(MarkDegraded)
+	expect(t, m.Healthz(ctx), nil)
+	expect(t, m.LastGoodTime().IsZero(), false)
+
+	// Probes don't count as reads of the config.
+	audited := 0
+	m.Config().TrackReads().AuditReads(Audit{Paths: []string{"port"}, Func: func(AuditEvent) { audited++ }})
+	m.Validator(func(cfg *Config) error {
+		_, err := cfg.Int("port")
+		return err
+	})
+	expect(t, m.Healthz(ctx), nil)
+	expect(t, audited, 0)
+	expect(t, len(m.Config().UnreadPaths()), 1)
+
+	get := func() (int, healthStatus) {
+		w := httptest.NewRecorder()
+		m.HealthHandler().ServeHTTP(w, httptest.NewRequest("GET", "/readyz", nil))
+		var status healthStatus
+		expect(t, json.Unmarshal(w.Body.Bytes(), &status), nil)
+		expect(t, w.Header().Get("Content-Type"), "application/json")
+		return w.Code, status
+	}
+	code, status := get()
+	expect(t, code, http.StatusOK)
+	expect(t, status.Status, "ok")
+	expect(t, status.Revision, 1)
+	expect(t, len(status.Problems), 0)
+
+	src.down = true
+	m.Reload(ctx)
+	expect(t, m.Healthz(ctx).Error(), "Unhealthy config: source: connection refused; degraded: connection refused")
+	code, status = get()
+	expect(t, code, http.StatusServiceUnavailable)
+	expect(t, status.Status, "unhealthy")
+	expect(t, status.LastError, "connection refused")
+	expect(t, fmt.Sprint(status.Problems), "[source: connection refused degraded: connection refused]")
+
+	src.down = false
+	expect(t, m.Reload(ctx), nil)
+	code, status = get()
+	expect(t, code, http.StatusOK)
+	expect(t, status.Revision, 2)
+	expect(t, status.LastError, "")
+}
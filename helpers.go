@@ -0,0 +1,124 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Dig returns the value found by following the given keys, or nil if any
+// of them is missing, like Sprig's dig. Every key is a single map key or
+// list index, so keys may contain dots.
+//
+//	// "localhost"
+//	host := config.Dig(cfg, "development", "database", "host")
+func Dig(cfg *Config, keys ...string) interface{} {
+	path := ""
+	for _, key := range keys {
+		path = joinPath(path, key)
+	}
+	value, err := cfg.get(path)
+	if err != nil {
+		return nil
+	}
+	return value
+}
+
+// DefaultTo returns def if v is empty, and v otherwise, like Sprig's
+// default. Empty values are nil, false, zero numbers, zero times and empty
+// strings, lists and maps.
+func DefaultTo(v, def interface{}) interface{} {
+	if isEmpty(v) {
+		return def
+	}
+	return v
+}
+
+// Coalesce returns the first non-empty value found at the given dotted
+// paths, like Sprig's coalesce, or nil if there is none. See DefaultTo for
+// what counts as empty.
+func (cfg *Config) Coalesce(paths ...string) interface{} {
+	for _, path := range paths {
+		if value, err := cfg.get(path); err == nil && !isEmpty(value) {
+			return cfg.detached(value)
+		}
+	}
+	return nil
+}
+
+// FirstPath returns the first of the given dotted paths holding a value,
+// even an empty one, unlike Coalesce. It lets renamed keys fall back to
+// their legacy names:
+//
+//	path, ok := cfg.FirstPath("http.port", "port")
+func (cfg *Config) FirstPath(paths ...string) (string, bool) {
+	for _, path := range paths {
+		if _, ok := cfg.getOk(path); ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// FirstString returns a string according to the first of the given dotted
+// paths holding a value, see FirstPath. A value which isn't a string is an
+// error, rather than a reason to try the next path.
+func (cfg *Config) FirstString(paths ...string) (string, error) {
+	for _, path := range paths {
+		if n, ok := cfg.getOk(path); ok {
+			v, err := toString(n)
+			return v, cfg.observeConversion(path, err)
+		}
+	}
+	return "", noneExist(paths)
+}
+
+// UFirstString returns a string according to the first of the given
+// dotted paths holding a value, or "".
+func (cfg *Config) UFirstString(paths ...string) string {
+	v, _ := cfg.FirstString(paths...)
+	return v
+}
+
+// noneExist returns the error of a lookup of several paths failing.
+func noneExist(paths []string) error {
+	return fmt.Errorf("Nonexistent paths: %s", quotePaths(paths))
+}
+
+// quotePaths lists paths quoted, separated by commas.
+func quotePaths(paths []string) string {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = fmt.Sprintf("%q", path)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// isEmpty reports whether a config value is empty in Sprig's sense.
+func isEmpty(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case int:
+		return v == 0
+	case float64:
+		return v == 0
+	case string:
+		return v == ""
+	case []byte:
+		return len(v) == 0
+	case time.Time:
+		return v.IsZero()
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
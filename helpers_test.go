@@ -0,0 +1,81 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestDig(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	expect(t, Dig(cfg, "config", "admin", "1", "username"), "hobbes")
+	expect(t, Dig(cfg, "config", "admin", "2", "username"), nil)
+	expect(t, Dig(cfg, "map", "key0", "foo"), nil)
+
+	cfg, err = ParseYaml(`
+root:
+  field.something: value
+`)
+	expect(t, err, nil)
+	expect(t, Dig(cfg, "root", "field.something"), "value")
+}
+
+func TestDefaultTo(t *testing.T) {
+	for _, empty := range []interface{}{nil, false, 0, 0.0, "", []interface{}{}, map[string]interface{}{}} {
+		expect(t, DefaultTo(empty, "default"), "default")
+	}
+	for _, value := range []interface{}{true, 1, 0.5, "value"} {
+		expect(t, DefaultTo(value, "default"), value)
+	}
+
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	expect(t, DefaultTo(Dig(cfg, "map", "key9"), "fallback"), "fallback")
+}
+
+func TestCoalesce(t *testing.T) {
+	cfg, err := ParseYaml(`
+http:
+  port: 0
+  host: ""
+port: 8080
+host: localhost
+`)
+	expect(t, err, nil)
+	expect(t, cfg.Coalesce("http.port", "port"), 8080)
+	expect(t, cfg.Coalesce("http.host", "http.missing", "host"), "localhost")
+	expect(t, cfg.Coalesce("http.missing"), nil)
+}
+
+func TestFirstString(t *testing.T) {
+	cfg, err := ParseYaml(`
+http:
+  host: ""
+  port: 9090
+port: 8080
+host: localhost
+list: [a]
+`)
+	expect(t, err, nil)
+	path, ok := cfg.FirstPath("http.port", "port")
+	expect(t, path, "http.port")
+	expect(t, ok, true)
+	path, ok = cfg.FirstPath("http.missing", "port")
+	expect(t, path, "port")
+	_, ok = cfg.FirstPath("http.missing")
+	expect(t, ok, false)
+
+	// Empty values resolve, unlike with Coalesce.
+	host, err := cfg.FirstString("http.host", "host")
+	expect(t, err, nil)
+	expect(t, host, "")
+	port, err := cfg.FirstString("http.missing", "port")
+	expect(t, err, nil)
+	expect(t, port, "8080")
+	_, err = cfg.FirstString("list", "host")
+	expect(t, err != nil, true)
+	_, err = cfg.FirstString("a", "b.c")
+	expect(t, err.Error(), `Nonexistent paths: "a", "b.c"`)
+	expect(t, cfg.UFirstString("a", "host"), "localhost")
+}
@@ -0,0 +1,787 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// HOCON ----------------------------------------------------------------------
+//
+// HOCON is the format of Typesafe Config, used by JVM services. It is a
+// superset of JSON with comments, unquoted strings, dotted keys, object
+// merging, includes, substitutions and "+=" appends. Durations and sizes
+// with units, like "10 seconds" or "512M", are kept as strings, which the
+// Duration and Size getters parse.
+
+// hoconMaxIncludes bounds the nesting of includes, to stop include cycles.
+const hoconMaxIncludes = 50
+
+// hoconSubst is a substitution, like ${a.b} or ${?a.b}, resolved once the
+// whole document is parsed.
+type hoconSubst struct {
+	path     string
+	optional bool
+}
+
+// hoconConcat is a value made of several parts, like `${host}":"${port}`
+// or `${base} { extra = 1 }`, concatenated once substitutions are
+// resolved. Parts are the values of objects and arrays, substitutions,
+// hoconText and hoconQuoted strings and hoconSpace whitespace.
+type hoconConcat []interface{}
+
+// hoconText is an unquoted string, and hoconQuoted a quoted one.
+type hoconText string
+type hoconQuoted string
+
+// hoconSpace is the whitespace between the parts of a concatenation, kept
+// in strings and dropped between objects or arrays.
+type hoconSpace string
+
+// ParseHocon reads a HOCON configuration from the given string. Includes
+// are read relative to the working directory, see ParseHoconFile.
+func ParseHocon(cfg string) (*Config, error) {
+	return parseHocon(cfg, "", 0)
+}
+
+// ParseHoconFile reads a HOCON configuration from the given filename.
+// Included files, like `include "common.conf"`, are read relative to the
+// including file. Missing files are skipped, unless wrapped in required().
+// Like in Typesafe Config, substitutions which aren't found in the config
+// are looked up in the environment, and substitutions are resolved against
+// the root of the whole config, including the ones of included files.
+// Self-referential substitutions, other than through "+=", are reported
+// as cycles.
+func ParseHoconFile(filename string) (*Config, error) {
+	cfg, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseHocon(string(cfg), filepath.Dir(filename), 0)
+}
+
+func parseHocon(cfg, dir string, depth int) (*Config, error) {
+	root, err := parseHoconDocument(cfg, dir, depth)
+	if err != nil {
+		return nil, err
+	}
+	r := &hoconResolver{root: root, resolving: map[string]bool{}}
+	v, _, err := r.resolve(root, "")
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(v)
+}
+
+// parseHoconDocument parses a document, without resolving substitutions.
+func parseHoconDocument(cfg, dir string, depth int) (map[string]interface{}, error) {
+	p := &hoconParser{s: cfg, line: 1, dir: dir, depth: depth}
+	p.skip(true)
+	var root map[string]interface{}
+	var err error
+	if p.peek() == '{' {
+		p.pos++
+		root, err = p.object('}')
+	} else {
+		root, err = p.object(0)
+	}
+	if err != nil {
+		return nil, err
+	}
+	p.skip(true)
+	if p.pos < len(p.s) {
+		return nil, p.errorf("unexpected %q after the root object", p.peek())
+	}
+	return root, nil
+}
+
+// hoconParser parses HOCON documents.
+type hoconParser struct {
+	s     string
+	pos   int
+	line  int
+	dir   string
+	depth int
+}
+
+func (p *hoconParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("HOCON line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+func (p *hoconParser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *hoconParser) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(p.s[p.pos:], prefix)
+}
+
+// skip skips whitespace and comments, and newlines when asked to.
+func (p *hoconParser) skip(newlines bool) {
+	for p.pos < len(p.s) {
+		switch c := p.s[p.pos]; {
+		case c == '\n':
+			if !newlines {
+				return
+			}
+			p.line++
+			p.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			p.pos++
+		case c == '#' || p.hasPrefix("//"):
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			if p.hasPrefix("\ufeff") || p.hasPrefix("\u00a0") {
+				_, size := utf8.DecodeRuneInString(p.s[p.pos:])
+				p.pos += size
+				continue
+			}
+			return
+		}
+	}
+}
+
+// object parses the fields of an object, up to the closing brace, or the
+// end of the document for a root without braces.
+func (p *hoconParser) object(closing byte) (map[string]interface{}, error) {
+	obj := map[string]interface{}{}
+	for {
+		p.skip(true)
+		if p.pos >= len(p.s) {
+			if closing != 0 {
+				return nil, p.errorf("missing %q", closing)
+			}
+			return obj, nil
+		}
+		if closing != 0 && p.peek() == closing {
+			p.pos++
+			return obj, nil
+		}
+
+		if ok, err := p.include(obj); ok || err != nil {
+			if err != nil {
+				return nil, err
+			}
+		} else if err := p.field(obj); err != nil {
+			return nil, err
+		}
+
+		p.skip(false)
+		switch c := p.peek(); {
+		case c == ',':
+			p.pos++
+		case c == '\n' || c == 0 || closing != 0 && c == closing:
+		default:
+			return nil, p.errorf("unexpected %q after a field", c)
+		}
+	}
+}
+
+// field parses a field and sets it in obj.
+func (p *hoconParser) field(obj map[string]interface{}) error {
+	keys, err := p.key()
+	if err != nil {
+		return err
+	}
+	p.skip(false)
+	appending := false
+	switch {
+	case p.peek() == '{':
+	case p.hasPrefix("+="):
+		appending = true
+		p.pos += 2
+	case p.peek() == '=' || p.peek() == ':':
+		p.pos++
+	default:
+		return p.errorf("expected '=', ':' or '{' after key %q", strings.Join(keys, "."))
+	}
+	p.skip(false)
+	value, err := p.value()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys[:len(keys)-1] {
+		child, ok := obj[key].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			obj[key] = child
+		}
+		obj = child
+	}
+	key := keys[len(keys)-1]
+	if appending {
+		obj[key] = appendHocon(obj[key], value)
+		return nil
+	}
+	obj[key] = mergeHocon(obj[key], value)
+	return nil
+}
+
+// appendHocon appends a value to the list of a "+=" field.
+func appendHocon(existing, value interface{}) interface{} {
+	item := []interface{}{value}
+	switch existing.(type) {
+	case nil:
+		return item
+	case []interface{}:
+		return append(existing.([]interface{}), value)
+	}
+	return hoconConcat{existing, item}
+}
+
+// mergeHocon returns the value of a field set again: objects are merged,
+// and other values replace the previous ones.
+func mergeHocon(existing, value interface{}) interface{} {
+	old, ok := existing.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for k, v := range m {
+		old[k] = mergeHocon(old[k], v)
+	}
+	return old
+}
+
+// key parses a possibly dotted key, like a.b or "a.b".c.
+func (p *hoconParser) key() ([]string, error) {
+	var keys []string
+	var cur strings.Builder
+	empty := true // the current key part has no characters nor quotes
+	for p.pos < len(p.s) {
+		c := p.peek()
+		if c == '"' {
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			cur.WriteString(s)
+			empty = false
+			continue
+		}
+		if c == '.' {
+			if empty {
+				return nil, p.errorf("empty key part")
+			}
+			keys = append(keys, cur.String())
+			cur.Reset()
+			empty = true
+			p.pos++
+			continue
+		}
+		if isHoconForbidden(c) || c == ' ' || c == '\t' || c == '\n' || c == '\r' || p.hasPrefix("//") {
+			break
+		}
+		cur.WriteByte(c)
+		empty = false
+		p.pos++
+	}
+	if empty {
+		return nil, p.errorf("expected a key, got %q", p.peek())
+	}
+	return append(keys, cur.String()), nil
+}
+
+// isHoconForbidden reports whether c can't appear in unquoted strings.
+func isHoconForbidden(c byte) bool {
+	return strings.IndexByte("$\"{}[]:=,+#`^?!@*&\\", c) >= 0
+}
+
+// include parses an include directive, if any, merging the included
+// object into obj.
+func (p *hoconParser) include(obj map[string]interface{}) (bool, error) {
+	if !p.hasPrefix("include") {
+		return false, nil
+	}
+	rest := strings.TrimLeft(p.s[p.pos+len("include"):], " \t")
+	if !strings.HasPrefix(rest, `"`) && !strings.HasPrefix(rest, "file(") &&
+		!strings.HasPrefix(rest, "required(") && !strings.HasPrefix(rest, "url(") &&
+		!strings.HasPrefix(rest, "classpath(") {
+		return false, nil
+	}
+	p.pos = len(p.s) - len(rest)
+
+	required := false
+	if p.hasPrefix("required(") {
+		required = true
+		p.pos += len("required(")
+		p.skip(false)
+	}
+	wrapped := ""
+	for _, w := range []string{"file(", "url(", "classpath("} {
+		if p.hasPrefix(w) {
+			wrapped = w[:len(w)-1]
+			p.pos += len(w)
+			p.skip(false)
+		}
+	}
+	if wrapped == "url" || wrapped == "classpath" {
+		return true, p.errorf("unsupported include of a %s", wrapped)
+	}
+	if p.peek() != '"' {
+		return true, p.errorf("expected a quoted file name after include")
+	}
+	name, err := p.quoted()
+	if err != nil {
+		return true, err
+	}
+	for _, closing := range []bool{wrapped != "", required} {
+		if !closing {
+			continue
+		}
+		p.skip(false)
+		if p.peek() != ')' {
+			return true, p.errorf("missing ')' in include")
+		}
+		p.pos++
+	}
+
+	if p.depth >= hoconMaxIncludes {
+		return true, p.errorf("too many nested includes, at %q", name)
+	}
+	filename := name
+	if !filepath.IsAbs(filename) {
+		filename = filepath.Join(p.dir, filename)
+	}
+	candidates := []string{filename}
+	if filepath.Ext(filename) == "" {
+		candidates = []string{filename + ".conf", filename + ".json", filename + ".properties"}
+	}
+	for _, candidate := range candidates {
+		data, err := readFile(candidate)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return true, err
+		}
+		var included map[string]interface{}
+		if filepath.Ext(candidate) == ".properties" {
+			cfg, err := ParseProperties(string(data))
+			if err != nil {
+				return true, fmt.Errorf("%s: %v", candidate, err)
+			}
+			included, _ = cfg.Root.(map[string]interface{})
+		} else if included, err = parseHoconDocument(string(data), filepath.Dir(candidate), p.depth+1); err != nil {
+			return true, fmt.Errorf("%s: %v", candidate, err)
+		}
+		mergeHocon(obj, included)
+		return true, nil
+	}
+	if required {
+		return true, p.errorf("missing required include %q", name)
+	}
+	return true, nil
+}
+
+// value parses a value, up to the end of its line or enclosing object or
+// array.
+func (p *hoconParser) value() (interface{}, error) {
+	var parts hoconConcat
+	for p.pos < len(p.s) {
+		c := p.peek()
+		switch {
+		case c == '{':
+			p.pos++
+			obj, err := p.object('}')
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, obj)
+		case c == '[':
+			p.pos++
+			list, err := p.array()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, list)
+		case c == '"':
+			s, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, hoconQuoted(s))
+		case p.hasPrefix("${"):
+			subst, err := p.substitution()
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, subst)
+		case c == ' ' || c == '\t':
+			start := p.pos
+			for p.pos < len(p.s) && (p.s[p.pos] == ' ' || p.s[p.pos] == '\t') {
+				p.pos++
+			}
+			parts = append(parts, hoconSpace(p.s[start:p.pos]))
+		case c == '\n' || c == '\r' || c == ',' || c == '}' || c == ']' || c == '#' || p.hasPrefix("//"):
+			return p.reduce(parts)
+		default:
+			start := p.pos
+			for p.pos < len(p.s) {
+				c := p.s[p.pos]
+				if isHoconForbidden(c) || c == ' ' || c == '\t' || c == '\n' || c == '\r' || p.hasPrefix("//") {
+					break
+				}
+				p.pos++
+			}
+			if p.pos == start {
+				return nil, p.errorf("unexpected %q in a value", c)
+			}
+			parts = append(parts, hoconText(p.s[start:p.pos]))
+		}
+	}
+	return p.reduce(parts)
+}
+
+// reduce returns the value made of the parsed parts.
+func (p *hoconParser) reduce(parts hoconConcat) (interface{}, error) {
+	for len(parts) > 0 {
+		if _, ok := parts[0].(hoconSpace); !ok {
+			break
+		}
+		parts = parts[1:]
+	}
+	for len(parts) > 0 {
+		if _, ok := parts[len(parts)-1].(hoconSpace); !ok {
+			break
+		}
+		parts = parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 0:
+		return nil, p.errorf("missing value")
+	case 1:
+		switch v := parts[0].(type) {
+		case hoconText:
+			return hoconScalar(string(v)), nil
+		case hoconQuoted:
+			return string(v), nil
+		}
+		return parts[0], nil
+	}
+	return parts, nil
+}
+
+// hoconScalar returns the value of an unquoted string standing alone.
+func hoconScalar(s string) interface{} {
+	switch s {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if i, err := strconv.ParseInt(s, 10, 0); err == nil {
+		return int(i)
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.IndexAny(s, "0123456789") >= 0 &&
+		!strings.ContainsAny(s, "xXpPnN_") {
+		return f
+	}
+	return s
+}
+
+// array parses the items of an array, up to the closing bracket.
+func (p *hoconParser) array() ([]interface{}, error) {
+	list := []interface{}{}
+	for {
+		p.skip(true)
+		if p.pos >= len(p.s) {
+			return nil, p.errorf("missing ']'")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return list, nil
+		}
+		item, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, item)
+		p.skip(false)
+		switch c := p.peek(); c {
+		case ',':
+			p.pos++
+		case '\n', ']':
+		default:
+			return nil, p.errorf("unexpected %q after an array item", c)
+		}
+	}
+}
+
+// quoted parses a quoted string, or a triple-quoted one which is raw and
+// spans lines.
+func (p *hoconParser) quoted() (string, error) {
+	if p.hasPrefix(`"""`) {
+		p.pos += 3
+		end := strings.Index(p.s[p.pos:], `"""`)
+		if end < 0 {
+			return "", p.errorf("unterminated triple-quoted string")
+		}
+		// Extra quotes before the closing ones belong to the string.
+		for p.pos+end+3 < len(p.s) && p.s[p.pos+end+3] == '"' {
+			end++
+		}
+		s := p.s[p.pos : p.pos+end]
+		p.line += strings.Count(s, "\n")
+		p.pos += end + 3
+		return s, nil
+	}
+
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.s) {
+		switch p.s[p.pos] {
+		case '\\':
+			p.pos += 2
+			continue
+		case '\n':
+			return "", p.errorf("unterminated string")
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.s[start:p.pos]), &s); err != nil {
+				return "", p.errorf("invalid string %s", p.s[start:p.pos])
+			}
+			return s, nil
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// substitution parses a substitution, like ${a.b} or ${?a.b}.
+func (p *hoconParser) substitution() (hoconSubst, error) {
+	p.pos += 2
+	end := strings.IndexByte(p.s[p.pos:], '}')
+	if end < 0 {
+		return hoconSubst{}, p.errorf("unterminated substitution")
+	}
+	path := strings.TrimSpace(p.s[p.pos : p.pos+end])
+	p.pos += end + 1
+	s := hoconSubst{path: path}
+	if strings.HasPrefix(path, "?") {
+		s.optional, s.path = true, strings.TrimSpace(path[1:])
+	}
+	if s.path == "" {
+		return s, p.errorf("empty substitution")
+	}
+	return s, nil
+}
+
+// hoconResolver resolves the substitutions of a document.
+type hoconResolver struct {
+	root      map[string]interface{}
+	resolving map[string]bool
+	// stack are the substitutions being resolved, in order, to report
+	// cycles.
+	stack []string
+}
+
+// resolve returns the value of a node found at the given path, with its
+// substitutions resolved. Values which only are undefined optional
+// substitutions are reported with false, to drop their fields.
+func (r *hoconResolver) resolve(node interface{}, path string) (interface{}, bool, error) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		// Keys are sorted, for errors not to depend on the map order.
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, ok, err := r.resolve(v[key], joinPath(path, key))
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				delete(v, key)
+				continue
+			}
+			v[key] = value
+		}
+		return v, true, nil
+	case []interface{}:
+		list := v[:0]
+		for i, item := range v {
+			value, ok, err := r.resolve(item, joinPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				list = append(list, value)
+			}
+		}
+		return list, true, nil
+	case hoconSubst:
+		return r.substitute(v)
+	case hoconConcat:
+		return r.concat(v, path)
+	}
+	return node, true, nil
+}
+
+// substitute returns the value of a substitution.
+func (r *hoconResolver) substitute(s hoconSubst) (interface{}, bool, error) {
+	parts, err := parsePath(s.path)
+	if err != nil {
+		return nil, false, err
+	}
+	if r.resolving[s.path] {
+		cycle := r.stack
+		for i, path := range r.stack {
+			if path == s.path {
+				cycle = r.stack[i:]
+				break
+			}
+		}
+		cycle = append(cycle[:len(cycle):len(cycle)], s.path)
+		return nil, false, fmt.Errorf("Cycle in HOCON substitution ${%s}: %s", s.path, strings.Join(cycle, " -> "))
+	}
+	r.resolving[s.path] = true
+	r.stack = append(r.stack, s.path)
+	defer func() {
+		delete(r.resolving, s.path)
+		r.stack = r.stack[:len(r.stack)-1]
+	}()
+
+	var parent map[string]interface{}
+	var node interface{} = r.root
+	found := true
+	for i, part := range parts {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			found = false
+			break
+		}
+		child, ok := m[part]
+		if !ok {
+			found = false
+			break
+		}
+		if _, resolved := child.(map[string]interface{}); !resolved {
+			value, ok, err := r.resolve(child, strings.Join(parts[:i+1], "."))
+			if err != nil {
+				return nil, false, err
+			}
+			if !ok {
+				delete(m, part)
+				found = false
+				break
+			}
+			m[part] = value
+			child = value
+		}
+		parent, node = m, child
+	}
+	if found && parent != nil {
+		value, ok, err := r.resolve(node, s.path)
+		if err != nil || !ok {
+			return nil, ok, err
+		}
+		// Substituted objects and arrays are copied, not shared.
+		value, err = normalizeValue(value)
+		return value, err == nil, err
+	}
+	if value, ok := os.LookupEnv(s.path); ok {
+		return value, true, nil
+	}
+	if s.optional {
+		return nil, false, nil
+	}
+	return nil, false, fmt.Errorf("Unresolved HOCON substitution ${%s}", s.path)
+}
+
+// concat returns the value of a concatenation: objects are merged, arrays
+// appended, and other values joined as strings.
+func (r *hoconResolver) concat(parts hoconConcat, path string) (interface{}, bool, error) {
+	var values []interface{}
+	var objects, lists, strs int
+	for _, part := range parts {
+		switch part.(type) {
+		case hoconSpace:
+			values = append(values, part)
+			continue
+		}
+		v, ok, err := r.resolve(part, path)
+		if err != nil {
+			return nil, false, err
+		}
+		if !ok {
+			continue
+		}
+		switch v.(type) {
+		case map[string]interface{}:
+			objects++
+		case []interface{}:
+			lists++
+		default:
+			strs++
+		}
+		values = append(values, v)
+	}
+
+	switch {
+	case objects+lists+strs == 0:
+		return nil, false, nil
+	case objects > 0 && lists+strs == 0:
+		merged := map[string]interface{}{}
+		for _, v := range values {
+			if m, ok := v.(map[string]interface{}); ok {
+				mergeHocon(merged, m)
+			}
+		}
+		return merged, true, nil
+	case lists > 0 && objects+strs == 0:
+		list := []interface{}{}
+		for _, v := range values {
+			if l, ok := v.([]interface{}); ok {
+				list = append(list, l...)
+			}
+		}
+		return list, true, nil
+	case objects+lists > 0:
+		return nil, false, fmt.Errorf("Can't concatenate objects, arrays and strings at %q", displayPath(path))
+	}
+
+	var b strings.Builder
+	for _, v := range values {
+		switch v := v.(type) {
+		case hoconSpace:
+			b.WriteString(string(v))
+		case hoconText:
+			b.WriteString(string(v))
+		case hoconQuoted:
+			b.WriteString(string(v))
+		case string:
+			b.WriteString(v)
+		case nil:
+			b.WriteString("null")
+		case float64:
+			b.WriteString(strconv.FormatFloat(v, 'g', -1, 64))
+		default:
+			fmt.Fprint(&b, v)
+		}
+	}
+	return strings.TrimSpace(b.String()), true, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var hoconString = `
+# Typesafe Config style
+include "common"
+
+akka {
+  loglevel = INFO
+  actor.provider = cluster
+  remote { port: 2552 }
+}
+akka.remote.host = ${host}
+akka.remote.address = ${host}":"${akka.remote.port}
+host = "10.0.0.1"
+
+timeout = 10 seconds
+buffer = 512M
+// merged objects
+db { url = "jdbc:postgresql://localhost/app", pool = 5 }
+db { pool = 10 }
+db.user = ${?DB_USER}
+db.password = ${?HOCON_TEST_PASSWORD}
+
+servers = [a, "b"]
+servers += c
+defaults { retries = 3 }
+client = ${defaults} { timeout = 5s }
+motd = """multi
+line "quoted" """
+`
+
+func TestParseHocon(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-hocon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "application.conf")
+	ioutil.WriteFile(filename, []byte(hoconString), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "common.conf"), []byte("akka.loglevel = DEBUG\nshared = true"), 0644)
+	os.Setenv("HOCON_TEST_PASSWORD", "secret")
+	defer os.Unsetenv("HOCON_TEST_PASSWORD")
+
+	cfg, err := ParseHoconFile(filename)
+	expect(t, err, nil)
+	expect(t, cfg.UString("akka.loglevel"), "INFO")
+	expect(t, cfg.UBool("shared"), true)
+	expect(t, cfg.UString("akka.actor.provider"), "cluster")
+	expect(t, cfg.UInt("akka.remote.port"), 2552)
+	expect(t, cfg.UString("akka.remote.host"), "10.0.0.1")
+	expect(t, cfg.UString("akka.remote.address"), "10.0.0.1:2552")
+	expect(t, cfg.UDuration("timeout"), 10*time.Second)
+	expect(t, cfg.USize("buffer"), int64(512<<20))
+	expect(t, cfg.UString("db.url"), "jdbc:postgresql://localhost/app")
+	expect(t, cfg.UInt("db.pool"), 10)
+	expect(t, cfg.UString("db.password"), "secret")
+	_, err = cfg.Get("db.user")
+	expect(t, err != nil, true)
+	expect(t, len(cfg.UList("servers")), 3)
+	expect(t, cfg.UString("servers.2"), "c")
+	expect(t, cfg.UInt("client.retries"), 3)
+	expect(t, cfg.UString("client.timeout"), "5s")
+	expect(t, len(cfg.UMap("defaults")), 1)
+	expect(t, cfg.UString("motd"), "multi\nline \"quoted\" ")
+
+	for doc, msg := range map[string]string{
+		"a = ${b}":                         "Unresolved HOCON substitution ${b}",
+		"a = ${b}\nb = ${a}":               "Cycle in HOCON substitution ${b}: b -> a -> b",
+		"a = ${b}\nb = ${c}\nc = ${b}":     "Cycle in HOCON substitution ${b}: b -> c -> b",
+		"a = [1] x":                        `Can't concatenate objects, arrays and strings at "a"`,
+		"a {\n b = 1\n":                    `HOCON line 3: missing '}'`,
+		"a 1":                              `HOCON line 1: expected '=', ':' or '{' after key "a"`,
+		`include required("missing.conf")`: `HOCON line 1: missing required include "missing.conf"`,
+	} {
+		_, err := ParseHocon(doc)
+		if err == nil {
+			t.Errorf("%q: expected error %q", doc, msg)
+			continue
+		}
+		expect(t, err.Error(), msg)
+	}
+
+	// JSON is HOCON.
+	cfg, err = ParseHocon(`{"a": {"b": [1, 2.5, null, true]}}`)
+	expect(t, err, nil)
+	expect(t, cfg.UFloat64("a.b.1"), 2.5)
+}
+
+func TestDurationAndSize(t *testing.T) {
+	cfg := Must(ParseYaml(`
+go: 1m30s
+hocon: 2 days
+fraction: 1.5h
+millis: 250
+bytes: 1024
+kb: 10kB
+kib: 10 KiB
+mega: 1.5 megabytes
+bad: 10 parsecs
+`))
+	expect(t, cfg.UDuration("go"), 90*time.Second)
+	expect(t, cfg.UDuration("hocon"), 48*time.Hour)
+	expect(t, cfg.UDuration("fraction"), 90*time.Minute)
+	expect(t, cfg.UDuration("millis"), 250*time.Millisecond)
+	expect(t, cfg.USize("bytes"), int64(1024))
+	expect(t, cfg.USize("kb"), int64(10000))
+	expect(t, cfg.USize("kib"), int64(10240))
+	expect(t, cfg.USize("mega"), int64(1500000))
+	_, err := cfg.Duration("bad")
+	expect(t, err.Error(), `Invalid duration "10 parsecs": unknown unit "parsecs"`)
+	expect(t, cfg.UDuration("missing", time.Second), time.Second)
+}
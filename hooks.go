@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ParseHook transforms a freshly parsed and normalized tree, e.g. to
+// decrypt values. It returns the tree to use instead.
+type ParseHook func(tree interface{}) (interface{}, error)
+
+// GetHook transforms a value read at a dotted path by the getters, e.g.
+// to convert units or to record the access. It returns the value to use
+// instead.
+type GetHook func(path string, v interface{}) (interface{}, error)
+
+var (
+	hooksMu    sync.Mutex
+	parseHooks atomic.Value // []ParseHook
+	getHooks   atomic.Value // []GetHook
+)
+
+// RegisterParseHook adds a hook run by every Parse* function after the
+// document is normalized. Hooks run in the order they were registered,
+// and an error from any of them fails the parsing.
+func RegisterParseHook(hook ParseHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks, _ := parseHooks.Load().([]ParseHook)
+	parseHooks.Store(append(hooks[:len(hooks):len(hooks)], hook))
+}
+
+// RegisterGetHook adds a hook run on every value read by the getters,
+// like String() or Int(), before the value is converted. Hooks run in the
+// order they were registered, and an error from any of them is returned
+// by the getter. Get() returns subtrees without running the hooks.
+func RegisterGetHook(hook GetHook) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+	hooks, _ := getHooks.Load().([]GetHook)
+	getHooks.Store(append(hooks[:len(hooks):len(hooks)], hook))
+}
+
+// runParseHooks applies the registered parse hooks to a tree.
+func runParseHooks(tree interface{}) (interface{}, error) {
+	hooks, _ := parseHooks.Load().([]ParseHook)
+	for _, hook := range hooks {
+		var err error
+		if tree, err = hook(tree); err != nil {
+			return nil, err
+		}
+	}
+	return tree, nil
+}
+
+// runGetHooks applies the registered get hooks to a value.
+func runGetHooks(path string, v interface{}) (interface{}, error) {
+	hooks, _ := getHooks.Load().([]GetHook)
+	for _, hook := range hooks {
+		var err error
+		if v, err = hook(path, v); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
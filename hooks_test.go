@@ -0,0 +1,90 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// resetHooks removes every registered hook.
+func resetHooks() {
+	parseHooks.Store([]ParseHook(nil))
+	getHooks.Store([]GetHook(nil))
+}
+
+func TestParseHook(t *testing.T) {
+	defer resetHooks()
+	RegisterParseHook(func(tree interface{}) (interface{}, error) {
+		if m, ok := tree.(map[string]interface{}); ok {
+			m["parsed"] = true
+		}
+		return tree, nil
+	})
+	RegisterParseHook(func(tree interface{}) (interface{}, error) {
+		if m, ok := tree.(map[string]interface{}); ok && m["fail"] != nil {
+			return nil, fmt.Errorf("refusing to parse")
+		}
+		return tree, nil
+	})
+
+	cfg, err := ParseYaml("key: value")
+	expect(t, err, nil)
+	expect(t, cfg.UBool("parsed"), true)
+	cfg, err = ParseJson(`{"key": "value"}`)
+	expect(t, err, nil)
+	expect(t, cfg.UBool("parsed"), true)
+
+	_, err = ParseYaml("fail: true")
+	expect(t, err.Error(), "refusing to parse")
+}
+
+func TestGetHook(t *testing.T) {
+	defer resetHooks()
+	reads := []string{}
+	RegisterGetHook(func(path string, v interface{}) (interface{}, error) {
+		reads = append(reads, path)
+		return v, nil
+	})
+	RegisterGetHook(func(path string, v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok || !strings.HasPrefix(s, "rot13:") {
+			return v, nil
+		}
+		if s == "rot13:" {
+			return nil, fmt.Errorf("empty secret at %q", path)
+		}
+		return strings.Map(rot13, strings.TrimPrefix(s, "rot13:")), nil
+	})
+
+	cfg, err := ParseYaml(`
+database:
+  user: calvin
+  password: "rot13:lhxba"
+  empty: "rot13:"
+`)
+	expect(t, err, nil)
+	expect(t, cfg.UString("database.user"), "calvin")
+	expect(t, cfg.UString("database.password"), "yukon")
+	_, err = cfg.String("database.empty")
+	expect(t, err.Error(), `empty secret at "database.empty"`)
+	expect(t, strings.Join(reads, ","), "database.user,database.password,database.empty")
+
+	// Subtrees are returned untouched.
+	db, err := cfg.Get("database")
+	expect(t, err, nil)
+	expect(t, db.Root.(map[string]interface{})["password"], "rot13:lhxba")
+}
+
+func rot13(r rune) rune {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return 'a' + (r-'a'+13)%26
+	case r >= 'A' && r <= 'Z':
+		return 'A' + (r-'A'+13)%26
+	}
+	return r
+}
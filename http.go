@@ -0,0 +1,121 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPSource loads a config from a JSON document served over HTTP, and
+// persists it back with PUT requests.
+//
+// Writes use optimistic concurrency: the ETag of the last loaded or
+// stored document is sent in an If-Match header, and a 412 Precondition
+// Failed response is reported as ErrConflict. Writes fail without an
+// ETag, rather than overwrite the document blindly: documents served
+// without one can be loaded but not persisted, and a store answered
+// without one requires a Load before the next.
+type HTTPSource struct {
+	URL string
+	// Client sends the requests, http.DefaultClient by default.
+	Client *http.Client
+	// Header is added to every request, like for authorization.
+	Header http.Header
+	// Refresh, when positive, makes Watch notify periodically, so that
+	// a Manager reloads the document.
+	Refresh time.Duration
+
+	mu   sync.Mutex
+	etag string
+}
+
+func (s *HTTPSource) client() *http.Client {
+	if s.Client == nil {
+		return http.DefaultClient
+	}
+	return s.Client
+}
+
+// do sends a request and returns the body of a successful response.
+func (s *HTTPSource) do(req *http.Request) ([]byte, string, error) {
+	for key, values := range s.Header {
+		req.Header[key] = values
+	}
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	switch {
+	case resp.StatusCode == http.StatusPreconditionFailed:
+		return nil, "", ErrConflict
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return nil, "", fmt.Errorf("%s %s: %s", req.Method, s.URL, resp.Status)
+	}
+	return body, resp.Header.Get("ETag"), nil
+}
+
+// Load fetches the document.
+func (s *HTTPSource) Load(ctx context.Context) (*Config, error) {
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	body, etag, err := s.do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := parseJson(body, ParseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.etag = etag
+	s.mu.Unlock()
+	return cfg, nil
+}
+
+// Persist renders cfg as JSON and stores it with a PUT request, provided
+// the document wasn't changed since it was last loaded or stored.
+func (s *HTTPSource) Persist(ctx context.Context, cfg *Config) error {
+	doc, err := RenderJson(cfg.Root)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPut, s.URL, strings.NewReader(doc))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.etag == "" {
+		return fmt.Errorf("PUT %s: no ETag to send in If-Match", s.URL)
+	}
+	req.Header.Set("If-Match", s.etag)
+	_, etag, err := s.do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	s.etag = etag
+	return nil
+}
+
+// Watch notifies every Refresh interval.
+func (s *HTTPSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return pollChanges(ctx, s.Refresh), nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// revisionServer serves a document with its revision as ETag, and
+// accepts PUTs matching the current revision.
+type revisionServer struct {
+	mu       sync.Mutex
+	doc      string
+	revision int
+}
+
+func (s *revisionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		if r.Header.Get("If-Match") != s.etag() {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		s.doc = string(body)
+		s.revision++
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("ETag", s.etag())
+	w.Write([]byte(s.doc))
+}
+
+func (s *revisionServer) etag() string {
+	return strconv.Quote(strconv.Itoa(s.revision))
+}
+
+func (s *revisionServer) set(doc string) {
+	s.mu.Lock()
+	s.doc = doc
+	s.revision++
+	s.mu.Unlock()
+}
+
+func TestHTTPSource(t *testing.T) {
+	server := &revisionServer{doc: `{"replicas": 1}`}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+
+	ctx := context.Background()
+	m, err := NewManager(ctx, &HTTPSource{URL: ts.URL + "/"})
+	expect(t, err, nil)
+	expect(t, m.Config().UInt("replicas"), 1)
+
+	err = m.Update(ctx, func(cfg *Config) error {
+		return cfg.Set("replicas", 3)
+	})
+	expect(t, err, nil)
+	expect(t, m.Config().UInt("replicas"), 3)
+	expect(t, server.doc, `{"replicas":3}`)
+	expect(t, server.revision, 1)
+
+	// Another writer changed the document: the update is rejected, and
+	// goes through after a reload.
+	server.set(`{"replicas": 5}`)
+	update := func(cfg *Config) error { return cfg.Set("paused", true) }
+	expect(t, m.Update(ctx, update), ErrConflict)
+	expect(t, m.Config().UBool("paused"), false)
+	expect(t, m.Reload(ctx), nil)
+	expect(t, m.Update(ctx, update), nil)
+	expect(t, server.doc, `{"paused":true,"replicas":5}`)
+
+	expect(t, m.Persist(ctx), nil)
+	expect(t, server.revision, 4)
+
+	_, err = (&HTTPSource{URL: ts.URL + "/missing"}).Load(ctx)
+	expect(t, err != nil, true)
+}
+
+func TestHTTPSourceWithoutETag(t *testing.T) {
+	doc, withETag := `{"replicas": 1}`, true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPut {
+			body, _ := ioutil.ReadAll(r.Body)
+			doc = string(body)
+		}
+		if withETag {
+			w.Header().Set("ETag", `"1"`)
+		}
+		w.Write([]byte(doc))
+	}))
+	defer ts.Close()
+
+	ctx := context.Background()
+	s := &HTTPSource{URL: ts.URL}
+	cfg, err := s.Load(ctx)
+	expect(t, err, nil)
+
+	// A store answered without an ETag requires a load before the next.
+	withETag = false
+	expect(t, cfg.Set("replicas", 2), nil)
+	expect(t, s.Persist(ctx, cfg), nil)
+	expect(t, cfg.Set("replicas", 3), nil)
+	expect(t, s.Persist(ctx, cfg).Error(), "PUT "+ts.URL+": no ETag to send in If-Match")
+	expect(t, doc, `{"replicas":2}`)
+
+	// So are the documents loaded without one.
+	_, err = s.Load(ctx)
+	expect(t, err, nil)
+	expect(t, s.Persist(ctx, cfg) != nil, true)
+	expect(t, doc, `{"replicas":2}`)
+}
+
+func TestManagerPersistWithoutPersister(t *testing.T) {
+	m, err := NewManager(context.Background(), StaticSource{Config: Must(ParseYaml("a: 1"))})
+	expect(t, err, nil)
+	expect(t, m.Persist(context.Background()) != nil, true)
+}
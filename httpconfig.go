@@ -0,0 +1,117 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// HTTPServer returns an *http.Server set up by the section at a dotted
+// path, without a handler:
+//
+//	http:
+//	  addr: ":8080"              # or host and port
+//	  read_timeout: 5s
+//	  read_header_timeout: 2s
+//	  write_timeout: 10s
+//	  idle_timeout: 2m
+//	  max_header_bytes: 64 KiB
+//	  tls: {cert_file: tls.crt, key_file: tls.key}
+//
+// Durations and sizes are read like with Duration and Size, and the tls
+// section like with TLS.
+func (cfg *Config) HTTPServer(path string) (*http.Server, error) {
+	s, err := cfg.newSection(path, "HTTP server")
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{}
+	var host string
+	var port int
+	s.string("addr", &srv.Addr)
+	s.string("host", &host)
+	s.int("port", &port)
+	s.duration("read_timeout", &srv.ReadTimeout)
+	s.duration("read_header_timeout", &srv.ReadHeaderTimeout)
+	s.duration("write_timeout", &srv.WriteTimeout)
+	s.duration("idle_timeout", &srv.IdleTimeout)
+	var maxHeaderBytes int64
+	s.size("max_header_bytes", &maxHeaderBytes)
+	if s.err != nil {
+		return nil, s.err
+	}
+	srv.MaxHeaderBytes = int(maxHeaderBytes)
+	if srv.Addr == "" && (host != "" || port != 0) {
+		srv.Addr = net.JoinHostPort(host, strconv.Itoa(port))
+	}
+	if s.has("tls") {
+		if srv.TLSConfig, err = cfg.TLS(joinPath(s.path, "tls")); err != nil {
+			return nil, err
+		}
+	}
+	return srv, nil
+}
+
+// HTTPClient returns an *http.Client set up by the section at a dotted
+// path, with a transport based on http.DefaultTransport:
+//
+//	client:
+//	  timeout: 30s                 # of whole requests
+//	  dial_timeout: 5s
+//	  tls_handshake_timeout: 5s
+//	  response_header_timeout: 10s
+//	  idle_conn_timeout: 90s
+//	  max_idle_conns: 100
+//	  max_idle_conns_per_host: 10
+//	  proxy: http://proxy.internal:3128   # or "environment"
+//	  tls: {ca_file: ca.crt}
+//
+// Without a proxy key, no proxy is used.
+func (cfg *Config) HTTPClient(path string) (*http.Client, error) {
+	s, err := cfg.newSection(path, "HTTP client")
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = nil
+	c := &http.Client{Transport: t}
+	dialer := &net.Dialer{}
+	var proxy string
+	s.duration("timeout", &c.Timeout)
+	s.duration("dial_timeout", &dialer.Timeout)
+	s.duration("tls_handshake_timeout", &t.TLSHandshakeTimeout)
+	s.duration("response_header_timeout", &t.ResponseHeaderTimeout)
+	s.duration("idle_conn_timeout", &t.IdleConnTimeout)
+	s.int("max_idle_conns", &t.MaxIdleConns)
+	s.int("max_idle_conns_per_host", &t.MaxIdleConnsPerHost)
+	s.string("proxy", &proxy)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if dialer.Timeout > 0 {
+		t.DialContext = dialer.DialContext
+	}
+	switch proxy {
+	case "":
+	case "environment":
+		t.Proxy = http.ProxyFromEnvironment
+	default:
+		u, err := url.Parse(proxy)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, s.invalid("proxy", fmt.Errorf("invalid URL %q", proxy))
+		}
+		t.Proxy = http.ProxyURL(u)
+	}
+	if s.has("tls") {
+		if t.TLSClientConfig, err = cfg.TLS(joinPath(s.path, "tls")); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
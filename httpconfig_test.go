@@ -0,0 +1,75 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestHTTPServer(t *testing.T) {
+	cfg := Must(ParseYaml(`
+http:
+  addr: ":8080"
+  read_timeout: 5s
+  read_header_timeout: 2000
+  write_timeout: 10 seconds
+  idle_timeout: 2m
+  max_header_bytes: 64 KiB
+hostport: {host: localhost, port: 9090}
+bad: {write_timeout: soon}
+`))
+	srv, err := cfg.HTTPServer("http")
+	expect(t, err, nil)
+	expect(t, srv.Addr, ":8080")
+	expect(t, srv.ReadTimeout, 5*time.Second)
+	expect(t, srv.ReadHeaderTimeout, 2*time.Second)
+	expect(t, srv.WriteTimeout, 10*time.Second)
+	expect(t, srv.IdleTimeout, 2*time.Minute)
+	expect(t, srv.MaxHeaderBytes, 64<<10)
+	expect(t, srv.TLSConfig == nil, true)
+
+	srv, err = cfg.HTTPServer("hostport")
+	expect(t, err, nil)
+	expect(t, srv.Addr, "localhost:9090")
+
+	_, err = cfg.HTTPServer("bad")
+	expect(t, err.Error(), `Invalid HTTP server config at "bad.write_timeout": Invalid duration "soon"`)
+}
+
+func TestHTTPClient(t *testing.T) {
+	cfg := Must(ParseYaml(`
+client:
+  timeout: 30s
+  dial_timeout: 5s
+  max_idle_conns_per_host: 10
+  proxy: http://proxy.internal:3128
+  tls: {server_name: api.example.com}
+env: {proxy: environment}
+direct: {}
+bad: {proxy: "proxy.internal"}
+`))
+	c, err := cfg.HTTPClient("client")
+	expect(t, err, nil)
+	expect(t, c.Timeout, 30*time.Second)
+	tr := c.Transport.(*http.Transport)
+	expect(t, tr.MaxIdleConnsPerHost, 10)
+	expect(t, tr.TLSClientConfig.ServerName, "api.example.com")
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	proxy, err := tr.Proxy(req)
+	expect(t, err, nil)
+	expect(t, proxy.String(), "http://proxy.internal:3128")
+
+	c, err = cfg.HTTPClient("env")
+	expect(t, err, nil)
+	expect(t, c.Transport.(*http.Transport).Proxy != nil, true)
+	c, err = cfg.HTTPClient("direct")
+	expect(t, err, nil)
+	expect(t, c.Transport.(*http.Transport).Proxy == nil, true)
+
+	_, err = cfg.HTTPClient("bad")
+	expect(t, err.Error(), `Invalid HTTP client config at "bad.proxy": invalid URL "proxy.internal"`)
+}
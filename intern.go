@@ -0,0 +1,47 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+// InternStrings makes the equal strings of a tree share their memory, map
+// keys included, for configs repeating the same values many times, like
+// the hostnames of large inventories. It is a ParseHook, so registering
+// it interns the strings of every parsed document:
+//
+//	config.RegisterParseHook(config.InternStrings)
+//
+// Maps are rebuilt with the interned keys, while lists are changed in
+// place. Strings are only shared within a tree, so nothing is retained
+// between documents.
+func InternStrings(tree interface{}) (interface{}, error) {
+	in := interner{}
+	return in.intern(tree), nil
+}
+
+// interner holds the first boxed value of every string, so that equal
+// strings share both their bytes and their interface{} box.
+type interner map[string]interface{}
+
+func (in interner) intern(node interface{}) interface{} {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(c))
+		for k, v := range c {
+			key := in.intern(k).(string)
+			m[key] = in.intern(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range c {
+			c[i] = in.intern(v)
+		}
+		return c
+	case string:
+		if v, ok := in[c]; ok {
+			return v
+		}
+		in[c] = node
+	}
+	return node
+}
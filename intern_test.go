@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+// boxOf returns the data word of an interface value.
+func boxOf(v interface{}) uintptr {
+	return (*[2]uintptr)(unsafe.Pointer(&v))[1]
+}
+
+// dataOf returns the address of the bytes of a string.
+func dataOf(s string) uintptr {
+	return (*[2]uintptr)(unsafe.Pointer(&s))[0]
+}
+
+func TestInternStrings(t *testing.T) {
+	doc := `
+hosts:
+  - {name: web, dc: eu-west-1}
+  - {name: api, dc: eu-west-1}
+  - [eu-west-1, other]
+`
+	cfg := Must(ParseYaml(doc))
+	want := Must(ParseYaml(doc))
+	root, err := InternStrings(cfg.Root)
+	expect(t, err, nil)
+	if !reflect.DeepEqual(root, want.Root) {
+		t.Fatalf("InternStrings changed the tree: %v", root)
+	}
+
+	cfg = &Config{Root: root}
+	a, _ := Get(cfg.Root, "hosts.0.dc")
+	b, _ := Get(cfg.Root, "hosts.1.dc")
+	c, _ := Get(cfg.Root, "hosts.2.0")
+	expect(t, boxOf(a), boxOf(b))
+	expect(t, boxOf(a), boxOf(c))
+	d, _ := Get(cfg.Root, "hosts.2.1")
+	expect(t, boxOf(a) != boxOf(d), true)
+
+	// Keys share the memory of the equal values.
+	root, _ = InternStrings(Must(ParseYaml("{name: name}")).Root)
+	for k, v := range root.(map[string]interface{}) {
+		expect(t, dataOf(k), dataOf(v.(string)))
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// interpolation is a string value with references, split into the text
+// between them and the paths they reference.
+type interpolation struct {
+	text []string // one more than refs
+	refs []string
+}
+
+// parseInterpolation splits a string into its text and ${path} references.
+// "$${" stands for a literal "${".
+func parseInterpolation(s string) (*interpolation, error) {
+	in := &interpolation{}
+	var text strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			text.WriteString(s)
+			break
+		}
+		if i > 0 && s[i-1] == '$' {
+			text.WriteString(s[:i-1] + "${")
+			s = s[i+2:]
+			continue
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated reference in %q", s)
+		}
+		ref := strings.TrimSpace(s[i+2 : i+end])
+		if ref == "" {
+			return nil, fmt.Errorf("empty reference")
+		}
+		text.WriteString(s[:i])
+		in.text = append(in.text, text.String())
+		in.refs = append(in.refs, canonicalPath("", ref))
+		text.Reset()
+		s = s[i+end+1:]
+	}
+	in.text = append(in.text, text.String())
+	return in, nil
+}
+
+// Interpolate replaces the ${path} references in the string values of the
+// config by the values at these paths, like in HOCON:
+//
+//	host: db.internal
+//	url: "postgres://${host}:5432/app"
+//	replica: ${database}
+//
+// A value which only is a reference takes the referenced value as is, even
+// a map or a list, while references within strings are replaced by their
+// values as strings. "$${" stands for a literal "${". References can
+// reference values with references, in any order, but not themselves:
+// cycles are reported with all their paths, like
+//
+//	Reference cycle: "a" -> "b" -> "a"
+func (cfg *Config) Interpolate() error {
+	values := map[string]*interpolation{}
+	var paths []string
+	var err error
+	walkLeaves(cfg.Root, "", func(path string, v interface{}) {
+		s, ok := v.(string)
+		if !ok || err != nil || !strings.Contains(s, "${") {
+			return
+		}
+		in, perr := parseInterpolation(s)
+		if perr != nil {
+			err = fmt.Errorf("Invalid reference at %q: %v", displayPath(path), perr)
+			return
+		}
+		if len(in.refs) > 0 || in.text[0] != s {
+			values[path] = in
+			paths = append(paths, path)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// The graph of references links every value to the values with
+	// references found at, under or above the paths it references, which
+	// must be resolved first.
+	deps := make(map[string][]string, len(paths))
+	for _, path := range paths {
+		for _, ref := range values[path].refs {
+			for _, other := range paths {
+				if pathWithin(other, ref) || pathWithin(ref, other) {
+					deps[path] = append(deps[path], other)
+				}
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[string]int{}
+	var stack []string
+	var visit func(path string) error
+	visit = func(path string) error {
+		switch state[path] {
+		case done:
+			return nil
+		case visiting:
+			cycle := stack
+			for i, p := range stack {
+				if p == path {
+					cycle = stack[i:]
+					break
+				}
+			}
+			cycle = append(cycle[:len(cycle):len(cycle)], path)
+			return fmt.Errorf("Reference cycle: %s", strings.Join(quoteDisplayPaths(cycle), " -> "))
+		}
+		state[path] = visiting
+		stack = append(stack, path)
+		for _, dep := range deps[path] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[path] = done
+		return cfg.interpolate(path, values[path])
+	}
+	for _, path := range paths {
+		if err := visit(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// interpolate replaces the references of the value at a path, whose
+// references are resolved.
+func (cfg *Config) interpolate(path string, in *interpolation) error {
+	var value interface{}
+	if len(in.refs) == 1 && in.text[0] == "" && in.text[1] == "" {
+		n, err := cfg.lookup(in.refs[0])
+		if err != nil {
+			return fmt.Errorf("Unresolved reference ${%s} at %q", in.refs[0], displayPath(path))
+		}
+		// Referenced maps and lists are copied, not shared.
+		if value, err = normalizeValue(n); err != nil {
+			return err
+		}
+	} else {
+		var b strings.Builder
+		for i, ref := range in.refs {
+			b.WriteString(in.text[i])
+			n, err := cfg.lookup(ref)
+			if err != nil {
+				return fmt.Errorf("Unresolved reference ${%s} at %q", ref, displayPath(path))
+			}
+			s, err := toString(n)
+			if err != nil {
+				return fmt.Errorf("Invalid reference ${%s} at %q: %v", ref, displayPath(path), err)
+			}
+			b.WriteString(s)
+		}
+		b.WriteString(in.text[len(in.refs)])
+		value = b.String()
+	}
+	return cfg.Set(path, value)
+}
+
+// quoteDisplayPaths returns the quoted printable forms of paths.
+func quoteDisplayPaths(paths []string) []string {
+	quoted := make([]string, len(paths))
+	for i, path := range paths {
+		quoted[i] = fmt.Sprintf("%q", displayPath(path))
+	}
+	return quoted
+}
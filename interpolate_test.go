@@ -0,0 +1,50 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	cfg := Must(ParseYaml(`
+url: "postgres://${db.host}:${db.port}/${db.name}"
+db:
+  host: ${hosts[0]}
+  port: 5432
+  name: app
+hosts: [db.internal, "${db.name}.example.com"]
+replica: ${db}
+literal: "$${not.a.ref}"
+`))
+	expect(t, cfg.Interpolate(), nil)
+	expect(t, cfg.UString("url"), "postgres://db.internal:5432/app")
+	expect(t, cfg.UString("hosts.1"), "app.example.com")
+	expect(t, cfg.UString("literal"), "${not.a.ref}")
+	expect(t, cfg.UInt("replica.port"), 5432)
+	if !reflect.DeepEqual(cfg.UMap("replica"), cfg.UMap("db")) {
+		t.Errorf("Expected replica to be a copy of db, got %v", cfg.UMap("replica"))
+	}
+	cfg.Set("replica.port", 5433)
+	expect(t, cfg.UInt("db.port"), 5432)
+
+	// References into values which are references themselves.
+	cfg = Must(ParseYaml("a: ${replica.host}\nreplica: ${database}\ndatabase: {host: h}"))
+	expect(t, cfg.Interpolate(), nil)
+	expect(t, cfg.UString("a"), "h")
+
+	for doc, msg := range map[string]string{
+		"a: ${b}\nb: ${a}":                      `Reference cycle: "a" -> "b" -> "a"`,
+		"a: x${b.c}\nb: {c: \"${d}\"}\nd: ${a}": `Reference cycle: "a" -> "b.c" -> "d" -> "a"`,
+		"a: {b: \"${a}\"}":                      `Reference cycle: "a.b" -> "a.b"`,
+		"a: ${missing}":                         `Unresolved reference ${missing} at "a"`,
+		"a: x${b}\nb: [1]":                      `Invalid reference ${b} at "a": Type mismatch: expected bool, float64, int or string; got []interface {}`,
+		"a: ${b":                                `Invalid reference at "a": unterminated reference in "${b"`,
+	} {
+		err := Must(ParseYaml(doc)).Interpolate()
+		expect(t, err.Error(), msg)
+	}
+}
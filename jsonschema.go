@@ -0,0 +1,209 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// JSON Schema ----------------------------------------------------------------
+//
+// Schemas convert from and to JSON Schema documents, so that editors like
+// VS Code, with the YAML extension, complete and check config files with
+// the schema enforced by the program.
+
+// jsonSchemaTypes maps the types of schemas to the JSON Schema ones.
+var jsonSchemaTypes = map[string]string{
+	"string": "string",
+	"int":    "integer",
+	"float":  "number",
+	"bool":   "boolean",
+	"time":   "string",
+	"list":   "array",
+	"map":    "object",
+}
+
+// JsonSchema returns the schema as a JSON Schema (draft-07) document, to
+// be rendered with RenderJson. Deprecation messages are exported as
+// "deprecationMessage", which editors show on deprecated keys.
+func (s *Schema) JsonSchema() map[string]interface{} {
+	doc := s.jsonSchema()
+	doc["$schema"] = "http://json-schema.org/draft-07/schema#"
+	return doc
+}
+
+func (s *Schema) jsonSchema() map[string]interface{} {
+	doc := map[string]interface{}{}
+	if t, ok := jsonSchemaTypes[s.Type]; ok {
+		doc["type"] = t
+	}
+	if s.Type == "time" {
+		doc["format"] = "date-time"
+	}
+	if s.Default != nil {
+		doc["default"] = s.Default
+	}
+	if len(s.Enum) > 0 {
+		doc["enum"] = s.Enum
+	}
+	if s.Description != "" {
+		doc["description"] = s.Description
+	}
+	if s.Deprecated != "" {
+		doc["deprecated"] = true
+		doc["deprecationMessage"] = s.Deprecated
+	}
+	if len(s.Keys) > 0 {
+		props := map[string]interface{}{}
+		var required []interface{}
+		names := make([]string, 0, len(s.Keys))
+		for name := range s.Keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			props[name] = s.Keys[name].jsonSchema()
+			if s.Keys[name].Required {
+				required = append(required, name)
+			}
+		}
+		doc["properties"] = props
+		if len(required) > 0 {
+			doc["required"] = required
+		}
+	}
+	if s.Items != nil {
+		doc["items"] = s.Items.jsonSchema()
+	}
+	return doc
+}
+
+// ParseJsonSchema reads a schema from a JSON Schema document. The
+// keywords having a counterpart in Schema are supported: type, format
+// "date-time", properties, required, items, default, enum, description
+// and deprecated. Annotations like title are ignored, and keywords
+// changing what is valid, like $ref or oneOf, are rejected.
+func ParseJsonSchema(cfg *Config) (*Schema, error) {
+	return parseJsonSchema(cfg.Root, "")
+}
+
+// parseJsonSchema reads a JSON Schema node found at the given path.
+func parseJsonSchema(node interface{}, path string) (*Schema, error) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid JSON Schema at %q: %v",
+			displayPath(path), typeMismatch("map[string]interface{}", node))
+	}
+	invalid := func(key string) error {
+		return fmt.Errorf("Invalid JSON Schema at %q: bad value for %q: %#v",
+			displayPath(path), key, m[key])
+	}
+
+	s := &Schema{}
+	var required []interface{}
+	for k, v := range m {
+		var ok = true
+		switch k {
+		case "type":
+			// Nullable values are written like ["string", "null"].
+			types, isList := v.([]interface{})
+			if !isList {
+				types = []interface{}{v}
+			}
+			for _, t := range types {
+				var name string
+				if name, ok = t.(string); !ok {
+					break
+				}
+				if name == "null" {
+					continue
+				}
+				if s.Type, ok = schemaTypeOf(name); ok {
+					break
+				}
+			}
+		case "format":
+			_, ok = v.(string)
+		case "default":
+			s.Default = v
+		case "enum":
+			s.Enum, ok = v.([]interface{})
+		case "description":
+			s.Description, ok = v.(string)
+		case "deprecated":
+			var deprecated bool
+			if deprecated, ok = v.(bool); deprecated && s.Deprecated == "" {
+				s.Deprecated = "deprecated"
+			}
+		case "deprecationMessage":
+			s.Deprecated, ok = v.(string)
+		case "required":
+			required, ok = v.([]interface{})
+		case "properties":
+			var props map[string]interface{}
+			if props, ok = v.(map[string]interface{}); ok {
+				s.Keys = make(map[string]*Schema, len(props))
+				for name, item := range props {
+					sub, err := parseJsonSchema(item, joinPath(path, name))
+					if err != nil {
+						return nil, err
+					}
+					s.Keys[name] = sub
+				}
+			}
+		case "items":
+			sub, err := parseJsonSchema(v, joinPath(path, "items"))
+			if err != nil {
+				return nil, err
+			}
+			s.Items = sub
+		case "$ref", "allOf", "anyOf", "oneOf", "not", "if", "then", "else",
+			"patternProperties", "additionalItems", "dependencies":
+			return nil, fmt.Errorf("Invalid JSON Schema at %q: unsupported keyword %q",
+				displayPath(path), k)
+		}
+		if !ok {
+			return nil, invalid(k)
+		}
+	}
+
+	if s.Type == "string" && m["format"] == "date-time" {
+		s.Type = "time"
+	}
+	for _, name := range required {
+		key, ok := name.(string)
+		if !ok {
+			return nil, invalid("required")
+		}
+		if s.Keys == nil {
+			s.Keys = map[string]*Schema{}
+		}
+		if s.Keys[key] == nil {
+			s.Keys[key] = &Schema{}
+		}
+		s.Keys[key].Required = true
+	}
+	return s, nil
+}
+
+// schemaTypeOf returns the schema type of a JSON Schema type.
+func schemaTypeOf(name string) (string, bool) {
+	switch name {
+	case "integer":
+		return "int", true
+	case "number":
+		return "float", true
+	case "boolean":
+		return "bool", true
+	case "array":
+		return "list", true
+	case "object":
+		return "map", true
+	case "string":
+		return "string", true
+	}
+	return "", false
+}
@@ -0,0 +1,62 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestJsonSchema(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(schemaString)))
+	expect(t, err, nil)
+	doc, err := RenderJson(schema.Keys["database"].JsonSchema())
+	expect(t, err, nil)
+	expect(t, doc, `{"$schema":"http://json-schema.org/draft-07/schema#",`+
+		`"properties":{"host":{"type":"string"},"mode":{"enum":["disable","require"],"type":"string"},`+
+		`"port":{"default":5432,"type":"integer"}},"required":["host"],"type":"object"}`)
+
+	// The exported schema reads back to an equivalent one.
+	doc, err = RenderJson(schema.JsonSchema())
+	expect(t, err, nil)
+	imported, err := ParseJsonSchema(Must(ParseJson(doc)))
+	expect(t, err, nil)
+	cfg := Must(ParseYaml(`
+database: {port: many, mode: verify-full}
+servers: [{weight: 1}]
+debug: maybe
+`))
+	expect(t, imported.Validate(cfg).Error(), schema.Validate(cfg).Error())
+}
+
+func TestParseJsonSchema(t *testing.T) {
+	schema, err := ParseJsonSchema(Must(ParseJson(`{
+		"title": "App",
+		"type": "object",
+		"required": ["started"],
+		"properties": {
+			"started": {"type": "string", "format": "date-time"},
+			"name": {"type": ["string", "null"], "deprecated": true, "deprecationMessage": "use id"},
+			"ports": {"type": "array", "items": {"type": "integer"}}
+		}
+	}`)))
+	expect(t, err, nil)
+	expect(t, schema.Keys["started"].Type, "time")
+	expect(t, schema.Keys["started"].Required, true)
+	expect(t, schema.Keys["name"].Type, "string")
+	expect(t, schema.Keys["name"].Deprecated, "use id")
+	expect(t, schema.Keys["ports"].Items.Type, "int")
+
+	for source, msg := range map[string]string{
+		`{"type": "tuple"}`:                      `Invalid JSON Schema at ".": bad value for "type": "tuple"`,
+		`{"properties": {"a": {"$ref": "#/b"}}}`: `Invalid JSON Schema at "a": unsupported keyword "$ref"`,
+		`{"items": true}`:                        `Invalid JSON Schema at "items": Type mismatch: expected map[string]interface{}; got bool`,
+		`{"required": "a"}`:                      `Invalid JSON Schema at ".": bad value for "required": "a"`,
+	} {
+		_, err := ParseJsonSchema(Must(ParseJson(source)))
+		if err == nil {
+			t.Errorf("%q: expected error", source)
+			continue
+		}
+		expect(t, err.Error(), msg)
+	}
+}
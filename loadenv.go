@@ -0,0 +1,96 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// EnvLayer describes a layer merged by LoadEnv.
+type EnvLayer struct {
+	// Source is the file of the layer, or "env" for the environment
+	// variables.
+	Source string
+	// Paths are the sorted paths of the values the layer sets.
+	Paths []string
+}
+
+// LoadEnv loads the config of an environment from a directory, following
+// the convention of Rails and node-config:
+//
+//	default.yaml      the values shared by every environment
+//	<env>.yaml        the values of the environment, like production.yaml
+//	local.yaml        the overrides of the machine, when present
+//
+// Each file overrides the previous ones, and may be a ".yml" or ".json"
+// file instead. Finally the environment variables override the existing
+// keys, with the naming of Env. LoadEnv returns the merged config and its
+// layers, in increasing priority, to tell where the values come from.
+func LoadEnv(dir, env string) (*Config, []EnvLayer, error) {
+	if env == "" || strings.ContainsAny(env, `/\`) {
+		return nil, nil, fmt.Errorf("Invalid environment %q", env)
+	}
+	cfg := &Config{Root: map[string]interface{}{}}
+	layers := []EnvLayer{}
+	for _, name := range []string{"default", env, "local"} {
+		filename, err := findEnvFile(dir, name)
+		if os.IsNotExist(err) && name == "local" {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		c, err := ParseFile(filename)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		if cfg, err = cfg.Extend(c); err != nil {
+			return nil, nil, fmt.Errorf("%s: %v", filename, err)
+		}
+		layer := EnvLayer{Source: filename, Paths: []string{}}
+		walkLeaves(c.Root, "", func(path string, _ interface{}) {
+			layer.Paths = append(layer.Paths, path)
+		})
+		layers = append(layers, layer)
+	}
+
+	found := map[string]bool{}
+	cfg.envLookup("", func(name string) (string, bool) {
+		v, ok := syscall.Getenv(name)
+		found[name] = ok
+		return v, ok
+	})
+	layer := EnvLayer{Source: "env", Paths: []string{}}
+	walkLeaves(cfg.Root, "", func(path string, _ interface{}) {
+		parts, _ := parsePath(path)
+		if found[strings.ToUpper(strings.Join(parts, "_"))] {
+			layer.Paths = append(layer.Paths, path)
+		}
+	})
+	if len(layer.Paths) > 0 {
+		layers = append(layers, layer)
+	}
+	return cfg, layers, nil
+}
+
+// findEnvFile returns the config file of a directory with the given base
+// name, trying the ".yaml", ".yml" and ".json" extensions in turn.
+func findEnvFile(dir, name string) (string, error) {
+	var err error
+	for _, ext := range []string{".yaml", ".yml", ".json"} {
+		filename := filepath.Join(dir, name+ext)
+		if _, err = os.Stat(filename); err == nil {
+			return filename, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+	}
+	return "", err
+}
@@ -0,0 +1,61 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+	write := func(name, doc string) {
+		expect(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(doc), 0644), nil)
+	}
+	write("default.yaml", "db: {host: localhost, port: 5432}\nlog: {level: info}\nloadenvtest: {token: none}")
+	write("production.json", `{"db": {"host": "db.internal"}, "log": {"level": "warn"}}`)
+	write("staging.yml", "db: {host: db.staging}")
+
+	defer os.Unsetenv("LOADENVTEST_TOKEN")
+	os.Setenv("LOADENVTEST_TOKEN", "secret")
+
+	cfg, layers, err := LoadEnv(dir, "production")
+	expect(t, err, nil)
+	expect(t, cfg.UString("db.host"), "db.internal")
+	expect(t, cfg.UInt("db.port"), 5432)
+	expect(t, cfg.UString("log.level"), "warn")
+	expect(t, cfg.UString("loadenvtest.token"), "secret")
+	want := []EnvLayer{
+		{filepath.Join(dir, "default.yaml"), []string{"db.host", "db.port", "loadenvtest.token", "log.level"}},
+		{filepath.Join(dir, "production.json"), []string{"db.host", "log.level"}},
+		{"env", []string{"loadenvtest.token"}},
+	}
+	if !reflect.DeepEqual(layers, want) {
+		t.Errorf("layers: got %v; want %v", layers, want)
+	}
+
+	write("local.yaml", "log: {level: debug}")
+	cfg, layers, err = LoadEnv(dir, "staging")
+	expect(t, err, nil)
+	expect(t, cfg.UString("db.host"), "db.staging")
+	expect(t, cfg.UString("log.level"), "debug")
+	expect(t, len(layers), 4)
+	expect(t, layers[2].Source, filepath.Join(dir, "local.yaml"))
+
+	// The file of the environment is required, unlike local.yaml.
+	_, _, err = LoadEnv(dir, "prodution")
+	expect(t, os.IsNotExist(err), true)
+	_, _, err = LoadEnv(dir, "../production")
+	expect(t, err != nil, true)
+
+	write("production.json", `{"db": `)
+	_, _, err = LoadEnv(dir, "production")
+	expect(t, err != nil, true)
+}
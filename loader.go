@@ -0,0 +1,234 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Loader merges the configs of several sources, or layers, with Extend:
+// the values of each layer override the ones of the previous layers.
+// A Loader is itself a Source, and a Watcher notifying of the changes of
+// any of its layers which is a Watcher, so it can be given to a Manager:
+//
+//	loader := config.NewLoader(defaults, &config.SSMSource{...})
+//	manager, err := config.NewManager(ctx, loader)
+type Loader struct {
+	layers []Source
+
+	schema   *Schema
+	prompter Prompter
+
+	// mu guards the layers merged by the last Load: merged[i] holds the
+	// layers up to i, whose contents hashed to hashes[i].
+	mu     sync.Mutex
+	hashes [][sha256.Size]byte
+	merged []*Config
+}
+
+// NewLoader returns a loader of the given layers, in increasing priority.
+func NewLoader(layers ...Source) *Loader {
+	return &Loader{layers: layers}
+}
+
+// Add appends a layer, overriding the previous ones.
+func (l *Loader) Add(layer Source) *Loader {
+	l.layers = append(l.layers, layer)
+	return l
+}
+
+// Prompt makes Load ask p for the required values of the schema which are
+// missing from the merged config, see Schema.PromptMissing.
+func (l *Loader) Prompt(schema *Schema, p Prompter) *Loader {
+	l.schema, l.prompter = schema, p
+	return l
+}
+
+// Load loads every layer and merges them. Layers are merged again only
+// from the first one whose contents changed since the previous Load, so
+// reloading after a change to the last layers doesn't merge the others.
+func (l *Loader) Load(ctx context.Context) (*Config, error) {
+	configs := make([]*Config, len(l.layers))
+	hashes := make([][sha256.Size]byte, len(l.layers))
+	for i, layer := range l.layers {
+		var err error
+		if h, ok := layer.(hashedSource); ok {
+			configs[i], hashes[i], err = h.loadHashed(ctx)
+		} else if configs[i], err = layer.Load(ctx); err == nil {
+			hashes[i] = hashConfig(configs[i])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	i := 0
+	for i < len(l.hashes) && i < len(hashes) && l.hashes[i] == hashes[i] {
+		i++
+	}
+	cfg := &Config{Root: map[string]interface{}{}}
+	if i > 0 {
+		cfg = l.merged[i-1]
+	}
+	merged := append(l.merged[:i:i], make([]*Config, len(configs)-i)...)
+	for j := i; j < len(configs); j++ {
+		var err error
+		if cfg, err = cfg.Extend(configs[j]); err != nil {
+			return nil, err
+		}
+		merged[j] = cfg
+	}
+	l.hashes, l.merged = hashes, merged
+
+	cfg, err := cfg.Copy()
+	if err != nil {
+		return nil, err
+	}
+	if l.prompter != nil {
+		if err := l.schema.PromptMissing(cfg, l.prompter); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// hashedSource is implemented by sources knowing the hash of the contents
+// they load, which Loader uses instead of hashing the loaded config.
+type hashedSource interface {
+	loadHashed(ctx context.Context) (*Config, [sha256.Size]byte, error)
+}
+
+// hashConfig returns a hash of the values of a config and their types.
+func hashConfig(cfg *Config) [sha256.Size]byte {
+	h := sha256.New()
+	hashValue(h, cfg.Root)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func hashValue(h hash.Hash, value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for k := range value {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(h, "map %d\n", len(keys))
+		for _, k := range keys {
+			fmt.Fprintf(h, "%q\n", k)
+			hashValue(h, value[k])
+		}
+	case []interface{}:
+		fmt.Fprintf(h, "list %d\n", len(value))
+		for _, v := range value {
+			hashValue(h, v)
+		}
+	default:
+		fmt.Fprintf(h, "%T %#v\n", value, value)
+	}
+}
+
+// Watch notifies of the changes of the layers. The returned channel is
+// closed once all the layers stop watching.
+func (l *Loader) Watch(ctx context.Context) (<-chan struct{}, error) {
+	changes := make(chan struct{})
+	var wg sync.WaitGroup
+	for _, layer := range l.layers {
+		w, ok := layer.(Watcher)
+		if !ok {
+			continue
+		}
+		layerChanges, err := w.Watch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range layerChanges {
+				select {
+				case changes <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(changes)
+	}()
+	return changes, nil
+}
+
+// StaticSource is a Source always loading the same config, like defaults
+// compiled into the program.
+type StaticSource struct {
+	Config *Config
+}
+
+// Load returns a copy of the config.
+func (s StaticSource) Load(ctx context.Context) (*Config, error) {
+	return s.Config.deepCopy()
+}
+
+// FileSource is a Source loading a file, in the format guessed by
+// FormatOf. The file is parsed again only when its contents change, which
+// makes the reloads of a Loader of many files cheap.
+type FileSource struct {
+	Filename string
+	// Options tune the parsing of the file, like EmptyDocumentAsMap for
+	// override files which may be empty.
+	Options ParseOptions
+	// Refresh is the interval of the polling of Watch; 0 disables it.
+	Refresh time.Duration
+
+	mu   sync.Mutex
+	hash [sha256.Size]byte
+	cfg  *Config
+}
+
+// Load reads the file, and parses it unless it is unchanged since the
+// previous Load.
+func (s *FileSource) Load(ctx context.Context) (*Config, error) {
+	cfg, _, err := s.loadHashed(ctx)
+	return cfg, err
+}
+
+func (s *FileSource) loadHashed(ctx context.Context) (*Config, [sha256.Size]byte, error) {
+	data, err := readFile(s.Filename)
+	if err != nil {
+		return nil, [sha256.Size]byte{}, err
+	}
+	sum := sha256.Sum256(data)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cfg == nil || s.hash != sum {
+		cfg, err := parseFormat(FormatOf(s.Filename), data, s.Options)
+		if err != nil {
+			return nil, sum, fmt.Errorf("%s: %v", s.Filename, err)
+		}
+		s.hash, s.cfg = sum, cfg
+	}
+	cfg, err := s.cfg.Copy()
+	return cfg, sum, err
+}
+
+// Watch polls the file every Refresh interval; Load tells whether it
+// changed.
+func (s *FileSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return pollChanges(ctx, s.Refresh), nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoader(t *testing.T) {
+	defaults := StaticSource{Must(ParseYaml(`
+server: {host: localhost, port: 8080}
+dotted.key: default
+`))}
+	overrides := &memorySource{doc: "server: {port: 9090}\ndotted.key: override", changes: make(chan struct{})}
+	loader := NewLoader(defaults).Add(overrides)
+
+	ctx := context.Background()
+	m, err := NewManager(ctx, loader)
+	expect(t, err, nil)
+	cfg := m.Config()
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, cfg.UString("[dotted.key]"), "override")
+
+	// Changes of a layer reload the merged config.
+	reloaded := make(chan int)
+	m.OnChange(func(old, new *Config) { reloaded <- new.UInt("server.port") })
+	done := make(chan error)
+	go func() { done <- m.Watch(ctx) }()
+	overrides.push("server: {port: 7070}")
+	expect(t, <-reloaded, 7070)
+	expect(t, m.Config().UString("server.host"), "localhost")
+	close(overrides.changes)
+	expect(t, <-done, nil)
+
+	failing := sourceFunc(func(context.Context) (*Config, error) {
+		return nil, errors.New("unavailable")
+	})
+	_, err = NewLoader(defaults, failing).Load(ctx)
+	expect(t, err.Error(), "unavailable")
+}
+
+func TestLoaderIncremental(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-loader")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+	write := func(name, doc string) {
+		expect(t, ioutil.WriteFile(filepath.Join(dir, name), []byte(doc), 0644), nil)
+	}
+	write("base.yaml", "server: {host: localhost, port: 8080}\nlevel: info")
+	write("env.json", `{"server": {"port": 9090}}`)
+	write("local.yaml", "level: debug")
+
+	base := &FileSource{Filename: filepath.Join(dir, "base.yaml")}
+	env := &FileSource{Filename: filepath.Join(dir, "env.json")}
+	local := &FileSource{Filename: filepath.Join(dir, "local.yaml")}
+	loader := NewLoader(base, env, local)
+
+	ctx := context.Background()
+	cfg, err := loader.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, cfg.UString("level"), "debug")
+
+	// Changing the last layer neither parses nor merges the others again.
+	parsed, merged := env.cfg, loader.merged[1]
+	write("local.yaml", "level: warn")
+	cfg, err = loader.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UString("level"), "warn")
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, env.cfg == parsed, true)
+	expect(t, loader.merged[1] == merged, true)
+
+	// Changing a middle layer merges the layers above it again.
+	write("env.json", `{"server": {"port": 7070}}`)
+	cfg, err = loader.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("server.port"), 7070)
+	expect(t, cfg.UString("level"), "warn")
+	expect(t, loader.merged[1] == merged, false)
+
+	// The returned configs don't alter the cached merges.
+	expect(t, cfg.Set("server.host", "example.com"), nil)
+	cfg, err = loader.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UString("server.host"), "localhost")
+
+	write("env.json", `{"server": `)
+	_, err = loader.Load(ctx)
+	expect(t, err != nil, true)
+}
+
+func TestFileSourceOptions(t *testing.T) {
+	f, err := ioutil.TempFile("", "config-*.yaml")
+	expect(t, err, nil)
+	defer os.Remove(f.Name())
+	f.Close()
+
+	ctx := context.Background()
+	cfg, err := (&FileSource{Filename: f.Name()}).Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.Root, nil)
+	src := &FileSource{Filename: f.Name(), Options: ParseOptions{EmptyDocumentAsMap: true}}
+	cfg, err = src.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.IsEmpty(), true)
+	expect(t, cfg.Root != nil, true)
+}
@@ -0,0 +1,176 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Location returns a time zone according to a dotted path, by its IANA
+// name like "Europe/Paris", or "UTC" and "Local". Unknown names are
+// reported when read rather than when first used; programs running
+// without a time zone database should import time/tzdata.
+func (cfg *Config) Location(path string) (*time.Location, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := n.(string)
+	if !ok {
+		return nil, cfg.observeConversion(path, typeMismatch("string", n))
+	}
+	if s == "" {
+		return nil, cfg.observeConversion(path, fmt.Errorf("Invalid time zone \"\""))
+	}
+	loc, err := time.LoadLocation(s)
+	if err != nil {
+		return nil, cfg.observeConversion(path, fmt.Errorf("Invalid time zone %q: %v", s, err))
+	}
+	return loc, nil
+}
+
+// ULocation returns a time zone according to a dotted path or default
+// value or UTC.
+func (c *Config) ULocation(path string, defaults ...*time.Location) *time.Location {
+	value, err := c.Location(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return time.UTC
+}
+
+// LanguageTag returns a BCP 47 language tag according to a dotted path,
+// like "en-US" or "zh-Hant-TW", in its canonical case. Tags are checked to
+// be well-formed, not against the registry of languages, so that the
+// package doesn't depend on golang.org/x/text; its language.Parse accepts
+// the returned tags.
+func (cfg *Config) LanguageTag(path string) (string, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return "", err
+	}
+	s, ok := n.(string)
+	if !ok {
+		return "", cfg.observeConversion(path, typeMismatch("string", n))
+	}
+	tag, err := parseLanguageTag(s)
+	return tag, cfg.observeConversion(path, err)
+}
+
+// ULanguageTag returns a BCP 47 language tag according to a dotted path or
+// default value or "".
+func (c *Config) ULanguageTag(path string, defaults ...string) string {
+	value, err := c.LanguageTag(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return ""
+}
+
+// parseLanguageTag checks the syntax of a language tag, as defined by RFC
+// 5646, and returns it in canonical case: lowercase languages, titlecase
+// scripts and uppercase regions.
+func parseLanguageTag(s string) (string, error) {
+	invalid := func(format string, args ...interface{}) (string, error) {
+		return "", fmt.Errorf("Invalid language tag %q: %s", s, fmt.Sprintf(format, args...))
+	}
+	if strings.ContainsRune(s, '_') {
+		return invalid("subtags are separated by \"-\", not \"_\"")
+	}
+	// Subtags are compared in lowercase.
+	subtags := strings.Split(strings.ToLower(s), "-")
+	for _, sub := range subtags {
+		if sub == "" || len(sub) > 8 || !isAlnum(sub) {
+			return invalid("bad subtag %q", sub)
+		}
+	}
+
+	i := 0
+	if subtags[0] != "x" {
+		// Language, with up to 3 extended language subtags.
+		lang := subtags[0]
+		if len(lang) < 2 || !isAlpha(lang) {
+			return invalid("bad language %q", lang)
+		}
+		i++
+		for ext := 0; ext < 3 && len(lang) <= 3 && i < len(subtags) && len(subtags[i]) == 3 && isAlpha(subtags[i]); ext++ {
+			i++
+		}
+		// Script, region and variants.
+		if i < len(subtags) && len(subtags[i]) == 4 && isAlpha(subtags[i]) {
+			subtags[i] = strings.ToUpper(subtags[i][:1]) + subtags[i][1:]
+			i++
+		}
+		if i < len(subtags) && (len(subtags[i]) == 2 && isAlpha(subtags[i]) || len(subtags[i]) == 3 && isDigits(subtags[i])) {
+			subtags[i] = strings.ToUpper(subtags[i])
+			i++
+		}
+		for i < len(subtags) && (len(subtags[i]) >= 5 || len(subtags[i]) == 4 && subtags[i][0] >= '0' && subtags[i][0] <= '9') {
+			i++
+		}
+		// Extensions, made of a singleton and its subtags.
+		for i < len(subtags) && len(subtags[i]) == 1 && subtags[i] != "x" {
+			singleton := subtags[i]
+			i++
+			start := i
+			for i < len(subtags) && len(subtags[i]) >= 2 {
+				i++
+			}
+			if i == start {
+				return invalid("empty extension %q", singleton)
+			}
+		}
+	}
+	if i < len(subtags) && subtags[i] == "x" {
+		if i+1 == len(subtags) {
+			return invalid("empty private use")
+		}
+		i = len(subtags)
+	}
+	if i < len(subtags) {
+		return invalid("unexpected subtag %q", subtags[i])
+	}
+	return strings.Join(subtags, "-"), nil
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlnum(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
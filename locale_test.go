@@ -0,0 +1,69 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLocation(t *testing.T) {
+	cfg := Must(ParseYaml(`
+paris: Europe/Paris
+utc: UTC
+typo: Europe/Pariss
+empty: ""
+number: 1
+`))
+	loc, err := cfg.Location("paris")
+	expect(t, err, nil)
+	expect(t, loc.String(), "Europe/Paris")
+	expect(t, cfg.ULocation("utc"), time.UTC)
+	_, err = cfg.Location("typo")
+	expect(t, err.Error(), `Invalid time zone "Europe/Pariss": unknown time zone Europe/Pariss`)
+	_, err = cfg.Location("empty")
+	expect(t, err.Error(), `Invalid time zone ""`)
+	_, err = cfg.Location("number")
+	expect(t, err != nil, true)
+	expect(t, cfg.ULocation("missing"), time.UTC)
+}
+
+func TestLanguageTag(t *testing.T) {
+	for s, want := range map[string]string{
+		"en":                 "en",
+		"EN-us":              "en-US",
+		"zh-hant-tw":         "zh-Hant-TW",
+		"es-419":             "es-419",
+		"sl-rozaj-biske":     "sl-rozaj-biske",
+		"de-CH-1996":         "de-CH-1996",
+		"zh-yue-HK":          "zh-yue-HK",
+		"en-US-u-ca-gregory": "en-US-u-ca-gregory",
+		"en-x-private":       "en-x-private",
+		"x-whatever":         "x-whatever",
+	} {
+		tag, err := parseLanguageTag(s)
+		expect(t, err, nil)
+		expect(t, tag, want)
+	}
+	for s, msg := range map[string]string{
+		"en_US":       `Invalid language tag "en_US": subtags are separated by "-", not "_"`,
+		"e":           `Invalid language tag "e": bad language "e"`,
+		"en--US":      `Invalid language tag "en--US": bad subtag ""`,
+		"en-US-u":     `Invalid language tag "en-US-u": empty extension "u"`,
+		"en-x":        `Invalid language tag "en-x": empty private use`,
+		"en-US-US":    `Invalid language tag "en-US-US": unexpected subtag "us"`,
+		"english-usa": `Invalid language tag "english-usa": unexpected subtag "usa"`,
+		"":            `Invalid language tag "": bad subtag ""`,
+	} {
+		_, err := parseLanguageTag(s)
+		expect(t, err.Error(), msg)
+	}
+
+	cfg := Must(ParseYaml("lang: pt-br\nbad: pt_BR"))
+	expect(t, cfg.ULanguageTag("lang"), "pt-BR")
+	_, err := cfg.LanguageTag("bad")
+	expect(t, err != nil, true)
+	expect(t, cfg.ULanguageTag("bad", "en"), "en")
+}
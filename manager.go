@@ -0,0 +1,337 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Watcher is implemented by sources which can tell when their config
+// changes.
+type Watcher interface {
+	// Watch returns a channel receiving a value after every change of the
+	// source. The channel is closed once ctx is done or the source stops
+	// watching.
+	Watch(ctx context.Context) (<-chan struct{}, error)
+}
+
+// Manager holds the current config of a source and replaces it on
+// reloads. It is safe for concurrent use.
+type Manager struct {
+	source  Source
+	current atomic.Value // *Config
+
+	mu         sync.Mutex
+	listeners  []func(old, new *Config)
+	onError    []func(err error)
+	validators []func(cfg *Config) error
+	policy     FailurePolicy
+	onDegraded []func(err error)
+	// lastErr is the error of the last reload, and degraded tells whether
+	// it marked the manager degraded. revision counts the configs made
+	// current, loaded at lastGood.
+	lastErr  error
+	degraded bool
+	revision int
+	lastGood time.Time
+
+	writeMu sync.Mutex // serializes Reload, Persist and Update
+	leader  Leader
+}
+
+// Leader tells whether the process is the elected leader of its replicas,
+// as decided by an election on etcd, ZooKeeper or a Kubernetes lease.
+type Leader interface {
+	IsLeader() bool
+}
+
+// LeaderFunc adapts a function to a Leader.
+type LeaderFunc func() bool
+
+// IsLeader returns f().
+func (f LeaderFunc) IsLeader() bool { return f() }
+
+// ErrNotLeader is returned by Persist and Update on the followers of a
+// manager restricted to a leader, see RequireLeader.
+var ErrNotLeader = errors.New("Only the leader can persist configs")
+
+// NewManager loads the config from the source and returns a manager
+// holding it.
+func NewManager(ctx context.Context, source Source) (*Manager, error) {
+	cfg, err := source.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{source: source, revision: 1, lastGood: time.Now()}
+	m.current.Store(cfg)
+	return m, nil
+}
+
+// FailurePolicy tells what a manager does when a reload fails, because
+// the source can't be loaded or the new config is invalid.
+type FailurePolicy int
+
+const (
+	// KeepLastGood keeps the current config, the last good one.
+	KeepLastGood FailurePolicy = iota
+	// MarkDegraded keeps the current config too, but marks the manager
+	// degraded until a reload succeeds, and calls the functions
+	// registered with OnDegraded.
+	MarkDegraded
+	// Crash panics, for services which would rather restart than run with
+	// a config which doesn't match its source.
+	Crash
+)
+
+// SetFailurePolicy sets the policy applied when a reload fails. The
+// default is KeepLastGood.
+func (m *Manager) SetFailurePolicy(policy FailurePolicy) {
+	m.mu.Lock()
+	m.policy = policy
+	m.mu.Unlock()
+}
+
+// Validator registers a function validating reloaded configs, like a
+// Schema's Validate method. A config failing any of them isn't made
+// current, and its error fails the reload.
+func (m *Manager) Validator(fn func(cfg *Config) error) {
+	m.mu.Lock()
+	m.validators = append(m.validators, fn)
+	m.mu.Unlock()
+}
+
+// OnDegraded registers a function called with the error of every reload
+// failing under the MarkDegraded policy.
+func (m *Manager) OnDegraded(fn func(err error)) {
+	m.mu.Lock()
+	m.onDegraded = append(m.onDegraded, fn)
+	m.mu.Unlock()
+}
+
+// LastError returns the error of the last reload, or nil if it succeeded.
+func (m *Manager) LastError() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+// Degraded reports whether the last reload failed under the MarkDegraded
+// policy.
+func (m *Manager) Degraded() bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.degraded
+}
+
+// LastGoodRevision returns the revision of the current config: 1 for the
+// config loaded by NewManager, incremented by every successful reload or
+// update.
+func (m *Manager) LastGoodRevision() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.revision
+}
+
+// Config returns the current config. It must not be modified, since
+// other goroutines may be reading it; use Copy to change it.
+func (m *Manager) Config() *Config {
+	return m.current.Load().(*Config)
+}
+
+// OnChange registers a function called after every successful reload,
+// with the previous and the new config.
+func (m *Manager) OnChange(fn func(old, new *Config)) {
+	m.mu.Lock()
+	m.listeners = append(m.listeners, fn)
+	m.mu.Unlock()
+}
+
+// OnError registers a function called when a reload triggered by Watch
+// fails. The current config is kept in that case.
+func (m *Manager) OnError(fn func(err error)) {
+	m.mu.Lock()
+	m.onError = append(m.onError, fn)
+	m.mu.Unlock()
+}
+
+// Reload loads the config from the source and makes it current. On
+// failure, the current config is kept and the error is returned.
+func (m *Manager) Reload(ctx context.Context) error {
+	// An update racing with the reload could be overwritten by a config
+	// loaded before it was stored.
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	cfg, err := m.source.Load(ctx)
+	if err == nil {
+		err = m.validate(cfg)
+	}
+	observeReload(err)
+	if err != nil {
+		m.fail(err)
+		return err
+	}
+	m.replace(cfg)
+	return nil
+}
+
+// validate runs the registered validators on a config.
+func (m *Manager) validate(cfg *Config) error {
+	m.mu.Lock()
+	validators := m.validators
+	m.mu.Unlock()
+	for _, fn := range validators {
+		if err := fn(cfg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fail applies the failure policy to the error of a reload.
+func (m *Manager) fail(err error) {
+	m.mu.Lock()
+	m.lastErr = err
+	policy := m.policy
+	var handlers []func(err error)
+	if policy == MarkDegraded {
+		m.degraded = true
+		handlers = m.onDegraded
+	}
+	m.mu.Unlock()
+	if policy == Crash {
+		panic(fmt.Sprintf("config: reload failed: %v", err))
+	}
+	for _, fn := range handlers {
+		fn(err)
+	}
+}
+
+// RequireLeader makes the manager read-only unless l reports the process
+// as the leader, so that a single replica of a cluster sharing a source
+// writes to it. Followers still reload the changes made by the leader.
+func (m *Manager) RequireLeader(l Leader) {
+	m.mu.Lock()
+	m.leader = l
+	m.mu.Unlock()
+}
+
+// Persist stores the current config back to the source, which must be a
+// Persister. Sources tracking revisions return ErrConflict when the
+// stored config changed since it was loaded; Reload before retrying.
+func (m *Manager) Persist(ctx context.Context) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	return m.persist(ctx, m.Config())
+}
+
+// Update applies fn to a copy of the current config, stores the result
+// back to the source and makes it current. Nothing changes when fn, the
+// validators or storing fail.
+func (m *Manager) Update(ctx context.Context, fn func(cfg *Config) error) error {
+	m.writeMu.Lock()
+	defer m.writeMu.Unlock()
+	cfg, err := m.Config().deepCopy()
+	if err != nil {
+		return err
+	}
+	if err := fn(cfg); err != nil {
+		return err
+	}
+	if err := m.validate(cfg); err != nil {
+		return err
+	}
+	if err := m.persist(ctx, cfg); err != nil {
+		return err
+	}
+	m.replace(cfg)
+	return nil
+}
+
+func (m *Manager) persist(ctx context.Context, cfg *Config) error {
+	m.mu.Lock()
+	leader := m.leader
+	m.mu.Unlock()
+	if leader != nil && !leader.IsLeader() {
+		return ErrNotLeader
+	}
+	p, ok := m.source.(Persister)
+	if !ok {
+		return fmt.Errorf("Source %T can't persist configs", m.source)
+	}
+	return p.Persist(ctx, cfg)
+}
+
+// replace makes cfg current and notifies the listeners.
+func (m *Manager) replace(cfg *Config) {
+	m.mu.Lock()
+	old := m.Config()
+	m.current.Store(cfg)
+	m.revision++
+	m.lastGood = time.Now()
+	m.lastErr, m.degraded = nil, false
+	listeners := m.listeners
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		fn(old, cfg)
+	}
+}
+
+// Watch reloads the config on every change notified by the source, until
+// ctx is done or the source stops watching. It returns nil right away if
+// the source isn't a Watcher.
+func (m *Manager) Watch(ctx context.Context) error {
+	w, ok := m.source.(Watcher)
+	if !ok {
+		return nil
+	}
+	changes, err := w.Watch(ctx)
+	if err != nil {
+		return err
+	}
+	for range changes {
+		if err := m.Reload(ctx); err != nil {
+			m.mu.Lock()
+			handlers := m.onError
+			m.mu.Unlock()
+			for _, fn := range handlers {
+				fn(err)
+			}
+		}
+	}
+	return ctx.Err()
+}
+
+// pollChanges returns a channel notified every interval until ctx is
+// done. A non-positive interval never notifies.
+func pollChanges(ctx context.Context, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		if interval <= 0 {
+			<-ctx.Done()
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				select {
+				case changes <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes
+}
@@ -0,0 +1,214 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memorySource serves YAML documents pushed by the test.
+type memorySource struct {
+	mu      sync.Mutex
+	doc     string
+	changes chan struct{}
+}
+
+func (s *memorySource) Load(ctx context.Context) (*Config, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ParseYaml(s.doc)
+}
+
+func (s *memorySource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return s.changes, nil
+}
+
+func (s *memorySource) push(doc string) {
+	s.mu.Lock()
+	s.doc = doc
+	s.mu.Unlock()
+	s.changes <- struct{}{}
+}
+
+func TestManager(t *testing.T) {
+	src := &memorySource{doc: "version: 1", changes: make(chan struct{})}
+	ctx := context.Background()
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	expect(t, m.Config().UInt("version"), 1)
+
+	changed := make(chan [2]int, 1)
+	m.OnChange(func(old, new *Config) {
+		changed <- [2]int{old.UInt("version"), new.UInt("version")}
+	})
+	failed := make(chan error, 1)
+	m.OnError(func(err error) { failed <- err })
+
+	done := make(chan error)
+	go func() { done <- m.Watch(ctx) }()
+
+	src.push("version: 2")
+	expect(t, <-changed, [2]int{1, 2})
+	expect(t, m.Config().UInt("version"), 2)
+
+	// A broken document keeps the current config.
+	src.push("version: [")
+	expect(t, (<-failed) != nil, true)
+	expect(t, m.Config().UInt("version"), 2)
+
+	close(src.changes)
+	expect(t, <-done, nil)
+
+	_, err = NewManager(ctx, sourceFunc(func(context.Context) (*Config, error) {
+		return nil, errors.New("unavailable")
+	}))
+	expect(t, err.Error(), "unavailable")
+}
+
+// sourceFunc adapts a function to a Source.
+type sourceFunc func(ctx context.Context) (*Config, error)
+
+func (f sourceFunc) Load(ctx context.Context) (*Config, error) { return f(ctx) }
+
+func TestManagerWithoutWatcher(t *testing.T) {
+	loads := 0
+	m, err := NewManager(context.Background(), sourceFunc(func(context.Context) (*Config, error) {
+		loads++
+		return ParseYaml("a: 1")
+	}))
+	expect(t, err, nil)
+	expect(t, m.Watch(context.Background()), nil)
+	expect(t, m.Reload(context.Background()), nil)
+	expect(t, loads, 2)
+}
+
+// persistedSource records the configs it persists.
+type persistedSource struct {
+	StaticSource
+	persisted []*Config
+}
+
+func (s *persistedSource) Persist(ctx context.Context, cfg *Config) error {
+	s.persisted = append(s.persisted, cfg)
+	return nil
+}
+
+// signalingSource signals its loads.
+type signalingSource struct {
+	persistedSource
+	loads chan struct{}
+}
+
+func (s *signalingSource) Load(ctx context.Context) (*Config, error) {
+	s.loads <- struct{}{}
+	return s.persistedSource.Load(ctx)
+}
+
+func TestManagerReloadWaitsForUpdate(t *testing.T) {
+	ctx := context.Background()
+	src := &signalingSource{loads: make(chan struct{}, 1)}
+	src.Config = Must(ParseYaml("a: 1"))
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	<-src.loads
+
+	updating, release, updated := make(chan struct{}), make(chan struct{}), make(chan error)
+	go func() {
+		updated <- m.Update(ctx, func(cfg *Config) error {
+			close(updating)
+			<-release
+			return cfg.Set("a", 2)
+		})
+	}()
+	<-updating
+	reloaded := make(chan error)
+	go func() { reloaded <- m.Reload(ctx) }()
+	select {
+	case <-src.loads:
+		t.Fatal("Reload loaded the config during an Update")
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(release)
+	expect(t, <-updated, nil)
+	<-src.loads
+	expect(t, <-reloaded, nil)
+}
+
+func TestManagerRequireLeader(t *testing.T) {
+	ctx := context.Background()
+	src := &persistedSource{StaticSource: StaticSource{Config: Must(ParseYaml("a: 1"))}}
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+
+	leading := false
+	m.RequireLeader(LeaderFunc(func() bool { return leading }))
+	update := func(cfg *Config) error { return cfg.Set("a", 2) }
+	expect(t, m.Update(ctx, update), ErrNotLeader)
+	expect(t, m.Persist(ctx), ErrNotLeader)
+	expect(t, m.Config().UInt("a"), 1)
+	expect(t, len(src.persisted), 0)
+
+	leading = true
+	expect(t, m.Update(ctx, update), nil)
+	expect(t, m.Config().UInt("a"), 2)
+	expect(t, len(src.persisted), 1)
+}
+
+func TestManagerFailurePolicy(t *testing.T) {
+	doc := "port: 80"
+	ctx := context.Background()
+	m, err := NewManager(ctx, sourceFunc(func(context.Context) (*Config, error) {
+		return ParseYaml(doc)
+	}))
+	expect(t, err, nil)
+	m.Validator(func(cfg *Config) error {
+		if cfg.UInt("port") == 0 {
+			return errors.New("Missing port")
+		}
+		return nil
+	})
+	expect(t, m.LastGoodRevision(), 1)
+	expect(t, m.LastError(), nil)
+
+	// A config failing validation keeps the last good one by default.
+	doc = "host: x"
+	expect(t, m.Reload(ctx).Error(), "Missing port")
+	expect(t, m.LastError().Error(), "Missing port")
+	expect(t, m.Degraded(), false)
+	expect(t, m.Config().UInt("port"), 80)
+	expect(t, m.LastGoodRevision(), 1)
+
+	var degraded []error
+	m.OnDegraded(func(err error) { degraded = append(degraded, err) })
+	m.SetFailurePolicy(MarkDegraded)
+	expect(t, m.Reload(ctx).Error(), "Missing port")
+	expect(t, m.Degraded(), true)
+	expect(t, len(degraded), 1)
+	expect(t, m.Config().UInt("port"), 80)
+
+	doc = "port: 81"
+	expect(t, m.Reload(ctx), nil)
+	expect(t, m.Degraded(), false)
+	expect(t, m.LastError(), nil)
+	expect(t, m.LastGoodRevision(), 2)
+
+	// Updates are validated too.
+	err = m.Update(ctx, func(cfg *Config) error { return cfg.Delete("port") })
+	expect(t, err.Error(), "Missing port")
+	expect(t, m.Config().UInt("port"), 81)
+
+	m.SetFailurePolicy(Crash)
+	doc = "port: ["
+	defer func() {
+		expect(t, recover() != nil, true)
+		expect(t, m.Config().UInt("port"), 81)
+	}()
+	m.Reload(ctx)
+	t.Error("Expected a panic")
+}
@@ -0,0 +1,269 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The keys of the matrix directives expanded by Resolve.
+const (
+	MatrixKey   = "$matrix"
+	TemplateKey = "$template"
+	NameKey     = "$key"
+)
+
+// expandMatrices replaces the matrix directives of the config by their
+// expansions.
+func (cfg *Config) expandMatrices() error {
+	var paths []string
+	findMatrices(cfg.Root, "", &paths)
+	for _, path := range paths {
+		node, err := Get(cfg.Root, path)
+		if err != nil {
+			return err
+		}
+		v, err := cfg.expandMatrix(node.(map[string]interface{}), path)
+		if err != nil {
+			return err
+		}
+		if err := cfg.Set(path, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findMatrices appends the paths of the matrix directives found in a node
+// to paths, outside of other directives.
+func findMatrices(node interface{}, path string, paths *[]string) {
+	switch c := node.(type) {
+	case map[string]interface{}:
+		if _, ok := c[MatrixKey]; ok {
+			*paths = append(*paths, path)
+			return
+		}
+		keys := make([]string, 0, len(c))
+		for key := range c {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			findMatrices(c[key], joinPath(path, key), paths)
+		}
+	case []interface{}:
+		for i, child := range c {
+			findMatrices(child, joinPath(path, strconv.Itoa(i)), paths)
+		}
+	}
+}
+
+// expandMatrix returns the expansion of the matrix directive m found at
+// the given path.
+func (cfg *Config) expandMatrix(m map[string]interface{}, path string) (interface{}, error) {
+	invalid := func(keys []string, format string, args ...interface{}) error {
+		at := path
+		for _, key := range keys {
+			at = joinPath(at, key)
+		}
+		return fmt.Errorf("Invalid matrix at %q: %s", displayPath(at), fmt.Sprintf(format, args...))
+	}
+	for key := range m {
+		if key != MatrixKey && key != TemplateKey && key != NameKey {
+			return nil, invalid([]string{key}, "unknown key, expected %q, %q or %q", MatrixKey, TemplateKey, NameKey)
+		}
+	}
+	template, ok := m[TemplateKey]
+	if !ok {
+		return nil, invalid([]string{TemplateKey}, "missing template")
+	}
+	axes, ok := m[MatrixKey].(map[string]interface{})
+	if !ok || len(axes) == 0 {
+		return nil, invalid([]string{MatrixKey}, "expected a map of variables to lists")
+	}
+	names := make([]string, 0, len(axes))
+	for name := range axes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	values := make([][]interface{}, len(names))
+	for i, name := range names {
+		axis := axes[name]
+		// Variables can take the values of a list of the config.
+		if s, ok := axis.(string); ok && strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+			ref := strings.TrimSpace(s[2 : len(s)-1])
+			n, err := cfg.lookup(ref)
+			if err != nil {
+				return nil, invalid([]string{MatrixKey, name}, "unresolved reference ${%s}", ref)
+			}
+			axis = n
+		}
+		list, ok := axis.([]interface{})
+		if !ok {
+			return nil, invalid([]string{MatrixKey, name}, "expected a list; got %T", axis)
+		}
+		values[i] = list
+	}
+
+	var nameTemplate string
+	if n, ok := m[NameKey]; ok {
+		if nameTemplate, ok = n.(string); !ok {
+			return nil, invalid([]string{NameKey}, "expected a string; got %T", n)
+		}
+	}
+	var list []interface{}
+	named := map[string]interface{}{}
+
+	// The combinations are enumerated like numbers, whose digits are the
+	// indices of the values of the variables, the last one varying
+	// fastest.
+	indices := make([]int, len(names))
+	for {
+		vars := make(map[string]interface{}, len(names))
+		for i, name := range names {
+			if len(values[i]) == 0 {
+				break
+			}
+			vars[name] = values[i][indices[i]]
+		}
+		if len(vars) < len(names) {
+			break
+		}
+		v, err := substituteMatrix(template, vars)
+		if err != nil {
+			return nil, invalid([]string{TemplateKey}, "%v", err)
+		}
+		// Templates can hold directives themselves.
+		var nested []string
+		findMatrices(v, "", &nested)
+		for _, p := range nested {
+			node, _ := Get(v, p)
+			expanded, err := cfg.expandMatrix(node.(map[string]interface{}), canonicalPath(joinPath(path, TemplateKey), p))
+			if err != nil {
+				return nil, err
+			}
+			if p == "" {
+				v = expanded
+			} else if v, err = setValue(v, p, expanded); err != nil {
+				return nil, err
+			}
+		}
+		if nameTemplate == "" {
+			list = append(list, v)
+		} else {
+			key, err := toString(substituteVars(nameTemplate, vars))
+			if err != nil {
+				return nil, invalid([]string{NameKey}, "%v", err)
+			}
+			if _, dup := named[key]; dup {
+				return nil, invalid([]string{NameKey}, "duplicate key %q", key)
+			}
+			named[key] = v
+		}
+
+		i := len(indices) - 1
+		for ; i >= 0; i-- {
+			if indices[i]++; indices[i] < len(values[i]) {
+				break
+			}
+			indices[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+	if nameTemplate != "" {
+		return named, nil
+	}
+	if list == nil {
+		list = []interface{}{}
+	}
+	return list, nil
+}
+
+// setValue sets the value at a dotted path of a tree, returning the tree.
+func setValue(tree interface{}, path string, v interface{}) (interface{}, error) {
+	cfg := &Config{Root: tree}
+	if err := cfg.Set(path, v); err != nil {
+		return nil, err
+	}
+	return cfg.Root, nil
+}
+
+// substituteMatrix returns a copy of a template with the ${name} of the
+// variables replaced by their values, in keys and in strings.
+func substituteMatrix(template interface{}, vars map[string]interface{}) (interface{}, error) {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for key, child := range t {
+			k, err := toString(substituteVars(key, vars))
+			if err != nil {
+				return nil, fmt.Errorf("key %q: %v", key, err)
+			}
+			v, err := substituteMatrix(child, vars)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := m[k]; dup {
+				return nil, fmt.Errorf("duplicate key %q", k)
+			}
+			m[k] = v
+		}
+		return m, nil
+	case []interface{}:
+		list := make([]interface{}, len(t))
+		for i, child := range t {
+			v, err := substituteMatrix(child, vars)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	case string:
+		return normalizeValue(substituteVars(t, vars))
+	}
+	return template, nil
+}
+
+// substituteVars replaces the ${name} of the variables in a string by
+// their values, leaving other references as they are. A string which only
+// is a variable takes its value as is.
+func substituteVars(s string, vars map[string]interface{}) interface{} {
+	if strings.HasPrefix(s, "${") && strings.HasSuffix(s, "}") {
+		if v, ok := vars[strings.TrimSpace(s[2:len(s)-1])]; ok {
+			return v
+		}
+	}
+	var b strings.Builder
+	for {
+		i := strings.Index(s, "${")
+		if i < 0 {
+			break
+		}
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			break
+		}
+		name := strings.TrimSpace(s[i+2 : i+end])
+		b.WriteString(s[:i])
+		if v, ok := vars[name]; ok {
+			str, err := toString(v)
+			if err != nil {
+				str = fmt.Sprint(v)
+			}
+			b.WriteString(str)
+		} else {
+			b.WriteString(s[i : i+end+1])
+		}
+		s = s[i+end+1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
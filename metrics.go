@@ -0,0 +1,122 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"expvar"
+	"sync/atomic"
+)
+
+// Metrics receives instrumentation events from the package, e.g. to find
+// configuration keys which are never read or read in hot paths. Paths are
+// full paths, like those of TrackReads: reading "host" from the config
+// returned by Get("db") reports "db.host". Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// Lookup is called for every path read by the getters.
+	Lookup(path string)
+	// Miss is called when a path read by the getters doesn't exist.
+	Miss(path string)
+	// Mismatch is called when a value can't be converted to the type
+	// requested by a getter.
+	Mismatch(path string)
+	// Reload is called when a config is reloaded from its source, with
+	// the error if reloading failed.
+	Reload(err error)
+}
+
+// metricsHolder lets atomic.Value store a nil Metrics.
+type metricsHolder struct{ m Metrics }
+
+var metrics atomic.Value // metricsHolder
+
+// SetMetrics installs the Metrics receiving the package events. Passing nil
+// turns instrumentation off, which is the default.
+func SetMetrics(m Metrics) {
+	metrics.Store(metricsHolder{m})
+}
+
+// currentMetrics returns the installed Metrics or nil.
+func currentMetrics() Metrics {
+	h, _ := metrics.Load().(metricsHolder)
+	return h.m
+}
+
+// observeLookup records a getter lookup and whether the path was found.
+func (cfg *Config) observeLookup(path string, found bool) {
+	if m := currentMetrics(); m != nil {
+		path = canonicalPath(cfg.prefix, path)
+		m.Lookup(path)
+		if !found {
+			m.Miss(path)
+		}
+	}
+}
+
+// observeReload records a reload and its result.
+func observeReload(err error) {
+	if m := currentMetrics(); m != nil {
+		m.Reload(err)
+	}
+}
+
+// observeConversion records a failed conversion of the value at path and
+// returns the error untouched.
+func (cfg *Config) observeConversion(path string, err error) error {
+	if err != nil {
+		if m := currentMetrics(); m != nil {
+			m.Mismatch(canonicalPath(cfg.prefix, path))
+		}
+	}
+	return err
+}
+
+// ExpvarMetrics is a Metrics publishing its counters with package expvar.
+type ExpvarMetrics struct {
+	// Lookups, Misses and Mismatches count events per path.
+	Lookups    *expvar.Map
+	Misses     *expvar.Map
+	Mismatches *expvar.Map
+	// Reloads counts reloads, and ReloadErrors the failed ones.
+	Reloads      *expvar.Int
+	ReloadErrors *expvar.Int
+}
+
+// NewExpvarMetrics returns an ExpvarMetrics published as a map variable
+// with the given name. Like expvar.NewMap, it panics if the name is
+// already in use.
+func NewExpvarMetrics(name string) *ExpvarMetrics {
+	m := &ExpvarMetrics{
+		Lookups:      new(expvar.Map).Init(),
+		Misses:       new(expvar.Map).Init(),
+		Mismatches:   new(expvar.Map).Init(),
+		Reloads:      new(expvar.Int),
+		ReloadErrors: new(expvar.Int),
+	}
+	v := expvar.NewMap(name)
+	v.Set("lookups", m.Lookups)
+	v.Set("misses", m.Misses)
+	v.Set("mismatches", m.Mismatches)
+	v.Set("reloads", m.Reloads)
+	v.Set("reload_errors", m.ReloadErrors)
+	return m
+}
+
+// Lookup implements Metrics.
+func (m *ExpvarMetrics) Lookup(path string) { m.Lookups.Add(path, 1) }
+
+// Miss implements Metrics.
+func (m *ExpvarMetrics) Miss(path string) { m.Misses.Add(path, 1) }
+
+// Mismatch implements Metrics.
+func (m *ExpvarMetrics) Mismatch(path string) { m.Mismatches.Add(path, 1) }
+
+// Reload implements Metrics.
+func (m *ExpvarMetrics) Reload(err error) {
+	m.Reloads.Add(1)
+	if err != nil {
+		m.ReloadErrors.Add(1)
+	}
+}
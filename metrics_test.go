@@ -0,0 +1,58 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"expvar"
+	"testing"
+)
+
+func TestExpvarMetrics(t *testing.T) {
+	m := NewExpvarMetrics("config_test")
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+	cfg.UString("map.key8")
+	cfg.UString("map.key8")
+	cfg.UInt("map.key8")
+	cfg.UBool("map.undefined")
+	observeReload(nil)
+	observeReload(errors.New("failed"))
+
+	expect(t, m.Lookups.Get("map.key8").String(), "3")
+	expect(t, m.Lookups.Get("map.undefined").String(), "1")
+	expect(t, m.Misses.Get("map.key8"), nil)
+	expect(t, m.Misses.Get("map.undefined").String(), "1")
+	expect(t, m.Mismatches.Get("map.key8").String(), "1")
+	expect(t, m.Reloads.Value(), int64(2))
+	expect(t, m.ReloadErrors.Value(), int64(1))
+	expect(t, expvar.Get("config_test").(*expvar.Map).Get("lookups"), expvar.Var(m.Lookups))
+
+	SetMetrics(nil)
+	cfg.UString("map.key8")
+	expect(t, m.Lookups.Get("map.key8").String(), "3")
+}
+
+func TestMetricsFullPaths(t *testing.T) {
+	m := NewExpvarMetrics("config_test_paths")
+	SetMetrics(m)
+	defer SetMetrics(nil)
+
+	cfg := Must(ParseYaml("db: {host: localhost, port: x}"))
+	cfg.UString("db.host")
+	db, err := cfg.Get("db")
+	expect(t, err, nil)
+	db.UString("host")
+	db.StringOk("host")
+	db.UInt("port")
+	db.UString("user")
+	expect(t, m.Lookups.Get("db.host").String(), "3")
+	expect(t, m.Lookups.Get("host"), nil)
+	expect(t, m.Mismatches.Get("db.port").String(), "1")
+	expect(t, m.Misses.Get("db.user").String(), "1")
+}
@@ -0,0 +1,242 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseNginx reads a configuration in the block syntax of nginx, where
+// directives are names followed by arguments and ended by semicolons, and
+// blocks group directives in braces:
+//
+//	http {
+//		server {
+//			listen 80;
+//			listen 443 ssl;
+//			location /api {
+//				proxy_pass http://backend;
+//			}
+//		}
+//	}
+//
+// Directives become keys holding their argument, a list of their
+// arguments when they have several, or true when they have none. Blocks
+// become maps, nested under their arguments when they have some, so the
+// block above sets "http.server.location./api.proxy_pass". Repeated
+// directives and blocks become lists, like "http.server.listen" holding
+// "80" and ["443", "ssl"], except blocks with different arguments, which
+// share their map. Include directives are kept as such.
+func ParseNginx(cfg string) (*Config, error) {
+	p := &nginxParser{s: cfg, line: 1}
+	root, err := p.block(false)
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(root)
+}
+
+// ParseNginxFile reads a configuration in the nginx syntax from the given
+// filename. Like ParseJsonFile, it decompresses compressed files
+// transparently.
+func ParseNginxFile(filename string) (*Config, error) {
+	cfg, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNginx(string(cfg))
+}
+
+// nginxParser parses the nginx syntax.
+type nginxParser struct {
+	s    string
+	pos  int
+	line int
+}
+
+func (p *nginxParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("Nginx line %d: %s", p.line, fmt.Sprintf(format, args...))
+}
+
+// token returns the next word, quoted string, or one of ";", "{" and "}".
+// It returns "" at the end of the input.
+func (p *nginxParser) token() (string, bool, error) {
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == '\n' {
+			p.line++
+		}
+		if c == '#' {
+			for p.pos < len(p.s) && p.s[p.pos] != '\n' {
+				p.pos++
+			}
+			continue
+		}
+		if c != ' ' && c != '\t' && c != '\r' && c != '\n' {
+			break
+		}
+		p.pos++
+	}
+	if p.pos >= len(p.s) {
+		return "", false, nil
+	}
+
+	switch c := p.s[p.pos]; c {
+	case ';', '{', '}':
+		p.pos++
+		return string(c), false, nil
+	case '"', '\'':
+		p.pos++
+		var b strings.Builder
+		for p.pos < len(p.s) {
+			d := p.s[p.pos]
+			p.pos++
+			switch {
+			case d == c:
+				return b.String(), true, nil
+			case d == '\\' && p.pos < len(p.s):
+				// Escapes keep their backslash, except before quotes, like
+				// nginx does.
+				e := p.s[p.pos]
+				p.pos++
+				if e != '"' && e != '\'' && e != '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(e)
+			default:
+				if d == '\n' {
+					p.line++
+				}
+				b.WriteByte(d)
+			}
+		}
+		return "", false, p.errorf("unterminated string")
+	}
+
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == ';' || c == '{' || c == '}' {
+			break
+		}
+		if c == '\\' && p.pos+1 < len(p.s) {
+			p.pos++
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos], false, nil
+}
+
+// block parses directives up to the closing brace of a block, or the end
+// of the input for the main context.
+func (p *nginxParser) block(braced bool) (map[string]interface{}, error) {
+	node := map[string]interface{}{}
+	d := nginxDirectives{node: node, repeated: map[string]bool{}, keyed: map[string]bool{}}
+	for {
+		var line int
+		var words []string
+		for {
+			tok, quoted, err := p.token()
+			if err != nil {
+				return nil, err
+			}
+			if len(words) == 0 {
+				line = p.line
+			}
+			if !quoted && (tok == "" || tok == ";" || tok == "{" || tok == "}") {
+				if tok == "" {
+					if len(words) > 0 {
+						return nil, p.errorf("missing ';' after %q", words[0])
+					}
+					if braced {
+						return nil, p.errorf("missing '}'")
+					}
+					return node, nil
+				}
+				if tok == "}" {
+					if len(words) > 0 {
+						return nil, p.errorf("missing ';' after %q", words[0])
+					}
+					if !braced {
+						return nil, p.errorf("unexpected '}'")
+					}
+					return node, nil
+				}
+				if len(words) == 0 {
+					return nil, p.errorf("unexpected %q", tok)
+				}
+
+				var value interface{}
+				if tok == "{" {
+					body, err := p.block(true)
+					if err != nil {
+						return nil, err
+					}
+					value = body
+					if len(words) > 1 {
+						value = map[string]interface{}{strings.Join(words[1:], " "): body}
+					}
+				} else {
+					value = nginxArgs(words[1:])
+				}
+				if err := d.add(words[0], value, len(words) > 1 && tok == "{"); err != nil {
+					return nil, fmt.Errorf("Nginx line %d: %v", line, err)
+				}
+				break
+			}
+			words = append(words, tok)
+		}
+	}
+}
+
+// nginxArgs returns the value of a simple directive.
+func nginxArgs(args []string) interface{} {
+	switch len(args) {
+	case 0:
+		return true
+	case 1:
+		return args[0]
+	}
+	list := make([]interface{}, len(args))
+	for i, arg := range args {
+		list[i] = arg
+	}
+	return list
+}
+
+// nginxDirectives gathers the directives of a block.
+type nginxDirectives struct {
+	node map[string]interface{}
+	// repeated tells the names holding lists of repeated directives, and
+	// keyed the ones holding maps of blocks with arguments.
+	repeated, keyed map[string]bool
+}
+
+// add sets a directive. Blocks with arguments are merged into the map of
+// their name, and other repeated directives are gathered in lists.
+func (d *nginxDirectives) add(name string, value interface{}, keyed bool) error {
+	existing, ok := d.node[name]
+	switch {
+	case !ok:
+		d.node[name] = value
+		d.keyed[name] = keyed
+	case keyed && d.keyed[name]:
+		m := existing.(map[string]interface{})
+		for key, body := range value.(map[string]interface{}) {
+			if _, dup := m[key]; dup {
+				return fmt.Errorf("duplicate block %q", name+" "+key)
+			}
+			m[key] = body
+		}
+	case d.repeated[name]:
+		d.node[name] = append(existing.([]interface{}), value)
+	default:
+		d.repeated[name] = true
+		d.keyed[name] = false
+		d.node[name] = []interface{}{existing, value}
+	}
+	return nil
+}
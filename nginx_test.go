@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestParseNginx(t *testing.T) {
+	cfg, err := ParseNginx(`
+user www-data;
+worker_processes auto; # a comment
+events { worker_connections 1024; }
+
+http {
+	include /etc/nginx/mime.types;
+	log_format main '$remote_addr "$request"';
+	server {
+		listen 80;
+		listen 443 ssl;
+		server_name example.com www.example.com;
+		location / {
+			root /var/www;
+		}
+		location /api {
+			proxy_pass http://backend;
+		}
+		location ~ \.php$ {
+			deny all;
+		}
+	}
+	server {
+		listen 8080;
+		ip_hash;
+	}
+}
+`)
+	expect(t, err, nil)
+	expect(t, cfg.UString("user"), "www-data")
+	expect(t, cfg.UString("events.worker_connections"), "1024")
+	expect(t, cfg.UString("http.include"), "/etc/nginx/mime.types")
+	expect(t, cfg.UString("http.log_format.1"), `$remote_addr "$request"`)
+	expect(t, len(cfg.UList("http.server")), 2)
+	expect(t, cfg.UString("http.server.0.listen.0"), "80")
+	expect(t, cfg.UString("http.server.0.listen.1.1"), "ssl")
+	expect(t, cfg.UString("http.server.0.server_name.1"), "www.example.com")
+	expect(t, cfg.UString("http.server.0.location./.root"), "/var/www")
+	expect(t, cfg.UString("http.server.0.location./api.proxy_pass"), "http://backend")
+	expect(t, cfg.UString(`http.server.0.location.[~ \.php$].deny`), "all")
+	expect(t, cfg.UBool("http.server.1.ip_hash"), true)
+
+	for doc, msg := range map[string]string{
+		"a b":                `Nginx line 1: missing ';' after "a"`,
+		"a {\n b c;\n":       `Nginx line 3: missing '}'`,
+		"a;\n}":              `Nginx line 2: unexpected '}'`,
+		"a {\n b c }":        `Nginx line 2: missing ';' after "b"`,
+		";":                  `Nginx line 1: unexpected ";"`,
+		"l /a { }\nl /a { }": `Nginx line 2: duplicate block "l /a"`,
+		"a 'open;":           `Nginx line 1: unterminated string`,
+	} {
+		_, err := ParseNginx(doc)
+		if err == nil {
+			t.Errorf("%q: expected error %q", doc, msg)
+			continue
+		}
+		expect(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NotFoundError is returned when a path doesn't resolve, because of a
+// missing map key, a list index out of range, or a value where a map or
+// list was expected. It describes the deepest node found, so that callers
+// can tell what is available:
+//
+//	var nf *config.NotFoundError
+//	if errors.As(err, &nf) && nf.Type == "map" {
+//		log.Printf("%v; available keys: %s", err, strings.Join(nf.Keys, ", "))
+//	}
+type NotFoundError struct {
+	// Path is the path up to the segment which wasn't found.
+	Path string
+	// Resolved is the path of the deepest node found, "" for the root.
+	Resolved string
+	// Type is the type of that node, as named by schemas: "map", "list",
+	// "string", "int", "float", "bool", "time" or "null".
+	Type string
+	// Keys holds the sorted keys of a map, and Len the length of a list.
+	Keys []string
+	Len  int
+
+	node  interface{}
+	parts []string
+	pos   int
+}
+
+// newNotFoundError returns the error for the key parts[pos] missing
+// below node.
+func newNotFoundError(node interface{}, parts []string, pos int) *NotFoundError {
+	e := &NotFoundError{
+		Path:     formatPath(parts[:pos+1]),
+		Resolved: formatPath(parts[:pos]),
+		Type:     typeName(node),
+		node:     node,
+		parts:    parts,
+		pos:      pos,
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		e.Keys = make([]string, 0, len(c))
+		for k := range c {
+			e.Keys = append(e.Keys, k)
+		}
+		sort.Strings(e.Keys)
+	case []interface{}:
+		e.Len = len(c)
+	}
+	return e
+}
+
+func (e *NotFoundError) Error() string {
+	// Messages keep the plain dotted form of the path.
+	path := strings.Join(e.parts[:e.pos+1], ".")
+	switch c := e.node.(type) {
+	case map[string]interface{}:
+		// Suggestions are only computed when the message is used, since
+		// missing keys are common with defaults and fallbacks.
+		return fmt.Sprintf("Nonexistent map key at %q%s", path, didYouMean(c, e.parts, e.pos))
+	case []interface{}:
+		if _, err := strconv.ParseInt(e.parts[e.pos], 10, 0); err == nil {
+			return fmt.Sprintf("Index out of range at %q: list has only %v items", path, len(c))
+		}
+		return fmt.Sprintf("Invalid list index at %q", path)
+	}
+	return fmt.Sprintf("Invalid type at %q: expected []interface{} or map[string]interface{}; got %T",
+		path, e.node)
+}
@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNotFoundError(t *testing.T) {
+	cfg, err := ParseYaml(`
+db: {host: localhost, port: 5432, user: app}
+servers: [a, b]
+a.b: {c: 1}
+`)
+	expect(t, err, nil)
+
+	tests := []struct {
+		path string
+		want NotFoundError
+		msg  string
+	}{
+		{"db.password", NotFoundError{Path: "db.password", Resolved: "db", Type: "map",
+			Keys: []string{"host", "port", "user"}},
+			`Nonexistent map key at "db.password"`},
+		{"servers.2", NotFoundError{Path: "servers.2", Resolved: "servers", Type: "list", Len: 2},
+			`Index out of range at "servers.2": list has only 2 items`},
+		{"servers.first", NotFoundError{Path: "servers.first", Resolved: "servers", Type: "list", Len: 2},
+			`Invalid list index at "servers.first"`},
+		{"db.port.x", NotFoundError{Path: "db.port.x", Resolved: "db.port", Type: "int"},
+			`Invalid type at "db.port.x": expected []interface{} or map[string]interface{}; got int`},
+		{`"a.b".d`, NotFoundError{Path: "[a.b].d", Resolved: "[a.b]", Type: "map", Keys: []string{"c"}},
+			`Nonexistent map key at "a.b.d"; did you mean "[a.b].c"?`},
+		{"missing", NotFoundError{Path: "missing", Resolved: "", Type: "map",
+			Keys: []string{"a.b", "db", "servers"}},
+			`Nonexistent map key at "missing"`},
+	}
+	for _, test := range tests {
+		_, err := cfg.String(test.path)
+		var nf *NotFoundError
+		if !errors.As(err, &nf) {
+			t.Errorf("%q: got %v; want a *NotFoundError", test.path, err)
+			continue
+		}
+		got := NotFoundError{Path: nf.Path, Resolved: nf.Resolved, Type: nf.Type, Keys: nf.Keys, Len: nf.Len}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %+v; want %+v", test.path, got, test.want)
+		}
+		expect(t, err.Error(), test.msg)
+	}
+
+	_, err = Get([]interface{}{1}, "-1")
+	expect(t, err.Error(), `Index out of range at "-1": list has only 1 items`)
+}
@@ -0,0 +1,188 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strconv"
+	"time"
+)
+
+// Comma-ok getters -----------------------------------------------------------
+//
+// The *Ok getters report whether a value exists at the path and has the
+// expected type, instead of returning an error. Missing paths don't
+// allocate an error, which makes them cheaper than the error-returning
+// getters in the common "use a default when absent" flow:
+//
+//	port, ok := cfg.IntOk("server.port")
+//	if !ok {
+//		port = 8080
+//	}
+
+// getOk is like get, reporting a failed lookup with false.
+func (cfg *Config) getOk(path string) (interface{}, bool) {
+	n, ok := getPlain(cfg.Root, path)
+	if !ok && (len(cfg.fallbacks) > 0 || !plainPath(path)) {
+		parts, err := parsePath(path)
+		if err != nil {
+			return nil, false
+		}
+		n, ok = cfg.lookupOk(parts)
+	}
+	cfg.observeLookup(path, ok)
+	if !ok {
+		return nil, false
+	}
+	cfg.trackRead(path)
+	if cfg.refresh != nil {
+		n = cfg.refreshed(path, n)
+	}
+	n, err := runGetHooks(path, n)
+	return n, err == nil
+}
+
+// lookupOk resolves parsed path keys through the fallback chain.
+func (cfg *Config) lookupOk(parts []string) (interface{}, bool) {
+	if cfg.primary != nil {
+		if n, ok := cfg.primary.lookupOk(parts); ok {
+			return n, true
+		}
+	} else if n, ok := findParts(cfg.Root, parts); ok {
+		return n, true
+	}
+	for _, fallback := range cfg.fallbacks {
+		if n, ok := fallback.lookupOk(parts); ok {
+			return n, true
+		}
+	}
+	return nil, false
+}
+
+// findParts is like getParts, without building an error on failure.
+func findParts(node interface{}, parts []string) (interface{}, bool) {
+	for _, part := range parts {
+		switch c := node.(type) {
+		case []interface{}:
+			i, err := strconv.Atoi(part)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			node = c[i]
+		case map[string]interface{}:
+			v, ok := c[part]
+			if !ok {
+				return nil, false
+			}
+			node = v
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
+
+// BoolOk returns a bool according to a dotted path and whether it was found.
+func (cfg *Config) BoolOk(path string) (bool, bool) {
+	if v, ok := cfg.flat(path, flatBool); ok {
+		return v.b, true
+	}
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return false, false
+	}
+	v, err := toBool(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// Float64Ok returns a float64 according to a dotted path and whether it was
+// found.
+func (cfg *Config) Float64Ok(path string) (float64, bool) {
+	if v, ok := cfg.flat(path, flatFloat); ok {
+		return v.f, true
+	}
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return 0, false
+	}
+	v, err := toFloat64(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// IntOk returns an int according to a dotted path and whether it was found.
+func (cfg *Config) IntOk(path string) (int, bool) {
+	if v, ok := cfg.flat(path, flatInt); ok {
+		return v.i, true
+	}
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return 0, false
+	}
+	v, err := toInt(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// ListOk returns a []interface{} according to a dotted path and whether it
+// was found.
+func (cfg *Config) ListOk(path string) ([]interface{}, bool) {
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return nil, false
+	}
+	v, err := toList(n)
+	if err == nil {
+		v = cfg.detached(v).([]interface{})
+	}
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// MapOk returns a map[string]interface{} according to a dotted path and
+// whether it was found.
+func (cfg *Config) MapOk(path string) (map[string]interface{}, bool) {
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return nil, false
+	}
+	v, err := toMap(n)
+	if err == nil {
+		v = cfg.detached(v).(map[string]interface{})
+	}
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// StringOk returns a string according to a dotted path and whether it was
+// found.
+func (cfg *Config) StringOk(path string) (string, bool) {
+	if v, ok := cfg.flat(path, flatString); ok {
+		return v.s, true
+	}
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return "", false
+	}
+	v, err := toString(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// TimeOk returns a time.Time according to a dotted path and whether it was
+// found.
+func (cfg *Config) TimeOk(path string) (time.Time, bool) {
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return time.Time{}, false
+	}
+	v, err := toTime(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
+
+// BytesOk returns a []byte according to a dotted path and whether it was
+// found.
+func (cfg *Config) BytesOk(path string) ([]byte, bool) {
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return nil, false
+	}
+	v, err := toBytes(n)
+	return v, cfg.observeConversion(path, err) == nil
+}
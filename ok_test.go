@@ -0,0 +1,76 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestOkGetters(t *testing.T) {
+	cfg, err := ParseYaml(yamlString)
+	expect(t, err, nil)
+
+	b, ok := cfg.BoolOk("map.key2")
+	expect(t, b, true)
+	expect(t, ok, true)
+	f, ok := cfg.Float64Ok("list.4")
+	expect(t, f, 4.3)
+	expect(t, ok, true)
+	n, ok := cfg.IntOk("map.key7")
+	expect(t, n, 42)
+	expect(t, ok, true)
+	s, ok := cfg.StringOk("config.admin[1].username")
+	expect(t, s, "hobbes")
+	expect(t, ok, true)
+	l, ok := cfg.ListOk("config.server")
+	expect(t, len(l), 3)
+	expect(t, ok, true)
+	m, ok := cfg.MapOk("config.admin.0")
+	expect(t, m["password"], "yukon")
+	expect(t, ok, true)
+
+	// Missing paths, mismatched types and invalid paths.
+	n, ok = cfg.IntOk("map.missing")
+	expect(t, n, 0)
+	expect(t, ok, false)
+	n, ok = cfg.IntOk("map.key8")
+	expect(t, n, 0)
+	expect(t, ok, false)
+	_, ok = cfg.StringOk("list.9")
+	expect(t, ok, false)
+	_, ok = cfg.StringOk("list.key")
+	expect(t, ok, false)
+	_, ok = cfg.StringOk("map.key8.deeper")
+	expect(t, ok, false)
+	_, ok = cfg.StringOk("map..key8")
+	expect(t, ok, false)
+	_, ok = cfg.TimeOk("map.key8")
+	expect(t, ok, false)
+	by, ok := cfg.BytesOk("map.key8")
+	expect(t, string(by), "value8")
+	expect(t, ok, true)
+
+	// Fallbacks are consulted like in the other getters.
+	view := cfg.WithFallback(Must(ParseYaml("map: {extra: 1}")))
+	n, ok = view.IntOk("map.extra")
+	expect(t, n, 1)
+	expect(t, ok, true)
+}
+
+func BenchmarkMissingIntOk(b *testing.B) {
+	cfg, _ := ParseYaml(yamlString)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfg.IntOk("map.missing")
+	}
+}
+
+func BenchmarkMissingUInt(b *testing.B) {
+	cfg, _ := ParseYaml(yamlString)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		cfg.UInt("map.missing", 1)
+	}
+}
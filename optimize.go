@@ -0,0 +1,109 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+// Optimized lookups ----------------------------------------------------------
+//
+// Optimize indexes the leaves of a read-mostly config by their dotted
+// paths, with their values already converted for the String, Int, Float64
+// and Bool getters. Those getters then answer with a single map lookup,
+// instead of walking the tree and converting the boxed value. Other
+// getters, and the paths missing from the index, like the ones of maps,
+// lists and fallbacks, still use the tree.
+
+// Types a flatValue converts to.
+const (
+	flatString uint8 = 1 << iota
+	flatInt
+	flatFloat
+	flatBool
+)
+
+// flatValue holds a leaf converted to the types of the scalar getters.
+type flatValue struct {
+	s     string
+	i     int
+	f     float64
+	b     bool
+	types uint8
+}
+
+// flatIndex maps the plain paths of the leaves of a tree to their values.
+type flatIndex struct {
+	root   uintptr
+	values map[string]flatValue
+}
+
+// Optimize indexes the leaves of the config for the String, Int, Float64
+// and Bool getters, and their U and Ok variants. The index is dropped by
+// Set, Delete and the other methods changing the config in place; call
+// Optimize again afterwards. Changes made to the maps and lists of the
+// tree directly aren't seen by an optimized config.
+func (cfg *Config) Optimize() {
+	root, ok := nodeID(cfg.Root)
+	if !ok {
+		return
+	}
+	ix := &flatIndex{root: root, values: map[string]flatValue{}}
+	walkLeaves(cfg.Root, "", func(path string, value interface{}) {
+		if !plainPath(path) {
+			// Bracketed keys are resolved through the tree.
+			return
+		}
+		var v flatValue
+		var err error
+		if v.s, err = toString(value); err == nil {
+			v.types |= flatString
+		}
+		if v.i, err = toInt(value); err == nil {
+			v.types |= flatInt
+		}
+		if v.f, err = toFloat64(value); err == nil {
+			v.types |= flatFloat
+		}
+		if v.b, err = toBool(value); err == nil {
+			v.types |= flatBool
+		}
+		if v.types != 0 {
+			ix.values[path] = v
+		}
+	})
+	cfg.state().flat.Store(ix)
+}
+
+// flat returns the value at a path from the index built by Optimize, when
+// it converts to the given type. Get hooks may change values, so they
+// disable the index.
+func (cfg *Config) flat(path string, typ uint8) (flatValue, bool) {
+	s, ok := cfg.cow.Load().(*cowState)
+	if !ok {
+		return flatValue{}, false
+	}
+	ix, _ := s.flat.Load().(*flatIndex)
+	if ix == nil {
+		return flatValue{}, false
+	}
+	if hooks, _ := getHooks.Load().([]GetHook); len(hooks) > 0 || cfg.refresh != nil {
+		return flatValue{}, false
+	}
+	v, ok := ix.values[path]
+	if !ok || v.types&typ == 0 {
+		return flatValue{}, false
+	}
+	if root, _ := nodeID(cfg.Root); root != ix.root {
+		// The root was replaced since Optimize.
+		return flatValue{}, false
+	}
+	cfg.observeLookup(path, true)
+	cfg.trackRead(path)
+	return v, true
+}
+
+// dropIndex drops the index built by Optimize before the tree changes.
+func (s *cowState) dropIndex() {
+	if ix, _ := s.flat.Load().(*flatIndex); ix != nil {
+		s.flat.Store((*flatIndex)(nil))
+	}
+}
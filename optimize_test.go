@@ -0,0 +1,56 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	cfg := Must(ParseYaml(`
+server: {host: localhost, port: "8080", debug: true, ratio: 0.5}
+servers: [{host: a}, {host: b}]
+"dotted.key": value
+`))
+	cfg.Optimize()
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UString("server.port"), "8080")
+	expect(t, cfg.UBool("server.debug"), true)
+	expect(t, cfg.UFloat64("server.ratio"), 0.5)
+	expect(t, cfg.UString("servers.1.host"), "b")
+	expect(t, cfg.UString("[dotted.key]"), "value")
+	expect(t, cfg.UMap("server")["host"], "localhost")
+
+	// Values which don't convert report the same errors as the tree.
+	_, err := cfg.Int("server.host")
+	expect(t, err != nil, true)
+	_, err = cfg.String("server.missing")
+	expect(t, err != nil, true)
+
+	// Changes drop the index.
+	expect(t, cfg.Set("server.port", 9090), nil)
+	expect(t, cfg.UInt("server.port"), 9090)
+	cfg.Optimize()
+	sub, err := cfg.Get("server")
+	expect(t, err, nil)
+	expect(t, sub.Set("host", "example.com"), nil)
+	expect(t, cfg.UString("server.host"), "example.com")
+	cfg.Optimize()
+	expect(t, cfg.Delete("servers.1"), nil)
+	expect(t, cfg.UString("servers.1.host", "none"), "none")
+	cfg.Optimize()
+	cfg.Root = map[string]interface{}{"server": map[string]interface{}{"host": "other"}}
+	expect(t, cfg.UString("server.host"), "other")
+
+	// Copies keep their values when the original is optimized.
+	cfg = Must(ParseYaml("a: 1"))
+	cp, err := cfg.Copy()
+	expect(t, err, nil)
+	cfg.Optimize()
+	expect(t, cp.Set("a", 2), nil)
+	expect(t, cfg.UInt("a"), 1)
+	expect(t, cp.UInt("a"), 2)
+}
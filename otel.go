@@ -0,0 +1,105 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strconv"
+	"strings"
+)
+
+// OTelAttributes flattens the subtree at a dotted path into OpenTelemetry
+// style attributes, so deployment metadata kept in the config can be put
+// on resources and spans consistently. Keys are the dotted paths of the
+// leaves, including the given path, e.g. "service.name". Values have the
+// types attributes support: string, bool, int64, float64, or a slice of one
+// of them for lists of scalars; lists holding maps or lists are flattened
+// with their indexes. Null values are skipped. The result maps directly to
+// attribute.String, attribute.Int64 and friends, without making this
+// package depend on the OpenTelemetry API.
+func (cfg *Config) OTelAttributes(path string) (map[string]interface{}, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return nil, err
+	}
+	attrs := map[string]interface{}{}
+	flattenAttributes(n, strings.Trim(path, "."), attrs)
+	return attrs, nil
+}
+
+// flattenAttributes adds the attributes of a node found at key.
+func flattenAttributes(node interface{}, key string, attrs map[string]interface{}) {
+	join := func(k string) string {
+		if key == "" {
+			return k
+		}
+		return key + "." + k
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		for k, v := range c {
+			flattenAttributes(v, join(k), attrs)
+		}
+	case []interface{}:
+		if list, ok := attributeSlice(c); ok {
+			attrs[key] = list
+			return
+		}
+		for i, v := range c {
+			flattenAttributes(v, join(strconv.Itoa(i)), attrs)
+		}
+	case nil:
+	default:
+		attrs[key] = attributeValue(c)
+	}
+}
+
+// attributeValue converts a scalar to an attribute value type.
+func attributeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bool, float64, string:
+		return v
+	case int:
+		return int64(v)
+	}
+	s, _ := toString(v)
+	return s
+}
+
+// attributeSlice converts a list of scalars to a typed slice. Lists mixing
+// types are converted to strings.
+func attributeSlice(list []interface{}) (interface{}, bool) {
+	if len(list) == 0 {
+		return []string{}, true
+	}
+	var bools []bool
+	var ints []int64
+	var floats []float64
+	strs := make([]string, len(list))
+	for i, item := range list {
+		switch item.(type) {
+		case map[string]interface{}, []interface{}, nil:
+			return nil, false
+		}
+		switch v := attributeValue(item).(type) {
+		case bool:
+			bools = append(bools, v)
+		case int64:
+			ints = append(ints, v)
+		case float64:
+			floats = append(floats, v)
+		}
+		strs[i], _ = toString(item)
+	}
+
+	switch len(list) {
+	case len(bools):
+		return bools, true
+	case len(ints):
+		return ints, true
+	case len(floats):
+		return floats, true
+	}
+	return strs, true
+}
@@ -0,0 +1,54 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOTelAttributes(t *testing.T) {
+	cfg, err := ParseYaml(`
+service:
+  name: checkout
+  version: 1.4.2
+  instances: 3
+  canary: false
+  weight: 0.25
+  regions: [eu, us]
+  ports: [80, 443]
+  mixed: [1, a]
+  owners:
+    - team: payments
+  empty: ~
+`)
+	expect(t, err, nil)
+
+	attrs, err := cfg.OTelAttributes("service")
+	expect(t, err, nil)
+	want := map[string]interface{}{
+		"service.name":          "checkout",
+		"service.version":       "1.4.2",
+		"service.instances":     int64(3),
+		"service.canary":        false,
+		"service.weight":        0.25,
+		"service.regions":       []string{"eu", "us"},
+		"service.ports":         []int64{80, 443},
+		"service.mixed":         []string{"1", "a"},
+		"service.owners.0.team": "payments",
+	}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("got %#v, want %#v", attrs, want)
+	}
+
+	service, err := cfg.Get("service")
+	expect(t, err, nil)
+	attrs, err = service.OTelAttributes("")
+	expect(t, err, nil)
+	expect(t, attrs["name"], "checkout")
+
+	_, err = cfg.OTelAttributes("deployment")
+	expect(t, err != nil, true)
+}
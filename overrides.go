@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ApplyOverrides sets values given as "path=value" strings, like the
+// --set flags of kubectl or helm:
+//
+//	err := config.ApplyOverrides(cfg, []string{
+//		"replicas=3",            // int
+//		"debug=true",            // bool
+//		"hosts=[a, b]",          // list
+//		"image.tag:string=1.10", // string, not float
+//	})
+//
+// Values are parsed as YAML, so their type is inferred, unless a type
+// follows the path: string, int, float, bool or time. Empty values are
+// empty strings. Overrides are applied in order; the first failing one is
+// reported, the previous ones being applied.
+func ApplyOverrides(cfg *Config, overrides []string) error {
+	for _, override := range overrides {
+		if err := applyOverride(cfg, override); err != nil {
+			return fmt.Errorf("Invalid override %q: %v", override, err)
+		}
+	}
+	return nil
+}
+
+func applyOverride(cfg *Config, override string) error {
+	i := strings.Index(override, "=")
+	if i < 0 {
+		return fmt.Errorf("expected path=value")
+	}
+	path, raw := override[:i], override[i+1:]
+	typ := ""
+	if j := strings.LastIndex(path, ":"); j >= 0 {
+		switch t := path[j+1:]; t {
+		case "string", "int", "float", "bool", "time":
+			path, typ = path[:j], t
+		}
+	}
+	if path == "" {
+		return fmt.Errorf("empty path")
+	}
+
+	var value interface{} = raw
+	if typ != "" {
+		var err error
+		if value, err = castValue(raw, typ); err != nil {
+			return err
+		}
+	} else if raw != "" {
+		parsed, err := ParseYaml(raw)
+		if err != nil {
+			return err
+		}
+		if parsed.Root != nil {
+			value = parsed.Root
+		}
+	}
+	return cfg.Set(path, value)
+}
@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestApplyOverrides(t *testing.T) {
+	cfg := Must(ParseYaml("image: {tag: latest}\nreplicas: 1"))
+	err := ApplyOverrides(cfg, []string{
+		"replicas=3",
+		"debug=true",
+		"hosts=[a, b]",
+		"image.tag:string=1.10",
+		"image.pull:bool=false",
+		"labels.[app.kubernetes.io/name]=web",
+		"note=",
+		"url=http://example.com/?a=b",
+	})
+	expect(t, err, nil)
+	expect(t, cfg.UInt("replicas"), 3)
+	expect(t, cfg.UBool("debug"), true)
+	expect(t, cfg.UString("hosts.1"), "b")
+	expect(t, cfg.UString("image.tag"), "1.10")
+	expect(t, cfg.UMap("image")["pull"], false)
+	expect(t, cfg.UString("labels.[app.kubernetes.io/name]"), "web")
+	expect(t, cfg.UString("note", "unset"), "")
+	expect(t, cfg.UString("url"), "http://example.com/?a=b")
+
+	for override, msg := range map[string]string{
+		"replicas":          `Invalid override "replicas": expected path=value`,
+		"=3":                `Invalid override "=3": empty path`,
+		"replicas:int=many": `Invalid override "replicas:int=many": strconv.ParseInt: parsing "many": invalid syntax`,
+	} {
+		expect(t, ApplyOverrides(cfg, []string{override}).Error(), msg)
+	}
+}
@@ -0,0 +1,408 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSON Patch -----------------------------------------------------------------
+
+// patchOperation is a single operation of a JSON Patch document.
+type patchOperation struct {
+	Op    string           `json:"op"`
+	Path  string           `json:"path"`
+	From  string           `json:"from,omitempty"`
+	Value *json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyJSONPatch applies a JSON Patch document (RFC 6902) to the config.
+// The patch is applied atomically: if any operation fails, including a
+// failed "test", the config is left unchanged. Applied to a config returned
+// by Get, it changes the parent config too, like Set.
+func (c *Config) ApplyJSONPatch(patch []byte) error {
+	if err := c.checkSealed("ApplyJSONPatch", ""); err != nil {
+		return err
+	}
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return err
+	}
+	root, err := normalizeValue(c.Root)
+	if err != nil {
+		return err
+	}
+	for i, op := range ops {
+		if root, err = applyOperation(root, op); err != nil {
+			return fmt.Errorf("Patch operation %d (%s %q): %v", i, op.Op, op.Path, err)
+		}
+	}
+	if err := c.checkRoot(root); err != nil {
+		return err
+	}
+	c.replaceRoot(root)
+	return nil
+}
+
+// applyOperation applies one patch operation and returns the new root.
+func applyOperation(root interface{}, op patchOperation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("Missing value")
+		}
+		if err := json.Unmarshal(*op.Value, &value); err != nil {
+			return nil, err
+		}
+		if value, err = normalizeValue(value); err != nil {
+			return nil, err
+		}
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = getPointer(root, from); err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			if value, err = normalizeValue(value); err != nil {
+				return nil, err
+			}
+		} else {
+			if strings.HasPrefix(op.Path+"/", op.From+"/") && op.Path != op.From {
+				return nil, fmt.Errorf("Can't move %q into itself", op.From)
+			}
+			if root, err = removePointer(root, from); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return addPointer(root, path, value)
+	case "remove":
+		return removePointer(root, path)
+	case "replace":
+		if _, err := getPointer(root, path); err != nil {
+			return nil, err
+		}
+		if root, err = removePointer(root, path); err != nil {
+			return nil, err
+		}
+		return addPointer(root, path, value)
+	case "test":
+		current, err := getPointer(root, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equalTrees(current, value) {
+			return nil, fmt.Errorf("Test failed: value is %#v", current)
+		}
+		return root, nil
+	}
+	return nil, fmt.Errorf("Unknown operation %q", op.Op)
+}
+
+// CreateJSONPatch returns a JSON Patch document (RFC 6902) which turns the
+// from config into the to config.
+func CreateJSONPatch(from, to *Config) ([]byte, error) {
+	ops := diffPointers(from.Root, to.Root, "", []map[string]interface{}{})
+	return json.Marshal(ops)
+}
+
+// diffPointers appends to ops the operations turning a into b.
+func diffPointers(a, b interface{}, path string, ops []map[string]interface{}) []map[string]interface{} {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		if b, ok := b.(map[string]interface{}); ok {
+			keys := make([]string, 0, len(a)+len(b))
+			for k := range a {
+				keys = append(keys, k)
+			}
+			for k := range b {
+				if _, ok := a[k]; !ok {
+					keys = append(keys, k)
+				}
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				av, inA := a[k]
+				bv, inB := b[k]
+				p := path + "/" + escapePointer(k)
+				switch {
+				case !inB:
+					ops = append(ops, map[string]interface{}{"op": "remove", "path": p})
+				case !inA:
+					ops = append(ops, map[string]interface{}{"op": "add", "path": p, "value": bv})
+				default:
+					ops = diffPointers(av, bv, p, ops)
+				}
+			}
+			return ops
+		}
+	case []interface{}:
+		if b, ok := b.([]interface{}); ok {
+			common := len(a)
+			if len(b) < common {
+				common = len(b)
+			}
+			for i := 0; i < common; i++ {
+				ops = diffPointers(a[i], b[i], path+"/"+strconv.Itoa(i), ops)
+			}
+			for i := common; i < len(b); i++ {
+				ops = append(ops, map[string]interface{}{
+					"op": "add", "path": path + "/" + strconv.Itoa(i), "value": b[i]})
+			}
+			for i := len(a) - 1; i >= common; i-- {
+				ops = append(ops, map[string]interface{}{
+					"op": "remove", "path": path + "/" + strconv.Itoa(i)})
+			}
+			return ops
+		}
+	}
+	if !equalTrees(a, b) {
+		ops = append(ops, map[string]interface{}{"op": "replace", "path": path, "value": b})
+	}
+	return ops
+}
+
+// JSON Merge Patch -----------------------------------------------------------
+
+// ApplyMergePatch applies a JSON Merge Patch document (RFC 7386) to the
+// config: objects are merged recursively, null values remove keys and any
+// other value replaces the current one. Like ApplyJSONPatch, it changes
+// the parent of a config returned by Get too.
+func (c *Config) ApplyMergePatch(patch []byte) error {
+	if err := c.checkSealed("ApplyMergePatch", ""); err != nil {
+		return err
+	}
+	// The patch is a document of its own, which the parse hooks leave
+	// alone, like the values of JSON Patch operations.
+	var p interface{}
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return err
+	}
+	p, err := normalizeValue(p)
+	if err != nil {
+		return err
+	}
+	root, err := normalizeValue(c.Root)
+	if err != nil {
+		return err
+	}
+	root = mergePatch(root, p)
+	if err := c.checkRoot(root); err != nil {
+		return err
+	}
+	c.replaceRoot(root)
+	return nil
+}
+
+// mergePatch implements the MergePatch function of RFC 7386.
+func mergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// JSON Pointer ---------------------------------------------------------------
+
+// parsePointer splits a JSON Pointer (RFC 6901) into unescaped tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, fmt.Errorf("Invalid JSON pointer %q", pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(token, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+// escapePointer escapes a key to be used as a JSON Pointer token.
+func escapePointer(key string) string {
+	return strings.Replace(strings.Replace(key, "~", "~0", -1), "/", "~1", -1)
+}
+
+// pointerIndex parses a list index token, allowing len(list) if end is
+// true.
+func pointerIndex(token string, list []interface{}, end bool) (int, error) {
+	max := len(list) - 1
+	if end {
+		max++
+		if token == "-" {
+			return len(list), nil
+		}
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max || (len(token) > 1 && token[0] == '0') {
+		return 0, fmt.Errorf("Invalid list index %q", token)
+	}
+	return i, nil
+}
+
+// getPointer returns the value at the given tokens.
+func getPointer(node interface{}, tokens []string) (interface{}, error) {
+	for _, token := range tokens {
+		switch c := node.(type) {
+		case map[string]interface{}:
+			value, ok := c[token]
+			if !ok {
+				return nil, fmt.Errorf("Nonexistent map key %q", token)
+			}
+			node = value
+		case []interface{}:
+			i, err := pointerIndex(token, c, false)
+			if err != nil {
+				return nil, err
+			}
+			node = c[i]
+		default:
+			return nil, fmt.Errorf("Invalid type at %q: got %T", token, node)
+		}
+	}
+	return node, nil
+}
+
+// modifyPointer replaces the value at the given tokens with the result of
+// fn and returns the new node.
+func modifyPointer(node interface{}, tokens []string, fn func(interface{}) (interface{}, error)) (interface{}, error) {
+	if len(tokens) == 0 {
+		return fn(node)
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		child, ok := c[tokens[0]]
+		if !ok {
+			return nil, fmt.Errorf("Nonexistent map key %q", tokens[0])
+		}
+		child, err := modifyPointer(child, tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[tokens[0]] = child
+		return c, nil
+	case []interface{}:
+		i, err := pointerIndex(tokens[0], c, false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := modifyPointer(c[i], tokens[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		c[i] = child
+		return c, nil
+	}
+	return nil, fmt.Errorf("Invalid type at %q: got %T", tokens[0], node)
+}
+
+// addPointer adds the value at the given tokens, inserting it into lists.
+func addPointer(root interface{}, tokens []string, value interface{}) (interface{}, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	last := tokens[len(tokens)-1]
+	return modifyPointer(root, tokens[:len(tokens)-1], func(parent interface{}) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			c[last] = value
+			return c, nil
+		case []interface{}:
+			i, err := pointerIndex(last, c, true)
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("Invalid type at %q: got %T", last, parent)
+	})
+}
+
+// removePointer removes the value at the given tokens.
+func removePointer(root interface{}, tokens []string) (interface{}, error) {
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+	last := tokens[len(tokens)-1]
+	return modifyPointer(root, tokens[:len(tokens)-1], func(parent interface{}) (interface{}, error) {
+		switch c := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := c[last]; !ok {
+				return nil, fmt.Errorf("Nonexistent map key %q", last)
+			}
+			delete(c, last)
+			return c, nil
+		case []interface{}:
+			i, err := pointerIndex(last, c, false)
+			if err != nil {
+				return nil, err
+			}
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return nil, fmt.Errorf("Invalid type at %q: got %T", last, parent)
+	})
+}
+
+// equalTrees compares two trees, treating ints and floats with the same
+// value as equal.
+func equalTrees(a, b interface{}) bool {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			if bv, ok := b[k]; !ok || !equalTrees(v, bv) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if !equalTrees(a[i], b[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return equalValues(a, b)
+}
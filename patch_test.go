@@ -0,0 +1,154 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+var patchTests = []struct {
+	doc   string
+	patch string
+	want  string
+	ok    bool
+}{
+	// Examples from RFC 6902, appendix A.
+	{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz", "value": "qux"}]`, `{"baz":"qux","foo":"bar"}`, true},
+	{`{"foo": ["bar", "baz"]}`, `[{"op": "add", "path": "/foo/1", "value": "qux"}]`, `{"foo":["bar","qux","baz"]}`, true},
+	{`{"baz": "qux", "foo": "bar"}`, `[{"op": "remove", "path": "/baz"}]`, `{"foo":"bar"}`, true},
+	{`{"foo": ["bar", "qux", "baz"]}`, `[{"op": "remove", "path": "/foo/1"}]`, `{"foo":["bar","baz"]}`, true},
+	{`{"baz": "qux", "foo": "bar"}`, `[{"op": "replace", "path": "/baz", "value": "boo"}]`, `{"baz":"boo","foo":"bar"}`, true},
+	{`{"foo": {"bar": "baz", "waldo": "fred"}, "qux": {"corge": "grault"}}`,
+		`[{"op": "move", "from": "/foo/waldo", "path": "/qux/thud"}]`,
+		`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`, true},
+	{`{"foo": ["all", "grass", "cows", "eat"]}`, `[{"op": "move", "from": "/foo/1", "path": "/foo/3"}]`,
+		`{"foo":["all","cows","eat","grass"]}`, true},
+	{`{"baz": "qux", "foo": ["a", 2, "c"]}`, `[
+		{"op": "test", "path": "/baz", "value": "qux"},
+		{"op": "test", "path": "/foo/1", "value": 2}]`, `{"baz":"qux","foo":["a",2,"c"]}`, true},
+	{`{"baz": "qux"}`, `[{"op": "test", "path": "/baz", "value": "bar"}]`, ``, false},
+	{`{"foo": "bar"}`, `[{"op": "add", "path": "/child", "value": {"grandchild": {}}}]`,
+		`{"child":{"grandchild":{}},"foo":"bar"}`, true},
+	{`{"foo": "bar"}`, `[{"op": "add", "path": "/baz/bat", "value": "qux"}]`, ``, false},
+	{`{"/": 9, "~1": 10}`, `[{"op": "test", "path": "/~01", "value": 10}]`, `{"/":9,"~1":10}`, true},
+	{`{"foo": ["bar"]}`, `[{"op": "add", "path": "/foo/-", "value": ["abc", "def"]}]`, `{"foo":["bar",["abc","def"]]}`, true},
+	// Other operations and failures.
+	{`{"foo": {"bar": 1}}`, `[{"op": "copy", "from": "/foo", "path": "/baz"}, {"op": "add", "path": "/baz/bar", "value": 2}]`,
+		`{"baz":{"bar":2},"foo":{"bar":1}}`, true},
+	{`{"foo": 1}`, `[{"op": "replace", "path": "", "value": [1]}]`, `[1]`, true},
+	{`{"foo": 1}`, `[{"op": "replace", "path": "/bar", "value": 1}]`, ``, false},
+	{`{"foo": {"bar": 1}}`, `[{"op": "move", "from": "/foo", "path": "/foo/bar/baz"}]`, ``, false},
+	{`{"foo": [1]}`, `[{"op": "remove", "path": "/foo/01"}]`, ``, false},
+	{`{"foo": 1}`, `[{"op": "add", "path": "/bar"}]`, ``, false},
+	{`{"foo": 1}`, `[{"op": "frobnicate", "path": "/foo"}]`, ``, false},
+	{`{"foo": 1}`, `[{"op": "add", "path": "/bar", "value": 2}, {"op": "remove", "path": "/baz"}]`, ``, false},
+}
+
+func TestApplyJSONPatch(t *testing.T) {
+	for _, test := range patchTests {
+		cfg := Must(ParseJson(test.doc))
+		before, _ := RenderJson(cfg.Root)
+		err := cfg.ApplyJSONPatch([]byte(test.patch))
+		got, _ := RenderJson(cfg.Root)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: expected error", test.patch)
+			}
+			if got != before {
+				t.Errorf("%s: failed patch changed the config to %s", test.patch, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.patch, err)
+		} else if got != test.want {
+			t.Errorf("%s: got %s, want %s", test.patch, got, test.want)
+		}
+	}
+}
+
+func TestCreateJSONPatch(t *testing.T) {
+	from := Must(ParseYaml(yamlString))
+	to := Must(from.Copy())
+	to.Set("map.key8", "changed")
+	to.Set("map.key10", []interface{}{"added"})
+	Set(to.Root, "config", map[string]interface{}{"server": []interface{}{"www.google.com"}})
+
+	patch, err := CreateJSONPatch(from, to)
+	expect(t, err, nil)
+	expect(t, string(patch), `[`+
+		`{"op":"remove","path":"/config/admin"},`+
+		`{"op":"remove","path":"/config/server/2"},`+
+		`{"op":"remove","path":"/config/server/1"},`+
+		`{"op":"add","path":"/map/key10","value":["added"]},`+
+		`{"op":"replace","path":"/map/key8","value":"changed"}]`)
+
+	patched := Must(from.Copy())
+	expect(t, patched.ApplyJSONPatch(patch), nil)
+	expect(t, len(Diff(patched, to)), 0)
+
+	same, err := CreateJSONPatch(from, from)
+	expect(t, err, nil)
+	expect(t, string(same), "[]")
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// Example from RFC 7386, section 3.
+	cfg := Must(ParseJson(`{
+  "title": "Goodbye!",
+  "author": {"givenName": "John", "familyName": "Doe"},
+  "tags": ["example", "sample"],
+  "content": "This will be unchanged"
+}`))
+	err := cfg.ApplyMergePatch([]byte(`{
+  "title": "Hello!",
+  "phoneNumber": "+01-123-456-7890",
+  "author": {"familyName": null},
+  "tags": ["example"]
+}`))
+	expect(t, err, nil)
+	got, _ := RenderJson(cfg.Root)
+	expect(t, got, `{"author":{"givenName":"John"},"content":"This will be unchanged",`+
+		`"phoneNumber":"+01-123-456-7890","tags":["example"],"title":"Hello!"}`)
+
+	cfg = Must(ParseJson(`{"a": "b"}`))
+	expect(t, cfg.ApplyMergePatch([]byte(`{"a": {"b": "c"}}`)), nil)
+	expect(t, cfg.UString("a.b"), "c")
+	expect(t, cfg.ApplyMergePatch([]byte(`["c"]`)), nil)
+	expect(t, cfg.UString("0"), "c")
+	expect(t, cfg.ApplyMergePatch([]byte(`{"a":`)) != nil, true)
+}
+
+func TestPatchViews(t *testing.T) {
+	cfg := Must(ParseYaml("a: {b: 1, c: 2}"))
+	v, err := cfg.Get("a")
+	expect(t, err, nil)
+	expect(t, v.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "/b", "value": 3}]`)), nil)
+	expect(t, cfg.UInt("a.b"), 3)
+	expect(t, v.ApplyMergePatch([]byte(`{"c": null, "d": 4}`)), nil)
+	expect(t, cfg.UInt("a.d"), 4)
+	expect(t, cfg.UInt("a.c", -1), -1)
+	expect(t, v.UInt("d"), 4)
+
+	// Views of a path the parent no longer holds are detached.
+	expect(t, cfg.Delete("a"), nil)
+	expect(t, v.ApplyMergePatch([]byte(`{"e": 5}`)), nil)
+	expect(t, v.UInt("e"), 5)
+	_, err = cfg.Get("a")
+	expect(t, err != nil, true)
+}
+
+func TestMergePatchSkipsParseHooks(t *testing.T) {
+	defer resetHooks()
+	RegisterParseHook(func(tree interface{}) (interface{}, error) {
+		if m, ok := tree.(map[string]interface{}); ok {
+			m["parsed"] = true
+		}
+		return tree, nil
+	})
+	cfg := Must(New(map[string]interface{}{"a": 1}))
+	expect(t, cfg.ApplyMergePatch([]byte(`{"b": 2}`)), nil)
+	expect(t, cfg.UInt("b"), 2)
+	_, err := cfg.Get("parsed")
+	expect(t, err != nil, true)
+}
@@ -0,0 +1,280 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Paths ----------------------------------------------------------------------
+//
+// Values are addressed with dotted paths, like "development.users.0.name".
+// The grammar of a path, in EBNF, is:
+//
+//	path     = [ "." ] [ segment { separator } [ "." ] ] .
+//	separator = "." segment | bracket .
+//	segment  = bracket | quoted | bare .
+//	bracket  = "[" { bracketChar } "]" .
+//	quoted   = `"` { quotedChar } `"` | "'" { quotedChar } "'" .
+//	bare     = bareChar { bareChar } .
+//
+// A bare segment is any text up to the next "." or "[", where a backslash
+// escapes the following character, so `a\.b` is the single key "a.b". A
+// bracketed segment is taken literally up to the closing "]", except that
+// `\]` and `\\` stand for "]" and "\"; this is the historical way to
+// address keys containing dots, as in "root.[field.one].value". Quoted
+// segments use backslash escapes too, and like brackets may hold any key,
+// including an empty one. A bracket may follow a segment directly, so
+// "servers[0]" is the same as "servers.0".
+//
+// List items are addressed by their index, written as a decimal segment.
+// A bare "*" segment is a wildcard matching every map key and list index;
+// it is only supported by Glob. A literal "*" key is written `\*` or "[*]".
+
+// pathKey is a segment of a parsed path.
+type pathKey struct {
+	name     string
+	wildcard bool
+}
+
+// parsePattern parses a path which may contain wildcards.
+func parsePattern(path string) ([]pathKey, error) {
+	keys := []pathKey{}
+	i := 0
+	if strings.HasPrefix(path, ".") {
+		i++
+	}
+	fail := func(format string, args ...interface{}) ([]pathKey, error) {
+		return nil, fmt.Errorf("Invalid path %q: %s at offset %d",
+			path, fmt.Sprintf(format, args...), i)
+	}
+
+	for i < len(path) {
+		var key pathKey
+		var buf strings.Builder
+		switch c := path[i]; c {
+		case '[':
+			i++
+			for {
+				if i >= len(path) {
+					return fail("unterminated bracket")
+				}
+				if path[i] == ']' {
+					i++
+					break
+				}
+				if path[i] == '\\' && i+1 < len(path) && (path[i+1] == ']' || path[i+1] == '\\') {
+					i++
+				}
+				buf.WriteByte(path[i])
+				i++
+			}
+			key.name = buf.String()
+		case '"', '\'':
+			i++
+			for {
+				if i >= len(path) {
+					return fail("unterminated quote")
+				}
+				if path[i] == c {
+					i++
+					break
+				}
+				if path[i] == '\\' {
+					if i++; i >= len(path) {
+						return fail("unterminated escape")
+					}
+				}
+				buf.WriteByte(path[i])
+				i++
+			}
+			key.name = buf.String()
+		default:
+			escaped := false
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				if path[i] == '\\' {
+					if i++; i >= len(path) {
+						return fail("unterminated escape")
+					}
+					escaped = true
+				}
+				buf.WriteByte(path[i])
+				i++
+			}
+			key.name = buf.String()
+			if key.name == "" {
+				return fail("empty key")
+			}
+			key.wildcard = key.name == "*" && !escaped
+		}
+		keys = append(keys, key)
+
+		if i < len(path) {
+			switch path[i] {
+			case '.':
+				i++
+			case '[':
+			default:
+				return fail("unexpected %q", path[i])
+			}
+		}
+	}
+	return keys, nil
+}
+
+// plainPath reports whether a path is a plain dotted path, which
+// parsePath would just split.
+func plainPath(path string) bool {
+	return !strings.ContainsAny(path, `[]"'\*`) && !strings.Contains(path, "..")
+}
+
+// parsePath parses a path into its keys, rejecting wildcards.
+func parsePath(path string) ([]string, error) {
+	if plainPath(path) {
+		// Plain dotted paths need no tokenizing.
+		trimmed := strings.TrimSuffix(strings.TrimPrefix(path, "."), ".")
+		if trimmed == "" {
+			return []string{}, nil
+		}
+		return strings.Split(trimmed, "."), nil
+	}
+	keys, err := parsePattern(path)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		if key.wildcard {
+			return nil, fmt.Errorf("Invalid path %q: wildcards are only supported by Glob", path)
+		}
+		parts[i] = key.name
+	}
+	return parts, nil
+}
+
+// formatKey returns a key in the form used in paths, bracketing it when
+// it can't be written bare.
+func formatKey(key string) string {
+	if key != "" && key != "*" && !strings.ContainsAny(key, `.[]\`) &&
+		key[0] != '"' && key[0] != '\'' {
+		return key
+	}
+	key = strings.Replace(key, `\`, `\\`, -1)
+	return "[" + strings.Replace(key, "]", `\]`, -1) + "]"
+}
+
+// formatPath joins keys into a path which parses back into the same keys.
+func formatPath(keys []string) string {
+	path := ""
+	for _, key := range keys {
+		path = joinPath(path, key)
+	}
+	return path
+}
+
+// joinPath appends a key to a dotted path, bracketing keys which can't be
+// written bare, like the ones containing dots.
+func joinPath(path, key string) string {
+	key = formatKey(key)
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// Glob returns the sorted paths of the values matching a path pattern,
+// where "*" segments match every map key and list index:
+//
+//	// ["users.0.name", "users.1.name"]
+//	paths, err := cfg.Glob("users.*.name")
+//
+// The returned paths can be passed to the getters.
+func (cfg *Config) Glob(pattern string) ([]string, error) {
+	keys, err := parsePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	matches := []string{}
+	globKeys(cfg.Root, keys, "", &matches)
+	sort.Strings(matches)
+	return matches, nil
+}
+
+// globKeys appends to matches the paths below node matching keys.
+func globKeys(node interface{}, keys []pathKey, path string, matches *[]string) {
+	if len(keys) == 0 {
+		*matches = append(*matches, path)
+		return
+	}
+	key := keys[0]
+	switch c := node.(type) {
+	case map[string]interface{}:
+		if !key.wildcard {
+			if v, ok := c[key.name]; ok {
+				globKeys(v, keys[1:], joinPath(path, key.name), matches)
+			}
+			return
+		}
+		for k, v := range c {
+			globKeys(v, keys[1:], joinPath(path, k), matches)
+		}
+	case []interface{}:
+		if !key.wildcard {
+			if i, err := strconv.Atoi(key.name); err == nil && i >= 0 && i < len(c) {
+				globKeys(c[i], keys[1:], joinPath(path, key.name), matches)
+			}
+			return
+		}
+		for i, v := range c {
+			globKeys(v, keys[1:], joinPath(path, strconv.Itoa(i)), matches)
+		}
+	}
+}
+
+// Find returns the paths of the values satisfying a predicate, like all the
+// strings mentioning localhost:
+//
+//	paths := cfg.Find(func(path string, v interface{}) bool {
+//		s, ok := v.(string)
+//		return ok && strings.Contains(s, "localhost")
+//	})
+//
+// The predicate is called for every map, list and value below the root,
+// in the order of their paths, with list items in the order of their
+// indices.
+func (cfg *Config) Find(match func(path string, v interface{}) bool) []string {
+	paths := []string{}
+	findValues(cfg.Root, "", match, &paths)
+	return paths
+}
+
+// findValues appends to paths the paths of the values below node
+// satisfying match.
+func findValues(node interface{}, path string, match func(string, interface{}) bool, paths *[]string) {
+	visit := func(path string, v interface{}) {
+		if match(path, v) {
+			*paths = append(*paths, path)
+		}
+		findValues(v, path, match, paths)
+	}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(c))
+		for k := range c {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			visit(joinPath(path, k), c[k])
+		}
+	case []interface{}:
+		for i, v := range c {
+			visit(joinPath(path, strconv.Itoa(i)), v)
+		}
+	}
+}
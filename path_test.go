@@ -0,0 +1,242 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", []string{}},
+		{".", []string{}},
+		{"a", []string{"a"}},
+		{".a.b", []string{"a", "b"}},
+		{"a.b.", []string{"a", "b"}},
+		{"list.0.name", []string{"list", "0", "name"}},
+		{"list[0].name", []string{"list", "0", "name"}},
+		{"list[0][1]", []string{"list", "0", "1"}},
+		{"root.[field.one].value", []string{"root", "field.one", "value"}},
+		{`[a\]b].c`, []string{"a]b", "c"}},
+		{`[a\b]`, []string{`a\b`}},
+		{`[a\\b]`, []string{`a\b`}},
+		{`a\.b.c`, []string{"a.b", "c"}},
+		{`"a.b".c`, []string{"a.b", "c"}},
+		{`'it''s'`, nil},
+		{`'it\'s'`, []string{"it's"}},
+		{`""`, []string{""}},
+		{"[]", []string{""}},
+		{`\*`, []string{"*"}},
+		{"[*]", []string{"*"}},
+		{"field number 3", []string{"field number 3"}},
+		{"a..b", nil},
+		{"..a", nil},
+		{"a.[b", nil},
+		{`"a`, nil},
+		{`a\`, nil},
+		{"[a]b", nil},
+		{"a.*.b", nil},
+	}
+	for _, test := range tests {
+		got, err := parsePath(test.path)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("parsePath(%q): expected an error; got %q", test.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePath(%q): %v", test.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parsePath(%q): got %q; want %q", test.path, got, test.want)
+		}
+	}
+}
+
+func TestFormatPath(t *testing.T) {
+	expect(t, formatPath([]string{"a", "0", "b"}), "a.0.b")
+	expect(t, formatPath([]string{"a.b", "c"}), "[a.b].c")
+	expect(t, formatPath([]string{`a]\b`}), `[a\]\\b]`)
+	expect(t, formatPath([]string{"*", "", `"q"`}), `[*].[].["q"]`)
+}
+
+func TestPathSegments(t *testing.T) {
+	cfg, err := ParseYaml(`
+a.b:
+  "*": star
+  "c]d": bracket
+list:
+  - name: one
+`)
+	expect(t, err, nil)
+
+	s, err := cfg.String(`[a.b].\*`)
+	expect(t, err, nil)
+	expect(t, s, "star")
+	s, err = cfg.String(`"a.b"."c]d"`)
+	expect(t, err, nil)
+	expect(t, s, "bracket")
+	s, err = cfg.String("list[0].name")
+	expect(t, err, nil)
+	expect(t, s, "one")
+
+	_, err = cfg.String("list.*.name")
+	expect(t, err.Error(), `Invalid path "list.*.name": wildcards are only supported by Glob`)
+	_, err = cfg.String("a..b")
+	expect(t, err.Error(), `Invalid path "a..b": empty key at offset 2`)
+
+	expect(t, cfg.Set(`[a.b].[x.y]`, 1), nil)
+	n, err := cfg.Int(`"a.b"."x.y"`)
+	expect(t, err, nil)
+	expect(t, n, 1)
+}
+
+func TestGlob(t *testing.T) {
+	cfg, err := ParseYaml(`
+users:
+  - name: alice
+    roles: [admin]
+  - name: bob
+    roles: []
+groups:
+  a.b: {name: dotted}
+  c: {name: plain}
+`)
+	expect(t, err, nil)
+
+	tests := []struct {
+		pattern string
+		want    []string
+	}{
+		{"users.*.name", []string{"users.0.name", "users.1.name"}},
+		{"users.1.*", []string{"users.1.name", "users.1.roles"}},
+		{"groups.*.name", []string{"groups.[a.b].name", "groups.c.name"}},
+		{"*.0.name", []string{"users.0.name"}},
+		{"users.*.missing", []string{}},
+		{"", []string{""}},
+	}
+	for _, test := range tests {
+		got, err := cfg.Glob(test.pattern)
+		expect(t, err, nil)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Glob(%q): got %q; want %q", test.pattern, got, test.want)
+		}
+		for _, path := range got {
+			if _, err := cfg.Get(path); err != nil {
+				t.Errorf("Glob(%q) returned unreachable path %q: %v", test.pattern, path, err)
+			}
+		}
+	}
+
+	_, err = cfg.Glob("users[")
+	expect(t, err != nil, true)
+}
+
+func TestFindValues(t *testing.T) {
+	cfg, err := ParseYaml(`
+db:
+  url: postgres://localhost:5432/app
+  replicas: [db1.internal, localhost]
+cache: {url: redis://cache.internal}
+a.b: localhost
+port: 80
+`)
+	expect(t, err, nil)
+
+	got := cfg.Find(func(path string, v interface{}) bool {
+		s, ok := v.(string)
+		return ok && strings.Contains(s, "localhost")
+	})
+	want := []string{"[a.b]", "db.replicas.1", "db.url"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Find: got %q; want %q", got, want)
+	}
+	for _, path := range got {
+		if _, err := cfg.String(path); err != nil {
+			t.Errorf("Find returned unreachable path %q: %v", path, err)
+		}
+	}
+
+	// Maps and lists are matched too.
+	got = cfg.Find(func(path string, v interface{}) bool {
+		_, ok := v.([]interface{})
+		return ok
+	})
+	expect(t, len(got), 1)
+	expect(t, got[0], "db.replicas")
+	expect(t, len(cfg.Find(func(string, interface{}) bool { return false })), 0)
+}
+
+func FuzzParsePath(f *testing.F) {
+	for _, seed := range []string{
+		"", ".", "a.b.c", "list[0].name", "root.[field.one].value",
+		`[a\]b]`, `"a.b".c`, `'x\'y'`, `a\.b`, "*.x", `\*`, "a..b", "[",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, path string) {
+		keys, err := parsePattern(path)
+		if err != nil {
+			return
+		}
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key.name
+			if key.wildcard {
+				if _, err := parsePath(path); err == nil {
+					t.Fatalf("parsePath(%q) accepted a wildcard", path)
+				}
+				return
+			}
+		}
+		if direct, err := parsePath(path); err != nil || !reflect.DeepEqual(direct, parts) {
+			t.Fatalf("parsePath(%q): got %q, %v; want %q", path, direct, err, parts)
+		}
+		formatted := formatPath(parts)
+		again, err := parsePath(formatted)
+		if err != nil {
+			t.Fatalf("parsePath(%q) of formatted %q: %v", formatted, path, err)
+		}
+		if !reflect.DeepEqual(again, parts) {
+			t.Fatalf("round trip of %q through %q: got %q; want %q",
+				path, formatted, again, parts)
+		}
+	})
+}
+
+func FuzzGetSet(f *testing.F) {
+	f.Add("a.b", "a.b")
+	f.Add("list.0", "list")
+	f.Add(`[x.y].z`, `"x.y"`)
+	f.Fuzz(func(t *testing.T, set, get string) {
+		parts, err := parsePath(set)
+		if err != nil || len(parts) == 0 {
+			return
+		}
+		for _, part := range parts {
+			// Keep list indices small, Set allocates up to them.
+			if i, err := strconv.Atoi(part); err == nil && (i < 0 || i > 100) {
+				return
+			}
+		}
+		cfg := &Config{}
+		if err := cfg.Set(set, "value"); err != nil {
+			t.Fatalf("Set(%q): %v", set, err)
+		}
+		// Any read must either succeed or fail cleanly.
+		cfg.Get(get)
+		if s, err := cfg.String(formatPath(parts)); err != nil || s != "value" {
+			t.Fatalf("Get(%q) after Set(%q): %q, %v", formatPath(parts), set, s, err)
+		}
+	})
+}
@@ -0,0 +1,661 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// Property lists -------------------------------------------------------------
+//
+// Property lists are the configuration files of macOS, like the ones of
+// LaunchAgents. Dictionaries become maps and arrays lists; integers, reals,
+// booleans, strings, dates and data become int, float64, bool, string,
+// time.Time and []byte values.
+
+// plistHeader starts the XML property lists.
+const plistHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+`
+
+// bplistMagic starts the binary property lists.
+const bplistMagic = "bplist00"
+
+// bplistEpoch is the reference date of binary plist dates.
+var bplistEpoch = time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ParsePlist reads a property list, in the XML or the binary format.
+func ParsePlist(cfg []byte) (*Config, error) {
+	var root interface{}
+	var err error
+	if bytes.HasPrefix(cfg, []byte(bplistMagic)) {
+		root, err = parseBinaryPlist(cfg)
+	} else {
+		root, err = parseXmlPlist(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(root)
+}
+
+// ParsePlistFile reads a property list from the given filename. Like
+// ParseJsonFile, it decompresses compressed files transparently.
+func ParsePlistFile(filename string) (*Config, error) {
+	cfg, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlist(cfg)
+}
+
+// RenderPlist renders an XML property list. Like RenderJson, it accepts a
+// *Config or any value from a config tree. Property lists have no null
+// value, so nil values can't be rendered.
+func RenderPlist(cfg interface{}) (string, error) {
+	cfg, err := renderable(cfg)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(plistHeader)
+	if err := writeXmlPlist(&buf, cfg, ""); err != nil {
+		return "", err
+	}
+	buf.WriteString("</plist>\n")
+	return buf.String(), nil
+}
+
+// RenderBinaryPlist renders a binary property list, see RenderPlist.
+func RenderBinaryPlist(cfg interface{}) ([]byte, error) {
+	cfg, err := renderable(cfg)
+	if err != nil {
+		return nil, err
+	}
+	w := &bplistWriter{}
+	if _, err := w.flatten(cfg); err != nil {
+		return nil, err
+	}
+	return w.encode(), nil
+}
+
+// XML ------------------------------------------------------------------------
+
+// parseXmlPlist reads an XML property list.
+func parseXmlPlist(data []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	start, err := nextPlistElement(dec)
+	if err != nil {
+		return nil, err
+	}
+	if start == nil || start.Name.Local != "plist" {
+		return nil, fmt.Errorf("Invalid plist: missing <plist> element")
+	}
+	value, err := nextPlistElement(dec)
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		return nil, nil
+	}
+	return parseXmlPlistValue(dec, *value)
+}
+
+// nextPlistElement returns the next start element, skipping comments and
+// whitespace. It returns nil at the end of the enclosing element.
+func nextPlistElement(dec *xml.Decoder) (*xml.StartElement, error) {
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, fmt.Errorf("Invalid plist: unexpected end of document")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			return &tok, nil
+		case xml.EndElement:
+			return nil, nil
+		case xml.CharData:
+			if len(bytes.TrimSpace(tok)) > 0 {
+				return nil, fmt.Errorf("Invalid plist: unexpected text %q", string(tok))
+			}
+		}
+	}
+}
+
+// parseXmlPlistValue reads the value of the given element.
+func parseXmlPlistValue(dec *xml.Decoder, start xml.StartElement) (interface{}, error) {
+	switch start.Name.Local {
+	case "dict":
+		node := map[string]interface{}{}
+		for {
+			key, err := nextPlistElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if key == nil {
+				return node, nil
+			}
+			if key.Name.Local != "key" {
+				return nil, fmt.Errorf("Invalid plist: expected <key>, got <%s>", key.Name.Local)
+			}
+			var name string
+			if err := dec.DecodeElement(&name, key); err != nil {
+				return nil, err
+			}
+			elem, err := nextPlistElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if elem == nil {
+				return nil, fmt.Errorf("Invalid plist: missing value of key %q", name)
+			}
+			if node[name], err = parseXmlPlistValue(dec, *elem); err != nil {
+				return nil, err
+			}
+		}
+	case "array":
+		node := []interface{}{}
+		for {
+			elem, err := nextPlistElement(dec)
+			if err != nil {
+				return nil, err
+			}
+			if elem == nil {
+				return node, nil
+			}
+			item, err := parseXmlPlistValue(dec, *elem)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, item)
+		}
+	case "true", "false":
+		return start.Name.Local == "true", dec.Skip()
+	}
+
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "string":
+		return text, nil
+	case "integer":
+		i, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+		return int(i), err
+	case "real":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	case "date":
+		return time.Parse(time.RFC3339, strings.TrimSpace(text))
+	case "data":
+		return base64.StdEncoding.DecodeString(strings.Join(strings.Fields(text), ""))
+	}
+	return nil, fmt.Errorf("Invalid plist: unknown element <%s>", start.Name.Local)
+}
+
+// writeXmlPlist writes a value indented with tabs, like the plists of
+// macOS.
+func writeXmlPlist(buf *bytes.Buffer, value interface{}, indent string) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 {
+			buf.WriteString(indent + "<dict/>\n")
+			return nil
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		buf.WriteString(indent + "<dict>\n")
+		for _, key := range keys {
+			buf.WriteString(indent + "\t<key>")
+			xml.EscapeText(buf, []byte(key))
+			buf.WriteString("</key>\n")
+			if err := writeXmlPlist(buf, v[key], indent+"\t"); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</dict>\n")
+	case []interface{}:
+		if len(v) == 0 {
+			buf.WriteString(indent + "<array/>\n")
+			return nil
+		}
+		buf.WriteString(indent + "<array>\n")
+		for _, item := range v {
+			if err := writeXmlPlist(buf, item, indent+"\t"); err != nil {
+				return err
+			}
+		}
+		buf.WriteString(indent + "</array>\n")
+	case string:
+		buf.WriteString(indent + "<string>")
+		xml.EscapeText(buf, []byte(v))
+		buf.WriteString("</string>\n")
+	case int:
+		fmt.Fprintf(buf, "%s<integer>%d</integer>\n", indent, v)
+	case float64:
+		fmt.Fprintf(buf, "%s<real>%s</real>\n", indent, strconv.FormatFloat(v, 'g', -1, 64))
+	case bool:
+		fmt.Fprintf(buf, "%s<%t/>\n", indent, v)
+	case time.Time:
+		fmt.Fprintf(buf, "%s<date>%s</date>\n", indent, v.UTC().Format("2006-01-02T15:04:05Z"))
+	case []byte:
+		fmt.Fprintf(buf, "%s<data>%s</data>\n", indent, base64.StdEncoding.EncodeToString(v))
+	default:
+		return fmt.Errorf("Unsupported plist value: %#v", value)
+	}
+	return nil
+}
+
+// Binary ---------------------------------------------------------------------
+
+// bplistReader reads the objects of a binary property list.
+type bplistReader struct {
+	data       []byte
+	offsets    []uint64
+	refSize    int
+	inProgress map[uint64]bool // containers being read, to detect cycles
+}
+
+// parseBinaryPlist reads a binary property list.
+func parseBinaryPlist(data []byte) (interface{}, error) {
+	if len(data) < len(bplistMagic)+32 {
+		return nil, fmt.Errorf("Invalid binary plist: too short")
+	}
+	trailer := data[len(data)-32:]
+	offsetSize := int(trailer[6])
+	r := &bplistReader{data: data, refSize: int(trailer[7]), inProgress: map[uint64]bool{}}
+	count := binary.BigEndian.Uint64(trailer[8:])
+	top := binary.BigEndian.Uint64(trailer[16:])
+	table := binary.BigEndian.Uint64(trailer[24:])
+	if offsetSize < 1 || offsetSize > 8 || r.refSize < 1 || r.refSize > 8 ||
+		table > uint64(len(data)) || count > (uint64(len(data))-table)/uint64(offsetSize) {
+		return nil, fmt.Errorf("Invalid binary plist: bad trailer")
+	}
+	r.offsets = make([]uint64, count)
+	for i := range r.offsets {
+		start := int(table) + i*offsetSize
+		r.offsets[i] = bplistUint(data[start : start+offsetSize])
+	}
+	return r.object(top)
+}
+
+// bplistUint decodes a big-endian unsigned integer.
+func bplistUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
+
+// bytes returns n bytes at the given offset.
+func (r *bplistReader) bytes(offset, n uint64) ([]byte, error) {
+	if offset > uint64(len(r.data)) || n > uint64(len(r.data))-offset {
+		return nil, fmt.Errorf("Invalid binary plist: object out of bounds")
+	}
+	return r.data[offset : offset+n], nil
+}
+
+// length returns the length of the object with the given marker at
+// offset, and the offset of its content. Lengths over 14 follow the
+// marker as integer objects.
+func (r *bplistReader) length(marker byte, offset uint64) (uint64, uint64, error) {
+	if marker&0xF != 0xF {
+		return uint64(marker & 0xF), offset + 1, nil
+	}
+	b, err := r.bytes(offset+1, 1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if b[0]&0xF0 != 0x10 {
+		return 0, 0, fmt.Errorf("Invalid binary plist: bad length")
+	}
+	size := uint64(1) << (b[0] & 0xF)
+	n, err := r.bytes(offset+2, size)
+	if err != nil {
+		return 0, 0, err
+	}
+	return bplistUint(n), offset + 2 + size, nil
+}
+
+// refs returns n object references at the given offset.
+func (r *bplistReader) refs(offset, n uint64) ([]uint64, error) {
+	if n > uint64(len(r.data)) {
+		return nil, fmt.Errorf("Invalid binary plist: object out of bounds")
+	}
+	b, err := r.bytes(offset, n*uint64(r.refSize))
+	if err != nil {
+		return nil, err
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		refs[i] = bplistUint(b[i*r.refSize : (i+1)*r.refSize])
+	}
+	return refs, nil
+}
+
+// object reads the object with the given index.
+func (r *bplistReader) object(index uint64) (interface{}, error) {
+	if index >= uint64(len(r.offsets)) {
+		return nil, fmt.Errorf("Invalid binary plist: bad object reference %d", index)
+	}
+	offset := r.offsets[index]
+	b, err := r.bytes(offset, 1)
+	if err != nil {
+		return nil, err
+	}
+	marker := b[0]
+
+	switch marker >> 4 {
+	case 0x0:
+		switch marker {
+		case 0x08:
+			return false, nil
+		case 0x09:
+			return true, nil
+		}
+	case 0x1:
+		size := uint64(1) << (marker & 0xF)
+		b, err := r.bytes(offset+1, size)
+		if err != nil {
+			return nil, err
+		}
+		if size > 8 {
+			// 128-bit integers only hold 64-bit values.
+			b = b[size-8:]
+		}
+		if len(b) == 8 {
+			return int(int64(binary.BigEndian.Uint64(b))), nil
+		}
+		return int(bplistUint(b)), nil
+	case 0x2, 0x3:
+		size := uint64(1) << (marker & 0xF)
+		b, err := r.bytes(offset+1, size)
+		if err != nil {
+			return nil, err
+		}
+		var f float64
+		switch size {
+		case 4:
+			f = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case 8:
+			f = math.Float64frombits(binary.BigEndian.Uint64(b))
+		default:
+			return nil, fmt.Errorf("Invalid binary plist: bad real size %d", size)
+		}
+		if marker>>4 == 0x3 {
+			sec, frac := math.Modf(f)
+			return bplistEpoch.Add(time.Duration(sec)*time.Second +
+				time.Duration(frac*float64(time.Second))), nil
+		}
+		return f, nil
+	case 0x4, 0x5, 0x6:
+		n, start, err := r.length(marker, offset)
+		if err != nil {
+			return nil, err
+		}
+		if marker>>4 == 0x6 {
+			if n > uint64(len(r.data)) {
+				return nil, fmt.Errorf("Invalid binary plist: object out of bounds")
+			}
+			b, err := r.bytes(start, 2*n)
+			if err != nil {
+				return nil, err
+			}
+			u := make([]uint16, n)
+			for i := range u {
+				u[i] = binary.BigEndian.Uint16(b[2*i:])
+			}
+			return string(utf16.Decode(u)), nil
+		}
+		b, err := r.bytes(start, n)
+		if err != nil {
+			return nil, err
+		}
+		if marker>>4 == 0x5 {
+			return string(b), nil
+		}
+		return append([]byte(nil), b...), nil
+	case 0xA, 0xD:
+		if r.inProgress[index] {
+			return nil, fmt.Errorf("Invalid binary plist: object %d contains itself", index)
+		}
+		r.inProgress[index] = true
+		defer delete(r.inProgress, index)
+
+		n, start, err := r.length(marker, offset)
+		if err != nil {
+			return nil, err
+		}
+		if marker>>4 == 0xA {
+			refs, err := r.refs(start, n)
+			if err != nil {
+				return nil, err
+			}
+			node := make([]interface{}, n)
+			for i, ref := range refs {
+				if node[i], err = r.object(ref); err != nil {
+					return nil, err
+				}
+			}
+			return node, nil
+		}
+		refs, err := r.refs(start, 2*n)
+		if err != nil {
+			return nil, err
+		}
+		node := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			k, err := r.object(refs[i])
+			if err != nil {
+				return nil, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("Invalid binary plist: dictionary key %#v isn't a string", k)
+			}
+			if node[key], err = r.object(refs[n+i]); err != nil {
+				return nil, err
+			}
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("Invalid binary plist: unsupported object type 0x%02x", marker)
+}
+
+// bplistRefs is a flattened array or dictionary, holding the indexes of
+// its objects: the keys of dictionaries, then their values.
+type bplistRefs struct {
+	marker byte
+	refs   []int
+}
+
+// bplistWriter renders binary property lists.
+type bplistWriter struct {
+	objects []interface{}
+}
+
+// flatten appends a value and its children to the objects, and returns
+// its index.
+func (w *bplistWriter) flatten(value interface{}) (int, error) {
+	index := len(w.objects)
+	w.objects = append(w.objects, value)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		node := bplistRefs{marker: 0xD0, refs: make([]int, 0, 2*len(keys))}
+		for _, key := range keys {
+			ref, _ := w.flatten(key)
+			node.refs = append(node.refs, ref)
+		}
+		for _, key := range keys {
+			ref, err := w.flatten(v[key])
+			if err != nil {
+				return 0, err
+			}
+			node.refs = append(node.refs, ref)
+		}
+		w.objects[index] = node
+	case []interface{}:
+		node := bplistRefs{marker: 0xA0, refs: make([]int, len(v))}
+		for i, item := range v {
+			ref, err := w.flatten(item)
+			if err != nil {
+				return 0, err
+			}
+			node.refs[i] = ref
+		}
+		w.objects[index] = node
+	case string, int, float64, bool, time.Time, []byte:
+	default:
+		return 0, fmt.Errorf("Unsupported plist value: %#v", value)
+	}
+	return index, nil
+}
+
+// encode writes the flattened objects, their offset table and the
+// trailer.
+func (w *bplistWriter) encode() []byte {
+	refSize := bplistIntSize(uint64(len(w.objects)))
+	var buf bytes.Buffer
+	buf.WriteString(bplistMagic)
+	offsets := make([]uint64, len(w.objects))
+	for i, object := range w.objects {
+		offsets[i] = uint64(buf.Len())
+		switch v := object.(type) {
+		case bplistRefs:
+			n := len(v.refs)
+			if v.marker == 0xD0 {
+				n /= 2
+			}
+			writeBplistLength(&buf, v.marker, n)
+			for _, ref := range v.refs {
+				writeBplistUint(&buf, uint64(ref), refSize)
+			}
+		case string:
+			ascii := true
+			for i := 0; i < len(v); i++ {
+				if v[i] >= 0x80 {
+					ascii = false
+					break
+				}
+			}
+			if ascii {
+				writeBplistLength(&buf, 0x50, len(v))
+				buf.WriteString(v)
+				break
+			}
+			u := utf16.Encode([]rune(v))
+			writeBplistLength(&buf, 0x60, len(u))
+			for _, c := range u {
+				writeBplistUint(&buf, uint64(c), 2)
+			}
+		case int:
+			writeBplistInt(&buf, int64(v))
+		case float64:
+			buf.WriteByte(0x23)
+			writeBplistUint(&buf, math.Float64bits(v), 8)
+		case bool:
+			if v {
+				buf.WriteByte(0x09)
+			} else {
+				buf.WriteByte(0x08)
+			}
+		case time.Time:
+			buf.WriteByte(0x33)
+			seconds := float64(v.Sub(bplistEpoch)) / float64(time.Second)
+			writeBplistUint(&buf, math.Float64bits(seconds), 8)
+		case []byte:
+			writeBplistLength(&buf, 0x40, len(v))
+			buf.Write(v)
+		}
+	}
+
+	table := uint64(buf.Len())
+	offsetSize := bplistIntSize(table)
+	for _, offset := range offsets {
+		writeBplistUint(&buf, offset, offsetSize)
+	}
+	buf.Write(make([]byte, 6))
+	buf.WriteByte(byte(offsetSize))
+	buf.WriteByte(byte(refSize))
+	writeBplistUint(&buf, uint64(len(w.objects)), 8)
+	writeBplistUint(&buf, 0, 8)
+	writeBplistUint(&buf, table, 8)
+	return buf.Bytes()
+}
+
+// bplistIntSize returns the number of bytes needed by an unsigned integer.
+func bplistIntSize(n uint64) int {
+	switch {
+	case n <= math.MaxUint8:
+		return 1
+	case n <= math.MaxUint16:
+		return 2
+	case n <= math.MaxUint32:
+		return 4
+	}
+	return 8
+}
+
+// writeBplistUint writes a big-endian unsigned integer on size bytes.
+func writeBplistUint(buf *bytes.Buffer, n uint64, size int) {
+	for i := size - 1; i >= 0; i-- {
+		buf.WriteByte(byte(n >> (8 * uint(i))))
+	}
+}
+
+// writeBplistInt writes an integer object. Negative integers take 8
+// bytes, the only signed size.
+func writeBplistInt(buf *bytes.Buffer, n int64) {
+	size := 8
+	if n >= 0 {
+		size = bplistIntSize(uint64(n))
+	}
+	buf.WriteByte(0x10 | byte(bplistSizeLog(size)))
+	writeBplistUint(buf, uint64(n), size)
+}
+
+// writeBplistLength writes the marker of an object with a length.
+func writeBplistLength(buf *bytes.Buffer, marker byte, n int) {
+	if n < 0xF {
+		buf.WriteByte(marker | byte(n))
+		return
+	}
+	buf.WriteByte(marker | 0xF)
+	writeBplistInt(buf, int64(n))
+}
+
+// bplistSizeLog returns the base 2 logarithm of an integer size.
+func bplistSizeLog(size int) int {
+	n := 0
+	for size > 1 {
+		size >>= 1
+		n++
+	}
+	return n
+}
@@ -0,0 +1,114 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var plistString = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>com.example.agent</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>/usr/local/bin/agent</string>
+		<string>--verbose</string>
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>StartInterval</key>
+	<integer>300</integer>
+	<!-- a comment -->
+	<key>Ratio</key>
+	<real>0.75</real>
+	<key>Since</key>
+	<date>2020-01-02T03:04:05Z</date>
+	<key>Token</key>
+	<data>
+	AQID
+	</data>
+	<key>Empty</key>
+	<dict/>
+</dict>
+</plist>
+`
+
+func TestParsePlist(t *testing.T) {
+	cfg, err := ParsePlist([]byte(plistString))
+	expect(t, err, nil)
+	expect(t, cfg.UString("Label"), "com.example.agent")
+	expect(t, cfg.UString("ProgramArguments.1"), "--verbose")
+	expect(t, cfg.UBool("RunAtLoad"), true)
+	expect(t, cfg.UInt("StartInterval"), 300)
+	expect(t, cfg.UFloat64("Ratio"), 0.75)
+	expect(t, cfg.UTime("Since").Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), true)
+	expect(t, string(cfg.UBytes("Token")), "\x01\x02\x03")
+
+	out, err := RenderPlist(cfg)
+	expect(t, err, nil)
+	expect(t, strings.Contains(out, "\t<key>RunAtLoad</key>\n\t<true/>\n"), true)
+	back, err := ParsePlist([]byte(out))
+	expect(t, err, nil)
+	expect(t, len(Diff(cfg, back)), 0)
+
+	for _, doc := range []string{
+		"<dict></dict>",
+		"<plist><dict><string>x</string></dict></plist>",
+		"<plist><dict><key>a</key></dict></plist>",
+		"<plist><integer>x</integer></plist>",
+	} {
+		if _, err := ParsePlist([]byte(doc)); err == nil {
+			t.Errorf("expected error for %q", doc)
+		}
+	}
+	_, err = RenderPlist(map[string]interface{}{"a": nil})
+	expect(t, err.Error(), "Unsupported plist value: <nil>")
+}
+
+func TestBinaryPlist(t *testing.T) {
+	long := strings.Repeat("x", 20)
+	root := map[string]interface{}{
+		"name":     "agent",
+		"unicode":  "héllo ☃",
+		"long":     long,
+		"port":     8080,
+		"big":      1 << 40,
+		"negative": -3,
+		"ratio":    0.5,
+		"enabled":  false,
+		"since":    time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		"token":    []byte{1, 2, 3},
+		"list":     []interface{}{1, "two", []interface{}{}},
+		"nested":   map[string]interface{}{"a": map[string]interface{}{}},
+	}
+	data, err := RenderBinaryPlist(root)
+	expect(t, err, nil)
+	expect(t, string(data[:8]), "bplist00")
+
+	cfg, err := ParsePlist(data)
+	expect(t, err, nil)
+	since := cfg.UTime("since")
+	expect(t, since.Equal(root["since"].(time.Time)), true)
+	delete(cfg.Root.(map[string]interface{}), "since")
+	delete(root, "since")
+	if !reflect.DeepEqual(cfg.Root, root) {
+		t.Errorf("got %#v, want %#v", cfg.Root, root)
+	}
+
+	// Corrupted documents are reported, without panicking.
+	for i := 8; i < len(data); i++ {
+		corrupted := append([]byte(nil), data...)
+		corrupted[i] ^= 0xFF
+		ParsePlist(corrupted)
+	}
+	_, err = ParsePlist(data[:20])
+	expect(t, err.Error(), "Invalid binary plist: too short")
+}
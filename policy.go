@@ -0,0 +1,167 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit is a rate of events, like the ones of token bucket limiters.
+type RateLimit struct {
+	Events int
+	Per    time.Duration
+	// Burst is the number of events allowed at once.
+	Burst int
+}
+
+// Rate returns the number of events per second, the rate.Limit of
+// golang.org/x/time/rate.
+func (r RateLimit) Rate() float64 {
+	return float64(r.Events) / r.Per.Seconds()
+}
+
+// Every returns the interval between events at the rate limit.
+func (r RateLimit) Every() time.Duration {
+	return r.Per / time.Duration(r.Events)
+}
+
+// RateLimit returns a rate limit according to a dotted path, written as a
+// string or as a map:
+//
+//	api: 100/s              # or "100 per second", "6000/1m", "5/10s"
+//	login: {events: 5, per: 1m, burst: 10}
+//
+// The burst defaults to the number of events.
+func (cfg *Config) RateLimit(path string) (RateLimit, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return RateLimit{}, err
+	}
+	var r RateLimit
+	invalid := func(format string, args ...interface{}) (RateLimit, error) {
+		return RateLimit{}, cfg.observeConversion(path, fmt.Errorf("Invalid rate limit at %q: %s",
+			displayPath(canonicalPath("", path)), fmt.Sprintf(format, args...)))
+	}
+	switch v := n.(type) {
+	case string:
+		if r.Events, r.Per, err = parseRate(v); err != nil {
+			return invalid("%v", err)
+		}
+	case map[string]interface{}:
+		s, err := cfg.newSection(path, "rate limit")
+		if err != nil {
+			return RateLimit{}, err
+		}
+		r.Per = time.Second
+		s.int("events", &r.Events)
+		s.duration("per", &r.Per)
+		s.int("burst", &r.Burst)
+		if s.err != nil {
+			return RateLimit{}, cfg.observeConversion(path, s.err)
+		}
+	default:
+		return RateLimit{}, cfg.observeConversion(path, typeMismatch("string or map[string]interface{}", n))
+	}
+	if r.Burst == 0 {
+		r.Burst = r.Events
+	}
+	switch {
+	case r.Events <= 0:
+		return invalid("events must be positive")
+	case r.Per <= 0:
+		return invalid("period must be positive")
+	case r.Burst < 0:
+		return invalid("burst can't be negative")
+	}
+	return r, nil
+}
+
+// rateUnits are the words of periods which durations don't spell.
+var rateUnits = map[string]string{"sec": "s", "min": "m", "hr": "h"}
+
+// parseRate parses a rate like "100/s" or "100 per 10 seconds".
+func parseRate(s string) (int, time.Duration, error) {
+	i := strings.IndexByte(s, '/')
+	sep := 1
+	if i < 0 {
+		i, sep = strings.Index(s, " per "), len(" per ")
+	}
+	if i < 0 {
+		return 0, 0, fmt.Errorf("expected events/period, like \"100/s\"; got %q", s)
+	}
+	events, err := strconv.Atoi(strings.TrimSpace(s[:i]))
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad number of events in %q", s)
+	}
+	period := strings.TrimSpace(s[i+sep:])
+	if unit, ok := rateUnits[period]; ok {
+		period = unit
+	}
+	if period != "" && (period[0] < '0' || period[0] > '9') {
+		period = "1" + period
+	}
+	per, err := toDuration(period)
+	if err != nil {
+		return 0, 0, fmt.Errorf("bad period in %q", s)
+	}
+	return events, per, nil
+}
+
+// Backoff is a policy of exponential backoff between retries.
+type Backoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	// Jitter is the fraction of the delays which is randomized, between 0
+	// and 1.
+	Jitter float64
+}
+
+// Delay returns the delay before the given retry, counting from 0, with
+// its jitter applied.
+func (b Backoff) Delay(retry int) time.Duration {
+	d := float64(b.Initial) * math.Pow(b.Multiplier, float64(retry))
+	if d > float64(b.Max) {
+		d = float64(b.Max)
+	}
+	d -= d * b.Jitter * rand.Float64()
+	return time.Duration(d)
+}
+
+// Backoff returns a backoff policy according to the map at a dotted path:
+//
+//	retry: {initial: 100ms, max: 30s, multiplier: 2, jitter: 0.2}
+//
+// The keys default to these values, except jitter which defaults to 0.
+func (cfg *Config) Backoff(path string) (Backoff, error) {
+	s, err := cfg.newSection(path, "backoff")
+	if err != nil {
+		return Backoff{}, err
+	}
+	b := Backoff{Initial: 100 * time.Millisecond, Max: 30 * time.Second, Multiplier: 2}
+	s.duration("initial", &b.Initial)
+	s.duration("max", &b.Max)
+	s.float("multiplier", &b.Multiplier)
+	s.float("jitter", &b.Jitter)
+	if s.err != nil {
+		return Backoff{}, s.err
+	}
+	switch {
+	case b.Initial <= 0:
+		err = s.invalid("initial", fmt.Errorf("must be positive"))
+	case b.Max < b.Initial:
+		err = s.invalid("max", fmt.Errorf("must be at least the initial delay, %v", b.Initial))
+	case b.Multiplier < 1:
+		err = s.invalid("multiplier", fmt.Errorf("must be at least 1"))
+	case b.Jitter < 0 || b.Jitter > 1:
+		err = s.invalid("jitter", fmt.Errorf("must be between 0 and 1"))
+	}
+	return b, err
+}
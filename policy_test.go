@@ -0,0 +1,76 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	cfg := Must(ParseYaml(`
+api: 100/s
+words: 1000 per minute
+window: 5/10s
+short: 30/min
+login: {events: 5, per: 1m, burst: 10}
+defaults: {events: 20}
+zero: 0/s
+garbage: fast
+period: 10/fortnight
+list: [1]
+`))
+	for path, want := range map[string]RateLimit{
+		"api":      {100, time.Second, 100},
+		"words":    {1000, time.Minute, 1000},
+		"window":   {5, 10 * time.Second, 5},
+		"short":    {30, time.Minute, 30},
+		"login":    {5, time.Minute, 10},
+		"defaults": {20, time.Second, 20},
+	} {
+		r, err := cfg.RateLimit(path)
+		expect(t, err, nil)
+		expect(t, r, want)
+	}
+	r, _ := cfg.RateLimit("words")
+	expect(t, r.Every(), 60*time.Millisecond)
+	expect(t, r.Rate() > 16.66 && r.Rate() < 16.67, true)
+
+	for path, msg := range map[string]string{
+		"zero":    `Invalid rate limit at "zero": events must be positive`,
+		"garbage": `Invalid rate limit at "garbage": expected events/period, like "100/s"; got "fast"`,
+		"period":  `Invalid rate limit at "period": bad period in "10/fortnight"`,
+		"list":    `Type mismatch: expected string or map[string]interface{}; got []interface {}`,
+	} {
+		_, err := cfg.RateLimit(path)
+		expect(t, err.Error(), msg)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	cfg := Must(ParseYaml(`
+retry: {initial: 200ms, max: 1s, multiplier: 3, jitter: 0.5}
+defaults: {}
+bad: {max: 10ms}
+jitter: {jitter: 2}
+`))
+	b, err := cfg.Backoff("retry")
+	expect(t, err, nil)
+	expect(t, b, Backoff{200 * time.Millisecond, time.Second, 3, 0.5})
+	for retry, max := range []time.Duration{200 * time.Millisecond, 600 * time.Millisecond, time.Second, time.Second} {
+		d := b.Delay(retry)
+		expect(t, d <= max && d >= max/2, true)
+	}
+
+	b, err = cfg.Backoff("defaults")
+	expect(t, err, nil)
+	expect(t, b, Backoff{100 * time.Millisecond, 30 * time.Second, 2, 0})
+	expect(t, b.Delay(3), 800*time.Millisecond)
+
+	_, err = cfg.Backoff("bad")
+	expect(t, err.Error(), `Invalid backoff config at "bad.max": must be at least the initial delay, 100ms`)
+	_, err = cfg.Backoff("jitter")
+	expect(t, err.Error(), `Invalid backoff config at "jitter.jitter": must be between 0 and 1`)
+}
@@ -0,0 +1,115 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Prompter asks the user for the value at a path. Secret values, see
+// IsSecret, should be read without echoing them.
+type Prompter interface {
+	Prompt(path string, schema *Schema, secret bool) (string, error)
+}
+
+// PromptMissing asks p for the required values of the schema missing from
+// the config, and sets the answers, converted to the types of the schema.
+// Empty answers leave the values missing, for Validate to report them.
+// Prompters with an Interactive method are only asked when it returns
+// true, so that programs prompt on terminals only.
+func (s *Schema) PromptMissing(cfg *Config, p Prompter) error {
+	if i, ok := p.(interface{ Interactive() bool }); ok && !i.Interactive() {
+		return nil
+	}
+	return s.promptMissing(cfg, "", p)
+}
+
+func (s *Schema) promptMissing(cfg *Config, path string, p Prompter) error {
+	names := make([]string, 0, len(s.Keys))
+	for name := range s.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub := s.Keys[name]
+		subPath := joinPath(path, name)
+		if _, err := cfg.lookup(subPath); err == nil || len(sub.Keys) > 0 {
+			// Maps are entered, to prompt for their required keys.
+			if err := sub.promptMissing(cfg, subPath, p); err != nil {
+				return err
+			}
+			continue
+		}
+		if !sub.Required {
+			continue
+		}
+		answer, err := p.Prompt(subPath, sub, IsSecret(subPath))
+		if err != nil {
+			return err
+		}
+		if answer == "" {
+			continue
+		}
+		v, err := decodeTagValue(answer, sub.Type)
+		if err != nil {
+			return fmt.Errorf("Invalid value for %q: %v", subPath, err)
+		}
+		if err := cfg.Set(subPath, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TerminalPrompter prompts on a terminal, showing the description of the
+// values. The package doesn't depend on a terminal library, so hidden
+// input takes a function like golang.org/x/term's ReadPassword:
+//
+//	p := &config.TerminalPrompter{In: os.Stdin, Out: os.Stderr, ReadPassword: term.ReadPassword}
+type TerminalPrompter struct {
+	In  *os.File
+	Out io.Writer
+	// ReadPassword reads a line from a terminal without echoing it.
+	// Secret values can't be prompted for without it.
+	ReadPassword func(fd int) ([]byte, error)
+
+	reader *bufio.Reader
+}
+
+// Interactive reports whether In is a terminal.
+func (t *TerminalPrompter) Interactive() bool {
+	info, err := t.In.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Prompt writes the prompt for a value to Out, and reads the answer from
+// In.
+func (t *TerminalPrompter) Prompt(path string, schema *Schema, secret bool) (string, error) {
+	if schema.Description != "" {
+		fmt.Fprintf(t.Out, "%s\n", schema.Description)
+	}
+	fmt.Fprintf(t.Out, "%s: ", path)
+	if secret {
+		if t.ReadPassword == nil {
+			return "", fmt.Errorf("Can't prompt for %q without hidden input", path)
+		}
+		b, err := t.ReadPassword(int(t.In.Fd()))
+		fmt.Fprintln(t.Out)
+		return strings.TrimSpace(string(b)), err
+	}
+	if t.reader == nil {
+		t.reader = bufio.NewReader(t.In)
+	}
+	line, err := t.reader.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
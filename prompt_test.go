@@ -0,0 +1,90 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// answers is a Prompter answering from a map, and recording the secrets
+// asked for.
+type answers struct {
+	values  map[string]string
+	secrets []string
+}
+
+func (a *answers) Prompt(path string, schema *Schema, secret bool) (string, error) {
+	if secret {
+		a.secrets = append(a.secrets, path)
+	}
+	return a.values[path], nil
+}
+
+func TestPromptMissing(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(`
+keys:
+  database:
+    type: map
+    required: true
+    keys:
+      host: {type: string, required: true}
+      port: {type: int, required: true}
+      password: {type: string, required: true}
+      user: {type: string}
+  name: {type: string, required: true}
+`)))
+	expect(t, err, nil)
+
+	p := &answers{values: map[string]string{
+		"database.port":     "5432",
+		"database.password": "hunter2",
+		"name":              "",
+	}}
+	loader := NewLoader(StaticSource{Must(ParseYaml("database: {host: localhost}"))}).Prompt(schema, p)
+	cfg, err := loader.Load(context.Background())
+	expect(t, err, nil)
+	expect(t, cfg.UString("database.host"), "localhost")
+	expect(t, cfg.UInt("database.port"), 5432)
+	expect(t, cfg.UString("database.password"), "hunter2")
+	expect(t, len(p.secrets), 1)
+	expect(t, schema.Validate(cfg).Error(), "name: required value is missing")
+
+	p.values["database.port"] = "many"
+	err = schema.PromptMissing(Must(ParseYaml("{}")), p)
+	expect(t, err != nil, true)
+}
+
+func TestTerminalPrompter(t *testing.T) {
+	f, err := ioutil.TempFile("", "config")
+	expect(t, err, nil)
+	defer os.Remove(f.Name())
+	defer f.Close()
+	f.WriteString("localhost\n")
+	f.Seek(0, 0)
+
+	var out bytes.Buffer
+	p := &TerminalPrompter{In: f, Out: &out}
+	expect(t, p.Interactive(), false)
+	answer, err := p.Prompt("host", &Schema{Description: "Server address."}, false)
+	expect(t, err, nil)
+	expect(t, answer, "localhost")
+	expect(t, out.String(), "Server address.\nhost: ")
+
+	_, err = p.Prompt("password", &Schema{}, true)
+	expect(t, err != nil, true)
+	p.ReadPassword = func(fd int) ([]byte, error) { return []byte("hunter2"), nil }
+	answer, err = p.Prompt("password", &Schema{}, true)
+	expect(t, answer, "hunter2")
+
+	// Non-interactive prompters aren't asked.
+	cfg := Must(ParseYaml("{}"))
+	schema := &Schema{Keys: map[string]*Schema{"host": {Required: true}}}
+	expect(t, schema.PromptMissing(cfg, p), nil)
+	expect(t, cfg.UString("host"), "")
+}
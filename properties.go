@@ -0,0 +1,234 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// flatNode is a node of the tree built by Unflatten.
+type flatNode struct {
+	key      string // the flat key setting the value, or creating the node
+	value    interface{}
+	leaf     bool
+	children map[string]*flatNode
+}
+
+// Unflatten builds a tree from values at dotted paths, like the keys of
+// properties files or key-value stores. Maps whose keys are all indices
+// become lists, so "servers.0.host" and "servers[1].host" build a list of
+// two maps, while "ports.http" builds a map. Unlike with Set, conflicts
+// are errors: a key both holding a value and subkeys, like "a" and "a.b",
+// indices mixed with other keys, and lists missing indices.
+func Unflatten(flat map[string]interface{}) (*Config, error) {
+	keys := make([]string, 0, len(flat))
+	for key := range flat {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := &flatNode{children: map[string]*flatNode{}}
+	for _, key := range keys {
+		parts, err := parsePath(key)
+		if err != nil {
+			return nil, err
+		}
+		if len(parts) == 0 {
+			return nil, fmt.Errorf("Invalid path %q", key)
+		}
+		node := root
+		for _, part := range parts {
+			if node.leaf {
+				return nil, fmt.Errorf("Conflicting keys %q and %q", node.key, key)
+			}
+			child, ok := node.children[part]
+			if !ok {
+				child = &flatNode{key: key, children: map[string]*flatNode{}}
+				node.children[part] = child
+			}
+			node = child
+		}
+		if len(node.children) > 0 {
+			return nil, fmt.Errorf("Conflicting keys %q and %q", key, node.key)
+		}
+		node.key, node.value, node.leaf = key, flat[key], true
+	}
+	tree, err := root.build("")
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(tree)
+}
+
+// build returns the value of a node found at the given path.
+func (n *flatNode) build(path string) (interface{}, error) {
+	if n.leaf {
+		return n.value, nil
+	}
+	indices := 0
+	for key := range n.children {
+		if i, err := strconv.Atoi(key); err == nil && i >= 0 && strconv.Itoa(i) == key {
+			indices++
+		}
+	}
+	if indices > 0 && indices < len(n.children) {
+		return nil, fmt.Errorf("Conflicting list indices and keys at %q", displayPath(path))
+	}
+
+	if indices > 0 {
+		list := make([]interface{}, len(n.children))
+		for i := range list {
+			child, ok := n.children[strconv.Itoa(i)]
+			if !ok {
+				return nil, fmt.Errorf("Missing index %d of list %q", i, displayPath(path))
+			}
+			v, err := child.build(joinPath(path, strconv.Itoa(i)))
+			if err != nil {
+				return nil, err
+			}
+			list[i] = v
+		}
+		return list, nil
+	}
+	m := make(map[string]interface{}, len(n.children))
+	for key, child := range n.children {
+		v, err := child.build(joinPath(path, key))
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+	}
+	return m, nil
+}
+
+// ParseProperties reads a Java properties file, like the
+// application.properties of Spring applications. Values are strings, which
+// getters like Int convert, and keys are dotted paths rebuilt into a tree
+// by Unflatten, so indexed keys like "servers[0].host" make lists.
+func ParseProperties(cfg string) (*Config, error) {
+	flat := map[string]interface{}{}
+	lines := strings.Split(strings.Replace(cfg, "\r\n", "\n", -1), "\n")
+	for i := 0; i < len(lines); i++ {
+		number := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// A line ending with an odd number of backslashes continues on
+		// the next one, without its leading whitespace.
+		for propertiesContinued(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number, err)
+		}
+		flat[key] = value
+	}
+	return Unflatten(flat)
+}
+
+// ParsePropertiesFile reads a Java properties file from the given
+// filename. Like ParseJsonFile, it decompresses compressed files
+// transparently.
+func ParsePropertiesFile(filename string) (*Config, error) {
+	cfg, err := readFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return ParseProperties(string(cfg))
+}
+
+// propertiesContinued reports whether a line ends with an odd number of
+// backslashes.
+func propertiesContinued(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty returns the unescaped key and value of a logical line.
+// The key ends at the first unescaped "=", ":" or whitespace.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.IndexByte("=: \t\f", line[i]) >= 0 {
+			end = i
+			break
+		}
+	}
+	rest := strings.TrimLeft(line[end:], " \t\f")
+	if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeft(rest[1:], " \t\f")
+	}
+	key, err := unescapeProperty(line[:end])
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	return key, value, err
+}
+
+// unescapeProperty replaces the escapes of properties files: \t, \n, \r,
+// \f and \uXXXX, and a backslash before any other character stands for
+// the character.
+func unescapeProperty(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\u escape")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape")
+			}
+			i += 4
+			// Characters outside the BMP are escaped as surrogate pairs.
+			if utf16.IsSurrogate(rune(r)) && i+6 < len(s) && s[i+1:i+3] == "\\u" {
+				if low, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil {
+					if pair := utf16.DecodeRune(rune(r), rune(low)); pair != unicode.ReplacementChar {
+						b.WriteRune(pair)
+						i += 6
+						continue
+					}
+				}
+			}
+			b.WriteRune(rune(r))
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
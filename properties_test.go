@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestUnflatten(t *testing.T) {
+	cfg, err := Unflatten(map[string]interface{}{
+		"servers.0.host":  "a",
+		"servers[1].host": "b",
+		"servers.1.port":  8080,
+		"ports.http":      80,
+		"ports.https":     443,
+	})
+	expect(t, err, nil)
+	expect(t, len(cfg.UList("servers")), 2)
+	expect(t, cfg.UString("servers.1.host"), "b")
+	expect(t, cfg.UInt("servers.1.port"), 8080)
+	expect(t, cfg.UInt("ports.https"), 443)
+
+	tests := []struct {
+		flat map[string]interface{}
+		err  string
+	}{
+		{map[string]interface{}{"a": 1, "a.b": 2}, `Conflicting keys "a" and "a.b"`},
+		{map[string]interface{}{"a.b.c": 1, "a.b": 2}, `Conflicting keys "a.b" and "a.b.c"`},
+		{map[string]interface{}{"a.0": 1, "a.x": 2}, `Conflicting list indices and keys at "a"`},
+		{map[string]interface{}{"a.0": 1, "a.00": 2}, `Conflicting list indices and keys at "a"`},
+		{map[string]interface{}{"a.0": 1, "a.2": 2}, `Missing index 1 of list "a"`},
+	}
+	for _, test := range tests {
+		_, err := Unflatten(test.flat)
+		expect(t, err.Error(), test.err)
+	}
+}
+
+func TestParseProperties(t *testing.T) {
+	cfg, err := ParseProperties(`# Spring style
+! also a comment
+server.port=8080
+spring.datasource.url = jdbc:postgresql://localhost/app
+servers[0].host: a.example.com
+servers[1].host  b.example.com
+message=Hello, \
+        World
+key\ with\ spaces=value
+unicode=caf\u00e9 \uD83D\uDE00
+tab=a\tb
+empty
+`)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UString("spring.datasource.url"), "jdbc:postgresql://localhost/app")
+	expect(t, cfg.UString("servers.0.host"), "a.example.com")
+	expect(t, cfg.UString("servers.1.host"), "b.example.com")
+	expect(t, cfg.UString("message"), "Hello, World")
+	expect(t, cfg.UString("[key with spaces]"), "value")
+	expect(t, cfg.UString("unicode"), "café 😀")
+	expect(t, cfg.UString("tab"), "a\tb")
+	expect(t, cfg.UString("empty", "unset"), "")
+
+	_, err = ParseProperties("a=1\nb=\\u12\n")
+	expect(t, err.Error(), `line 2: malformed \u escape`)
+	_, err = ParseProperties("a=1\na.b=2\n")
+	expect(t, err.Error(), `Conflicting keys "a" and "a.b"`)
+}
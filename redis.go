@@ -0,0 +1,125 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// RedisClient is the subset of a Redis client used by RedisSource. With
+// github.com/redis/go-redis, it is implemented by a thin wrapper:
+//
+//	type client struct{ *redis.Client }
+//
+//	func (c client) Get(ctx context.Context, key string) (string, error) {
+//		return c.Client.Get(ctx, key).Result()
+//	}
+//
+//	func (c client) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+//		return c.Client.HGetAll(ctx, key).Result()
+//	}
+//
+//	func (c client) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+//		sub := c.Client.Subscribe(ctx, channel)
+//		if _, err := sub.Receive(ctx); err != nil {
+//			return nil, err
+//		}
+//		out := make(chan string)
+//		go func() {
+//			defer close(out)
+//			defer sub.Close()
+//			for {
+//				select {
+//				case msg := <-sub.Channel():
+//					out <- msg.Payload
+//				case <-ctx.Done():
+//					return
+//				}
+//			}
+//		}()
+//		return out, nil
+//	}
+type RedisClient interface {
+	Get(ctx context.Context, key string) (string, error)
+	HGetAll(ctx context.Context, key string) (map[string]string, error)
+	// Subscribe returns the payloads of the messages published on the
+	// channel, until ctx is done.
+	Subscribe(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// RedisSource loads a config from Redis, either from a string key holding
+// a JSON document, or from a hash of dotted paths to values, rebuilt with
+// Unflatten. Hash values are decoded as JSON when possible, and kept as
+// strings otherwise.
+type RedisSource struct {
+	Client RedisClient
+	// Key holds the config.
+	Key string
+	// Hash tells that Key is a hash of flattened paths.
+	Hash bool
+	// Channel, when set, is subscribed to by Watch: every message
+	// published on it triggers a reload.
+	Channel string
+}
+
+// Load reads the config from Redis.
+func (s *RedisSource) Load(ctx context.Context) (*Config, error) {
+	if !s.Hash {
+		doc, err := s.Client.Get(ctx, s.Key)
+		if err != nil {
+			return nil, err
+		}
+		return ParseJson(doc)
+	}
+
+	fields, err := s.Client.HGetAll(ctx, s.Key)
+	if err != nil {
+		return nil, err
+	}
+	flat := make(map[string]interface{}, len(fields))
+	for path, field := range fields {
+		v, err := decodeFlatValue(field)
+		if err != nil {
+			return nil, err
+		}
+		flat[path] = v
+	}
+	return Unflatten(flat)
+}
+
+// decodeFlatValue decodes a value stored as text by a flat key-value
+// store: JSON values are decoded, and anything else is kept as a string.
+func decodeFlatValue(s string) (interface{}, error) {
+	var decoded interface{}
+	if json.Unmarshal([]byte(s), &decoded) != nil {
+		return s, nil
+	}
+	return normalizeValue(decoded)
+}
+
+// Watch notifies of the messages published on Channel. Without a channel,
+// it returns a channel closed once ctx is done.
+func (s *RedisSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	if s.Channel == "" {
+		return pollChanges(ctx, 0), nil
+	}
+	messages, err := s.Client.Subscribe(ctx, s.Channel)
+	if err != nil {
+		return nil, err
+	}
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		for range messages {
+			select {
+			case changes <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
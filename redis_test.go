@@ -0,0 +1,67 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"testing"
+)
+
+type fakeRedis struct {
+	strings  map[string]string
+	hashes   map[string]map[string]string
+	messages chan string
+}
+
+func (r *fakeRedis) Get(ctx context.Context, key string) (string, error) {
+	return r.strings[key], nil
+}
+
+func (r *fakeRedis) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return r.hashes[key], nil
+}
+
+func (r *fakeRedis) Subscribe(ctx context.Context, channel string) (<-chan string, error) {
+	return r.messages, nil
+}
+
+func TestRedisSource(t *testing.T) {
+	client := &fakeRedis{
+		strings: map[string]string{"app": `{"server": {"port": 8080}}`},
+		hashes: map[string]map[string]string{"flat": {
+			"server.port":  "8080",
+			"server.host":  "localhost",
+			"server.debug": "true",
+			"users.0":      `"calvin"`,
+			"users.1":      "hobbes",
+		}},
+		messages: make(chan string),
+	}
+	ctx := context.Background()
+
+	cfg, err := (&RedisSource{Client: client, Key: "app"}).Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("server.port"), 8080)
+
+	cfg, err = (&RedisSource{Client: client, Key: "flat", Hash: true}).Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UBool("server.debug"), true)
+	expect(t, cfg.UString("users.1"), "hobbes")
+
+	// Published messages trigger reloads.
+	src := &RedisSource{Client: client, Key: "app", Channel: "reload"}
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	reloaded := make(chan int)
+	m.OnChange(func(old, new *Config) { reloaded <- new.UInt("server.port") })
+	go m.Watch(ctx)
+
+	client.strings["app"] = `{"server": {"port": 9090}}`
+	client.messages <- "changed"
+	expect(t, <-reloaded, 9090)
+	close(client.messages)
+}
@@ -0,0 +1,217 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"sync"
+	"time"
+)
+
+// RefreshPolicy tells when values fetched from remote providers, like
+// secrets, are fetched again, see Refresh and RefreshRefs.
+type RefreshPolicy struct {
+	// TTL is how long a fetched value stays fresh. Expired values are
+	// fetched again when they are read.
+	TTL time.Duration
+	// StaleWhileRevalidate makes reads return expired values while they
+	// are fetched again in the background, rather than wait for them.
+	StaleWhileRevalidate bool
+	// OnError is called with the errors of fetches, if set. Failed
+	// fetches keep the previous value, and are retried by the next read.
+	OnError func(path string, err error)
+}
+
+// refresher holds the values of a config refreshed after their TTL.
+type refresher struct {
+	mu sync.Mutex
+	// refs is the policy of the references resolved by the Resolve*Refs
+	// functions, set by RefreshRefs.
+	refs    *RefreshPolicy
+	entries map[string]*refreshEntry
+}
+
+// refreshEntry is a value refreshed after its TTL.
+type refreshEntry struct {
+	path   string
+	parts  []string
+	policy RefreshPolicy
+	fetch  func() (interface{}, error)
+
+	mu       sync.Mutex
+	value    interface{}
+	expires  time.Time
+	fetching bool
+}
+
+// Refresh sets the value at a dotted path to the value returned by fetch,
+// and fetches it again when it is read after the TTL of the policy, so
+// that values like rotated credentials stay fresh without reloading the
+// config:
+//
+//	err := cfg.Refresh("db.password", config.RefreshPolicy{TTL: 5 * time.Minute},
+//		func() (interface{}, error) { return vault.Read("db/password") })
+//
+// Refreshed values are kept apart from the tree, so that reads stay safe
+// for concurrent use: they are returned by the getters reading the path
+// or a path under it, while Root, Get and the maps holding the path keep
+// the first value. Setting or deleting the path, a path under it or a
+// path holding it stops refreshing it.
+func (cfg *Config) Refresh(path string, policy RefreshPolicy, fetch func() (interface{}, error)) error {
+	v, err := fetch()
+	if err != nil {
+		return err
+	}
+	if v, err = normalizeValue(v); err != nil {
+		return err
+	}
+	if err := cfg.Set(path, v); err != nil {
+		return err
+	}
+	return cfg.refreshAfter(path, policy, fetch, v)
+}
+
+// RefreshRefs makes the references resolved by the Resolve*Refs functions
+// from now on, like ResolveGCPSecretRefs, refreshed like by Refresh. The
+// references are resolved again with the context given to the function,
+// which must outlive the config.
+func (cfg *Config) RefreshRefs(policy RefreshPolicy) {
+	r := cfg.refresher()
+	r.mu.Lock()
+	r.refs = &policy
+	r.mu.Unlock()
+}
+
+// refresher returns the refresher of a config, creating it when needed.
+func (cfg *Config) refresher() *refresher {
+	if cfg.refresh == nil {
+		cfg.refresh = &refresher{entries: map[string]*refreshEntry{}}
+	}
+	return cfg.refresh
+}
+
+// refreshAfter registers the fetched value at a path for refreshing.
+func (cfg *Config) refreshAfter(path string, policy RefreshPolicy, fetch func() (interface{}, error), v interface{}) error {
+	parts, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+	full := canonicalPath("", path)
+	r := cfg.refresher()
+	r.mu.Lock()
+	r.entries[full] = &refreshEntry{
+		path:    full,
+		parts:   parts,
+		policy:  policy,
+		fetch:   fetch,
+		value:   v,
+		expires: time.Now().Add(policy.TTL),
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// forget stops refreshing the values at, under or holding the given
+// parsed path, which Set or Delete changed.
+func (cfg *Config) forget(parts []string) {
+	if cfg.refresh == nil {
+		return
+	}
+	cfg.refresh.mu.Lock()
+	for path, e := range cfg.refresh.entries {
+		if hasPrefixParts(e.parts, parts) || hasPrefixParts(parts, e.parts) {
+			delete(cfg.refresh.entries, path)
+		}
+	}
+	cfg.refresh.mu.Unlock()
+}
+
+// refsPolicy returns the policy of resolved references, if any.
+func (cfg *Config) refsPolicy() *RefreshPolicy {
+	if cfg.refresh == nil {
+		return nil
+	}
+	cfg.refresh.mu.Lock()
+	defer cfg.refresh.mu.Unlock()
+	return cfg.refresh.refs
+}
+
+// refreshed returns the value read at a path, n in the tree, or the
+// refreshed value of the entry holding the path.
+func (cfg *Config) refreshed(path string, n interface{}) interface{} {
+	parts, err := parsePath(path)
+	if err != nil {
+		return n
+	}
+	cfg.refresh.mu.Lock()
+	var entry *refreshEntry
+	for _, e := range cfg.refresh.entries {
+		if hasPrefixParts(parts, e.parts) {
+			entry = e
+			break
+		}
+	}
+	cfg.refresh.mu.Unlock()
+	if entry == nil {
+		return n
+	}
+	v, ok := findParts(entry.current(), parts[len(entry.parts):])
+	if !ok {
+		return n
+	}
+	return v
+}
+
+// current returns the value of an entry, fetching it again when it
+// expired.
+func (e *refreshEntry) current() interface{} {
+	e.mu.Lock()
+	if time.Now().Before(e.expires) {
+		defer e.mu.Unlock()
+		return e.value
+	}
+	if e.policy.StaleWhileRevalidate {
+		if !e.fetching {
+			e.fetching = true
+			go e.revalidate()
+		}
+		defer e.mu.Unlock()
+		return e.value
+	}
+	// Concurrent readers wait for the fetch, holding the lock.
+	err := e.update(e.fetch())
+	v := e.value
+	e.mu.Unlock()
+	e.report(err)
+	return v
+}
+
+// revalidate fetches the value of an entry in the background.
+func (e *refreshEntry) revalidate() {
+	v, err := e.fetch()
+	e.mu.Lock()
+	e.fetching = false
+	err = e.update(v, err)
+	e.mu.Unlock()
+	e.report(err)
+}
+
+// update stores a fetched value, with e.mu held, unless fetching failed.
+func (e *refreshEntry) update(v interface{}, err error) error {
+	if err == nil {
+		v, err = normalizeValue(v)
+	}
+	if err != nil {
+		return err
+	}
+	e.value, e.expires = v, time.Now().Add(e.policy.TTL)
+	return nil
+}
+
+// report passes the error of a fetch to OnError.
+func (e *refreshEntry) report(err error) {
+	if err != nil && e.policy.OnError != nil {
+		e.policy.OnError(e.path, err)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefresh(t *testing.T) {
+	cfg := Must(ParseYaml("db: {host: localhost}"))
+	version := 0
+	fail := false
+	var failures []string
+	policy := RefreshPolicy{OnError: func(path string, err error) {
+		failures = append(failures, path+": "+err.Error())
+	}}
+	err := cfg.Refresh("db.creds", policy, func() (interface{}, error) {
+		if fail {
+			return nil, errors.New("unavailable")
+		}
+		version++
+		return map[string]interface{}{"user": "app", "version": version}, nil
+	})
+	expect(t, err, nil)
+	// The TTL is 0, so every read fetches the value again.
+	expect(t, cfg.UInt("db.creds.version"), 2)
+	expect(t, cfg.UInt("db.creds.version"), 3)
+	v, ok := cfg.IntOk("db.creds.version")
+	expect(t, v, 4)
+	expect(t, ok, true)
+	expect(t, cfg.UString("db.creds.user"), "app")
+	expect(t, cfg.UString("db.host"), "localhost")
+	// The tree keeps the first value.
+	n, _ := Get(cfg.Root, "db.creds.version")
+	expect(t, n, 1)
+
+	fail = true
+	expect(t, cfg.UInt("db.creds.version"), 5)
+	expect(t, len(failures), 1)
+	expect(t, failures[0], "db.creds: unavailable")
+
+	err = cfg.Refresh("other", policy, func() (interface{}, error) {
+		return nil, errors.New("unavailable")
+	})
+	expect(t, err.Error(), "unavailable")
+}
+
+func TestRefreshStopsOnSet(t *testing.T) {
+	cfg := Must(ParseYaml("db: {host: localhost}"))
+	fetch := func() (interface{}, error) { return "fetched", nil }
+	expect(t, cfg.Refresh("db.password", RefreshPolicy{}, fetch), nil)
+	expect(t, cfg.UString("db.password"), "fetched")
+	expect(t, cfg.Set("db.password", "manual"), nil)
+	expect(t, cfg.UString("db.password"), "manual")
+
+	expect(t, cfg.Refresh("db.creds", RefreshPolicy{}, func() (interface{}, error) {
+		return map[string]interface{}{"user": "fetched"}, nil
+	}), nil)
+	expect(t, cfg.Set("db", map[string]interface{}{"creds": map[string]interface{}{"user": "manual"}}), nil)
+	expect(t, cfg.UString("db.creds.user"), "manual")
+
+	expect(t, cfg.Refresh("db.token", RefreshPolicy{}, fetch), nil)
+	expect(t, cfg.Delete("db.token"), nil)
+	_, err := cfg.String("db.token")
+	expect(t, err != nil, true)
+	expect(t, cfg.Set("db.token", "manual"), nil)
+	expect(t, cfg.UString("db.token"), "manual")
+}
+
+func TestRefreshStaleWhileRevalidate(t *testing.T) {
+	cfg := Must(ParseYaml("{}"))
+	fetched := make(chan struct{}, 1)
+	version := 0
+	err := cfg.Refresh("token", RefreshPolicy{TTL: time.Hour, StaleWhileRevalidate: true}, func() (interface{}, error) {
+		version++
+		if version > 1 {
+			fetched <- struct{}{}
+		}
+		return version, nil
+	})
+	expect(t, err, nil)
+	expect(t, cfg.UInt("token"), 1)
+
+	entry := cfg.refresh.entries["token"]
+	entry.mu.Lock()
+	entry.expires = time.Now()
+	entry.mu.Unlock()
+	// The expired value is returned while it is fetched again.
+	expect(t, cfg.UInt("token"), 1)
+	<-fetched
+	for i := 0; i < 100 && cfg.UInt("token") == 1; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	expect(t, cfg.UInt("token"), 2)
+	expect(t, cfg.UInt("token"), 2)
+}
+
+func TestRefreshRefs(t *testing.T) {
+	client := fakeSecretManager{"projects/p/secrets/db/versions/latest": "v1"}
+	cfg := Must(ParseYaml(`password: "gcpsecret://p/db"`))
+	cfg.RefreshRefs(RefreshPolicy{})
+	expect(t, ResolveGCPSecretRefs(context.Background(), cfg, client), nil)
+	expect(t, cfg.UString("password"), "v1")
+	client["projects/p/secrets/db/versions/latest"] = "v2"
+	expect(t, cfg.UString("password"), "v2")
+}
@@ -0,0 +1,113 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RegistryKey is an open key of the Windows registry. OpenRegistryKey
+// opens keys on Windows builds.
+type RegistryKey interface {
+	SubKeyNames() ([]string, error)
+	OpenSubKey(name string) (RegistryKey, error)
+	ValueNames() ([]string, error)
+	// Value returns REG_SZ and REG_EXPAND_SZ values as strings, the
+	// latter expanded, REG_MULTI_SZ values as []string, REG_DWORD and
+	// REG_QWORD values as uint64, and other values as []byte.
+	Value(name string) (interface{}, error)
+	Close() error
+}
+
+// ParseRegistry reads the subtree of a registry key: subkeys become maps,
+// and named values their values. The unnamed default values of keys have
+// no path, and are ignored.
+func ParseRegistry(key RegistryKey) (*Config, error) {
+	root, err := parseRegistryKey(key, "")
+	if err != nil {
+		return nil, err
+	}
+	return &Config{Root: root}, nil
+}
+
+// parseRegistryKey reads the subtree of a key found at the given path.
+func parseRegistryKey(key RegistryKey, path string) (map[string]interface{}, error) {
+	node := map[string]interface{}{}
+	names, err := key.ValueNames()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		v, err := key.Value(name)
+		if err != nil {
+			return nil, err
+		}
+		switch value := v.(type) {
+		case uint64:
+			node[name] = int(value)
+		case []string:
+			items := make([]interface{}, len(value))
+			for i, item := range value {
+				items[i] = item
+			}
+			node[name] = items
+		default:
+			node[name] = value
+		}
+	}
+
+	subKeys, err := key.SubKeyNames()
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(subKeys)
+	for _, name := range subKeys {
+		subPath := joinPath(path, name)
+		if _, ok := node[name]; ok {
+			return nil, fmt.Errorf("Registry key %q has both a value and a subkey named %q",
+				displayPath(path), name)
+		}
+		sub, err := key.OpenSubKey(name)
+		if err != nil {
+			return nil, err
+		}
+		node[name], err = parseRegistryKey(sub, subPath)
+		sub.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// RegistrySource loads a config from a registry key, like
+// `HKLM\SOFTWARE\Example\App`, on Windows builds. See ParseRegistry.
+type RegistrySource struct {
+	Path string
+	// Refresh, when positive, makes Watch notify periodically, so that
+	// a Manager rereads the key.
+	Refresh time.Duration
+}
+
+// Load reads the key.
+func (s *RegistrySource) Load(ctx context.Context) (*Config, error) {
+	key, err := OpenRegistryKey(s.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+	return ParseRegistry(key)
+}
+
+// Watch notifies every Refresh interval.
+func (s *RegistrySource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	return pollChanges(ctx, s.Refresh), nil
+}
@@ -0,0 +1,16 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package config
+
+import "errors"
+
+// OpenRegistryKey opens a registry key for reading. The registry only
+// exists on Windows.
+func OpenRegistryKey(path string) (RegistryKey, error) {
+	return nil, errors.New("The registry is only available on Windows")
+}
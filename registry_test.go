@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// memoryRegistryKey is a RegistryKey holding subkeys and values in maps.
+type memoryRegistryKey struct {
+	keys   map[string]*memoryRegistryKey
+	values map[string]interface{}
+}
+
+func (k *memoryRegistryKey) SubKeyNames() ([]string, error) {
+	names := []string{}
+	for name := range k.keys {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (k *memoryRegistryKey) OpenSubKey(name string) (RegistryKey, error) {
+	if sub, ok := k.keys[name]; ok {
+		return sub, nil
+	}
+	return nil, fmt.Errorf("no key %q", name)
+}
+
+func (k *memoryRegistryKey) ValueNames() ([]string, error) {
+	names := []string{}
+	for name := range k.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (k *memoryRegistryKey) Value(name string) (interface{}, error) {
+	return k.values[name], nil
+}
+
+func (k *memoryRegistryKey) Close() error { return nil }
+
+func TestParseRegistry(t *testing.T) {
+	key := &memoryRegistryKey{
+		values: map[string]interface{}{"": "default", "LogLevel": "debug", "Workers": uint64(4)},
+		keys: map[string]*memoryRegistryKey{
+			"Database": {values: map[string]interface{}{
+				"Hosts": []string{"db1", "db2"},
+				"Key":   []byte{1, 2},
+			}},
+		},
+	}
+	cfg, err := ParseRegistry(key)
+	expect(t, err, nil)
+	expect(t, cfg.UString("LogLevel"), "debug")
+	expect(t, cfg.UInt("Workers"), 4)
+	expect(t, cfg.UString("Database.Hosts.1"), "db2")
+	expect(t, cfg.UString("Database.Key"), "\x01\x02")
+	expect(t, len(cfg.UMap("")), 3)
+
+	key.values["Database"] = "value"
+	_, err = ParseRegistry(key)
+	expect(t, err.Error(), `Registry key "." has both a value and a subkey named "Database"`)
+}
@@ -0,0 +1,184 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"unicode/utf16"
+	"unsafe"
+)
+
+// registryRoots maps the names of the predefined keys to their handles.
+var registryRoots = map[string]syscall.Handle{
+	"HKCR":                syscall.HKEY_CLASSES_ROOT,
+	"HKEY_CLASSES_ROOT":   syscall.HKEY_CLASSES_ROOT,
+	"HKCU":                syscall.HKEY_CURRENT_USER,
+	"HKEY_CURRENT_USER":   syscall.HKEY_CURRENT_USER,
+	"HKLM":                syscall.HKEY_LOCAL_MACHINE,
+	"HKEY_LOCAL_MACHINE":  syscall.HKEY_LOCAL_MACHINE,
+	"HKU":                 syscall.HKEY_USERS,
+	"HKEY_USERS":          syscall.HKEY_USERS,
+	"HKCC":                syscall.HKEY_CURRENT_CONFIG,
+	"HKEY_CURRENT_CONFIG": syscall.HKEY_CURRENT_CONFIG,
+}
+
+// errNoMoreItems is ERROR_NO_MORE_ITEMS, ending enumerations.
+const errNoMoreItems = syscall.Errno(259)
+
+// The syscall package has no RegEnumValue.
+var procRegEnumValueW = syscall.NewLazyDLL("advapi32.dll").NewProc("RegEnumValueW")
+
+// OpenRegistryKey opens a registry key for reading, given as a path
+// starting with a predefined key, like `HKLM\SOFTWARE\Example\App`.
+func OpenRegistryKey(path string) (RegistryKey, error) {
+	path = strings.Replace(path, "/", `\`, -1)
+	root, sub := path, ""
+	if i := strings.Index(path, `\`); i >= 0 {
+		root, sub = path[:i], path[i+1:]
+	}
+	h, ok := registryRoots[strings.ToUpper(root)]
+	if !ok {
+		return nil, fmt.Errorf("Unknown registry root key %q", root)
+	}
+	return openRegistryKey(h, sub)
+}
+
+// windowsRegistryKey is a RegistryKey reading the registry.
+type windowsRegistryKey struct {
+	h syscall.Handle
+}
+
+func openRegistryKey(parent syscall.Handle, path string) (*windowsRegistryKey, error) {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	var h syscall.Handle
+	if err := syscall.RegOpenKeyEx(parent, p, 0, syscall.KEY_READ, &h); err != nil {
+		return nil, fmt.Errorf("Can't open registry key %q: %v", path, err)
+	}
+	return &windowsRegistryKey{h}, nil
+}
+
+func (k *windowsRegistryKey) SubKeyNames() ([]string, error) {
+	var names []string
+	buf := make([]uint16, 256) // key names have at most 255 characters
+	for i := uint32(0); ; i++ {
+		n := uint32(len(buf))
+		err := syscall.RegEnumKeyEx(k.h, i, &buf[0], &n, nil, nil, nil, nil)
+		if err == errNoMoreItems {
+			return names, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, syscall.UTF16ToString(buf[:n]))
+	}
+}
+
+func (k *windowsRegistryKey) OpenSubKey(name string) (RegistryKey, error) {
+	return openRegistryKey(k.h, name)
+}
+
+func (k *windowsRegistryKey) ValueNames() ([]string, error) {
+	var names []string
+	buf := make([]uint16, 16384) // value names have at most 16383 characters
+	for i := uint32(0); ; i++ {
+		n := uint32(len(buf))
+		r, _, _ := procRegEnumValueW.Call(uintptr(k.h), uintptr(i),
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(unsafe.Pointer(&n)), 0, 0, 0, 0)
+		if err := syscall.Errno(r); err == errNoMoreItems {
+			return names, nil
+		} else if r != 0 {
+			return nil, err
+		}
+		names = append(names, syscall.UTF16ToString(buf[:n]))
+	}
+}
+
+func (k *windowsRegistryKey) Value(name string) (interface{}, error) {
+	p, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	var typ, n uint32
+	if err := syscall.RegQueryValueEx(k.h, p, nil, &typ, nil, &n); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	if n > 0 {
+		if err := syscall.RegQueryValueEx(k.h, p, nil, &typ, &buf[0], &n); err != nil {
+			return nil, err
+		}
+		buf = buf[:n]
+	}
+
+	switch typ {
+	case syscall.REG_SZ:
+		return registryString(buf), nil
+	case syscall.REG_EXPAND_SZ:
+		return expandWindowsEnv(registryString(buf)), nil
+	case syscall.REG_MULTI_SZ:
+		s := strings.TrimRight(string(utf16.Decode(registryUTF16(buf))), "\x00")
+		if s == "" {
+			return []string{}, nil
+		}
+		return strings.Split(s, "\x00"), nil
+	case syscall.REG_DWORD:
+		if len(buf) == 4 {
+			return uint64(binary.LittleEndian.Uint32(buf)), nil
+		}
+	case syscall.REG_QWORD:
+		if len(buf) == 8 {
+			return binary.LittleEndian.Uint64(buf), nil
+		}
+	}
+	return buf, nil
+}
+
+func (k *windowsRegistryKey) Close() error {
+	return syscall.RegCloseKey(k.h)
+}
+
+// registryUTF16 returns the UTF-16 code units of a string value.
+func registryUTF16(buf []byte) []uint16 {
+	u := make([]uint16, len(buf)/2)
+	for i := range u {
+		u[i] = binary.LittleEndian.Uint16(buf[2*i:])
+	}
+	return u
+}
+
+// registryString decodes a NUL-terminated string value.
+func registryString(buf []byte) string {
+	return syscall.UTF16ToString(registryUTF16(buf))
+}
+
+// expandWindowsEnv replaces the %NAME% references to environment
+// variables, keeping the undefined ones like Windows does.
+func expandWindowsEnv(s string) string {
+	parts := strings.Split(s, "%")
+	var out strings.Builder
+	out.WriteString(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if i+1 < len(parts) {
+			if value, ok := os.LookupEnv(parts[i]); ok && parts[i] != "" {
+				out.WriteString(value)
+			} else {
+				out.WriteString("%" + parts[i] + "%")
+			}
+			out.WriteString(parts[i+1])
+			i++
+			continue
+		}
+		out.WriteString("%")
+		out.WriteString(parts[i])
+	}
+	return out.String()
+}
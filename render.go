@@ -0,0 +1,585 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Buffers --------------------------------------------------------------------
+//
+// Rendering reuses its buffers through pools, since servers may render
+// config snapshots on every admin request.
+
+// maxPooledBuffer is the capacity past which buffers aren't reused, so
+// that rendering a huge config once doesn't pin its memory.
+const maxPooledBuffer = 64 << 10
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+var writerPool = sync.Pool{New: func() interface{} { return bufio.NewWriter(nil) }}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool. It must not be used afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(buf)
+	}
+}
+
+// getWriter returns a buffered writer to w from the pool.
+func getWriter(w io.Writer) *bufio.Writer {
+	bw := writerPool.Get().(*bufio.Writer)
+	bw.Reset(w)
+	return bw
+}
+
+// putWriter returns a buffered writer to the pool, dropping its writer.
+func putWriter(bw *bufio.Writer) {
+	bw.Reset(nil)
+	writerPool.Put(bw)
+}
+
+// YamlOptions tune the output of RenderYamlWith.
+type YamlOptions struct {
+	// Anchors writes the maps and lists found several times in the tree
+	// once, with an anchor, and aliases to it elsewhere. Shared subtrees
+	// are detected by content, so both the aliases of a parsed file and
+	// the subtrees shared by reference are found.
+	Anchors bool
+	// Indent is the number of spaces per nesting level, 2 by default.
+	Indent int
+	// LineWidth is the width past which long strings are folded over
+	// several lines, 80 by default. Strings are never folded when it is
+	// negative.
+	LineWidth int
+	// Quote chooses how strings on a single line are quoted. Strings
+	// which would read as other types, like "true", are always quoted.
+	Quote QuoteStyle
+	// QuoteMultiline writes the strings with newlines double-quoted,
+	// instead of as literal blocks ("|").
+	QuoteMultiline bool
+	// KeyOrder lists the keys written first in every map, in this order,
+	// like "name" and "version". The other keys follow, sorted.
+	KeyOrder []string
+}
+
+// JsonOptions tune the output of RenderJsonWith.
+type JsonOptions struct {
+	// Indent, when set, writes a value per line, indented with it, like
+	// json.MarshalIndent.
+	Indent string
+	// KeyOrder lists the keys written first in every object, in this
+	// order. The other keys follow, sorted.
+	KeyOrder []string
+}
+
+// RenderJsonWith renders a JSON configuration like RenderJson, with
+// options.
+func RenderJsonWith(cfg interface{}, opts JsonOptions) (string, error) {
+	root, err := renderable(cfg)
+	if err != nil {
+		return "", err
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	bw := getWriter(buf)
+	defer putWriter(bw)
+	if err := encodeJson(bw, root, opts.KeyOrder); err != nil {
+		return "", err
+	}
+	bw.Flush()
+	if opts.Indent == "" {
+		return buf.String(), nil
+	}
+	out := getBuffer()
+	defer putBuffer(out)
+	if err := json.Indent(out, buf.Bytes(), "", opts.Indent); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// orderedKeys returns the keys of a map: the ones listed in order first,
+// in this order, and then the others, sorted.
+func orderedKeys(m map[string]interface{}, order []string) []string {
+	keys := make([]string, 0, len(m))
+	for _, k := range order {
+		if _, ok := m[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	rest := len(keys)
+	for k := range m {
+		if !containsString(keys[:rest], k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys[rest:])
+	return keys
+}
+
+// containsString reports whether a list holds a string.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// QuoteStyle chooses how strings are quoted in YAML.
+type QuoteStyle int
+
+// Quoting styles.
+const (
+	// QuoteAuto quotes strings only when they need to, like yaml.v2.
+	QuoteAuto QuoteStyle = iota
+	QuoteSingle
+	QuoteDouble
+)
+
+// RenderYamlWith renders a YAML configuration like RenderYaml, with
+// options. The comments of a *Config are written too, see SetComment.
+func RenderYamlWith(cfg interface{}, opts YamlOptions) (string, error) {
+	w := &yamlWriter{YamlOptions: opts}
+	if c, ok := cfg.(*Config); ok && c != nil {
+		w.comments = c.comments
+	}
+	root, err := renderable(cfg)
+	if err != nil {
+		return "", err
+	}
+	return w.render(root)
+}
+
+// yamlWriter renders a tree as a block style YAML document, like
+// yaml.Marshal does, with the additions yaml.v2 lacks, like comments.
+type yamlWriter struct {
+	YamlOptions
+	buf      *bytes.Buffer
+	comments map[string]Comment
+
+	// anchors names the subtrees written once, by content, and written
+	// tells which were written already.
+	anchors map[string]string
+	written map[string]bool
+}
+
+// indent returns the indentation of a nesting level.
+func (w *yamlWriter) indent() string {
+	if w.Indent <= 0 {
+		return "  "
+	}
+	return strings.Repeat(" ", w.Indent)
+}
+
+// dash returns a list dash, followed by the spaces aligning the items
+// with the nesting levels.
+func (w *yamlWriter) dash() string {
+	return "-" + w.indent()[1:]
+}
+
+// render returns the YAML document of a renderable tree.
+func (w *yamlWriter) render(root interface{}) (string, error) {
+	if w.Anchors {
+		if err := w.findAnchors(root); err != nil {
+			return "", err
+		}
+	}
+	w.buf = getBuffer()
+	defer putBuffer(w.buf)
+	if err := w.writeNode(root, "", "", ""); err != nil {
+		return "", err
+	}
+	return w.buf.String(), nil
+}
+
+// findAnchors names the subtrees found several times, in writing order.
+// The subtrees of repeated ones are only counted once, since they are
+// written once.
+func (w *yamlWriter) findAnchors(root interface{}) error {
+	counts := map[string]int{}
+	keys := map[string]string{}
+	var order []string
+	var walk func(node interface{}, key string) error
+	walk = func(node interface{}, key string) error {
+		id, ok, err := subtreeID(node)
+		if err != nil || !ok {
+			return err
+		}
+		counts[id]++
+		if counts[id] > 1 {
+			return nil
+		}
+		keys[id] = key
+		order = append(order, id)
+		switch node := node.(type) {
+		case map[string]interface{}:
+			for _, k := range orderedKeys(node, w.KeyOrder) {
+				if err := walk(node[k], k); err != nil {
+					return err
+				}
+			}
+		case []interface{}:
+			for _, v := range node {
+				if err := walk(v, key); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(root, ""); err != nil {
+		return err
+	}
+
+	w.anchors = map[string]string{}
+	w.written = map[string]bool{}
+	used := map[string]bool{}
+	for _, id := range order {
+		if counts[id] < 2 {
+			continue
+		}
+		name := anchorName(keys[id])
+		for i := 2; used[name]; i++ {
+			name = anchorName(keys[id]) + "_" + strconv.Itoa(i)
+		}
+		used[name] = true
+		w.anchors[id] = name
+	}
+	return nil
+}
+
+// subtreeID identifies a non-empty map or list by its content.
+func subtreeID(node interface{}) (string, bool, error) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if len(node) == 0 {
+			return "", false, nil
+		}
+	case []interface{}:
+		if len(node) == 0 {
+			return "", false, nil
+		}
+	default:
+		return "", false, nil
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	bw := getWriter(buf)
+	defer putWriter(bw)
+	if err := encodeJson(bw, node, nil); err != nil {
+		return "", false, err
+	}
+	bw.Flush()
+	return buf.String(), true, nil
+}
+
+// anchorName derives an anchor name from a map key.
+func anchorName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+			return r
+		}
+		return '_'
+	}, key)
+	if name == "" {
+		return "anchor"
+	}
+	return name
+}
+
+// anchor returns the anchor of a subtree, and whether it was written
+// already, in which case an alias is written instead.
+func (w *yamlWriter) anchor(node interface{}) (string, bool) {
+	if w.anchors == nil {
+		return "", false
+	}
+	id, ok, _ := subtreeID(node)
+	if !ok || w.anchors[id] == "" {
+		return "", false
+	}
+	written := w.written[id]
+	w.written[id] = true
+	return w.anchors[id], written
+}
+
+// writeHead writes the head comment of a path, if any.
+func (w *yamlWriter) writeHead(path, indent string) {
+	c, ok := w.comments[path]
+	if !ok || c.Head == "" {
+		return
+	}
+	for _, line := range strings.Split(c.Head, "\n") {
+		w.buf.WriteString(strings.TrimRight(indent+"# "+line, " ") + "\n")
+	}
+}
+
+// writeLine ends the line of a value, with its line comment if any.
+func (w *yamlWriter) writeLine(path string) {
+	if c, ok := w.comments[path]; ok && c.Line != "" {
+		w.buf.WriteString(" # " + strings.Replace(c.Line, "\n", " ", -1))
+	}
+	w.buf.WriteByte('\n')
+}
+
+// writeNode writes the value found at path. The first line of the value
+// follows prefix, which is the indentation, or a list dash or map key
+// already written; the other lines are indented with indent.
+func (w *yamlWriter) writeNode(node interface{}, path, prefix, indent string) error {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		if len(node) == 0 {
+			break
+		}
+		for i, k := range orderedKeys(node, w.KeyOrder) {
+			keyPath := joinPath(path, k)
+			key, err := yamlScalar(k, indent)
+			if err != nil {
+				return err
+			}
+			if i > 0 || prefix == "" {
+				w.writeHead(keyPath, indent)
+				prefix = indent
+			}
+			if err := w.writeEntry(node[k], keyPath, prefix+key+":", indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if len(node) == 0 {
+			break
+		}
+		for i, v := range node {
+			itemPath := joinPath(path, strconv.Itoa(i))
+			if i > 0 || prefix == "" {
+				prefix = indent
+			}
+			w.writeHead(itemPath, indent)
+			if name, written := w.anchor(v); written {
+				w.buf.WriteString(prefix + "- *" + name)
+				w.writeLine(itemPath)
+				continue
+			} else if name != "" {
+				w.buf.WriteString(prefix + "- &" + name)
+				w.writeLine(itemPath)
+				if err := w.writeNode(v, itemPath, "", indent+w.indent()); err != nil {
+					return err
+				}
+				continue
+			}
+			// Maps and lists in lists start on the line of the dash.
+			switch v := v.(type) {
+			case map[string]interface{}:
+				if len(v) > 0 {
+					w.writeHead(joinPath(itemPath, orderedKeys(v, w.KeyOrder)[0]), indent+w.indent())
+					if err := w.writeNode(v, itemPath, prefix+w.dash(), indent+w.indent()); err != nil {
+						return err
+					}
+					continue
+				}
+			case []interface{}:
+				if len(v) > 0 {
+					if err := w.writeNode(v, itemPath, prefix+w.dash(), indent+w.indent()); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			if err := w.writeEntry(v, itemPath, prefix+"-", indent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	s, err := w.scalar(node, indent)
+	if err != nil {
+		return err
+	}
+	w.buf.WriteString(prefix + s)
+	w.writeLine(path)
+	return nil
+}
+
+// writeEntry writes a value after a map key or a list dash.
+func (w *yamlWriter) writeEntry(v interface{}, path, prefix, indent string) error {
+	if name, written := w.anchor(v); written {
+		w.buf.WriteString(prefix + " *" + name)
+		w.writeLine(path)
+		return nil
+	} else if name != "" {
+		prefix += " &" + name
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if len(v) > 0 {
+			w.buf.WriteString(prefix)
+			w.writeLine(path)
+			return w.writeNode(v, path, "", indent+w.indent())
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			w.buf.WriteString(prefix)
+			w.writeLine(path)
+			// Like yaml.v2, lists in maps aren't indented.
+			return w.writeNode(v, path, "", indent)
+		}
+	}
+	s, err := w.scalar(v, indent)
+	if err != nil {
+		return err
+	}
+	w.buf.WriteString(prefix + " " + s)
+	w.writeLine(path)
+	return nil
+}
+
+// scalar returns a value written after a key or dash found at indent,
+// in the style of the options.
+func (w *yamlWriter) scalar(v interface{}, indent string) (string, error) {
+	str, ok := v.(string)
+	if !ok || (w.Indent <= 0 || w.Indent == 2) && w.LineWidth == 0 &&
+		w.Quote == QuoteAuto && !w.QuoteMultiline {
+		return yamlScalar(v, indent)
+	}
+	if strings.Contains(str, "\n") && !w.QuoteMultiline {
+		if block, ok := literalBlock(str, indent, w.indent()); ok {
+			return block, nil
+		}
+	}
+
+	auto, err := yamlScalar(str, "")
+	if err != nil {
+		return "", err
+	}
+	quoted := strings.HasPrefix(auto, "'") || strings.HasPrefix(auto, "\"") ||
+		strings.HasPrefix(auto, "|") || strings.HasPrefix(auto, ">")
+	out := str
+	switch {
+	case w.Quote == QuoteDouble, strings.ContainsAny(str, "\n\r\t") || !isPrintable(str):
+		b, err := json.Marshal(str)
+		if err != nil {
+			return "", err
+		}
+		out = string(b)
+	case w.Quote == QuoteSingle || quoted:
+		out = "'" + strings.Replace(str, "'", "''", -1) + "'"
+	}
+	width := w.LineWidth
+	if width == 0 {
+		width = 80
+	}
+	return foldLine(out, width-len(indent), indent+w.indent()), nil
+}
+
+// literalBlock returns a string as a literal block scalar following a key
+// or dash found at indent, unless the string can't be written so. The
+// content is indented by a level more.
+func literalBlock(s, indent, level string) (string, bool) {
+	body := strings.TrimRight(s, "\n")
+	if body == "" || strings.ContainsAny(s, "\r\t") || !isPrintable(s) {
+		return "", false
+	}
+	header := "|"
+	if strings.HasPrefix(strings.TrimLeft(s, "\n"), " ") {
+		// The indentation can't be guessed from the first line which
+		// isn't blank.
+		header += strconv.Itoa(len(level))
+	}
+	indent += level
+	switch trailing := len(s) - len(body); {
+	case trailing == 0:
+		header += "-"
+	case trailing > 1:
+		header += "+"
+	}
+	lines := strings.Split(body, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	for i := 1; i < len(s)-len(body); i++ {
+		lines = append(lines, "")
+	}
+	return header + "\n" + strings.Join(lines, "\n"), true
+}
+
+// isPrintable reports whether a string holds printable characters only,
+// besides newlines, carriage returns and tabs.
+func isPrintable(s string) bool {
+	for _, r := range s {
+		if !unicode.IsPrint(r) && !strings.ContainsRune("\n\r\t", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// foldLine breaks a scalar written on a single line at spaces, so that its
+// lines fit in width when possible. Only single spaces between other
+// characters are broken, since YAML reads these line breaks back as a
+// space. The lines after the first one are indented with indent.
+func foldLine(s string, width int, indent string) string {
+	if width <= 0 || len(s) <= width {
+		return s
+	}
+	var lines []string
+	start, last := 0, -1
+	for i := 1; i < len(s)-1; i++ {
+		// Lines starting with "#" would read as comments.
+		if s[i] != ' ' || s[i-1] == ' ' || s[i+1] == ' ' || s[i+1] == '#' {
+			continue
+		}
+		if i-start > width && last > start {
+			lines = append(lines, s[start:last])
+			start = last + 1
+		}
+		last = i
+	}
+	if len(s)-start > width && last > start {
+		lines = append(lines, s[start:last])
+		start = last + 1
+	}
+	lines = append(lines, s[start:])
+	return strings.Join(lines, "\n"+indent)
+}
+
+// yamlScalar returns a scalar, or an empty map or list, as YAML. The
+// lines of block scalars after the first one are indented with indent,
+// the one of the key or dash they follow.
+func yamlScalar(v interface{}, indent string) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = indent + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
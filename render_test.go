@@ -0,0 +1,161 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestYamlWriter(t *testing.T) {
+	// The writer renders like yaml.v2 does.
+	for _, doc := range []string{yamlString, "[]", "{}", "a", "- [a, {b: 1, c: [x]}]\n- {}"} {
+		cfg := Must(ParseYaml(doc))
+		want, err := RenderYaml(cfg.Root)
+		expect(t, err, nil)
+		got, err := (&yamlWriter{}).render(cfg.Root)
+		expect(t, err, nil)
+		expect(t, got, want)
+	}
+}
+
+func TestRenderYamlAnchors(t *testing.T) {
+	cfg := Must(ParseYaml(`
+defaults: &defaults
+  adapter: postgres
+  pool: {min: 1, max: 5}
+development:
+  db: *defaults
+  hosts: [a, b]
+test:
+  db: *defaults
+  hosts: [a, b]
+  pool: {min: 1, max: 5}
+`))
+	out, err := RenderYamlWith(cfg, YamlOptions{Anchors: true})
+	expect(t, err, nil)
+	expect(t, out, `defaults: &defaults
+  adapter: postgres
+  pool: &pool
+    max: 5
+    min: 1
+development:
+  db: *defaults
+  hosts: &hosts
+  - a
+  - b
+test:
+  db: *defaults
+  hosts: *hosts
+  pool: *pool
+`)
+	expect(t, len(Diff(cfg, Must(ParseYaml(out)))), 0)
+
+	// Subtrees shared by reference, in lists.
+	shared := map[string]interface{}{"name": "x"}
+	out, err = RenderYamlWith([]interface{}{shared, shared, []interface{}{1}, []interface{}{1}}, YamlOptions{Anchors: true})
+	expect(t, err, nil)
+	expect(t, out, "- &anchor\n  name: x\n- *anchor\n- &anchor_2\n  - 1\n- *anchor_2\n")
+	parsed := Must(ParseYaml(out))
+	expect(t, parsed.UString("1.name"), "x")
+	expect(t, parsed.UInt("3.0"), 1)
+}
+
+func TestRenderYamlStyles(t *testing.T) {
+	long := "the quick brown fox jumps over the lazy dog, again and again, until it is tired #1"
+	cfg := Must(ParseYaml(`
+name: app
+enabled: "true"
+script: "set -e\nmake\n"
+banner: " indented\nline\n\n"
+servers:
+- host: a
+  notes: "it's"
+`))
+	expect(t, cfg.Set("description", long), nil)
+
+	out, err := RenderYamlWith(cfg, YamlOptions{Indent: 4, LineWidth: 40, Quote: QuoteDouble})
+	expect(t, err, nil)
+	expect(t, out, `banner: |4+
+     indented
+    line
+
+description: "the quick brown fox jumps over the lazy
+    dog, again and again, until it is
+    tired #1"
+enabled: "true"
+name: "app"
+script: |
+    set -e
+    make
+servers:
+-   host: "a"
+    notes: "it's"
+`)
+	expect(t, len(Diff(cfg, Must(ParseYaml(out)))), 0)
+
+	out, err = RenderYamlWith(cfg, YamlOptions{LineWidth: -1, Quote: QuoteSingle, QuoteMultiline: true})
+	expect(t, err, nil)
+	expect(t, out, `banner: " indented\nline\n\n"
+description: '`+long+`'
+enabled: 'true'
+name: 'app'
+script: "set -e\nmake\n"
+servers:
+- host: 'a'
+  notes: 'it''s'
+`)
+	expect(t, len(Diff(cfg, Must(ParseYaml(out)))), 0)
+
+	// Block scalars round-trip whatever their first lines.
+	for _, value := range []string{"\n", "\n\n", "\n  ''\na", "\n x\n", "  \nx\n", "a\n\n b\n"} {
+		for _, indent := range []int{2, 4} {
+			root := map[string]interface{}{"k": value, "l": []interface{}{value}}
+			out, err := RenderYamlWith(root, YamlOptions{Indent: indent})
+			expect(t, err, nil)
+			parsed, err := ParseYaml(out)
+			if err != nil {
+				t.Fatalf("%q with indent %d: %v in\n%s", value, indent, err, out)
+			}
+			expect(t, parsed.UString("k"), value)
+			expect(t, parsed.UString("l.0"), value)
+		}
+	}
+
+	// Plain strings are folded too.
+	plain := "the quick brown fox jumps over the lazy dog"
+	out, err = RenderYamlWith(map[string]interface{}{"list": []interface{}{plain}}, YamlOptions{LineWidth: 20})
+	expect(t, err, nil)
+	expect(t, out, "list:\n- the quick brown fox\n  jumps over the lazy\n  dog\n")
+	expect(t, Must(ParseYaml(out)).UString("list.0"), plain)
+}
+
+func TestRenderKeyOrder(t *testing.T) {
+	cfg := Must(ParseYaml(`
+services:
+- ports: [80]
+  name: web
+  version: 2
+version: 1
+name: app
+alpha: true
+`))
+	order := []string{"name", "version"}
+	out, err := RenderYamlWith(cfg, YamlOptions{KeyOrder: order})
+	expect(t, err, nil)
+	expect(t, out, `name: app
+version: 1
+alpha: true
+services:
+- name: web
+  version: 2
+  ports:
+  - 80
+`)
+
+	out, err = RenderJsonWith(cfg, JsonOptions{KeyOrder: order})
+	expect(t, err, nil)
+	expect(t, out, `{"name":"app","version":1,"alpha":true,"services":[{"name":"web","version":2,"ports":[80]}]}`)
+	out, err = RenderJsonWith(Must(ParseYaml("{b: 1, a: [x]}")), JsonOptions{Indent: "  "})
+	expect(t, err, nil)
+	expect(t, out, "{\n  \"a\": [\n    \"x\"\n  ],\n  \"b\": 1\n}")
+}
@@ -0,0 +1,97 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ReplaceAll replaces the occurrences of old by new in the string values,
+// like strings.ReplaceAll, and returns the number of values changed. When
+// prefixes are given, only the values at or below these paths are changed:
+//
+//	// Promote the staging hostnames of the services.
+//	n := cfg.ReplaceAll("staging.internal", "prod.internal", "services")
+//
+// Sealed configs are left unchanged, see Seal, and so are the configs
+// whose validators reject the changes.
+func (cfg *Config) ReplaceAll(old, new string, prefixes ...string) int {
+	return cfg.replaceStrings(func(s string) string {
+		return strings.Replace(s, old, new, -1)
+	}, prefixes)
+}
+
+// ReplaceAllRegexp replaces the matches of re by repl in the string
+// values, like Regexp.ReplaceAllString, so repl may refer to submatches
+// with $1 or ${name}. It returns the number of values changed, and only
+// changes the values at or below the prefixes, when given.
+func (cfg *Config) ReplaceAllRegexp(re *regexp.Regexp, repl string, prefixes ...string) int {
+	return cfg.replaceStrings(func(s string) string {
+		return re.ReplaceAllString(s, repl)
+	}, prefixes)
+}
+
+// replaceStrings replaces the string values below the prefixes by their
+// result of replace.
+func (cfg *Config) replaceStrings(replace func(string) string, prefixes []string) int {
+	if cfg.checkSealed("ReplaceAll", "") != nil {
+		return 0
+	}
+	if cfg.validators != nil {
+		// Check the changes on a copy first.
+		root, err := normalizeValue(cfg.Root)
+		if err != nil {
+			return 0
+		}
+		candidate := &Config{Root: root}
+		candidate.replaceStrings(replace, prefixes)
+		if cfg.checkRoot(candidate.Root) != nil {
+			return 0
+		}
+	}
+	canonical := make([]string, len(prefixes))
+	for i, prefix := range prefixes {
+		canonical[i] = canonicalPath("", prefix)
+	}
+	within := func(path string) bool {
+		if len(canonical) == 0 {
+			return true
+		}
+		for _, prefix := range canonical {
+			if prefix == "" || path == prefix || strings.HasPrefix(path, prefix+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	cfg.ownTree()
+	count := 0
+	var walk func(node interface{}, path string) interface{}
+	walk = func(node interface{}, path string) interface{} {
+		switch c := node.(type) {
+		case map[string]interface{}:
+			for k, v := range c {
+				c[k] = walk(v, joinPath(path, k))
+			}
+		case []interface{}:
+			for i, v := range c {
+				c[i] = walk(v, joinPath(path, strconv.Itoa(i)))
+			}
+		case string:
+			if within(path) {
+				if s := replace(c); s != c {
+					count++
+					return s
+				}
+			}
+		}
+		return node
+	}
+	cfg.Root = walk(cfg.Root, "")
+	return count
+}
@@ -0,0 +1,56 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"regexp"
+	"testing"
+)
+
+const replaceYaml = `
+services:
+  api: {url: "https://api.staging.internal", replicas: 2}
+  web: [web1.staging.internal, web2.staging.internal]
+a.b: {host: db.staging.internal}
+docs: see staging.internal
+`
+
+func TestReplaceAll(t *testing.T) {
+	cfg, err := ParseYaml(replaceYaml)
+	expect(t, err, nil)
+
+	expect(t, cfg.ReplaceAll("staging.internal", "prod.internal", "services", "[a.b]"), 4)
+	expect(t, cfg.UString("services.api.url"), "https://api.prod.internal")
+	expect(t, cfg.UString("services.web.1"), "web2.prod.internal")
+	expect(t, cfg.UString(`"a.b".host`), "db.prod.internal")
+	expect(t, cfg.UString("docs"), "see staging.internal")
+	expect(t, cfg.UInt("services.api.replicas"), 2)
+
+	expect(t, cfg.ReplaceAll("staging", "prod"), 1)
+	expect(t, cfg.UString("docs"), "see prod.internal")
+	expect(t, cfg.ReplaceAll("staging", "prod", "missing"), 0)
+
+	// A prefix may be a value itself.
+	expect(t, cfg.ReplaceAll("web2", "web3", "services.web.1"), 1)
+	expect(t, cfg.UString("services.web.1"), "web3.prod.internal")
+
+	leaf := &Config{Root: "a-b"}
+	expect(t, leaf.ReplaceAll("-", "_"), 1)
+	expect(t, leaf.Root, "a_b")
+}
+
+func TestReplaceAllRegexp(t *testing.T) {
+	cfg, err := ParseYaml(replaceYaml)
+	expect(t, err, nil)
+
+	re := regexp.MustCompile(`^(\w+)\.staging\.internal$`)
+	expect(t, cfg.ReplaceAllRegexp(re, "$1.prod.example.com"), 3)
+	expect(t, cfg.UString("services.web.0"), "web1.prod.example.com")
+	expect(t, cfg.UString(`"a.b".host`), "db.prod.example.com")
+	expect(t, cfg.UString("services.api.url"), "https://api.staging.internal")
+
+	expect(t, cfg.ReplaceAllRegexp(regexp.MustCompile(`staging`), "prod", "services.api"), 1)
+	expect(t, cfg.UString("services.api.url"), "https://api.prod.internal")
+}
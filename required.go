@@ -0,0 +1,38 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "fmt"
+
+// MissingError lists the paths Required didn't find.
+type MissingError struct {
+	Paths []string
+}
+
+func (e *MissingError) Error() string {
+	return fmt.Sprintf("Missing required paths: %s", quotePaths(e.Paths))
+}
+
+// Required checks that every given dotted path holds a value, in the config
+// or its fallbacks, and returns a *MissingError naming all the missing
+// ones, so that mandatory settings are asserted at once:
+//
+//	if err := cfg.Required("database.url", "auth.secret"); err != nil {
+//		log.Fatal(err)
+//	}
+//
+// Null values count as present; use a Schema to check types.
+func (cfg *Config) Required(paths ...string) error {
+	var missing []string
+	for _, path := range paths {
+		if _, ok := cfg.getOk(path); !ok {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		return &MissingError{Paths: missing}
+	}
+	return nil
+}
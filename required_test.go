@@ -0,0 +1,26 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRequired(t *testing.T) {
+	cfg := Must(ParseYaml(`
+database: {url: "postgres://db", pool: null}
+servers: [a, b]
+`))
+	cfg = cfg.WithFallback(Must(ParseYaml("auth: {secret: s3cr3t}")))
+	expect(t, cfg.Required("database.url", "database.pool", "servers.1", "auth.secret"), nil)
+	expect(t, cfg.Required(), nil)
+
+	err := cfg.Required("database.url", "database.user", "servers.2", "auth.key")
+	missing, ok := err.(*MissingError)
+	expect(t, ok, true)
+	expect(t, reflect.DeepEqual(missing.Paths, []string{"database.user", "servers.2", "auth.key"}), true)
+	expect(t, err.Error(), `Missing required paths: "database.user", "servers.2", "auth.key"`)
+}
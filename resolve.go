@@ -0,0 +1,212 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WhenKey is the key of the conditions of the sections pruned by Resolve.
+const WhenKey = "when"
+
+// Resolve expands the matrices and evaluates the conditions of the
+// config, so that a single file can serve several platforms or
+// deployments without external templating.
+//
+// Maps with a "$matrix" key are generators, replaced by a list holding a
+// copy of their "$template" for every combination of the values of the
+// variables of the matrix, which are lists or ${path} references to
+// lists. ${name} references to the variables are replaced in the keys and
+// strings of the template, while the other references are kept. With a
+// "$key" naming the copies, the generator is replaced by a map instead:
+//
+//	regions: [us, eu, ap]
+//	clusters:
+//	  $matrix: {region: "${regions}", tier: [web, api]}
+//	  $key: "${region}-${tier}"
+//	  $template:
+//	    url: https://${tier}.${region}.example.com
+//	    replicas: 2
+//
+// Then maps with a "when" key, holding a bool or an expression, are kept
+// without the key when it is true, and removed from their parent map or
+// list otherwise, so templates can also have conditions, like
+// `when: '"${region}" != "ap"'`:
+//
+//	cache:
+//	  - when: goos == "linux"
+//	    dir: /var/cache/app
+//	  - when: goos == "darwin"
+//	    dir: ~/Library/Caches/app
+//	gpu:
+//	  when: ${features.gpu} && goarch == "amd64"
+//	  devices: 2
+//
+// Conditions compare ${path} references, environment variables like
+// env.DEPLOY, goos and goarch, with ==, !=, <, <=, >, >=, &&, || and !.
+// They read the config as it is before pruning, and the conditions of
+// removed sections aren't evaluated.
+func (cfg *Config) Resolve() error {
+	if err := cfg.expandMatrices(); err != nil {
+		return err
+	}
+	var edits []string
+	if err := cfg.collectConditions(cfg.Root, "", &edits); err != nil {
+		return err
+	}
+	// Later paths are deleted first, so that removing list items doesn't
+	// shift the indices of the ones still to remove.
+	for i := len(edits) - 1; i >= 0; i-- {
+		if err := cfg.Delete(edits[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectConditions evaluates the conditions found in a node at the given
+// path, and appends the paths to delete to edits: the sections whose
+// condition is false, and the keys of the true conditions.
+func (cfg *Config) collectConditions(node interface{}, path string, edits *[]string) error {
+	var keys []string
+	var children []interface{}
+	switch c := node.(type) {
+	case map[string]interface{}:
+		keys = make([]string, 0, len(c))
+		for key := range c {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		children = make([]interface{}, len(keys))
+		for i, key := range keys {
+			children[i] = c[key]
+		}
+	case []interface{}:
+		keys = make([]string, len(c))
+		for i := range c {
+			keys[i] = strconv.Itoa(i)
+		}
+		children = c
+	default:
+		return nil
+	}
+	for i, child := range children {
+		childPath := joinPath(path, keys[i])
+		if m, ok := child.(map[string]interface{}); ok {
+			if cond, ok := m[WhenKey]; ok {
+				keep, err := cfg.condition(cond)
+				if err != nil {
+					return fmt.Errorf("Invalid condition at %q: %v", displayPath(joinPath(childPath, WhenKey)), err)
+				}
+				if !keep {
+					*edits = append(*edits, childPath)
+					continue
+				}
+				*edits = append(*edits, joinPath(childPath, WhenKey))
+			}
+		}
+		if err := cfg.collectConditions(child, childPath, edits); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// condition evaluates a condition, a bool or an expression.
+func (cfg *Config) condition(cond interface{}) (bool, error) {
+	if b, ok := cond.(bool); ok {
+		return b, nil
+	}
+	s, ok := cond.(string)
+	if !ok {
+		return false, typeMismatch("bool or string", cond)
+	}
+	v, err := cfg.evalExpr(s)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("Expression %q is a %T, not a bool", s, v)
+	}
+	return b, nil
+}
+
+// ExprPrefix starts the string values computed by ResolveExpressions.
+const ExprPrefix = "= "
+
+// ResolveExpressions replaces the string values starting with "= " by the
+// values of the expressions following it, for settings derived from
+// others:
+//
+//	workers: 4
+//	queue_size: = ${workers} * 256
+//	threads: = max(2, numcpu() - 1)
+//
+// Expressions can reference the values of other expressions, which are
+// computed first, while cycles are reported with all their paths.
+// Expressions are written like the conditions of Resolve, with the
+// arithmetic operators +, -, *, / and %, and the functions min, max, abs,
+// floor, ceil, round, int, float, string and numcpu, the only ones they
+// can call.
+func (cfg *Config) ResolveExpressions() error {
+	exprs := map[string]string{}
+	var paths []string
+	walkLeaves(cfg.Root, "", func(path string, v interface{}) {
+		if s, ok := v.(string); ok && strings.HasPrefix(s, ExprPrefix) {
+			exprs[path] = strings.TrimSpace(s[len(ExprPrefix):])
+			paths = append(paths, path)
+		}
+	})
+
+	done := map[string]bool{}
+	var stack []string
+	var resolve func(path string) error
+	resolve = func(path string) error {
+		if done[path] {
+			return nil
+		}
+		for i, p := range stack {
+			if p == path {
+				cycle := append(stack[i:len(stack):len(stack)], path)
+				return fmt.Errorf("Expression cycle: %s", strings.Join(quoteDisplayPaths(cycle), " -> "))
+			}
+		}
+		stack = append(stack, path)
+		defer func() { stack = stack[:len(stack)-1] }()
+		// failure is the error of a referenced expression, returned as
+		// is rather than as an unresolved reference.
+		var failure error
+		v, err := evalExprWith(exprs[path], func(ref string) (interface{}, error) {
+			// The expressions found at or under the reference are
+			// computed before it is read.
+			for _, p := range paths {
+				if pathWithin(p, ref) {
+					if failure = resolve(p); failure != nil {
+						return nil, failure
+					}
+				}
+			}
+			return cfg.lookup(ref)
+		})
+		if failure != nil {
+			return failure
+		}
+		if err != nil {
+			return fmt.Errorf("Invalid expression at %q: %v", displayPath(path), err)
+		}
+		done[path] = true
+		return cfg.Set(path, v)
+	}
+	for _, path := range paths {
+		if err := resolve(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	cfg := Must(ParseYaml(`
+features: {gpu: false}
+cache:
+  - {when: 'goos == "` + runtime.GOOS + `"', dir: /var/cache/app}
+  - {when: 'goos == "plan10"', dir: /nowhere}
+  - {when: true, dir: /tmp}
+  - {when: false, dir: /dev/null}
+gpu:
+  when: ${features.gpu}
+  devices: 2
+  nested: {when: '${missing}'}
+server:
+  when: 'goarch == "` + runtime.GOARCH + `"'
+  port: 80
+  debug: {when: 'env.CONFIG_TEST_DEBUG == "1"', level: 2}
+`))
+	expect(t, cfg.Resolve(), nil)
+	list := cfg.UList("cache")
+	expect(t, len(list), 2)
+	expect(t, cfg.UString("cache.0.dir"), "/var/cache/app")
+	expect(t, cfg.UString("cache.1.dir"), "/tmp")
+	_, err := cfg.Get("cache.0.when")
+	expect(t, err != nil, true)
+	_, err = cfg.Get("gpu")
+	expect(t, err != nil, true)
+	expect(t, cfg.UInt("server.port"), 80)
+	_, err = cfg.Get("server.when")
+	expect(t, err != nil, true)
+	_, err = cfg.Get("server.debug")
+	expect(t, err != nil, true)
+
+	// Keys holding dots or brackets are keys, not paths.
+	cfg = Must(ParseYaml("hosts: {a.b: {when: false}, 'c[0]': {when: true, port: 1}, c: [{when: false}]}"))
+	expect(t, cfg.Resolve(), nil)
+	if !reflect.DeepEqual(cfg.UMap("hosts"), map[string]interface{}{
+		"c[0]": map[string]interface{}{"port": 1},
+		"c":    []interface{}{},
+	}) {
+		t.Errorf("Unexpected hosts %v", cfg.UMap("hosts"))
+	}
+
+	for doc, msg := range map[string]string{
+		"a: {when: '${missing}'}": `Invalid condition at "a.when": Invalid expression "${missing}": unresolved reference ${missing}`,
+		"a: {when: 1}":            `Invalid condition at "a.when": Type mismatch: expected bool or string; got int`,
+		"a: {when: '1'}":          `Invalid condition at "a.when": Expression "1" is a int, not a bool`,
+	} {
+		err := Must(ParseYaml(doc)).Resolve()
+		expect(t, err.Error(), msg)
+	}
+}
+
+func TestResolveExpressions(t *testing.T) {
+	cfg := Must(ParseYaml(`
+workers: 4
+queue_size: = ${derived.threads} * 256
+derived:
+  threads: = max(2, ${workers} - 1)
+  name: = "queue-" + string(${queue_size})
+literal: "=not an expression"
+`))
+	expect(t, cfg.ResolveExpressions(), nil)
+	expect(t, cfg.UInt("derived.threads"), 3)
+	expect(t, cfg.UInt("queue_size"), 768)
+	expect(t, cfg.UString("derived.name"), "queue-768")
+	expect(t, cfg.UString("literal"), "=not an expression")
+
+	for doc, msg := range map[string]string{
+		"a: = ${b} + 1\nb: = ${c} * 2\nc: = ${a}": `Expression cycle: "a" -> "b" -> "c" -> "a"`,
+		"a: = ${b} + 1\nb: = 1 / 0":               `Invalid expression at "b": Invalid expression "1 / 0": division by zero`,
+		"a: = ${missing}":                         `Invalid expression at "a": Invalid expression "${missing}": unresolved reference ${missing}`,
+		"a: = system(1)":                          `Invalid expression at "a": Invalid expression "system(1)": unknown function "system"`,
+	} {
+		err := Must(ParseYaml(doc)).ResolveExpressions()
+		expect(t, err.Error(), msg)
+	}
+}
+
+func TestResolveMatrix(t *testing.T) {
+	cfg := Must(ParseYaml(`
+regions: [us, eu, ap]
+clusters:
+  $matrix: {region: "${regions}", tier: [web, api]}
+  $key: "${region}-${tier}"
+  $template:
+    url: "https://${tier}.${region}.example.com"
+    region: "${region}"
+    db: "${db.host}"
+    when: '"${region}" != "ap" || "${tier}" == "api"'
+ports:
+  $matrix: {port: [80, 443]}
+  $template: {port: "${port}", "${port}_enabled": true}
+nested:
+  $matrix: {a: [p, q]}
+  $template:
+    name: "${a}"
+    items:
+      $matrix: {b: [1, 2]}
+      $template: "${a}${b}"
+empty:
+  $matrix: {a: []}
+  $template: x
+`))
+	expect(t, cfg.Resolve(), nil)
+	expect(t, len(cfg.UMap("clusters")), 5)
+	expect(t, cfg.UString("clusters.eu-web.url"), "https://web.eu.example.com")
+	expect(t, cfg.UString("clusters.ap-api.region"), "ap")
+	expect(t, cfg.UString("clusters.us-api.db"), "${db.host}")
+	_, err := cfg.Get("clusters.ap-web")
+	expect(t, err != nil, true)
+
+	expect(t, len(cfg.UList("ports")), 2)
+	expect(t, cfg.UInt("ports.1.port"), 443)
+	expect(t, cfg.UBool("ports.0.80_enabled"), true)
+
+	expect(t, cfg.UString("nested.1.name"), "q")
+	expect(t, cfg.UString("nested.1.items.0"), "q1")
+	expect(t, cfg.UString("nested.0.items.1"), "p2")
+	expect(t, len(cfg.UList("empty")), 0)
+
+	for doc, msg := range map[string]string{
+		"a: {$matrix: {x: [1]}}":                           `Invalid matrix at "a.$template": missing template`,
+		"a: {$matrix: [1], $template: x}":                  `Invalid matrix at "a.$matrix": expected a map of variables to lists`,
+		"a: {$matrix: {x: 1}, $template: x}":               `Invalid matrix at "a.$matrix.x": expected a list; got int`,
+		"a: {$matrix: {x: '${nope}'}, $template: x}":       `Invalid matrix at "a.$matrix.x": unresolved reference ${nope}`,
+		"a: {$matrix: {x: [1, 1]}, $template: x, $key: k}": `Invalid matrix at "a.$key": duplicate key "k"`,
+		"a: {$matrix: {x: [1]}, $template: x, extra: 1}":   `Invalid matrix at "a.extra": unknown key, expected "$matrix", "$template" or "$key"`,
+	} {
+		err := Must(ParseYaml(doc)).Resolve()
+		expect(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Rule is a check across the values of a map, declared by the "rules" of
+// its schema:
+//
+//	type: map
+//	keys:
+//	  port: {type: int}
+//	  adminPort: {type: int}
+//	  tls:
+//	    keys:
+//	      enabled: {type: bool}
+//	      cert: {type: string}
+//	      key: {type: string}
+//	rules:
+//	  - if: {tls.enabled: true}
+//	    require: [tls.cert, tls.key]
+//	  - distinct: [port, adminPort]
+//
+// Paths are relative to the map, and values are compared as strings, like
+// enums, so that "true" matches true.
+type Rule struct {
+	// If holds the values the rule applies to: it only applies when every
+	// path holds its value.
+	If map[string]interface{}
+	// Require lists the paths which must exist.
+	Require []string
+	// Forbid lists the paths which must not exist.
+	Forbid []string
+	// Distinct lists the paths whose values must differ, when they exist.
+	Distinct []string
+}
+
+// parseRules reads the rules of a schema found at the given path.
+func parseRules(node interface{}, path string) ([]*Rule, error) {
+	list, ok := node.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid schema at %q: bad value for %q: %#v",
+			displayPath(path), "rules", node)
+	}
+	rules := make([]*Rule, len(list))
+	for i, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Invalid schema at %q: bad rule %d: %#v",
+				displayPath(path), i, item)
+		}
+		rule := &Rule{}
+		for k, v := range m {
+			var err error
+			switch k {
+			case "if":
+				if rule.If, ok = v.(map[string]interface{}); !ok {
+					err = fmt.Errorf("bad value for %q: %#v", k, v)
+				}
+			case "require":
+				rule.Require, err = ruleStrings(k, v)
+			case "forbid":
+				rule.Forbid, err = ruleStrings(k, v)
+			case "distinct":
+				rule.Distinct, err = ruleStrings(k, v)
+			default:
+				err = fmt.Errorf("unknown field %q", k)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Invalid schema at %q: rule %d: %v",
+					displayPath(path), i, err)
+			}
+		}
+		rules[i] = rule
+	}
+	return rules, nil
+}
+
+// ruleStrings reads the list of paths of a rule field.
+func ruleStrings(field string, v interface{}) ([]string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("bad value for %q: %#v", field, v)
+	}
+	paths := make([]string, len(list))
+	for i, item := range list {
+		if paths[i], ok = item.(string); !ok {
+			return nil, fmt.Errorf("bad value for %q: %#v", field, v)
+		}
+	}
+	return paths, nil
+}
+
+// check reports the violations of the rule by the map at the given path.
+func (rule *Rule) check(cfg *Config, path string, r *Report) {
+	fail := func(at, format string, args ...interface{}) {
+		r.Errors = append(r.Errors, &SchemaError{
+			Path:    canonicalPath(path, at),
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Checks don't count as reads, see peek.
+	valueAt := func(at string) (string, error) {
+		n, err := cfg.peek(canonicalPath(path, at))
+		if err != nil {
+			return "", err
+		}
+		return toString(n)
+	}
+
+	var conditions []string
+	for at, want := range rule.If {
+		value, err := valueAt(at)
+		if err != nil || value != fmt.Sprint(want) {
+			return
+		}
+		conditions = append(conditions, fmt.Sprintf("%s is %v", at, want))
+	}
+	sort.Strings(conditions)
+	when := ""
+	if len(conditions) > 0 {
+		when = " when " + strings.Join(conditions, " and ")
+	}
+
+	for _, at := range rule.Require {
+		if _, err := cfg.peek(canonicalPath(path, at)); err != nil {
+			fail(at, "required value is missing%s", when)
+		}
+	}
+	for _, at := range rule.Forbid {
+		if _, err := cfg.peek(canonicalPath(path, at)); err == nil {
+			fail(at, "value is not allowed%s", when)
+		}
+	}
+	seen := map[string]string{}
+	for _, at := range rule.Distinct {
+		value, err := valueAt(at)
+		if err != nil {
+			continue
+		}
+		if other, ok := seen[value]; ok {
+			fail(at, "value %q must differ from %s%s", value, other, when)
+			continue
+		}
+		seen[value] = at
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Sample returns an example config matching the schema, like for a
+// --print-default-config flag. Values are the defaults, or else the first
+// enum values, or else the zero values of their types. In YAML, every key
+// is preceded by comments giving its description, type, allowed values
+// and whether it is required or deprecated:
+//
+//	database:
+//	  # Server address.
+//	  # string, required
+//	  host: ""
+//	  # string, one of: disable, require
+//	  mode: disable
+//
+// JSON has no comments, so only the values are rendered.
+func (s *Schema) Sample(format Format) (string, error) {
+	if format != FormatYaml {
+		return render(format, s.sample())
+	}
+	var buf bytes.Buffer
+	if len(s.Keys) == 0 {
+		value, err := sampleScalar(s.sample())
+		if err != nil {
+			return "", err
+		}
+		buf.WriteString(value + "\n")
+		return buf.String(), nil
+	}
+	if err := s.writeSampleKeys(&buf, ""); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// sample returns the example value of the schema.
+func (s *Schema) sample() interface{} {
+	if s.Default != nil {
+		return s.Default
+	}
+	if len(s.Enum) > 0 {
+		return s.Enum[0]
+	}
+	switch s.Type {
+	case "string":
+		return ""
+	case "int":
+		return 0
+	case "float":
+		return 0.0
+	case "bool":
+		return false
+	case "list":
+		return []interface{}{}
+	case "map":
+		m := map[string]interface{}{}
+		for name, sub := range s.Keys {
+			m[name] = sub.sample()
+		}
+		return m
+	}
+	return nil
+}
+
+// writeSampleKeys writes the commented keys of a map schema.
+func (s *Schema) writeSampleKeys(buf *bytes.Buffer, indent string) error {
+	names := make([]string, 0, len(s.Keys))
+	for name := range s.Keys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		sub := s.Keys[name]
+		for _, comment := range sub.sampleComments() {
+			fmt.Fprintf(buf, "%s# %s\n", indent, comment)
+		}
+		key, err := sampleScalar(name)
+		if err != nil {
+			return err
+		}
+		if len(sub.Keys) > 0 && sub.Default == nil {
+			fmt.Fprintf(buf, "%s%s:\n", indent, key)
+			if err := sub.writeSampleKeys(buf, indent+"  "); err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := sampleScalar(sub.sample())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s%s: %s\n", indent, key, value)
+	}
+	return nil
+}
+
+// sampleComments returns the comment lines describing a value.
+func (s *Schema) sampleComments() []string {
+	var comments []string
+	if s.Description != "" {
+		comments = append(comments, strings.Split(s.Description, "\n")...)
+	}
+	var facts []string
+	if s.Type != "" {
+		typ := s.Type
+		if s.Items != nil && s.Items.Type != "" {
+			typ += " of " + s.Items.Type
+		}
+		facts = append(facts, typ)
+	}
+	if s.Required {
+		facts = append(facts, "required")
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, len(s.Enum))
+		for i, v := range s.Enum {
+			values[i] = fmt.Sprint(v)
+		}
+		facts = append(facts, "one of: "+strings.Join(values, ", "))
+	}
+	if len(facts) > 0 {
+		comments = append(comments, strings.Join(facts, ", "))
+	}
+	if s.Deprecated != "" {
+		comments = append(comments, "Deprecated: "+s.Deprecated)
+	}
+	return comments
+}
+
+// sampleScalar renders a value on a single line: scalars are quoted as
+// YAML needs, and maps and lists are written in flow style.
+func sampleScalar(v interface{}) (string, error) {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return RenderJson(v)
+	}
+	out, err := RenderYaml(v)
+	return strings.TrimSuffix(out, "\n"), err
+}
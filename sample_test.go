@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestSchemaSample(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(`
+type: map
+keys:
+  database:
+    type: map
+    description: The main database.
+    keys:
+      host: {type: string, required: true, description: Server address.}
+      port: {type: int, default: 5432}
+      mode: {type: string, enum: [disable, require]}
+      user: {deprecated: use role}
+  servers: {type: list, items: {type: string}}
+  "on": {type: bool}
+`)))
+	expect(t, err, nil)
+
+	sample, err := schema.Sample(FormatYaml)
+	expect(t, err, nil)
+	expect(t, sample, `# The main database.
+# map
+database:
+  # Server address.
+  # string, required
+  host: ""
+  # string, one of: disable, require
+  mode: disable
+  # int
+  port: 5432
+  # Deprecated: use role
+  user: null
+# bool
+"on": false
+# list of string
+servers: []
+`)
+	cfg, err := ParseYaml(sample)
+	expect(t, err, nil)
+	expect(t, cfg.UInt("database.port"), 5432)
+
+	sample, err = schema.Sample(FormatJson)
+	expect(t, err, nil)
+	expect(t, sample, `{"database":{"host":"","mode":"disable","port":5432,"user":null},"on":false,"servers":[]}`)
+}
@@ -0,0 +1,465 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema describes the expected structure of a configuration. A schema is
+// usually written in YAML or JSON and loaded with ParseSchema:
+//
+//	type: map
+//	keys:
+//	  database:
+//	    type: map
+//	    required: true
+//	    keys:
+//	      host: {type: string, required: true}
+//	      port: {type: int, default: 5432}
+//	      mode: {type: string, enum: [disable, require]}
+//	      user: {type: string, deprecated: "use database.role"}
+//
+// Types are checked with the conversion rules of the getters, so a value
+// matches "int" whenever Int() would accept it. Supported types are
+// string, int, float, bool, time, list and map; an empty type matches
+// any value.
+type Schema struct {
+	Type        string
+	Required    bool
+	Default     interface{}
+	Enum        []interface{}
+	Description string
+	// Deprecated, when set, tells why the value shouldn't be used
+	// anymore. Deprecated values are reported as warnings by Check.
+	Deprecated string
+	// Keys describes the values of a map.
+	Keys map[string]*Schema
+	// Items describes every item of a list.
+	Items *Schema
+	// Rules checks the values of a map against each other, see Rule.
+	Rules []*Rule
+}
+
+// ParseSchema reads a schema from the given config.
+func ParseSchema(cfg *Config) (*Schema, error) {
+	return parseSchema(cfg.Root, "")
+}
+
+// parseSchema reads a schema node found at the given path.
+func parseSchema(node interface{}, path string) (*Schema, error) {
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Invalid schema at %q: %v",
+			displayPath(path), typeMismatch("map[string]interface{}", node))
+	}
+	s := &Schema{}
+	for k, v := range m {
+		var ok = true
+		switch k {
+		case "type":
+			s.Type, ok = v.(string)
+			switch s.Type {
+			case "", "string", "int", "float", "bool", "time", "list", "map":
+			default:
+				return nil, fmt.Errorf("Invalid schema at %q: unknown type %q",
+					displayPath(path), s.Type)
+			}
+		case "required":
+			s.Required, ok = v.(bool)
+		case "default":
+			s.Default = v
+		case "enum":
+			s.Enum, ok = v.([]interface{})
+		case "description":
+			s.Description, ok = v.(string)
+		case "deprecated":
+			switch v := v.(type) {
+			case bool:
+				if v {
+					s.Deprecated = "deprecated"
+				}
+			case string:
+				s.Deprecated = v
+			default:
+				ok = false
+			}
+		case "keys":
+			var keys map[string]interface{}
+			if keys, ok = v.(map[string]interface{}); ok {
+				s.Keys = make(map[string]*Schema, len(keys))
+				for name, item := range keys {
+					sub, err := parseSchema(item, joinPath(path, name))
+					if err != nil {
+						return nil, err
+					}
+					s.Keys[name] = sub
+				}
+			}
+		case "items":
+			sub, err := parseSchema(v, joinPath(path, "items"))
+			if err != nil {
+				return nil, err
+			}
+			s.Items = sub
+		case "rules":
+			rules, err := parseRules(v, path)
+			if err != nil {
+				return nil, err
+			}
+			s.Rules = rules
+		default:
+			return nil, fmt.Errorf("Invalid schema at %q: unknown field %q",
+				displayPath(path), k)
+		}
+		if !ok {
+			return nil, fmt.Errorf("Invalid schema at %q: bad value for %q: %#v",
+				displayPath(path), k, v)
+		}
+	}
+	return s, nil
+}
+
+// SchemaError describes a value which doesn't match its schema.
+type SchemaError struct {
+	Path    string
+	Message string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %s", displayPath(e.Path), e.Message)
+}
+
+// SchemaErrors lists every mismatch found by Schema.Validate.
+type SchemaErrors []*SchemaError
+
+func (e SchemaErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Report lists the findings of Schema.Check, sorted by path. Errors are
+// values which don't match the schema, and warnings the ones which match
+// but shouldn't be used, like deprecated keys.
+type Report struct {
+	Errors   SchemaErrors
+	Warnings SchemaErrors
+}
+
+// Fatal reports whether the config is unusable, that is whether the
+// report has errors. Warnings are meant to be logged only.
+func (r *Report) Fatal() bool {
+	return len(r.Errors) > 0
+}
+
+// Err returns the errors of the report as SchemaErrors, or nil.
+func (r *Report) Err() error {
+	if len(r.Errors) == 0 {
+		return nil
+	}
+	return r.Errors
+}
+
+// String lists the findings, one per line, prefixed by their severity.
+func (r *Report) String() string {
+	var lines []string
+	for _, err := range r.Errors {
+		lines = append(lines, "error: "+err.Error())
+	}
+	for _, err := range r.Warnings {
+		lines = append(lines, "warning: "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Validate checks the config against the schema, and runs its validators,
+// see Config.Validator. It returns nil or SchemaErrors listing every
+// mismatch, sorted by path. Warnings are
+// ignored; use Check to get them too.
+func (s *Schema) Validate(cfg *Config) error {
+	return s.Check(cfg).Err()
+}
+
+// Check checks the config against the schema, and reports both errors and
+// warnings:
+//
+//	report := schema.Check(cfg)
+//	for _, w := range report.Warnings {
+//		log.Printf("config: %v", w)
+//	}
+//	if report.Fatal() {
+//		log.Fatal(report.Err())
+//	}
+func (s *Schema) Check(cfg *Config) *Report {
+	r := &Report{}
+	s.validate(cfg, "", r)
+	cfg.runValidators(&r.Errors)
+	for _, errs := range []SchemaErrors{r.Errors, r.Warnings} {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Path < errs[j].Path })
+	}
+	return r
+}
+
+// validate checks the value at the given path, which is known to exist.
+func (s *Schema) validate(cfg *Config, path string, r *Report) {
+	fail := func(format string, args ...interface{}) {
+		r.Errors = append(r.Errors, &SchemaError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if s.Deprecated != "" {
+		r.Warnings = append(r.Warnings, &SchemaError{Path: path, Message: s.Deprecated})
+	}
+
+	// Checks don't count as reads, see peek.
+	n, err := cfg.peek(path)
+	if err == nil {
+		switch s.Type {
+		case "string":
+			_, err = toString(n)
+		case "int":
+			_, err = toInt(n)
+		case "float":
+			_, err = toFloat64(n)
+		case "bool":
+			_, err = toBool(n)
+		case "time":
+			_, err = toTime(n)
+		case "list":
+			_, err = toList(n)
+		case "map":
+			_, err = toMap(n)
+		}
+	}
+	if err != nil {
+		fail("expected %s: %v", s.Type, err)
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		value, _ := toString(n)
+		found := false
+		for _, item := range s.Enum {
+			if fmt.Sprint(item) == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			fail("value %q is not one of %v", value, s.Enum)
+		}
+	}
+
+	if len(s.Keys) > 0 {
+		names := make([]string, 0, len(s.Keys))
+		for name := range s.Keys {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			sub := s.Keys[name]
+			subPath := joinPath(path, name)
+			if _, err := cfg.peek(subPath); err != nil {
+				if sub.Required {
+					r.Errors = append(r.Errors, &SchemaError{Path: subPath, Message: "required value is missing"})
+				}
+				continue
+			}
+			sub.validate(cfg, subPath, r)
+		}
+	}
+
+	if s.Items != nil {
+		list, _ := toList(n)
+		for i := range list {
+			s.Items.validate(cfg, joinPath(path, strconv.Itoa(i)), r)
+		}
+	}
+
+	for _, rule := range s.Rules {
+		rule.check(cfg, path, r)
+	}
+}
+
+// SchemaFromStruct derives a schema from a struct, or a pointer to one,
+// so that the struct a config is read into describes it too. Keys are
+// named by the "config" tag, or else the "yaml" and "json" ones, or else
+// the lowercased field name, like yaml.v2 does. Fields tagged "-" are
+// skipped, and embedded structs are inlined. The other parts of the
+// schema come from tags as well:
+//
+//	type Database struct {
+//		Host string `config:"host,required" description:"Server address"`
+//		Port int    `config:"port" default:"5432"`
+//		Mode string `config:"mode" enum:"disable,require"`
+//		User string `config:"user" deprecated:"use role"`
+//	}
+//
+// Defaults and enum values are converted to the type of the field, or
+// decoded as JSON when it has no schema type.
+func SchemaFromStruct(v interface{}) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("Can't derive a schema from %T: not a struct", v)
+	}
+	return schemaOfType(t, "")
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+// schemaOfType derives the schema of the values of a Go type.
+func schemaOfType(t reflect.Type, path string) (*Schema, error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	s := &Schema{}
+	switch t.Kind() {
+	case reflect.String:
+		s.Type = "string"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		// time.Duration values are written like "5s" as well.
+		if t != durationType {
+			s.Type = "int"
+		}
+	case reflect.Float32, reflect.Float64:
+		s.Type = "float"
+	case reflect.Bool:
+		s.Type = "bool"
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// Binary values.
+			break
+		}
+		s.Type = "list"
+		items, err := schemaOfType(t.Elem(), joinPath(path, "items"))
+		if err != nil {
+			return nil, err
+		}
+		s.Items = items
+	case reflect.Map:
+		s.Type = "map"
+	case reflect.Struct:
+		if t == timeType {
+			s.Type = "time"
+			break
+		}
+		s.Type = "map"
+		s.Keys = map[string]*Schema{}
+		if err := addStructKeys(s, t, path); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// addStructKeys adds the schemas of the fields of a struct to s.Keys.
+func addStructKeys(s *Schema, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag, opts := structKeyTag(f)
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := addStructKeys(s, ft, path); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+		name := tag
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		keyPath := joinPath(path, name)
+		sub, err := schemaOfType(f.Type, keyPath)
+		if err != nil {
+			return err
+		}
+		for _, opt := range opts {
+			if opt == "required" {
+				sub.Required = true
+			}
+		}
+		sub.Description = f.Tag.Get("description")
+		sub.Deprecated = f.Tag.Get("deprecated")
+		if def, ok := f.Tag.Lookup("default"); ok {
+			if sub.Default, err = decodeTagValue(def, sub.Type); err != nil {
+				return fmt.Errorf("Invalid default of %q: %v", keyPath, err)
+			}
+		}
+		if enum := f.Tag.Get("enum"); enum != "" {
+			for _, item := range strings.Split(enum, ",") {
+				v, err := decodeTagValue(item, sub.Type)
+				if err != nil {
+					return fmt.Errorf("Invalid enum of %q: %v", keyPath, err)
+				}
+				sub.Enum = append(sub.Enum, v)
+			}
+		}
+		if _, ok := s.Keys[name]; ok {
+			return fmt.Errorf("Can't derive a schema: duplicate key %q", keyPath)
+		}
+		s.Keys[name] = sub
+	}
+	return nil
+}
+
+// decodeTagValue decodes a value given in a struct tag, according to the
+// type of the schema.
+func decodeTagValue(s, typ string) (interface{}, error) {
+	switch typ {
+	case "string":
+		return s, nil
+	case "int":
+		return strconv.Atoi(s)
+	case "float":
+		return strconv.ParseFloat(s, 64)
+	case "bool":
+		return strconv.ParseBool(s)
+	}
+	return decodeFlatValue(s)
+}
+
+// structKeyTag returns the key name and the options of the first of the
+// config, yaml and json tags of a field.
+func structKeyTag(f reflect.StructField) (string, []string) {
+	for _, key := range []string{"config", "yaml", "json"} {
+		if tag, ok := f.Tag.Lookup(key); ok {
+			parts := strings.Split(tag, ",")
+			return parts[0], parts[1:]
+		}
+	}
+	return "", nil
+}
+
+// displayPath returns a printable form of a dotted path.
+func displayPath(path string) string {
+	if path == "" {
+		return "."
+	}
+	return path
+}
@@ -0,0 +1,233 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+var schemaString = `
+type: map
+keys:
+  database:
+    type: map
+    required: true
+    keys:
+      host: {type: string, required: true}
+      port: {type: int, default: 5432}
+      mode: {type: string, enum: [disable, require]}
+  servers:
+    type: list
+    items:
+      type: map
+      keys:
+        name: {type: string, required: true}
+        weight: {type: float}
+  debug: {type: bool}
+`
+
+func TestSchemaValidate(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(schemaString)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, schema.Keys["database"].Keys["port"].Default, 5432)
+
+	cfg, err := ParseYaml(`
+database:
+  host: localhost
+  port: "5432"
+  mode: require
+servers:
+  - name: a
+    weight: 0.5
+  - name: b
+debug: "true"
+`)
+	expect(t, err, nil)
+	expect(t, schema.Validate(cfg), nil)
+
+	cfg, err = ParseYaml(`
+database:
+  port: many
+  mode: verify-full
+servers:
+  - weight: 1
+  - name: b
+    weight: heavy
+debug: maybe
+`)
+	expect(t, err, nil)
+	err = schema.Validate(cfg)
+	errs, ok := err.(SchemaErrors)
+	expect(t, ok, true)
+	expect(t, len(errs), 6)
+	expect(t, errs[0].Path, "database.host")
+	expect(t, errs[0].Message, "required value is missing")
+	expect(t, errs[1].Path, "database.mode")
+	expect(t, errs[2].Path, "database.port")
+	expect(t, errs[3].Path, "debug")
+	expect(t, errs[4].Error(), "servers.0.name: required value is missing")
+	expect(t, errs[5].Path, "servers.1.weight")
+
+	cfg, err = ParseYaml("servers: []")
+	expect(t, err, nil)
+	expect(t, schema.Validate(cfg).Error(), "database: required value is missing")
+}
+
+func TestSchemaValidateDoesNotRead(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(schemaString)))
+	expect(t, err, nil)
+	cfg := Must(ParseYaml("database: {host: localhost, mode: require}\ndebug: true"))
+	audited := 0
+	cfg.TrackReads().AuditReads(Audit{Paths: []string{"database.host"}, Func: func(AuditEvent) { audited++ }})
+	expect(t, schema.Validate(cfg), nil)
+	expect(t, len(cfg.UnreadPaths()), 3)
+	expect(t, audited, 0)
+}
+
+func TestParseSchemaErrors(t *testing.T) {
+	for source, msg := range map[string]string{
+		"type: number":                     `Invalid schema at ".": unknown type "number"`,
+		"keys: {a: {type: map, max: 1}}":   `Invalid schema at "a": unknown field "max"`,
+		"keys: {a: {required: sometimes}}": `Invalid schema at "a": bad value for "required": "sometimes"`,
+		"items: string":                    `Invalid schema at "items": Type mismatch: expected map[string]interface{}; got string`,
+	} {
+		_, err := ParseSchema(Must(ParseYaml(source)))
+		if err == nil {
+			t.Errorf("%q: expected error", source)
+			continue
+		}
+		expect(t, err.Error(), msg)
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(`
+keys:
+  port: {type: int, required: true}
+  host: {type: string, deprecated: "use address"}
+  legacy: {deprecated: true}
+`)))
+	expect(t, err, nil)
+	expect(t, schema.Keys["legacy"].Deprecated, "deprecated")
+
+	report := schema.Check(Must(ParseYaml("port: 80\nhost: localhost")))
+	expect(t, report.Fatal(), false)
+	expect(t, report.Err(), nil)
+	expect(t, len(report.Warnings), 1)
+	expect(t, report.String(), "warning: host: use address")
+
+	report = schema.Check(Must(ParseYaml("port: any\nlegacy: 1")))
+	expect(t, report.Fatal(), true)
+	expect(t, len(report.Errors), 1)
+	expect(t, report.Errors[0].Path, "port")
+	expect(t, report.Warnings[0].Error(), "legacy: deprecated")
+	expect(t, schema.Validate(Must(ParseYaml("legacy: 1"))).Error(), "port: required value is missing")
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	type Common struct {
+		Debug bool
+	}
+	type Server struct {
+		Name   string  `yaml:"name,omitempty" config:",required"`
+		Weight float64 `json:"weight"`
+	}
+	type App struct {
+		Common
+		Database struct {
+			Host    string        `config:"host,required"`
+			Port    int           `config:"port" default:"5432"`
+			Mode    string        `config:"mode" enum:"disable,require"`
+			User    string        `config:"user" deprecated:"use role"`
+			Timeout time.Duration `config:"timeout"`
+		} `config:"database,required"`
+		Servers  []*Server         `config:"servers"`
+		Labels   map[string]string `config:"labels"`
+		Started  time.Time         `config:"started"`
+		Internal string            `config:"-"`
+		hidden   int
+	}
+
+	schema, err := SchemaFromStruct(&App{})
+	expect(t, err, nil)
+	expect(t, len(schema.Keys), 5)
+	expect(t, schema.Keys["debug"].Type, "bool")
+	db := schema.Keys["database"]
+	expect(t, db.Required, true)
+	expect(t, db.Keys["port"].Default, 5432)
+	expect(t, db.Keys["mode"].Enum[1], "require")
+	expect(t, db.Keys["user"].Deprecated, "use role")
+	expect(t, db.Keys["timeout"].Type, "")
+	expect(t, schema.Keys["servers"].Items.Keys["name"].Required, true)
+	expect(t, schema.Keys["servers"].Items.Keys["weight"].Type, "float")
+	expect(t, schema.Keys["labels"].Type, "map")
+	expect(t, schema.Keys["started"].Type, "time")
+
+	err = schema.Validate(Must(ParseYaml(`
+database: {port: many, mode: verify-full}
+servers: [{weight: 1}]
+`)))
+	expect(t, err.Error(), `database.host: required value is missing; `+
+		`database.mode: value "verify-full" is not one of [disable require]; `+
+		`database.port: expected int: strconv.ParseInt: parsing "many": invalid syntax; `+
+		`servers.0.name: required value is missing`)
+
+	_, err = SchemaFromStruct(42)
+	expect(t, err != nil, true)
+}
+
+func TestSchemaRules(t *testing.T) {
+	schema, err := ParseSchema(Must(ParseYaml(`
+type: map
+keys:
+  server:
+    keys:
+      port: {type: int}
+      adminPort: {type: int}
+      tls:
+        keys:
+          enabled: {type: bool}
+    rules:
+      - if: {tls.enabled: true}
+        require: [tls.cert, tls.key]
+      - if: {tls.enabled: false}
+        forbid: [tls.cert]
+      - distinct: [port, adminPort]
+`)))
+	expect(t, err, nil)
+	expect(t, len(schema.Keys["server"].Rules), 3)
+
+	valid := []string{
+		"server: {port: 80, adminPort: 81, tls: {enabled: true, cert: c, key: k}}",
+		"server: {port: 80, tls: {enabled: false}}",
+		"server: {port: 80, adminPort: 81}",
+	}
+	for _, doc := range valid {
+		expect(t, schema.Validate(Must(ParseYaml(doc))), nil)
+	}
+
+	err = schema.Validate(Must(ParseYaml("server: {port: 80, adminPort: \"80\", tls: {enabled: \"true\", key: k}}")))
+	expect(t, err.Error(), `server.adminPort: value "80" must differ from port; `+
+		`server.tls.cert: required value is missing when tls.enabled is true`)
+	err = schema.Validate(Must(ParseYaml("server: {tls: {enabled: false, cert: c}}")))
+	expect(t, err.Error(), "server.tls.cert: value is not allowed when tls.enabled is false")
+
+	// Rules don't count as reads.
+	cfg := Must(ParseYaml(valid[0])).TrackReads()
+	expect(t, schema.Validate(cfg), nil)
+	expect(t, len(cfg.UnreadPaths()), 5)
+
+	for source, msg := range map[string]string{
+		"rules: {require: [a]}":     `Invalid schema at ".": bad value for "rules": map[string]interface {}{"require":[]interface {}{"a"}}`,
+		"rules: [{require: a}]":     `Invalid schema at ".": rule 0: bad value for "require": "a"`,
+		"rules: [{unless: {a: 1}}]": `Invalid schema at ".": rule 0: unknown field "unless"`,
+	} {
+		_, err := ParseSchema(Must(ParseYaml(source)))
+		expect(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSealed is returned by the methods changing a sealed config.
+var ErrSealed = errors.New("Config is sealed")
+
+// SealMode tells what happens to changes of a sealed config.
+type SealMode uint32
+
+const (
+	// SealReject makes Set and Delete return ErrSealed.
+	SealReject SealMode = iota + 1
+	// SealPanic makes Set and Delete panic, to find the code changing
+	// the config.
+	SealPanic
+)
+
+// Seal makes the config read-only: Set, Delete, ReplaceAll, the patches,
+// Cast and Coerce, and the methods relying on them like Env, fail
+// according to mode, for the
+// config and the configs returned by its Get method. Copies of a sealed
+// config aren't sealed.
+func (cfg *Config) Seal(mode SealMode) {
+	atomic.StoreUint32(&cfg.state().sealed, uint32(mode))
+}
+
+// SealAfterStartup lets the config change during the startup window,
+// while flags, environment variables and secrets are applied, and seals
+// it afterwards, catching the changes made at run time to settings which
+// should be immutable. It returns a function sealing the config at once,
+// for programs which know when they are done starting:
+//
+//	seal := cfg.SealAfterStartup(30*time.Second, config.SealPanic)
+//	// ...
+//	seal()
+func (cfg *Config) SealAfterStartup(window time.Duration, mode SealMode) func() {
+	s := cfg.state()
+	t := time.AfterFunc(window, func() { cfg.Seal(mode) })
+	return func() {
+		t.Stop()
+		atomic.StoreUint32(&s.sealed, uint32(mode))
+	}
+}
+
+// checkSealed returns ErrSealed, or panics, when the config is sealed.
+func (cfg *Config) checkSealed(op, path string) error {
+	s, ok := cfg.cow.Load().(*cowState)
+	if !ok {
+		return nil
+	}
+	switch SealMode(atomic.LoadUint32(&s.sealed)) {
+	case SealReject:
+		return ErrSealed
+	case SealPanic:
+		panic(fmt.Sprintf("config: %s of %q after the config was sealed", op, displayPath(path)))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSeal(t *testing.T) {
+	cfg := Must(ParseYaml("server: {host: localhost, port: 8080}"))
+	sub, err := cfg.Get("server")
+	expect(t, err, nil)
+	cfg.Seal(SealReject)
+	expect(t, cfg.Set("server.port", 9090), ErrSealed)
+	expect(t, cfg.Delete("server.host"), ErrSealed)
+	expect(t, sub.Set("port", 9090), ErrSealed)
+	expect(t, cfg.ReplaceAll("localhost", "example.com"), 0)
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UString("server.host"), "localhost")
+
+	// Copies can change.
+	cp, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, cp.Set("server.port", 9090), nil)
+	expect(t, cfg.UInt("server.port"), 8080)
+
+	cfg.Seal(SealPanic)
+	defer func() {
+		expect(t, recover(), `config: Set of "server.port" after the config was sealed`)
+	}()
+	cfg.Set("server.port", 9090)
+}
+
+func TestSealPatchAndCast(t *testing.T) {
+	cfg := Must(ParseYaml("port: '8080'"))
+	cfg.Seal(SealReject)
+	expect(t, cfg.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "/port", "value": "9090"}]`)), ErrSealed)
+	expect(t, cfg.ApplyMergePatch([]byte(`{"port": "9090"}`)), ErrSealed)
+	expect(t, cfg.Cast("port", "int"), ErrSealed)
+	schema := &Schema{Type: "map", Keys: map[string]*Schema{"port": {Type: "int"}}}
+	expect(t, schema.Coerce(cfg), ErrSealed)
+	v, _ := Get(cfg.Root, "port")
+	expect(t, v, "8080")
+
+	cfg = Must(ParseYaml("'8080'"))
+	cfg.Seal(SealReject)
+	expect(t, cfg.Cast("", "int"), ErrSealed)
+	expect(t, (&Schema{Type: "int"}).Coerce(cfg), ErrSealed)
+	expect(t, cfg.Root, "8080")
+}
+
+func TestSealAfterStartup(t *testing.T) {
+	cfg := Must(ParseYaml("port: 8080"))
+	seal := cfg.SealAfterStartup(time.Hour, SealReject)
+	expect(t, cfg.Set("port", 9090), nil)
+	seal()
+	expect(t, cfg.Set("port", 7070), ErrSealed)
+	expect(t, cfg.UInt("port"), 9090)
+
+	cfg = Must(ParseYaml("port: 8080"))
+	cfg.SealAfterStartup(time.Millisecond, SealReject)
+	deadline := time.Now().Add(time.Second)
+	for cfg.Set("port", 9090) == nil && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	expect(t, cfg.Set("port", 9090), ErrSealed)
+}
@@ -0,0 +1,91 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// section reads the optional keys of a map into settings, keeping the
+// first error, for the builders of settings like HTTPServer.
+type section struct {
+	sub  *Config
+	path string
+	kind string
+	err  error
+}
+
+// newSection returns the section of a kind of settings at a dotted path,
+// which must hold a map.
+func (cfg *Config) newSection(path, kind string) (*section, error) {
+	sub, err := cfg.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sub.Map(""); err != nil {
+		return nil, err
+	}
+	return &section{sub: sub, path: canonicalPath("", path), kind: kind}, nil
+}
+
+// invalid returns the error of an invalid key.
+func (s *section) invalid(key string, err error) error {
+	return fmt.Errorf("Invalid %s config at %q: %v", s.kind, displayPath(joinPath(s.path, key)), err)
+}
+
+// read sets the value of a key, if it is set and no error occurred yet.
+func (s *section) read(key string, get func() error) {
+	if s.err != nil {
+		return
+	}
+	if _, ok := s.sub.getOk(key); !ok {
+		return
+	}
+	if err := get(); err != nil {
+		s.err = s.invalid(key, err)
+	}
+}
+
+func (s *section) string(key string, dst *string) {
+	s.read(key, func() (err error) {
+		*dst, err = s.sub.String(key)
+		return err
+	})
+}
+
+func (s *section) int(key string, dst *int) {
+	s.read(key, func() (err error) {
+		*dst, err = s.sub.Int(key)
+		return err
+	})
+}
+
+func (s *section) float(key string, dst *float64) {
+	s.read(key, func() (err error) {
+		*dst, err = s.sub.Float64(key)
+		return err
+	})
+}
+
+func (s *section) duration(key string, dst *time.Duration) {
+	s.read(key, func() (err error) {
+		*dst, err = s.sub.Duration(key)
+		return err
+	})
+}
+
+func (s *section) size(key string, dst *int64) {
+	s.read(key, func() (err error) {
+		*dst, err = s.sub.Size(key)
+		return err
+	})
+}
+
+// has reports whether a key is set.
+func (s *section) has(key string) bool {
+	_, ok := s.sub.getOk(key)
+	return ok
+}
@@ -0,0 +1,72 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "fmt"
+
+// StringSet returns the strings of the list at a dotted path as a set,
+// dropping duplicates, like for allowlists:
+//
+//	allowed, err := cfg.StringSet("cors.origins")
+//	if _, ok := allowed[origin]; !ok {
+//		// ...
+//	}
+//
+// Numbers and booleans are converted like by String.
+func (cfg *Config) StringSet(path string) (map[string]struct{}, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return nil, err
+	}
+	list, err := toList(n)
+	if err != nil {
+		return nil, cfg.observeConversion(path, err)
+	}
+	set := make(map[string]struct{}, len(list))
+	for i, item := range list {
+		s, err := toString(item)
+		if err != nil {
+			return nil, cfg.observeConversion(path, fmt.Errorf("Item %d of %q: %v", i, path, err))
+		}
+		set[s] = struct{}{}
+	}
+	return set, nil
+}
+
+// UStringSet returns a set of strings according to a dotted path or
+// default value or an empty set.
+func (c *Config) UStringSet(path string, defaults ...map[string]struct{}) map[string]struct{} {
+	value, err := c.StringSet(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return map[string]struct{}{}
+}
+
+// Contains reports whether the list at a dotted path holds the given
+// value, comparing them as strings, so that ports written as numbers match
+// "8080". Missing paths and values which aren't lists hold nothing, so
+// a missing denylist denies nothing.
+func (cfg *Config) Contains(path, value string) bool {
+	n, ok := cfg.getOk(path)
+	if !ok {
+		return false
+	}
+	list, ok := n.([]interface{})
+	if !ok {
+		return false
+	}
+	for _, item := range list {
+		if s, err := toString(item); err == nil && s == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringSet(t *testing.T) {
+	cfg := Must(ParseYaml(`
+origins: [a.com, b.com, a.com]
+ports: [80, "443", 80]
+nested: [a, [b]]
+name: app
+`))
+	set, err := cfg.StringSet("origins")
+	expect(t, err, nil)
+	if !reflect.DeepEqual(set, map[string]struct{}{"a.com": {}, "b.com": {}}) {
+		t.Errorf("Expected a.com and b.com, got %v", set)
+	}
+	set, err = cfg.StringSet("ports")
+	expect(t, err, nil)
+	expect(t, len(set), 2)
+
+	_, err = cfg.StringSet("nested")
+	expect(t, err.Error(), "Item 1 of \"nested\": Type mismatch: expected bool, float64, int or string; got []interface {}")
+	_, err = cfg.StringSet("name")
+	expect(t, err.Error(), "Type mismatch: expected []interface{}; got string")
+	expect(t, len(cfg.UStringSet("missing")), 0)
+
+	expect(t, cfg.Contains("origins", "b.com"), true)
+	expect(t, cfg.Contains("origins", "c.com"), false)
+	expect(t, cfg.Contains("ports", "80"), true)
+	expect(t, cfg.Contains("ports", "443"), true)
+	expect(t, cfg.Contains("name", "app"), false)
+	expect(t, cfg.Contains("missing", "a"), false)
+}
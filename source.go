@@ -0,0 +1,75 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Sources --------------------------------------------------------------------
+//
+// A Source loads configs from outside the process, like a database or a
+// key-value store. The package doesn't depend on any client library: the
+// sources talking to remote services take small interfaces which the
+// corresponding client packages satisfy, or can be adapted to in a few
+// lines.
+
+// Source loads a config.
+type Source interface {
+	Load(ctx context.Context) (*Config, error)
+}
+
+// Persister is implemented by sources which can store a config back.
+type Persister interface {
+	Persist(ctx context.Context, cfg *Config) error
+}
+
+// ErrConflict is returned by Persist when the stored config changed since
+// it was last loaded, so that writing it would lose that change.
+var ErrConflict = errors.New("Config changed since it was loaded")
+
+// resolveRefs replaces the string values of cfg which resolve reports as
+// references by their resolved values. The kind of references names them
+// in errors.
+func resolveRefs(cfg *Config, kind string, resolve func(s string) (string, bool, error)) error {
+	var err error
+	resolved := map[string]string{}
+	refs := map[string]string{}
+	walkLeaves(cfg.Root, "", func(path string, v interface{}) {
+		s, ok := v.(string)
+		if !ok || err != nil {
+			return
+		}
+		value, ref, rerr := resolve(s)
+		if rerr != nil {
+			err = fmt.Errorf("Unresolved %s reference at %q: %v", kind, path, rerr)
+		} else if ref {
+			resolved[path], refs[path] = value, s
+		}
+	})
+	if err != nil {
+		return err
+	}
+	policy := cfg.refsPolicy()
+	for path, value := range resolved {
+		if err := cfg.Set(path, value); err != nil {
+			return err
+		}
+		if policy == nil {
+			continue
+		}
+		ref := refs[path]
+		fetch := func() (interface{}, error) {
+			value, _, err := resolve(ref)
+			return value, err
+		}
+		if err := cfg.refreshAfter(path, *policy, fetch, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
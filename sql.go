@@ -0,0 +1,173 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// SQLSource loads a config from a database table with a row per leaf:
+//
+//	CREATE TABLE config (
+//		path  TEXT PRIMARY KEY,
+//		value TEXT NOT NULL,
+//		type  TEXT NOT NULL
+//	);
+//
+// The path is a dotted path, and the type one of "string", "int",
+// "float", "bool", "null" or "json"; the latter holds any other value,
+// like empty maps and lists, encoded as JSON.
+type SQLSource struct {
+	DB *sql.DB
+	// Table is the name of the table, "config" by default.
+	Table string
+	// Bind returns the placeholder for the n-th query argument, counted
+	// from 1. The default returns "?"; use DollarBind for PostgreSQL.
+	Bind func(n int) string
+}
+
+// NewSQLSource returns a source reading the given table.
+func NewSQLSource(db *sql.DB, table string) *SQLSource {
+	return &SQLSource{DB: db, Table: table}
+}
+
+// DollarBind returns PostgreSQL placeholders, like "$1".
+func DollarBind(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+func (s *SQLSource) table() string {
+	if s.Table == "" {
+		return "config"
+	}
+	return s.Table
+}
+
+func (s *SQLSource) bind(n int) string {
+	if s.Bind == nil {
+		return "?"
+	}
+	return s.Bind(n)
+}
+
+// Load reads the table and builds the config tree from its rows.
+func (s *SQLSource) Load(ctx context.Context) (*Config, error) {
+	rows, err := s.DB.QueryContext(ctx,
+		fmt.Sprintf("SELECT path, value, type FROM %s ORDER BY path", s.table()))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cfg := &Config{}
+	for rows.Next() {
+		var path, value, kind string
+		if err := rows.Scan(&path, &value, &kind); err != nil {
+			return nil, err
+		}
+		v, err := decodeSQLValue(value, kind)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value at %q: %v", path, err)
+		}
+		if path == "" {
+			cfg.Root = v
+			continue
+		}
+		if err := cfg.Set(path, v); err != nil {
+			return nil, err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	root, err := normalizeValue(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		root = map[string]interface{}{}
+	}
+	return newConfig(root)
+}
+
+// Persist replaces the rows of the table with the leaves of cfg, in a
+// single transaction.
+func (s *SQLSource) Persist(ctx context.Context, cfg *Config) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+s.table()); err != nil {
+		return err
+	}
+	stmt, err := tx.PrepareContext(ctx, fmt.Sprintf(
+		"INSERT INTO %s (path, value, type) VALUES (%s, %s, %s)",
+		s.table(), s.bind(1), s.bind(2), s.bind(3)))
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	walkLeaves(cfg.Root, "", func(path string, v interface{}) {
+		if err != nil {
+			return
+		}
+		var value, kind string
+		if value, kind, err = encodeSQLValue(v); err == nil {
+			_, err = stmt.ExecContext(ctx, path, value, kind)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// decodeSQLValue converts a stored value according to its type.
+func decodeSQLValue(value, kind string) (interface{}, error) {
+	switch kind {
+	case "string":
+		return value, nil
+	case "int":
+		return strconv.Atoi(value)
+	case "float":
+		return strconv.ParseFloat(value, 64)
+	case "bool":
+		return strconv.ParseBool(value)
+	case "null":
+		return nil, nil
+	case "json":
+		var v interface{}
+		if err := json.Unmarshal([]byte(value), &v); err != nil {
+			return nil, err
+		}
+		return normalizeValue(v)
+	}
+	return nil, fmt.Errorf("unknown type %q", kind)
+}
+
+// encodeSQLValue returns the stored form of a value and its type.
+func encodeSQLValue(v interface{}) (string, string, error) {
+	switch v := v.(type) {
+	case string:
+		return v, "string", nil
+	case int:
+		return strconv.Itoa(v), "int", nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), "float", nil
+	case bool:
+		return strconv.FormatBool(v), "bool", nil
+	case nil:
+		return "", "null", nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), "json", err
+}
@@ -0,0 +1,192 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTable is an in-memory table of the fake SQL driver, understanding
+// just the queries issued by SQLSource.
+type fakeTable struct {
+	mu      sync.Mutex
+	rows    [][]string
+	queries []string
+}
+
+var (
+	fakeTablesMu sync.Mutex
+	fakeTables   = map[string]*fakeTable{}
+)
+
+func init() {
+	sql.Register("config-fake", fakeDriver{})
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	fakeTablesMu.Lock()
+	defer fakeTablesMu.Unlock()
+	if fakeTables[name] == nil {
+		fakeTables[name] = &fakeTable{}
+	}
+	return &fakeConn{table: fakeTables[name]}, nil
+}
+
+type fakeConn struct {
+	table  *fakeTable
+	backup [][]string
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return &fakeStmt{c, query}, nil
+}
+
+func (c *fakeConn) Close() error { return nil }
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	c.table.mu.Lock()
+	c.backup = append([][]string(nil), c.table.rows...)
+	c.table.mu.Unlock()
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error { return nil }
+
+func (c *fakeConn) Rollback() error {
+	c.table.mu.Lock()
+	c.table.rows = c.backup
+	c.table.mu.Unlock()
+	return nil
+}
+
+type fakeStmt struct {
+	conn  *fakeConn
+	query string
+}
+
+func (s *fakeStmt) Close() error  { return nil }
+func (s *fakeStmt) NumInput() int { return -1 }
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	t := s.conn.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries = append(t.queries, s.query)
+	switch {
+	case strings.HasPrefix(s.query, "DELETE"):
+		t.rows = nil
+	case strings.HasPrefix(s.query, "INSERT"):
+		row := make([]string, len(args))
+		for i, arg := range args {
+			row[i] = fmt.Sprint(arg)
+		}
+		if row[0] == "fail" {
+			return nil, fmt.Errorf("insert failed")
+		}
+		t.rows = append(t.rows, row)
+	default:
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	t := s.conn.table
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries = append(t.queries, s.query)
+	if !strings.HasPrefix(s.query, "SELECT") {
+		return nil, fmt.Errorf("unexpected query: %s", s.query)
+	}
+	rows := append([][]string(nil), t.rows...)
+	sort.Slice(rows, func(i, j int) bool { return rows[i][0] < rows[j][0] })
+	return &fakeRows{rows: rows}, nil
+}
+
+type fakeRows struct {
+	rows [][]string
+}
+
+func (r *fakeRows) Columns() []string { return []string{"path", "value", "type"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	for i, v := range r.rows[0] {
+		dest[i] = v
+	}
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestSQLSource(t *testing.T) {
+	db, err := sql.Open("config-fake", t.Name())
+	expect(t, err, nil)
+	defer db.Close()
+	expect(t, db.Ping(), nil)
+	table := fakeTables[t.Name()]
+	table.rows = [][]string{
+		{"server.host", "localhost", "string"},
+		{"server.port", "8080", "int"},
+		{"server.[dotted.key]", "true", "bool"},
+		{"ratio", "0.5", "float"},
+		{"users.1", "hobbes", "string"},
+		{"users.0", "calvin", "string"},
+		{"empty", "{}", "json"},
+		{"nothing", "", "null"},
+	}
+
+	src := NewSQLSource(db, "settings")
+	ctx := context.Background()
+	cfg, err := src.Load(ctx)
+	expect(t, err, nil)
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UBool("server.[dotted.key]"), true)
+	expect(t, cfg.UFloat64("ratio"), 0.5)
+	expect(t, cfg.UString("users.1"), "hobbes")
+	expect(t, len(cfg.UMap("empty", map[string]interface{}{"x": 1})), 0)
+	v, err := cfg.Get("nothing")
+	expect(t, err, nil)
+	expect(t, v.Root, nil)
+	expect(t, table.queries[0], "SELECT path, value, type FROM settings ORDER BY path")
+
+	// Persisting and loading again yields the same tree.
+	expect(t, cfg.Set("server.port", 9090), nil)
+	expect(t, cfg.Set("users.2", "susie"), nil)
+	expect(t, src.Persist(ctx, cfg), nil)
+	reloaded, err := src.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect(t, len(Diff(cfg, reloaded)), 0)
+	expect(t, reloaded.UInt("server.port"), 9090)
+
+	// A failed write rolls back the transaction.
+	before := len(table.rows)
+	expect(t, cfg.Set("fail", 1), nil)
+	expect(t, src.Persist(ctx, cfg).Error(), "insert failed")
+	expect(t, len(table.rows), before)
+
+	table.rows = [][]string{{"bad", "x", "int"}}
+	_, err = src.Load(ctx)
+	expect(t, err.Error(), `Invalid value at "bad": strconv.Atoi: parsing "x": invalid syntax`)
+}
+
+func TestDollarBind(t *testing.T) {
+	expect(t, DollarBind(2), "$2")
+}
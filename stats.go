@@ -0,0 +1,119 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// statsLargest is the number of subtrees reported by Stats.
+const statsLargest = 10
+
+// Stats describes the size of a config tree, to enforce budgets on
+// configs supplied by users and spot the parts growing out of bounds.
+type Stats struct {
+	// Nodes counts the maps, lists and values of the tree, by the type
+	// names of schemas: "map", "list", "string", "int", "float", "bool",
+	// "time", and "null" for nil values.
+	Nodes map[string]int
+	// Total is the number of nodes, the root included.
+	Total int
+	// MaxDepth is the length of the longest path, 0 for a tree holding
+	// a single value.
+	MaxDepth int
+	// StringBytes is the length of the strings of the tree, map keys
+	// included.
+	StringBytes int
+	// Largest holds the ten largest maps and lists below the root, by number
+	// of nodes, in decreasing order.
+	Largest []SubtreeStats
+}
+
+// SubtreeStats is the size of a map or list of a tree.
+type SubtreeStats struct {
+	Path        string
+	Nodes       int
+	StringBytes int
+}
+
+// Stats walks the config tree and returns its size:
+//
+//	stats := cfg.Stats()
+//	if stats.Total > 10000 || stats.StringBytes > 1<<20 {
+//		return fmt.Errorf("config too large: %v", stats.Largest)
+//	}
+func (cfg *Config) Stats() *Stats {
+	s := &Stats{Nodes: map[string]int{}}
+	var subtrees []SubtreeStats
+	var walk func(node interface{}, path string, depth int) (int, int)
+	walk = func(node interface{}, path string, depth int) (int, int) {
+		if depth > s.MaxDepth {
+			s.MaxDepth = depth
+		}
+		s.Nodes[typeName(node)]++
+		nodes, bytes := 1, 0
+		switch c := node.(type) {
+		case map[string]interface{}:
+			for k, v := range c {
+				n, b := walk(v, joinPath(path, k), depth+1)
+				nodes, bytes = nodes+n, bytes+b+len(k)
+			}
+		case []interface{}:
+			for i, v := range c {
+				n, b := walk(v, joinPath(path, strconv.Itoa(i)), depth+1)
+				nodes, bytes = nodes+n, bytes+b
+			}
+		case string:
+			bytes = len(c)
+		}
+		switch node.(type) {
+		case map[string]interface{}, []interface{}:
+			if path != "" {
+				subtrees = append(subtrees, SubtreeStats{Path: path, Nodes: nodes, StringBytes: bytes})
+			}
+		}
+		return nodes, bytes
+	}
+	s.Total, s.StringBytes = walk(cfg.Root, "", 0)
+
+	sort.Slice(subtrees, func(i, j int) bool {
+		if subtrees[i].Nodes != subtrees[j].Nodes {
+			return subtrees[i].Nodes > subtrees[j].Nodes
+		}
+		return subtrees[i].Path < subtrees[j].Path
+	})
+	if len(subtrees) > statsLargest {
+		subtrees = subtrees[:statsLargest]
+	}
+	s.Largest = subtrees
+	return s
+}
+
+// typeName returns the schema type name of a value, or its Go type for
+// values of other types.
+func typeName(node interface{}) string {
+	switch node.(type) {
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	case string:
+		return "string"
+	case int:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "time"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", node)
+}
@@ -0,0 +1,46 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	cfg, err := ParseYaml(`
+name: app
+ports: [80, 443]
+db:
+  host: localhost
+  pool: {size: 10, ratio: 0.5, lazy: true}
+  extra: null
+`)
+	expect(t, err, nil)
+
+	s := cfg.Stats()
+	want := map[string]int{"map": 3, "list": 1, "string": 2, "int": 3, "float": 1, "bool": 1, "null": 1}
+	if !reflect.DeepEqual(s.Nodes, want) {
+		t.Errorf("Nodes: got %v; want %v", s.Nodes, want)
+	}
+	expect(t, s.Total, 12)
+	expect(t, s.MaxDepth, 3)
+	// Values "app" and "localhost", and the keys.
+	expect(t, s.StringBytes, 12+len("nameportsdbhostpoolsizeratiolazyextra"))
+	wantLargest := []SubtreeStats{
+		{Path: "db", Nodes: 7, StringBytes: 9 + len("hostpoolsizeratiolazyextra")},
+		{Path: "db.pool", Nodes: 4, StringBytes: len("sizeratiolazy")},
+		{Path: "ports", Nodes: 3},
+	}
+	if !reflect.DeepEqual(s.Largest, wantLargest) {
+		t.Errorf("Largest: got %v; want %v", s.Largest, wantLargest)
+	}
+
+	leaf := (&Config{Root: "value"}).Stats()
+	expect(t, leaf.Total, 1)
+	expect(t, leaf.MaxDepth, 0)
+	expect(t, leaf.StringBytes, 5)
+	expect(t, len(leaf.Largest), 0)
+}
@@ -0,0 +1,150 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSuggestions is the number of keys suggested by errors.
+const maxSuggestions = 3
+
+// Suggest returns the existing paths closest to a missing path, replacing
+// the first segment not found by the keys of its map within a small edit
+// distance, closest first:
+//
+//	cfg.Suggest("databse.host")  // ["database.host"]
+//
+// It returns nothing when the path exists or no key is close enough. The
+// errors of the getters for missing keys include these suggestions.
+func (cfg *Config) Suggest(path string) []string {
+	parts, err := parsePath(path)
+	if err != nil {
+		return []string{}
+	}
+	seen := map[string]bool{}
+	suggestions := []string{}
+	var collect func(c *Config)
+	collect = func(c *Config) {
+		for _, s := range suggestPaths(c.Root, parts) {
+			if !seen[s] {
+				seen[s] = true
+				suggestions = append(suggestions, s)
+			}
+		}
+		if c.primary != nil {
+			collect(c.primary)
+		}
+		for _, fallback := range c.fallbacks {
+			collect(fallback)
+		}
+	}
+	collect(cfg)
+	return suggestions
+}
+
+// suggestPaths returns the paths replacing the first key of parts missing
+// below node with the closest existing keys.
+func suggestPaths(node interface{}, parts []string) []string {
+	for pos, part := range parts {
+		switch c := node.(type) {
+		case map[string]interface{}:
+			value, ok := c[part]
+			if ok {
+				node = value
+				continue
+			}
+			var paths []string
+			for _, key := range closestKeys(c, part) {
+				paths = append(paths, formatPath(append(append(parts[:pos:pos], key), parts[pos+1:]...)))
+			}
+			return paths
+		case []interface{}:
+			node, _ = getParts(c, []string{part})
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// closestKeys returns the keys of a map within a small edit distance of a
+// missing key, closest first. Case differences don't count.
+func closestKeys(m map[string]interface{}, missing string) []string {
+	limit := (len(missing) + 2) / 3
+	type candidate struct {
+		key      string
+		distance int
+	}
+	var candidates []candidate
+	for key := range m {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(missing)); d <= limit {
+			candidates = append(candidates, candidate{key, d})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].distance != candidates[j].distance {
+			return candidates[i].distance < candidates[j].distance
+		}
+		return candidates[i].key < candidates[j].key
+	})
+	keys := make([]string, len(candidates))
+	for i, c := range candidates {
+		keys[i] = c.key
+	}
+	return keys
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	row := make([]int, len(t)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		prev := row[0]
+		row[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			cur := row[j]
+			row[j] = min3(row[j]+1, row[j-1]+1, prev+cost)
+			prev = cur
+		}
+	}
+	return row[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+// didYouMean returns the suggestion appended to the errors for a missing
+// key of a map, or "".
+func didYouMean(m map[string]interface{}, parts []string, pos int) string {
+	keys := closestKeys(m, parts[pos])
+	if len(keys) == 0 {
+		return ""
+	}
+	if len(keys) > maxSuggestions {
+		keys = keys[:maxSuggestions]
+	}
+	quoted := make([]string, len(keys))
+	for i, key := range keys {
+		quoted[i] = fmt.Sprintf("%q", formatPath(append(parts[:pos:pos], key)))
+	}
+	return "; did you mean " + strings.Join(quoted, " or ") + "?"
+}
@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuggest(t *testing.T) {
+	cfg, err := ParseYaml(`
+database: {host: localhost, port: 5432}
+databases: [{host: a}]
+servers: [{name: web, port: 80}]
+log.level: info
+`)
+	expect(t, err, nil)
+
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"databse.host", []string{"database.host", "databases.host"}},
+		{"database.hots", []string{"database.host"}},
+		{"DataBase.port", []string{"database.port", "databases.port"}},
+		{"servers.0.nmae", []string{"servers.0.name"}},
+		{"log.levl", []string{}},
+		{"[log.levl]", []string{"[log.level]"}},
+		{"database.host", []string{}},
+		{"unrelated", []string{}},
+		{"database.host.x", []string{}},
+		{"a[", []string{}},
+	}
+	for _, test := range tests {
+		got := cfg.Suggest(test.path)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Suggest(%q): got %q; want %q", test.path, got, test.want)
+		}
+	}
+
+	_, err = cfg.String("databse.host")
+	expect(t, err.Error(), `Nonexistent map key at "databse"; did you mean "database" or "databases"?`)
+	_, err = cfg.Int("database.prot")
+	expect(t, err.Error(), `Nonexistent map key at "database.prot"; did you mean "database.port"?`)
+	_, err = cfg.Int("database.user")
+	expect(t, err.Error(), `Nonexistent map key at "database.user"`)
+
+	// Fallbacks are considered too.
+	defaults, err := ParseYaml(`cache: {ttl: 10}`)
+	expect(t, err, nil)
+	layered := cfg.WithFallback(defaults)
+	if got := layered.Suggest("cahce.ttl"); !reflect.DeepEqual(got, []string{"cache.ttl"}) {
+		t.Errorf("Suggest with fallback: got %q", got)
+	}
+}
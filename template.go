@@ -0,0 +1,29 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "text/template"
+
+// TemplateFuncs returns template functions reading values from the given
+// config, for generating files like nginx or systemd units:
+//
+//	listen {{ cfgInt "server.port" }};
+//	{{ range cfgList "server.names" }}server_name {{ . }};{{ end }}
+//
+// The functions are cfgString, cfgInt, cfgFloat, cfgBool, cfgList and
+// cfgMap, wrapping the getters of the same name. A missing path or a type
+// mismatch stops the template execution with the getter's error. The map
+// can be used with html/template too, by converting it to
+// html/template.FuncMap.
+func TemplateFuncs(cfg *Config) template.FuncMap {
+	return template.FuncMap{
+		"cfgString": cfg.String,
+		"cfgInt":    cfg.Int,
+		"cfgFloat":  cfg.Float64,
+		"cfgBool":   cfg.Bool,
+		"cfgList":   cfg.List,
+		"cfgMap":    cfg.Map,
+	}
+}
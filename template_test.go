@@ -0,0 +1,57 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	cfg, err := ParseYaml(`
+server:
+  port: 8080
+  ratio: 0.5
+  gzip: true
+  names:
+    - example.com
+    - www.example.com
+  headers:
+    X-Frame-Options: DENY
+`)
+	expect(t, err, nil)
+
+	tmpl := template.Must(template.New("nginx").Funcs(TemplateFuncs(cfg)).Parse(
+		`listen {{ cfgInt "server.port" }};
+{{ range cfgList "server.names" }}server_name {{ . }};
+{{ end }}gzip {{ if cfgBool "server.gzip" }}on{{ else }}off{{ end }};
+{{ range $k, $v := cfgMap "server.headers" }}add_header {{ $k }} {{ $v }};
+{{ end }}# {{ cfgString "server.port" }} {{ cfgFloat "server.ratio" }}`))
+	var out bytes.Buffer
+	expect(t, tmpl.Execute(&out, nil), nil)
+	expect(t, out.String(), `listen 8080;
+server_name example.com;
+server_name www.example.com;
+gzip on;
+add_header X-Frame-Options DENY;
+# 8080 0.5`)
+
+	// Errors stop the execution.
+	tmpl = template.Must(template.New("bad").Funcs(TemplateFuncs(cfg)).Parse(
+		`{{ cfgInt "server.missing" }}`))
+	err = tmpl.Execute(&out, nil)
+	expect(t, err != nil, true)
+	expect(t, strings.Contains(err.Error(), `Nonexistent map key at "server.missing"`), true)
+
+	// The functions work with html/template.
+	html := htmltemplate.Must(htmltemplate.New("html").Funcs(
+		htmltemplate.FuncMap(TemplateFuncs(cfg))).Parse(`<p>{{ cfgString "server.names.0" }}</p>`))
+	out.Reset()
+	expect(t, html.Execute(&out, nil), nil)
+	expect(t, out.String(), "<p>example.com</p>")
+}
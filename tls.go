@@ -0,0 +1,151 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+// tlsVersions maps the names of TLS versions to their values.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// tlsClientAuths maps the names of client authentication policies to
+// their values.
+var tlsClientAuths = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify_if_given":    tls.VerifyClientCertIfGiven,
+	"require_and_verify": tls.RequireAndVerifyClientCert,
+}
+
+// TLS builds a *tls.Config from the map at a dotted path, for servers and
+// clients alike:
+//
+//	tls:
+//	  cert_file: /etc/app/tls.crt
+//	  key_file: /etc/app/tls.key
+//	  ca_file: /etc/app/ca.crt          # trusted roots, and client CAs
+//	  min_version: "1.2"                # 1.0, 1.1, 1.2 or 1.3
+//	  client_auth: require_and_verify   # none, request, require,
+//	                                    # verify_if_given, require_and_verify
+//	  server_name: api.example.com
+//	  insecure_skip_verify: false
+//
+// All the keys are optional, but cert_file and key_file go together, and
+// min_version defaults to 1.2. The files are read at once, so that missing
+// or invalid ones are reported at startup.
+func (cfg *Config) TLS(path string) (*tls.Config, error) {
+	sub, err := cfg.Get(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sub.Map(""); err != nil {
+		return nil, err
+	}
+	invalid := func(key string, err error) error {
+		return fmt.Errorf("Invalid TLS config at %q: %v", displayPath(canonicalPath(path, key)), err)
+	}
+	optional := func(key string) (string, error) {
+		if _, ok := sub.getOk(key); !ok {
+			return "", nil
+		}
+		s, err := sub.String(key)
+		if err != nil {
+			return "", invalid(key, err)
+		}
+		return s, nil
+	}
+
+	c := &tls.Config{MinVersion: tls.VersionTLS12}
+	certFile, err := optional("cert_file")
+	if err != nil {
+		return nil, err
+	}
+	keyFile, err := optional("key_file")
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case certFile != "" && keyFile != "":
+		if _, err := os.Stat(certFile); err != nil {
+			return nil, invalid("cert_file", err)
+		}
+		if _, err := os.Stat(keyFile); err != nil {
+			return nil, invalid("key_file", err)
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, invalid("cert_file", err)
+		}
+		c.Certificates = []tls.Certificate{cert}
+	case certFile != "":
+		return nil, invalid("key_file", fmt.Errorf("missing, while cert_file is set"))
+	case keyFile != "":
+		return nil, invalid("cert_file", fmt.Errorf("missing, while key_file is set"))
+	}
+
+	caFile, err := optional("ca_file")
+	if err != nil {
+		return nil, err
+	}
+	if caFile != "" {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, invalid("ca_file", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, invalid("ca_file", fmt.Errorf("no PEM certificates in %s", caFile))
+		}
+		c.RootCAs, c.ClientCAs = pool, pool
+	}
+
+	version, err := optional("min_version")
+	if err != nil {
+		return nil, err
+	}
+	if version != "" {
+		v, ok := tlsVersions[version]
+		if !ok {
+			return nil, invalid("min_version", fmt.Errorf("unknown version %q", version))
+		}
+		c.MinVersion = v
+	}
+
+	auth, err := optional("client_auth")
+	if err != nil {
+		return nil, err
+	}
+	if auth != "" {
+		a, ok := tlsClientAuths[auth]
+		if !ok {
+			return nil, invalid("client_auth", fmt.Errorf("unknown policy %q", auth))
+		}
+		if a >= tls.VerifyClientCertIfGiven && c.ClientCAs == nil {
+			return nil, invalid("client_auth", fmt.Errorf("%s needs ca_file", auth))
+		}
+		c.ClientAuth = a
+	}
+
+	if c.ServerName, err = optional("server_name"); err != nil {
+		return nil, err
+	}
+	if _, ok := sub.getOk("insecure_skip_verify"); ok {
+		if c.InsecureSkipVerify, err = sub.Bool("insecure_skip_verify"); err != nil {
+			return nil, invalid("insecure_skip_verify", err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,94 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCert writes a self-signed certificate and its key to dir.
+func writeTestCert(t *testing.T, dir string) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	expect(t, err, nil)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	expect(t, err, nil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	expect(t, err, nil)
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	expect(t, ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600), nil)
+	expect(t, ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600), nil)
+	return certFile, keyFile
+}
+
+func TestTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config-tls")
+	expect(t, err, nil)
+	defer os.RemoveAll(dir)
+	certFile, keyFile := writeTestCert(t, dir)
+
+	cfg := Must(ParseYaml(`
+server:
+  tls:
+    cert_file: ` + certFile + `
+    key_file: ` + keyFile + `
+    ca_file: ` + certFile + `
+    min_version: "1.3"
+    client_auth: require_and_verify
+client:
+  tls: {server_name: api.example.com, insecure_skip_verify: true}
+`))
+	c, err := cfg.TLS("server.tls")
+	expect(t, err, nil)
+	expect(t, len(c.Certificates), 1)
+	expect(t, c.MinVersion, uint16(tls.VersionTLS13))
+	expect(t, c.ClientAuth, tls.RequireAndVerifyClientCert)
+	expect(t, c.ClientCAs != nil && c.RootCAs != nil, true)
+
+	c, err = cfg.TLS("client.tls")
+	expect(t, err, nil)
+	expect(t, c.ServerName, "api.example.com")
+	expect(t, c.InsecureSkipVerify, true)
+	expect(t, c.MinVersion, uint16(tls.VersionTLS12))
+
+	for doc, msg := range map[string]string{
+		"tls: {cert_file: " + certFile + "}":                           `Invalid TLS config at "tls.key_file": missing, while cert_file is set`,
+		"tls: {cert_file: " + certFile + ", key_file: /nonexistent}":   `Invalid TLS config at "tls.key_file": stat /nonexistent`,
+		"tls: {cert_file: " + keyFile + ", key_file: " + keyFile + "}": `Invalid TLS config at "tls.cert_file": tls:`,
+		"tls: {ca_file: " + keyFile + "}":                              `Invalid TLS config at "tls.ca_file": no PEM certificates`,
+		"tls: {min_version: \"1.4\"}":                                  `Invalid TLS config at "tls.min_version": unknown version "1.4"`,
+		"tls: {client_auth: require_and_verify}":                       `Invalid TLS config at "tls.client_auth": require_and_verify needs ca_file`,
+		"tls: {client_auth: always}":                                   `Invalid TLS config at "tls.client_auth": unknown policy "always"`,
+		"tls: {insecure_skip_verify: maybe}":                           `Invalid TLS config at "tls.insecure_skip_verify"`,
+		"tls: on":                                                      `Type mismatch`,
+	} {
+		_, err := Must(ParseYaml(doc)).TLS("tls")
+		if err == nil || !strings.HasPrefix(err.Error(), msg) {
+			t.Errorf("%s: got %v, want %s", doc, err, msg)
+		}
+	}
+	_, err = cfg.TLS("missing")
+	expect(t, err != nil, true)
+}
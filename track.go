@@ -0,0 +1,85 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"strings"
+	"sync"
+)
+
+// readTracker records the paths read from a config and its subtrees.
+type readTracker struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// TrackReads turns on the recording of the paths read by the getters,
+// including reads from configs returned by Get, so that UnreadPaths can
+// report the keys which are never used. It returns the config itself.
+func (cfg *Config) TrackReads() *Config {
+	if cfg.reads == nil {
+		cfg.reads = &readTracker{paths: map[string]bool{}}
+	}
+	return cfg
+}
+
+// UnreadPaths returns the sorted paths of the leaf values which haven't
+// been read since TrackReads was called. Reading a map or a list counts as
+// reading all the values inside it. It returns nil when reads aren't
+// tracked.
+func (cfg *Config) UnreadPaths() []string {
+	if cfg.reads == nil {
+		return nil
+	}
+	cfg.reads.mu.Lock()
+	defer cfg.reads.mu.Unlock()
+
+	unread := []string{}
+	walkLeaves(cfg.Root, cfg.prefix, func(path string, value interface{}) {
+		ancestor := ""
+		parts, _ := parsePath(path)
+		for _, part := range parts {
+			ancestor = joinPath(ancestor, part)
+			if cfg.reads.paths[ancestor] {
+				return
+			}
+		}
+		if cfg.reads.paths[""] {
+			return
+		}
+		unread = append(unread, strings.TrimPrefix(path, cfg.prefix+"."))
+	})
+	return unread
+}
+
+// trackRead records a read of the given path, if reads are tracked, and
+// reports it if it is audited.
+func (cfg *Config) trackRead(path string) {
+	if cfg.reads == nil && cfg.audit == nil {
+		return
+	}
+	full := canonicalPath(cfg.prefix, path)
+	if cfg.audit != nil {
+		cfg.audit.report(full)
+	}
+	if cfg.reads != nil {
+		cfg.reads.mu.Lock()
+		cfg.reads.paths[full] = true
+		cfg.reads.mu.Unlock()
+	}
+}
+
+// canonicalPath appends a dotted path to a base path, in the form used
+// by walkLeaves.
+func canonicalPath(base, path string) string {
+	parts, err := parsePath(path)
+	if err != nil {
+		return joinPath(base, path)
+	}
+	for _, part := range parts {
+		base = joinPath(base, part)
+	}
+	return base
+}
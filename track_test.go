@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnreadPaths(t *testing.T) {
+	cfg, err := ParseYaml(`
+database:
+  host: localhost
+  port: 5432
+  field.dotted: value
+servers:
+  - a
+  - b
+admin:
+  - username: calvin
+    password: yukon
+stale: true
+`)
+	expect(t, err, nil)
+	expect(t, cfg.UnreadPaths() == nil, true)
+
+	cfg.TrackReads()
+	cfg.UString("database.host")
+	cfg.UString("database.missing")
+	cfg.UList("servers")
+	db, err := cfg.Get("database")
+	expect(t, err, nil)
+	db.UInt("port")
+	admin, err := cfg.Get("admin.0")
+	expect(t, err, nil)
+	admin.UString("username")
+
+	expect(t, reflect.DeepEqual(cfg.UnreadPaths(), []string{
+		"admin.0.password",
+		"database.[field.dotted]",
+		"stale",
+	}), true)
+	expect(t, reflect.DeepEqual(db.UnreadPaths(), []string{"[field.dotted]"}), true)
+
+	cfg.UString("database.[field.dotted]")
+	cfg.UBool(".stale")
+	expect(t, reflect.DeepEqual(cfg.UnreadPaths(), []string{"admin.0.password"}), true)
+	cfg.UMap("")
+	expect(t, len(cfg.UnreadPaths()), 0)
+}
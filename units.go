@@ -0,0 +1,278 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Durations and sizes --------------------------------------------------------
+//
+// Durations and sizes are written as strings with units, like "1m30s",
+// "10 seconds" or "512 MiB", following Go and HOCON conventions, so that
+// configs converted from Typesafe Config keep their meaning.
+
+// durationUnits maps the HOCON duration units to their durations.
+var durationUnits = map[string]time.Duration{}
+
+// sizeUnits maps the HOCON size units to their number of bytes.
+var sizeUnits = map[string]float64{}
+
+func init() {
+	for _, u := range []struct {
+		d     time.Duration
+		names string
+	}{
+		{time.Nanosecond, "ns nano nanos nanosecond nanoseconds"},
+		{time.Microsecond, "us µs micro micros microsecond microseconds"},
+		{time.Millisecond, "ms milli millis millisecond milliseconds"},
+		{time.Second, "s second seconds"},
+		{time.Minute, "m minute minutes"},
+		{time.Hour, "h hour hours"},
+		{24 * time.Hour, "d day days"},
+	} {
+		for _, name := range strings.Fields(u.names) {
+			durationUnits[name] = u.d
+		}
+	}
+
+	sizeUnits["B"], sizeUnits["b"], sizeUnits["byte"], sizeUnits["bytes"] = 1, 1, 1, 1
+	for i, p := range []string{"kilo", "mega", "giga", "tera", "peta", "exa"} {
+		decimal := math.Pow(1000, float64(i+1))
+		binary := math.Pow(1024, float64(i+1))
+		letter := strings.ToUpper(p[:1])
+		bi := p[:2] + "bi"
+		sizeUnits[letter+"B"], sizeUnits[p+"byte"], sizeUnits[p+"bytes"] = decimal, decimal, decimal
+		for _, name := range []string{letter, strings.ToLower(letter), letter + "i", letter + "iB", bi + "byte", bi + "bytes"} {
+			sizeUnits[name] = binary
+		}
+	}
+	// Kilobytes are written with a lowercase k.
+	sizeUnits["kB"] = 1000
+	delete(sizeUnits, "KB")
+}
+
+// splitUnit splits a string like "10 seconds" into its number and unit.
+func splitUnit(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '.' || r == '-' || r == '+')
+	})
+	if i < 0 {
+		i = len(s)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, strings.TrimSpace(s[i:]), nil
+}
+
+// toDuration converts a config value to a time.Duration. Strings are
+// parsed with time.ParseDuration, or as a number followed by a HOCON unit,
+// like "10 seconds" or "2d". Numbers, and strings without units, are
+// milliseconds, like in HOCON.
+func toDuration(n interface{}) (time.Duration, error) {
+	switch n := n.(type) {
+	case int:
+		return time.Duration(n) * time.Millisecond, nil
+	case float64:
+		return time.Duration(n * float64(time.Millisecond)), nil
+	case string:
+		if d, err := time.ParseDuration(n); err == nil {
+			return d, nil
+		}
+		v, unit, err := splitUnit(n)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid duration %q", n)
+		}
+		d, ok := time.Millisecond, true
+		if unit != "" {
+			d, ok = durationUnits[unit]
+		}
+		if !ok {
+			return 0, fmt.Errorf("Invalid duration %q: unknown unit %q", n, unit)
+		}
+		return time.Duration(v * float64(d)), nil
+	}
+	return 0, typeMismatch("int, float64 or string", n)
+}
+
+// toSize converts a config value to a number of bytes. Strings are a
+// number followed by a HOCON unit: "kB", "MB", "kilobytes" are powers of
+// 1000, while "K", "Ki", "KiB", "kibibytes" are powers of 1024. Numbers,
+// and strings without units, are bytes.
+func toSize(n interface{}) (int64, error) {
+	switch n := n.(type) {
+	case int:
+		return int64(n), nil
+	case float64:
+		return int64(n), nil
+	case string:
+		v, unit, err := splitUnit(n)
+		if err != nil {
+			return 0, fmt.Errorf("Invalid size %q", n)
+		}
+		size, ok := 1.0, true
+		if unit != "" {
+			size, ok = sizeUnits[unit]
+		}
+		if !ok {
+			return 0, fmt.Errorf("Invalid size %q: unknown unit %q", n, unit)
+		}
+		return int64(v * size), nil
+	}
+	return 0, typeMismatch("int, float64 or string", n)
+}
+
+// Duration returns a time.Duration according to a dotted path, see
+// toDuration for the accepted values.
+func (cfg *Config) Duration(path string) (time.Duration, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := toDuration(n)
+	return v, cfg.observeConversion(path, err)
+}
+
+// UDuration returns a time.Duration according to a dotted path or default
+// value or 0.
+func (c *Config) UDuration(path string, defaults ...time.Duration) time.Duration {
+	value, err := c.Duration(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return 0
+}
+
+// Size returns a number of bytes according to a dotted path, like "512
+// MiB", see toSize for the accepted values.
+func (cfg *Config) Size(path string) (int64, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return 0, err
+	}
+	v, err := toSize(n)
+	return v, cfg.observeConversion(path, err)
+}
+
+// USize returns a number of bytes according to a dotted path or default
+// value or 0.
+func (c *Config) USize(path string, defaults ...int64) int64 {
+	value, err := c.Size(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return 0
+}
+
+// Ratios ---------------------------------------------------------------------
+//
+// Rates and ratios are misread as often as they are written: is 15 a
+// percentage, or 15 times? Percent only accepts bare numbers between -1
+// and 1, which are fractions, and wants the others written with a unit,
+// like "15%" or "150 ppm".
+
+// ratioUnits maps the units of ratios to their fractions. RegisterRatioUnit
+// adds units to it.
+var ratioUnits = map[string]float64{
+	"%":        1e-2,
+	"percent":  1e-2,
+	"‰":        1e-3,
+	"permille": 1e-3,
+	"bp":       1e-4,
+	"bps":      1e-4,
+	"ppm":      1e-6,
+}
+
+// RegisterRatioUnit adds a unit of the ratios read by Percent, like
+// RegisterRatioUnit("ppb", 1e-9). It isn't safe to call concurrently with
+// Percent, so register units in init functions.
+func RegisterRatioUnit(unit string, fraction float64) {
+	ratioUnits[unit] = fraction
+}
+
+// toQuantity converts a config value to a number. Strings are a number
+// followed by one of the given units, which multiplies it; numbers, and
+// strings without units, are kept as is.
+func toQuantity(n interface{}, units map[string]float64) (float64, string, error) {
+	switch n := n.(type) {
+	case int:
+		return float64(n), "", nil
+	case float64:
+		return n, "", nil
+	case string:
+		v, unit, err := splitUnit(n)
+		if err != nil {
+			return 0, "", fmt.Errorf("Invalid quantity %q", n)
+		}
+		if unit == "" {
+			return v, "", nil
+		}
+		factor, ok := units[unit]
+		if !ok {
+			return 0, "", fmt.Errorf("Invalid quantity %q: unknown unit %q", n, unit)
+		}
+		return v * factor, unit, nil
+	}
+	return 0, "", typeMismatch("int, float64 or string", n)
+}
+
+// Quantity returns a number according to a dotted path, converting the
+// units given in the value, like "250 ms" with units {"ms": 1e-3, "s": 1}.
+// Values without units are returned as is.
+func (cfg *Config) Quantity(path string, units map[string]float64) (float64, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return 0, err
+	}
+	v, _, err := toQuantity(n, units)
+	return v, cfg.observeConversion(path, err)
+}
+
+// Percent returns a fraction according to a dotted path: "15%", "15
+// percent" and 0.15 are all 0.15, and "150 ppm" is 0.00015. Bare numbers
+// outside [-1, 1], like 15, are rejected as ambiguous.
+func (cfg *Config) Percent(path string) (float64, error) {
+	n, err := cfg.get(path)
+	if err != nil {
+		return 0, err
+	}
+	v, unit, err := toQuantity(n, ratioUnits)
+	if err == nil && unit == "" && (v > 1 || v < -1) {
+		err = fmt.Errorf("Ambiguous ratio %v: write it as a fraction, or with a unit like \"%v%%\"", n, v)
+	}
+	return v, cfg.observeConversion(path, err)
+}
+
+// UPercent returns a fraction according to a dotted path or default value
+// or 0.
+func (c *Config) UPercent(path string, defaults ...float64) float64 {
+	value, err := c.Percent(path)
+
+	if err == nil {
+		return value
+	}
+
+	for _, def := range defaults {
+		return def
+	}
+	return 0
+}
@@ -0,0 +1,61 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPercent(t *testing.T) {
+	cfg := Must(ParseYaml(`
+percent: 15%
+spaced: 15 percent
+fraction: 0.15
+string: "0.15"
+one: 1
+ppm: 150ppm
+bps: 25 bps
+over: 150%
+bare: 15
+unknown: 15 pct
+list: [1]
+`))
+	near := func(got, want float64) bool { return math.Abs(got-want) < 1e-12 }
+	for path, want := range map[string]float64{
+		"percent":  0.15,
+		"spaced":   0.15,
+		"fraction": 0.15,
+		"string":   0.15,
+		"one":      1,
+		"ppm":      0.00015,
+		"bps":      0.0025,
+		"over":     1.5,
+	} {
+		v, err := cfg.Percent(path)
+		expect(t, err, nil)
+		expect(t, near(v, want), true)
+	}
+	_, err := cfg.Percent("bare")
+	expect(t, err.Error(), `Ambiguous ratio 15: write it as a fraction, or with a unit like "15%"`)
+	_, err = cfg.Percent("unknown")
+	expect(t, err.Error(), `Invalid quantity "15 pct": unknown unit "pct"`)
+	_, err = cfg.Percent("list")
+	expect(t, err != nil, true)
+	expect(t, cfg.UPercent("bare", 0.5), 0.5)
+
+	RegisterRatioUnit("pct", 0.01)
+	defer delete(ratioUnits, "pct")
+	expect(t, near(cfg.UPercent("unknown"), 0.15), true)
+
+	cfg = Must(ParseYaml("timeout: 250 ms\nplain: 2"))
+	units := map[string]float64{"ms": 1e-3, "s": 1}
+	v, err := cfg.Quantity("timeout", units)
+	expect(t, err, nil)
+	expect(t, near(v, 0.25), true)
+	v, err = cfg.Quantity("plain", units)
+	expect(t, err, nil)
+	expect(t, v, 2.0)
+}
@@ -0,0 +1,153 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// validator checks the values set at a path, see Config.Validator.
+type validator struct {
+	path  string
+	parts []string
+	fn    func(v interface{}) error
+}
+
+// Validator registers a function checking the value at a dotted path. It
+// runs on every Set changing the value, including Sets of a map holding
+// it or of a value inside it, which fail without changing the config when
+// the function returns an error, and by Validate and Schema.Validate. It
+// returns the config itself:
+//
+//	cfg.Validator("server.port", func(v interface{}) error {
+//		if port, ok := v.(int); !ok || port < 1024 {
+//			return fmt.Errorf("expected a port above 1024")
+//		}
+//		return nil
+//	})
+//
+// Delete, ReplaceAll, the patches, Cast and Coerce run the validators of
+// the values they change too. Copies of the config and the configs
+// returned by Get keep its validators, but the ones of a path only run
+// the validators of the paths inside it.
+func (cfg *Config) Validator(path string, fn func(v interface{}) error) *Config {
+	full := canonicalPath(cfg.prefix, path)
+	parts, err := parsePath(full)
+	if err != nil {
+		panic(fmt.Sprintf("config: invalid validator path %q: %v", path, err))
+	}
+	n := len(cfg.validators)
+	cfg.validators = append(cfg.validators[:n:n], validator{full, parts, fn})
+	return cfg
+}
+
+// Validate runs the validators of the config on the values they check,
+// and returns nil or SchemaErrors listing every failure, sorted by path.
+// Missing values are skipped; use Required or a schema to check them.
+func (cfg *Config) Validate() error {
+	var errs SchemaErrors
+	cfg.runValidators(&errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// runValidators appends the failures of the validators to errs.
+func (cfg *Config) runValidators(errs *SchemaErrors) {
+	base, _ := parsePath(cfg.prefix)
+	for _, v := range cfg.validators {
+		if !hasPrefixParts(v.parts, base) {
+			continue
+		}
+		value, ok := findParts(cfg.Root, v.parts[len(base):])
+		if !ok {
+			continue
+		}
+		if err := v.fn(value); err != nil {
+			*errs = append(*errs, &SchemaError{Path: v.path, Message: err.Error()})
+		}
+	}
+	sort.SliceStable(*errs, func(i, j int) bool { return (*errs)[i].Path < (*errs)[j].Path })
+}
+
+// checkValidators runs the validators of the values changed by setting
+// val at the given parsed path, before the change.
+func (cfg *Config) checkValidators(parts []string, val interface{}) error {
+	base, _ := parsePath(cfg.prefix)
+	full := append(base[:len(base):len(base)], parts...)
+	for _, v := range cfg.validators {
+		if !hasPrefixParts(v.parts, base) {
+			continue
+		}
+		var value interface{}
+		switch {
+		case hasPrefixParts(v.parts, full):
+			// The value set holds the validated one.
+			var ok bool
+			if value, ok = findParts(val, v.parts[len(full):]); !ok {
+				continue
+			}
+		case hasPrefixParts(full, v.parts):
+			// The value set is inside the validated one, which is
+			// checked as it will be.
+			current, ok := findParts(cfg.Root, v.parts[len(base):])
+			if !ok {
+				current = newContainer(full[len(v.parts)])
+			}
+			root, err := normalizeValue(current)
+			if err != nil {
+				return err
+			}
+			if value, err = setParts(root, full[len(v.parts):], val); err != nil {
+				return err
+			}
+		default:
+			continue
+		}
+		if err := v.fn(value); err != nil {
+			return fmt.Errorf("Invalid value at %q: %v", displayPath(v.path), err)
+		}
+	}
+	return nil
+}
+
+// checkRoot runs the validators of the values which differ between the
+// root of the config and the given one, before the config takes it.
+// Values missing from the given root are skipped, like by Validate.
+func (cfg *Config) checkRoot(root interface{}) error {
+	base, _ := parsePath(cfg.prefix)
+	for _, v := range cfg.validators {
+		if !hasPrefixParts(v.parts, base) {
+			continue
+		}
+		value, ok := findParts(root, v.parts[len(base):])
+		if !ok {
+			continue
+		}
+		if current, ok := findParts(cfg.Root, v.parts[len(base):]); ok && equalTrees(current, value) {
+			continue
+		}
+		if err := v.fn(value); err != nil {
+			return fmt.Errorf("Invalid value at %q: %v", displayPath(v.path), err)
+		}
+	}
+	return nil
+}
+
+// hasPrefixParts reports whether the keys of a path start with the keys
+// of another.
+func hasPrefixParts(parts, prefix []string) bool {
+	if len(parts) < len(prefix) {
+		return false
+	}
+	for i, part := range prefix {
+		if parts[i] != part {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,114 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidator(t *testing.T) {
+	cfg := Must(ParseYaml("server: {port: 8080, tls: {enabled: false}}"))
+	cfg.Validator("server.port", func(v interface{}) error {
+		if port, ok := v.(int); !ok || port < 1024 {
+			return errors.New("expected a port above 1024")
+		}
+		return nil
+	})
+	cfg.Validator("server.tls", func(v interface{}) error {
+		tls, _ := v.(map[string]interface{})
+		if tls["enabled"] == true && tls["cert"] == nil {
+			return errors.New("missing cert")
+		}
+		return nil
+	})
+	expect(t, cfg.Validate(), nil)
+
+	expect(t, cfg.Set("server.port", 9090), nil)
+	expect(t, cfg.Set("server.port", 80).Error(), `Invalid value at "server.port": expected a port above 1024`)
+	expect(t, cfg.Set("server", map[string]interface{}{"port": 443}) != nil, true)
+	expect(t, cfg.Set("server.tls.enabled", true).Error(), `Invalid value at "server.tls": missing cert`)
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, cfg.UBool("server.tls.enabled"), false)
+	expect(t, cfg.Set("server.tls.cert", "cert.pem"), nil)
+	expect(t, cfg.Set("server.tls.enabled", true), nil)
+
+	// Views and copies keep the validators.
+	server, err := cfg.Get("server")
+	expect(t, err, nil)
+	expect(t, server.Set("port", 22) != nil, true)
+	expect(t, server.Set("port", 2222), nil)
+	expect(t, cfg.UInt("server.port"), 2222)
+	cp, err := cfg.Copy()
+	expect(t, err, nil)
+	expect(t, cp.Set("server.port", 22) != nil, true)
+
+	// Invalid values set by other means are reported by Validate.
+	cfg = Must(ParseYaml("server: {port: 80}")).Validator("server.port", func(v interface{}) error {
+		return errors.New("always")
+	})
+	expect(t, cfg.Validate().Error(), "server.port: always")
+	schema, err := ParseSchema(Must(ParseYaml("keys: {server: {type: map}}")))
+	expect(t, err, nil)
+	expect(t, schema.Validate(cfg).Error(), "server.port: always")
+}
+
+func TestValidatorMutations(t *testing.T) {
+	cfg := Must(ParseYaml("server: {port: 8080, host: localhost}"))
+	cfg.Validator("server", func(v interface{}) error {
+		if server, _ := v.(map[string]interface{}); server["host"] == nil {
+			return errors.New("missing host")
+		}
+		return nil
+	})
+	cfg.Validator("server.port", func(v interface{}) error {
+		if port, err := toInt(v); err != nil || port < 1024 {
+			return errors.New("expected a port above 1024")
+		}
+		return nil
+	})
+
+	expect(t, cfg.Delete("server.host").Error(), `Invalid value at "server": missing host`)
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.ApplyJSONPatch([]byte(`[{"op": "replace", "path": "/server/port", "value": 80}]`)).Error(),
+		`Invalid value at "server.port": expected a port above 1024`)
+	expect(t, cfg.ApplyMergePatch([]byte(`{"server": {"port": 80}}`)).Error(),
+		`Invalid value at "server.port": expected a port above 1024`)
+	expect(t, cfg.ApplyMergePatch([]byte(`{"server": {"host": null}}`)).Error(),
+		`Invalid value at "server": missing host`)
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.ApplyMergePatch([]byte(`{"server": {"port": 9090}}`)), nil)
+	expect(t, cfg.UInt("server.port"), 9090)
+	expect(t, cfg.ReplaceAll("localhost", ""), 1)
+	expect(t, cfg.Delete("server.port"), nil)
+
+	// Copies of a path keep the validators inside it.
+	cfg = Must(ParseYaml("server: {port: 8080, host: localhost}"))
+	cfg.Validator("server.port", func(v interface{}) error {
+		if port, err := toInt(v); err != nil || port < 1024 {
+			return errors.New("expected a port above 1024")
+		}
+		return nil
+	})
+	server, err := cfg.Copy("server")
+	expect(t, err, nil)
+	expect(t, server.Set("port", 22).Error(), `Invalid value at "server.port": expected a port above 1024`)
+	expect(t, server.Set("host", "example.com"), nil)
+	expect(t, cfg.UString("server.host"), "localhost")
+	server.Validator("host", func(v interface{}) error {
+		return errors.New("read-only")
+	})
+	expect(t, server.Set("host", "localhost").Error(), `Invalid value at "server.host": read-only`)
+
+	// Values set by Cast are checked too.
+	cfg = Must(ParseYaml("'80'")).Validator("", func(v interface{}) error {
+		if _, ok := v.(int); ok {
+			return errors.New("no ints")
+		}
+		return nil
+	})
+	expect(t, cfg.Cast("", "int").Error(), `Invalid value at ".": no ints`)
+	expect(t, cfg.Root, "80")
+}
@@ -0,0 +1,101 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// WeightedTarget is a target of a weighted list, with its weight.
+type WeightedTarget struct {
+	Target string
+	Weight float64
+}
+
+// WeightedList is a list of targets picked at random in proportion to
+// their weights, like for canary routing.
+type WeightedList struct {
+	Targets []WeightedTarget
+	// cumulative are the running sums of the weights.
+	cumulative []float64
+}
+
+// Pick returns a target at random, in proportion to the weights.
+func (w *WeightedList) Pick() string {
+	return w.PickAt(rand.Float64())
+}
+
+// PickAt returns the target at the fraction x of the total weight, between
+// 0 and 1. Passing a hash of a user ID, scaled to [0, 1), picks the same
+// target for a user as long as the weights don't change.
+func (w *WeightedList) PickAt(x float64) string {
+	total := w.cumulative[len(w.cumulative)-1]
+	i := sort.Search(len(w.cumulative), func(i int) bool {
+		return w.cumulative[i] > x*total
+	})
+	if i == len(w.cumulative) {
+		i--
+	}
+	// Targets with a zero weight are never picked.
+	for w.Targets[i].Weight == 0 {
+		i--
+	}
+	return w.Targets[i].Target
+}
+
+// Weighted returns the weighted list at a dotted path, a list of maps with
+// a target and a weight:
+//
+//	backends:
+//	  - {target: stable, weight: 90}
+//	  - {target: canary, weight: 10}
+//
+// Weights are relative, so they needn't add up to 100, but they can't be
+// negative, all be zero, or be missing. Targets must be distinct.
+func (cfg *Config) Weighted(path string) (*WeightedList, error) {
+	list, err := cfg.List(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("Invalid weighted list at %q: no targets", displayPath(canonicalPath("", path)))
+	}
+	w := &WeightedList{}
+	seen := map[string]bool{}
+	total := 0.0
+	for i := range list {
+		s, err := cfg.newSection(joinPath(path, strconv.Itoa(i)), "weighted list")
+		if err != nil {
+			return nil, err
+		}
+		var t WeightedTarget
+		t.Weight = -1
+		s.string("target", &t.Target)
+		s.float("weight", &t.Weight)
+		switch {
+		case s.err != nil:
+			return nil, s.err
+		case t.Target == "":
+			return nil, s.invalid("target", fmt.Errorf("missing target"))
+		case seen[t.Target]:
+			return nil, s.invalid("target", fmt.Errorf("duplicate target %q", t.Target))
+		case !s.has("weight"):
+			return nil, s.invalid("weight", fmt.Errorf("missing weight"))
+		case t.Weight < 0:
+			return nil, s.invalid("weight", fmt.Errorf("negative weight %v", t.Weight))
+		}
+		seen[t.Target] = true
+		total += t.Weight
+		w.Targets = append(w.Targets, t)
+		w.cumulative = append(w.cumulative, total)
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("Invalid weighted list at %q: all weights are zero", displayPath(canonicalPath("", path)))
+	}
+	return w, nil
+}
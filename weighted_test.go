@@ -0,0 +1,52 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import "testing"
+
+func TestWeighted(t *testing.T) {
+	cfg := Must(ParseYaml(`
+backends:
+  - {target: stable, weight: 90}
+  - {target: off, weight: 0}
+  - {target: canary, weight: 10.0}
+empty: []
+zero: [{target: a, weight: 0}]
+negative: [{target: a, weight: -1}]
+missing: [{target: a}]
+duplicate: [{target: a, weight: 1}, {target: a, weight: 2}]
+untargeted: [{weight: 1}]
+items: [a]
+`))
+	w, err := cfg.Weighted("backends")
+	expect(t, err, nil)
+	expect(t, len(w.Targets), 3)
+	expect(t, w.Targets[2], WeightedTarget{"canary", 10})
+	expect(t, w.PickAt(0), "stable")
+	expect(t, w.PickAt(0.89), "stable")
+	expect(t, w.PickAt(0.9), "canary")
+	expect(t, w.PickAt(0.99), "canary")
+	expect(t, w.PickAt(1), "canary")
+
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		counts[w.Pick()]++
+	}
+	expect(t, counts["off"], 0)
+	expect(t, counts["stable"] > counts["canary"], true)
+
+	for path, msg := range map[string]string{
+		"empty":      `Invalid weighted list at "empty": no targets`,
+		"zero":       `Invalid weighted list at "zero": all weights are zero`,
+		"negative":   `Invalid weighted list config at "negative.0.weight": negative weight -1`,
+		"missing":    `Invalid weighted list config at "missing.0.weight": missing weight`,
+		"duplicate":  `Invalid weighted list config at "duplicate.1.target": duplicate target "a"`,
+		"untargeted": `Invalid weighted list config at "untargeted.0.target": missing target`,
+		"items":      `Type mismatch: expected map[string]interface{}; got string`,
+	} {
+		_, err := cfg.Weighted(path)
+		expect(t, err.Error(), msg)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"path"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ZooKeeperClient is the subset of a ZooKeeper client used by
+// ZooKeeperSource. Both methods set a one-shot watch, and return a channel
+// receiving, or closed, when the znode or its children change. With
+// github.com/go-zookeeper/zk, the channels of the zk.Event values returned
+// by ChildrenW and GetW only need to be adapted to chan struct{}.
+type ZooKeeperClient interface {
+	ChildrenW(path string) ([]string, <-chan struct{}, error)
+	GetW(path string) ([]byte, <-chan struct{}, error)
+}
+
+// ZooKeeperSource loads a config from the znodes under a chroot. Znodes
+// with children become maps, and the data of the other ones becomes the
+// values, decoded as JSON when possible and kept as strings otherwise.
+//
+// Every Load watches the znodes it reads, and Watch notifies of the first
+// change after each Load. After a failed Load, Watch notifies again after
+// RetryInterval, so that the config is loaded again even if none of the
+// znodes read before the failure changes.
+type ZooKeeperSource struct {
+	Client ZooKeeperClient
+	// Root is the chroot of the config, like "/services/app".
+	Root string
+	// RetryInterval is the delay before a failed Load is retried, a
+	// second by default.
+	RetryInterval time.Duration
+
+	mu      sync.Mutex
+	watches []<-chan struct{}
+	failed  bool          // whether the last Load failed
+	loaded  chan struct{} // closed and replaced by every Load
+}
+
+// Load reads the znodes under Root.
+func (s *ZooKeeperSource) Load(ctx context.Context) (*Config, error) {
+	var watches []<-chan struct{}
+	root, err := s.load(ctx, s.Root, &watches)
+
+	// Failed loads re-arm Watch too, with the watches set before the
+	// failure.
+	s.mu.Lock()
+	s.watches, s.failed = watches, err != nil
+	if s.loaded != nil {
+		close(s.loaded)
+	}
+	s.loaded = make(chan struct{})
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return newConfig(root)
+}
+
+// load reads the tree under a znode.
+func (s *ZooKeeperSource) load(ctx context.Context, node string, watches *[]<-chan struct{}) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	children, watch, err := s.Client.ChildrenW(node)
+	if err != nil {
+		return nil, err
+	}
+	*watches = append(*watches, watch)
+	if len(children) == 0 {
+		data, watch, err := s.Client.GetW(node)
+		if err != nil {
+			return nil, err
+		}
+		*watches = append(*watches, watch)
+		if node == s.Root && len(data) == 0 {
+			return map[string]interface{}{}, nil
+		}
+		return decodeFlatValue(string(data))
+	}
+
+	sort.Strings(children)
+	m := make(map[string]interface{}, len(children))
+	for _, child := range children {
+		if m[child], err = s.load(ctx, path.Join(node, child), watches); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Watch notifies once a znode read by the last Load changes.
+func (s *ZooKeeperSource) Watch(ctx context.Context) (<-chan struct{}, error) {
+	changes := make(chan struct{})
+	go func() {
+		defer close(changes)
+		for {
+			s.mu.Lock()
+			watches, loaded, failed := s.watches, s.loaded, s.failed
+			s.mu.Unlock()
+
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())},
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(loaded)},
+			}
+			if failed {
+				retry := s.RetryInterval
+				if retry <= 0 {
+					retry = time.Second
+				}
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(time.After(retry))})
+			}
+			for _, w := range watches {
+				cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(w)})
+			}
+			switch chosen, _, _ := reflect.Select(cases); chosen {
+			case 0:
+				return
+			case 1:
+				// Newer watches were set.
+				continue
+			}
+
+			select {
+			case changes <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			// The watches fired; wait for the reload setting new ones.
+			select {
+			case <-loaded:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return changes, nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2012 The Gorilla Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"context"
+	"errors"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeZooKeeper keeps znodes in a map of paths to data.
+type fakeZooKeeper struct {
+	mu      sync.Mutex
+	nodes   map[string]string
+	watches []chan struct{}
+	err     error // returned by GetW when set
+}
+
+func (zk *fakeZooKeeper) watch() <-chan struct{} {
+	ch := make(chan struct{})
+	zk.watches = append(zk.watches, ch)
+	return ch
+}
+
+func (zk *fakeZooKeeper) ChildrenW(p string) ([]string, <-chan struct{}, error) {
+	zk.mu.Lock()
+	defer zk.mu.Unlock()
+	seen := map[string]bool{}
+	children := []string{}
+	for node := range zk.nodes {
+		if strings.HasPrefix(node, p+"/") {
+			child := strings.SplitN(strings.TrimPrefix(node, p+"/"), "/", 2)[0]
+			if !seen[child] {
+				seen[child] = true
+				children = append(children, child)
+			}
+		}
+	}
+	return children, zk.watch(), nil
+}
+
+func (zk *fakeZooKeeper) GetW(p string) ([]byte, <-chan struct{}, error) {
+	zk.mu.Lock()
+	defer zk.mu.Unlock()
+	if zk.err != nil {
+		return nil, nil, zk.err
+	}
+	return []byte(zk.nodes[p]), zk.watch(), nil
+}
+
+// set changes a znode and fires the watches.
+func (zk *fakeZooKeeper) set(p, data string) {
+	zk.mu.Lock()
+	defer zk.mu.Unlock()
+	zk.nodes[p] = data
+	for _, ch := range zk.watches {
+		close(ch)
+	}
+	zk.watches = nil
+}
+
+func TestZooKeeperSource(t *testing.T) {
+	zk := &fakeZooKeeper{nodes: map[string]string{
+		"/app/server/host": "localhost",
+		"/app/server/port": "8080",
+		"/app/debug":       "true",
+		"/other/key":       "ignored",
+	}}
+	src := &ZooKeeperSource{Client: zk, Root: "/app", RetryInterval: 10 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	m, err := NewManager(ctx, src)
+	expect(t, err, nil)
+	cfg := m.Config()
+	expect(t, cfg.UString("server.host"), "localhost")
+	expect(t, cfg.UInt("server.port"), 8080)
+	expect(t, cfg.UBool("debug"), true)
+	_, err = cfg.Get("key")
+	expect(t, err != nil, true)
+
+	reloaded := make(chan int)
+	m.OnChange(func(old, new *Config) { reloaded <- new.UInt("server.port") })
+	go m.Watch(ctx)
+
+	zk.set(path.Join("/app", "server", "port"), "9090")
+	expect(t, <-reloaded, 9090)
+	zk.set("/app/server/port", "9191")
+	expect(t, <-reloaded, 9191)
+
+	// A failed reload is retried, rather than stop the watch.
+	failed := make(chan error, 1)
+	m.OnError(func(err error) { failed <- err })
+	zk.mu.Lock()
+	zk.err = errors.New("connection loss")
+	zk.mu.Unlock()
+	zk.set("/app/server/port", "9292")
+	expect(t, (<-failed).Error(), "connection loss")
+	zk.mu.Lock()
+	zk.err = nil
+	zk.mu.Unlock()
+	expect(t, <-reloaded, 9292)
+
+	empty, err := (&ZooKeeperSource{Client: zk, Root: "/missing"}).Load(ctx)
+	expect(t, err, nil)
+	expect(t, len(empty.UMap("")), 0)
+}